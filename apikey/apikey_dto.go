@@ -0,0 +1,11 @@
+package apikey
+
+import "time"
+
+type Dto struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// Scopes the key is restricted to, empty grants the key the access of its user
+	Scopes []string `json:"scopes,omitempty" validate:"omitempty,dive,oneof=users:read users:write orgs:read orgs:write"`
+	// ExpiresAt of the key, empty never expires
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,11 @@
+package apikey
+
+import "errors"
+
+var (
+	ErrNotFound    = errors.New("api key not found")
+	ErrInvalid     = errors.New("api key not valid")
+	ErrExpired     = errors.New("api key expired")
+	ErrExpiryPast  = errors.New("expires_at must be in the future")
+	ErrScopeDenied = errors.New("api key or token lacks the scope of this route")
+)
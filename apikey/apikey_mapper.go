@@ -0,0 +1,49 @@
+package apikey
+
+import (
+	"go-echo-api/models"
+	"time"
+)
+
+type Mapper struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func NewAPIKeyMapper() *Mapper {
+	return &Mapper{}
+}
+
+func (m *Mapper) Map(model models.APIKey) *Mapper {
+	m.ID = model.ID
+	m.Name = model.Name
+	m.Prefix = model.Prefix
+	m.Scopes = append([]string{}, model.ScopeList()...)
+	m.ExpiresAt = model.ExpiresAt
+	m.LastUsedAt = model.LastUsedAt
+	m.CreatedAt = model.CreatedAt
+	return m
+}
+
+func (m *Mapper) MapList(model []models.APIKey) interface{} {
+	serialized := make([]Mapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&Mapper{}).Map(v)
+	}
+	return serialized
+}
+
+// CreatedMapper is the only response holding the key, it cannot be read again
+type CreatedMapper struct {
+	Mapper
+	Key string `json:"key"`
+}
+
+func NewCreatedMapper(model models.APIKey, key string) *CreatedMapper {
+	return &CreatedMapper{Mapper: *NewAPIKeyMapper().Map(model), Key: key}
+}
@@ -0,0 +1,19 @@
+package apikey
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+// Repository of the API keys of the tenant of the context
+type Repository interface {
+	FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.APIKey, int64, error)
+	FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	Store(ctx context.Context, model *models.APIKey) error
+	Delete(ctx context.Context, userID string, id string) error
+	// Touch record the last use of the key
+	Touch(ctx context.Context, id string, usedAt time.Time) error
+	// DeleteByUser delete the API keys of the user, once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,9 @@
+package apikey
+
+// Scopes an API key can be restricted to, the routes of the modules require them with middleware.RequireScope
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopeOrgsRead   = "orgs:read"
+	ScopeOrgsWrite  = "orgs:write"
+)
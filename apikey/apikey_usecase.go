@@ -0,0 +1,15 @@
+package apikey
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+type Usecase interface {
+	FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.APIKey, int64, error)
+	// Create return the key and its only clear copy, to be shown once to the user
+	Create(ctx context.Context, userID string, dto Dto) (models.APIKey, string, error)
+	Revoke(ctx context.Context, userID string, id string) error
+	// Authenticate return the key and its user, ErrInvalid when the key is unknown and ErrExpired past its expiry
+	Authenticate(ctx context.Context, key string) (*models.APIKey, *models.User, error)
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/apikey"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/utils"
+)
+
+type apiKeyController struct {
+	apiKeyUsecase apikey.Usecase
+	apiKeyMapper  *apikey.Mapper
+}
+
+func NewAPIKeyController(s apikey.Usecase) *apiKeyController {
+	return &apiKeyController{apiKeyUsecase: s,
+		apiKeyMapper: apikey.NewAPIKeyMapper(),
+	}
+}
+
+func (c *apiKeyController) FindAll(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.apiKeyUsecase.FindAll(ctx.Request().Context(), middleware.UserID(ctx), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.apiKeyMapper.MapList(result), nil)
+}
+
+func (c *apiKeyController) Store(ctx echo.Context) error {
+	var dto apikey.Dto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, key, err := c.apiKeyUsecase.Create(ctx.Request().Context(), middleware.UserID(ctx), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, apikey.NewCreatedMapper(result, key), nil)
+}
+
+func (c *apiKeyController) Delete(ctx echo.Context) error {
+	if err := c.apiKeyUsecase.Revoke(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+// errorResponse map the errors of the api key use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case apikey.ErrNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	case apikey.ErrExpiryPast:
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("api key use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/apikey/repository"
+	"go-echo-api/apikey/usecase"
+	"go-echo-api/middleware"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/sink"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+)
+
+// Module wire the api key controller to the database and register the routes managing the keys
+// of the logged in user under /me. They require the access token of the user,
+// an API key or an OAuth client cannot mint other keys.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/me"
+}
+
+// Subscribe delete the API keys of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewAPIKeyRepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	controller := NewAPIKeyController(usecase.NewAPIKeyService(repository.NewAPIKeyRepository(m.db), userRepository.NewUserRepository(m.db)))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	loggedIn := middleware.IsLoggedIn(sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL))
+	g.GET("/api-keys", controller.FindAll, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/api-keys", controller.Store, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.DELETE("/api-keys/:id", controller.Delete, tenantScope, loggedIn, middleware.RequireUnscoped)
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/apikey"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	g.Use(nil, map[string]openapi.Response{
+		"401": g.Error("Missing or invalid access token"),
+		"403": g.Error("Token issued to an OAuth client"),
+	})
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+
+	g.Add(echo.GET, "/api-keys", openapi.Operation{
+		Tags:        []string{"api-key"},
+		Summary:     "List the API keys of the logged in user, without the keys",
+		OperationID: "listAPIKeys",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of keys skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of API keys", apikey.Mapper{}),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/api-keys", openapi.Operation{
+		Tags:        []string{"api-key"},
+		Summary:     "Create an API key, the key is in this response only",
+		OperationID: "createAPIKey",
+		RequestBody: g.Body(apikey.Dto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Created API key with its key", apikey.CreatedMapper{}),
+			"422": g.Error("Invalid body or expiry in the past"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.DELETE, "/api-keys/:id", openapi.Operation{
+		Tags:        []string{"api-key"},
+		Summary:     "Revoke an API key of the logged in user",
+		OperationID: "revokeAPIKey",
+		Parameters:  []openapi.Parameter{openapi.PathParam("id", "ID of the API key")},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("API key revoked", nil),
+			"404": g.Error("API key not found"),
+		},
+		Security: openapi.BearerAuth,
+	})
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/apikey"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"time"
+)
+
+type apiKeyGormRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) apikey.Repository {
+	return &apiKeyGormRepository{db: db}
+}
+
+func (r *apiKeyGormRepository) FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.APIKey, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.APIKey{}).Where("user_id = ?", userID)
+	var model []models.APIKey
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("created_at").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *apiKeyGormRepository) FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.APIKey
+	err = db.Where("prefix = ?", prefix).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, apikey.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *apiKeyGormRepository) Store(ctx context.Context, model *models.APIKey) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *apiKeyGormRepository) Delete(ctx context.Context, userID string, id string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apikey.ErrNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyGormRepository) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return db.Model(&models.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
+
+func (r *apiKeyGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ?", userID).Delete(&models.APIKey{}).Error
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/apikey"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// apiKeyMemoryRepository keeps the API keys in memory, it backs the use-case unit tests
+type apiKeyMemoryRepository struct {
+	mu   sync.RWMutex
+	keys map[string]models.APIKey
+}
+
+func NewAPIKeyMemoryRepository(keys ...models.APIKey) apikey.Repository {
+	r := &apiKeyMemoryRepository{keys: make(map[string]models.APIKey)}
+	for _, k := range keys {
+		r.keys[k.ID] = k
+	}
+	return r
+}
+
+// tenantKeys return the keys of the tenant of the context, callers hold the lock
+func (r *apiKeyMemoryRepository) tenantKeys(ctx context.Context) ([]models.APIKey, string, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	var keys []models.APIKey
+	for _, k := range r.keys {
+		if k.TenantID == tenantID {
+			keys = append(keys, k)
+		}
+	}
+	return keys, tenantID, nil
+}
+
+func (r *apiKeyMemoryRepository) FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.APIKey, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys, _, err := r.tenantKeys(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []models.APIKey
+	for _, k := range keys {
+		if k.UserID == userID {
+			all = append(all, k)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	total := int64(len(all))
+	if offset >= total {
+		return []models.APIKey{}, total, nil
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *apiKeyMemoryRepository) FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys, _, err := r.tenantKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if k.Prefix == prefix {
+			return &k, nil
+		}
+	}
+	return nil, apikey.ErrNotFound
+}
+
+func (r *apiKeyMemoryRepository) Store(ctx context.Context, model *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.keys[model.ID] = *model
+	return nil
+}
+
+func (r *apiKeyMemoryRepository) Delete(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.keys[id]; !ok || existing.TenantID != tenantID || existing.UserID != userID {
+		return apikey.ErrNotFound
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+func (r *apiKeyMemoryRepository) Touch(ctx context.Context, id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	existing, ok := r.keys[id]
+	if !ok || existing.TenantID != tenantID {
+		return apikey.ErrNotFound
+	}
+	existing.LastUsedAt = &usedAt
+	r.keys[id] = existing
+	return nil
+}
+
+func (r *apiKeyMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, v := range r.keys {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.keys, id)
+		}
+	}
+	return nil
+}
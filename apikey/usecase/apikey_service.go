@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"go-echo-api/apikey"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/models"
+	"go-echo-api/user"
+	"strings"
+	"time"
+)
+
+// KeyPrefix start every API key so that leaked keys are easy to spot, e.g. by secret scanners.
+// A key reads "gea_<prefix>_<secret>", the prefix finds the stored key and the whole key is hashed.
+const KeyPrefix = "gea_"
+
+type APIKeyService struct {
+	apiKeyRepository apikey.Repository
+	userRepository   user.Repository
+}
+
+func NewAPIKeyService(r apikey.Repository, users user.Repository) apikey.Usecase {
+	return APIKeyService{apiKeyRepository: r, userRepository: users}
+}
+
+func (s APIKeyService) FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.APIKey, int64, error) {
+	return s.apiKeyRepository.FindAll(ctx, userID, limit, offset)
+}
+
+func (s APIKeyService) Create(ctx context.Context, userID string, dto apikey.Dto) (models.APIKey, string, error) {
+	if dto.ExpiresAt != nil && !dto.ExpiresAt.After(time.Now()) {
+		return models.APIKey{}, "", apikey.ErrExpiryPast
+	}
+	prefix, err := random(6, hex.EncodeToString)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	secret, err := random(24, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	key := KeyPrefix + prefix + "_" + secret
+	model := models.APIKey{
+		UserID:    userID,
+		Name:      dto.Name,
+		Prefix:    prefix,
+		Hash:      hash(key),
+		Scopes:    strings.Join(dto.Scopes, " "),
+		ExpiresAt: dto.ExpiresAt,
+	}
+	if err := s.apiKeyRepository.Store(ctx, &model); err != nil {
+		return model, "", err
+	}
+	logger.FromContext(ctx).WithField(logger.APIKeyIDField, model.ID).Info("api key created")
+	return model, key, nil
+}
+
+func (s APIKeyService) Revoke(ctx context.Context, userID string, id string) error {
+	if err := s.apiKeyRepository.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.APIKeyIDField, id).Info("api key revoked")
+	return nil
+}
+
+func (s APIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, *models.User, error) {
+	prefix, ok := parse(key)
+	if !ok {
+		return nil, nil, apikey.ErrInvalid
+	}
+	model, err := s.apiKeyRepository.FindByPrefix(ctx, prefix)
+	if err == apikey.ErrNotFound {
+		return nil, nil, apikey.ErrInvalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash(key)), []byte(model.Hash)) != 1 {
+		return nil, nil, apikey.ErrInvalid
+	}
+	now := time.Now()
+	if model.Expired(now) {
+		return nil, nil, apikey.ErrExpired
+	}
+	owner, err := s.userRepository.FindById(ctx, model.UserID)
+	if err == user.ErrNotFound {
+		return nil, nil, apikey.ErrInvalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.apiKeyRepository.Touch(ctx, model.ID, now); err != nil {
+		logger.FromContext(ctx).WithError(err).Warn("record the use of the api key failed")
+	}
+	return model, owner, nil
+}
+
+// parse return the prefix of a well-formed key
+func parse(key string) (string, bool) {
+	if !strings.HasPrefix(key, KeyPrefix) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(key, KeyPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// hash of the key as stored, the keys are random enough for a fast hash
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// random return n random bytes encoded with encode
+func random(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
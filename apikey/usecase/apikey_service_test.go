@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/apikey"
+	"go-echo-api/apikey/repository"
+	"go-echo-api/infrastructure/database/dbtest"
+	userRepository "go-echo-api/user/repository"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newAPIKeyService return a service backed by in-memory repositories holding the fixture users
+func newAPIKeyService() apikey.Usecase {
+	return NewAPIKeyService(repository.NewAPIKeyMemoryRepository(), userRepository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan))
+}
+
+func TestAPIKeyService_Create(t *testing.T) {
+	a := newAPIKeyService()
+
+	s := t.Run("success", func(t *testing.T) {
+		data, key, err := a.Create(dbtest.Context(), dbtest.UserUje.ID, apikey.Dto{Name: "batch", Scopes: []string{apikey.ScopeUsersRead}})
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(key, KeyPrefix+data.Prefix+"_"))
+		assert.NotContains(t, data.Hash, key)
+		assert.Equal(t, []string{apikey.ScopeUsersRead}, data.ScopeList())
+
+		list, total, err := a.FindAll(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, data.ID, list[0].ID)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		_, _, err := a.Create(dbtest.Context(), dbtest.UserUje.ID, apikey.Dto{Name: "batch", ExpiresAt: &past})
+		assert.Equal(t, apikey.ErrExpiryPast, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	a := newAPIKeyService()
+	_, key, err := a.Create(dbtest.Context(), dbtest.UserIpan.ID, apikey.Dto{Name: "batch"})
+	assert.NoError(t, err)
+
+	s := t.Run("success", func(t *testing.T) {
+		data, owner, err := a.Authenticate(dbtest.Context(), key)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserIpan.ID, owner.ID)
+		list, _, _ := a.FindAll(dbtest.Context(), dbtest.UserIpan.ID, 10, 0)
+		assert.Equal(t, data.ID, list[0].ID)
+		assert.NotNil(t, list[0].LastUsedAt)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// a wrong secret, a malformed key and a key of another tenant
+		_, _, err := a.Authenticate(dbtest.Context(), key+"x")
+		assert.Equal(t, apikey.ErrInvalid, err)
+		_, _, err = a.Authenticate(dbtest.Context(), "not-a-key")
+		assert.Equal(t, apikey.ErrInvalid, err)
+		_, _, err = a.Authenticate(dbtest.TenantContext(dbtest.TenantGlobex), key)
+		assert.Equal(t, apikey.ErrInvalid, err)
+	})
+	e := t.Run("error-expired", func(t *testing.T) {
+		soon := time.Now().Add(50 * time.Millisecond)
+		_, expiring, err := a.Create(dbtest.Context(), dbtest.UserIpan.ID, apikey.Dto{Name: "short", ExpiresAt: &soon})
+		assert.NoError(t, err)
+		time.Sleep(60 * time.Millisecond)
+		_, _, err = a.Authenticate(dbtest.Context(), expiring)
+		assert.Equal(t, apikey.ErrExpired, err)
+	})
+	r := t.Run("revoke", func(t *testing.T) {
+		list, _, _ := a.FindAll(dbtest.Context(), dbtest.UserIpan.ID, 10, 0)
+		assert.Equal(t, apikey.ErrNotFound, a.Revoke(dbtest.Context(), dbtest.UserUje.ID, list[0].ID), "only the owner revokes")
+		assert.NoError(t, a.Revoke(dbtest.Context(), dbtest.UserIpan.ID, list[0].ID))
+		_, _, err := a.Authenticate(dbtest.Context(), key)
+		assert.Equal(t, apikey.ErrInvalid, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, e, "Expired scenario failed run")
+	assert.Equal(t, true, r, "Revoke scenario failed run")
+}
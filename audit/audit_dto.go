@@ -0,0 +1,54 @@
+package audit
+
+import "time"
+
+// FilterDto is the query string of a search of the audit log, every field optional
+type FilterDto struct {
+	ActorID    string `query:"actor_id"`
+	Action     string `query:"action"`
+	TargetType string `query:"target_type"`
+	TargetID   string `query:"target_id"`
+	// From and To bound the time of the events, RFC 3339
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+// Filter of the events of the audit log, the zero values match any event
+type Filter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	// From and To bound the time of the events, both included
+	From time.Time
+	To   time.Time
+}
+
+// Filter return the filter of the query, ErrInvalidFilter when a time is not RFC 3339
+func (d FilterDto) Filter() (Filter, error) {
+	filter := Filter{ActorID: d.ActorID, Action: d.Action, TargetType: d.TargetType, TargetID: d.TargetID}
+	for _, bound := range []struct {
+		text  string
+		value *time.Time
+	}{{d.From, &filter.From}, {d.To, &filter.To}} {
+		if bound.text == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, bound.text)
+		if err != nil {
+			return Filter{}, ErrInvalidFilter
+		}
+		*bound.value = parsed
+	}
+	return filter, nil
+}
+
+// Matches tell whether the event at createdAt of actorID, action and target passes the filter
+func (f Filter) Matches(actorID string, action string, targetType string, targetID string, createdAt time.Time) bool {
+	return (f.ActorID == "" || f.ActorID == actorID) &&
+		(f.Action == "" || f.Action == action) &&
+		(f.TargetType == "" || f.TargetType == targetType) &&
+		(f.TargetID == "" || f.TargetID == targetID) &&
+		(f.From.IsZero() || !createdAt.Before(f.From)) &&
+		(f.To.IsZero() || !createdAt.After(f.To))
+}
@@ -0,0 +1,7 @@
+package audit
+
+import "errors"
+
+var (
+	ErrInvalidFilter = errors.New("from and to must be RFC 3339 times")
+)
@@ -0,0 +1,20 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-echo-api/models"
+)
+
+// Hash return the hex SHA-256 of the content of the event, PrevHash included so that each event
+// seals the chain before it. The time counts to the second, as truncated when recorded.
+func Hash(model models.AuditEvent) string {
+	content, _ := json.Marshal([]interface{}{
+		model.ID, model.TenantID, model.Sequence, model.ActorID, model.APIKeyID, model.Action,
+		model.TargetType, model.TargetID, model.IP, model.UserAgent, model.RequestID, model.Diff,
+		model.CreatedAt.Unix(), model.PrevHash,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
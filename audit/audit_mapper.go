@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"encoding/json"
+	"go-echo-api/models"
+	"time"
+)
+
+// Change is the value of a field before and after an action
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type Mapper struct {
+	ID         string            `json:"id"`
+	Sequence   int64             `json:"sequence"`
+	ActorID    string            `json:"actor_id"`
+	APIKeyID   string            `json:"api_key_id"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type"`
+	TargetID   string            `json:"target_id"`
+	IP         string            `json:"ip"`
+	UserAgent  string            `json:"user_agent"`
+	RequestID  string            `json:"request_id"`
+	Diff       map[string]Change `json:"diff"`
+	CreatedAt  time.Time         `json:"created_at"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash"`
+}
+
+func NewAuditMapper() *Mapper {
+	return &Mapper{}
+}
+
+func (m *Mapper) Map(model models.AuditEvent) *Mapper {
+	m.ID = model.ID
+	m.Sequence = model.Sequence
+	m.ActorID = model.ActorID
+	m.APIKeyID = model.APIKeyID
+	m.Action = model.Action
+	m.TargetType = model.TargetType
+	m.TargetID = model.TargetID
+	m.IP = model.IP
+	m.UserAgent = model.UserAgent
+	m.RequestID = model.RequestID
+	m.Diff = map[string]Change{}
+	if model.Diff != "" {
+		_ = json.Unmarshal([]byte(model.Diff), &m.Diff)
+	}
+	m.CreatedAt = model.CreatedAt
+	m.PrevHash = model.PrevHash
+	m.Hash = model.Hash
+	return m
+}
+
+func (m *Mapper) MapList(model []models.AuditEvent) interface{} {
+	serialized := make([]Mapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&Mapper{}).Map(v)
+	}
+	return serialized
+}
+
+// VerificationMapper is the outcome of a check of the hash chain
+type VerificationMapper struct {
+	Valid  bool  `json:"valid"`
+	Events int64 `json:"events"`
+	// BrokenAt is the sequence of the first event altered or missing, 0 for an intact chain
+	BrokenAt int64 `json:"broken_at"`
+}
+
+func NewVerificationMapper(v Verification) VerificationMapper {
+	return VerificationMapper{Valid: v.BrokenAt == 0, Events: v.Events, BrokenAt: v.BrokenAt}
+}
@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+// Repository of the audit log of the tenant of the context, the events are never updated nor deleted
+type Repository interface {
+	// Append add the event at the end of the chain of the tenant, it sets its ID, Sequence, PrevHash and Hash
+	Append(ctx context.Context, model *models.AuditEvent) error
+	// FindAll return a page of the events matching the filter, the latest first
+	FindAll(ctx context.Context, filter Filter, limit int64, offset int64) ([]models.AuditEvent, int64, error)
+	// FindAfter return at most limit events following the sequence, in the order of the chain
+	FindAfter(ctx context.Context, sequence int64, limit int64) ([]models.AuditEvent, error)
+}
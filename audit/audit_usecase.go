@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+// actions recorded
+const (
+	ActionUserCreated    = "user.created"
+	ActionUserUpdated    = "user.updated"
+	ActionUserDeleted    = "user.deleted"
+	ActionUsersImported  = "user.imported"
+	ActionLogin          = "auth.login"
+	ActionLoginFailed    = "auth.login_failed"
+	ActionMFAChallenged  = "auth.mfa_challenged"
+	ActionRegistered     = "auth.registered"
+	ActionRegisterFailed = "auth.register_failed"
+	ActionTokenRefreshed = "auth.token_refreshed"
+	ActionRefreshFailed  = "auth.refresh_failed"
+)
+
+// types of the targets
+const (
+	TargetUser = "user"
+	// TargetEmail is the target of the failures of an email not known to be a user
+	TargetEmail = "email"
+)
+
+// Source is the request acting
+type Source struct {
+	ActorID   string
+	APIKeyID  string
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// Entry is an action on a target, Before and After are the states of the target serialized to JSON
+// for the diff, nil when the target did not exist before or after the action
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// Verification is the outcome of a check of the hash chain
+type Verification struct {
+	// Events is the number of events checked
+	Events int64
+	// BrokenAt is the sequence of the first event altered or missing, 0 for an intact chain
+	BrokenAt int64
+}
+
+type Usecase interface {
+	// Record append the entry acted by source to the audit log of the tenant of the context
+	Record(ctx context.Context, source Source, entry Entry) error
+	FindAll(ctx context.Context, filter Filter, limit int64, offset int64) ([]models.AuditEvent, int64, error)
+	// Verify recompute the hash chain of the audit log of the tenant
+	Verify(ctx context.Context) (Verification, error)
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/audit"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/utils"
+)
+
+type auditController struct {
+	auditUsecase audit.Usecase
+	auditMapper  *audit.Mapper
+}
+
+func NewAuditController(s audit.Usecase) *auditController {
+	return &auditController{auditUsecase: s,
+		auditMapper: audit.NewAuditMapper(),
+	}
+}
+
+func (c *auditController) FindAll(ctx echo.Context) error {
+	var dto audit.FilterDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	filter, err := dto.Filter()
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.auditUsecase.FindAll(ctx.Request().Context(), filter, limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.auditMapper.MapList(result), nil)
+}
+
+// Verify check the hash chain of the audit log of the tenant
+func (c *auditController) Verify(ctx echo.Context) error {
+	result, err := c.auditUsecase.Verify(ctx.Request().Context())
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, audit.NewVerificationMapper(result), nil)
+}
+
+// errorResponse map the errors of the audit use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case audit.ErrInvalidFilter:
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("audit use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
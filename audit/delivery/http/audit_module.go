@@ -0,0 +1,31 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/audit/repository"
+	"go-echo-api/audit/usecase"
+	"go-echo-api/middleware"
+	tenantRepository "go-echo-api/tenant/repository"
+)
+
+// Module wire the audit log to the database and register its routes under /audit, they read the audit
+// log of the tenant of the request with the admin key of the deployment
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/audit"
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	controller := NewAuditController(usecase.NewAuditService(repository.NewAuditRepository(m.db)))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	g.GET("", controller.FindAll, tenantScope, middleware.IsAdmin)
+	g.GET("/verify", controller.Verify, tenantScope, middleware.IsAdmin)
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/audit"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	middleware.AdminOpenAPI(g)
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+	dateTime := &openapi.Schema{Type: "string", Format: "date-time"}
+
+	g.Add(echo.GET, "", openapi.Operation{
+		Tags:        []string{"audit"},
+		Summary:     "Search the audit log of the tenant, the latest events first",
+		OperationID: "listAuditEvents",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("actor_id", "ID of the user acting", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("action", "Action, e.g. user.updated or auth.login_failed", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("target_type", "Type of the target, user or email", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("target_id", "ID of the target", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("from", "Events at or after this time, RFC 3339", dateTime),
+			openapi.QueryParam("to", "Events at or before this time, RFC 3339", dateTime),
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of events skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of events", audit.Mapper{}),
+			"400": g.Error("Invalid time"),
+			"422": g.Error("Invalid query parameter"),
+		},
+	})
+	g.Add(echo.GET, "/verify", openapi.Operation{
+		Tags:        []string{"audit"},
+		Summary:     "Check the hash chain of the audit log of the tenant, an event altered or removed breaks it",
+		OperationID: "verifyAuditLog",
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Outcome of the check", audit.VerificationMapper{}),
+		},
+	})
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/audit"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+)
+
+// maxAppendAttempts bounds the retries of an append racing another one for the next sequence of the
+// tenant, the unique index on the sequence lets one of them win
+const maxAppendAttempts = 3
+
+type auditGormRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) audit.Repository {
+	return &auditGormRepository{db: db}
+}
+
+func (r *auditGormRepository) Append(ctx context.Context, model *models.AuditEvent) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	for attempt := 1; ; attempt++ {
+		err = database.Transaction(db, func(tx *gorm.DB) error {
+			var last models.AuditEvent
+			if err := tx.Order("sequence DESC").First(&last).Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+				return err
+			}
+			model.ID = uuid.New().String()
+			model.Sequence = last.Sequence + 1
+			model.PrevHash = last.Hash
+			model.Hash = audit.Hash(*model)
+			return tx.Create(model).Error
+		})
+		if err == nil || attempt == maxAppendAttempts {
+			return err
+		}
+	}
+}
+
+func (r *auditGormRepository) FindAll(ctx context.Context, filter audit.Filter, limit int64, offset int64) ([]models.AuditEvent, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.AuditEvent{})
+	for column, value := range map[string]string{
+		"actor_id":    filter.ActorID,
+		"action":      filter.Action,
+		"target_type": filter.TargetType,
+		"target_id":   filter.TargetID,
+	} {
+		if value != "" {
+			scoped = scoped.Where(column+" = ?", value)
+		}
+	}
+	if !filter.From.IsZero() {
+		scoped = scoped.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		scoped = scoped.Where("created_at <= ?", filter.To)
+	}
+	var model []models.AuditEvent
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("sequence DESC").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *auditGormRepository) FindAfter(ctx context.Context, sequence int64, limit int64) ([]models.AuditEvent, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model []models.AuditEvent
+	err = db.Where("sequence > ?", sequence).Order("sequence").Limit(limit).Find(&model).Error
+	return model, err
+}
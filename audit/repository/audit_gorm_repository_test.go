@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/audit"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"testing"
+	"time"
+)
+
+func TestAuditGormRepository(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewAuditRepository(db)
+	now := time.Now().Truncate(time.Second)
+	first := models.AuditEvent{ActorID: dbtest.UserUje.ID, Action: audit.ActionLogin, TargetType: audit.TargetUser, TargetID: dbtest.UserUje.ID, CreatedAt: now.Add(-time.Hour)}
+	second := models.AuditEvent{ActorID: dbtest.UserUje.ID, Action: audit.ActionUserUpdated, TargetType: audit.TargetUser, TargetID: dbtest.UserUje.ID, CreatedAt: now}
+	other := models.AuditEvent{Action: audit.ActionLoginFailed, TargetType: audit.TargetEmail, TargetID: "uje@email.com", CreatedAt: now}
+	assert.NoError(t, r.Append(dbtest.Context(), &first))
+	assert.NoError(t, r.Append(dbtest.Context(), &second))
+	assert.NoError(t, r.Append(dbtest.TenantContext(dbtest.TenantGlobex), &other))
+
+	// every tenant has its own chain
+	assert.Equal(t, int64(1), first.Sequence)
+	assert.Equal(t, "", first.PrevHash)
+	assert.Equal(t, int64(2), second.Sequence)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.Equal(t, int64(1), other.Sequence)
+
+	result, total, err := r.FindAll(dbtest.Context(), audit.Filter{}, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, second.ID, result[0].ID)
+	// the events read back hash the same
+	assert.Equal(t, second.Hash, audit.Hash(result[0]))
+
+	result, total, _ = r.FindAll(dbtest.Context(), audit.Filter{Action: audit.ActionLogin, From: now.Add(-2 * time.Hour), To: now.Add(-time.Minute)}, 10, 0)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, first.ID, result[0].ID)
+	_, total, _ = r.FindAll(dbtest.Context(), audit.Filter{From: now.Add(time.Minute)}, 10, 0)
+	assert.Equal(t, int64(0), total)
+
+	result, err = r.FindAfter(dbtest.Context(), 1, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, second.ID, result[0].ID)
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/audit"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sync"
+)
+
+// auditMemoryRepository keeps the chains of the tenants in memory, it backs the use-case unit tests
+type auditMemoryRepository struct {
+	mu     sync.RWMutex
+	chains map[string][]models.AuditEvent
+}
+
+func NewAuditMemoryRepository() audit.Repository {
+	return &auditMemoryRepository{chains: make(map[string][]models.AuditEvent)}
+}
+
+func (r *auditMemoryRepository) Append(ctx context.Context, model *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	chain := r.chains[tenantID]
+	model.ID = uuid.New().String()
+	model.TenantID = tenantID
+	model.Sequence = int64(len(chain)) + 1
+	model.PrevHash = ""
+	if len(chain) > 0 {
+		model.PrevHash = chain[len(chain)-1].Hash
+	}
+	model.Hash = audit.Hash(*model)
+	r.chains[tenantID] = append(chain, *model)
+	return nil
+}
+
+func (r *auditMemoryRepository) FindAll(ctx context.Context, filter audit.Filter, limit int64, offset int64) ([]models.AuditEvent, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []models.AuditEvent
+	chain := r.chains[tenantID]
+	for i := len(chain) - 1; i >= 0; i-- {
+		e := chain[i]
+		if filter.Matches(e.ActorID, e.Action, e.TargetType, e.TargetID, e.CreatedAt) {
+			all = append(all, e)
+		}
+	}
+	total := int64(len(all))
+	if offset >= total {
+		return []models.AuditEvent{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *auditMemoryRepository) FindAfter(ctx context.Context, sequence int64, limit int64) ([]models.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []models.AuditEvent
+	for _, e := range r.chains[tenantID] {
+		if e.Sequence > sequence && int64(len(result)) < limit {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
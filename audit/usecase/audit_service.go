@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-echo-api/audit"
+	"go-echo-api/models"
+	"reflect"
+	"time"
+)
+
+// verifyPage is the number of events read at once by Verify
+const verifyPage = 500
+
+type AuditService struct {
+	auditRepository audit.Repository
+}
+
+func NewAuditService(r audit.Repository) audit.Usecase {
+	return AuditService{auditRepository: r}
+}
+
+func (s AuditService) Record(ctx context.Context, source audit.Source, entry audit.Entry) error {
+	changes, err := diff(entry.Before, entry.After)
+	if err != nil {
+		return err
+	}
+	model := models.AuditEvent{
+		ActorID:    source.ActorID,
+		APIKeyID:   source.APIKeyID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         source.IP,
+		UserAgent:  source.UserAgent,
+		RequestID:  source.RequestID,
+		Diff:       changes,
+		CreatedAt:  time.Now().Truncate(time.Second),
+	}
+	return s.auditRepository.Append(ctx, &model)
+}
+
+func (s AuditService) FindAll(ctx context.Context, filter audit.Filter, limit int64, offset int64) ([]models.AuditEvent, int64, error) {
+	return s.auditRepository.FindAll(ctx, filter, limit, offset)
+}
+
+// Verify walk the chain from its first event, each event must follow the previous one, name its hash
+// and match its own. An event removed at the end of the chain goes unnoticed.
+func (s AuditService) Verify(ctx context.Context) (audit.Verification, error) {
+	var result audit.Verification
+	var previous models.AuditEvent
+	for {
+		page, err := s.auditRepository.FindAfter(ctx, previous.Sequence, verifyPage)
+		if err != nil {
+			return audit.Verification{}, err
+		}
+		for _, event := range page {
+			if event.Sequence != previous.Sequence+1 {
+				result.BrokenAt = previous.Sequence + 1
+				return result, nil
+			}
+			if event.PrevHash != previous.Hash || event.Hash != audit.Hash(event) {
+				result.BrokenAt = event.Sequence
+				return result, nil
+			}
+			result.Events++
+			previous = event
+		}
+		if len(page) < verifyPage {
+			return result, nil
+		}
+	}
+}
+
+// diff return the JSON object of the fields of before and after, serialized to JSON, which differ.
+// It is empty when nothing changed.
+func diff(before interface{}, after interface{}) (string, error) {
+	old, err := fields(before)
+	if err != nil {
+		return "", err
+	}
+	updated, err := fields(after)
+	if err != nil {
+		return "", err
+	}
+	changes := make(map[string]audit.Change)
+	for name, value := range old {
+		if other, ok := updated[name]; !ok || !reflect.DeepEqual(value, other) {
+			changes[name] = audit.Change{Before: value, After: other}
+		}
+	}
+	for name, value := range updated {
+		if _, ok := old[name]; !ok {
+			changes[name] = audit.Change{After: value}
+		}
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+	text, err := json.Marshal(changes)
+	return string(text), err
+}
+
+func fields(v interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if v == nil {
+		return result, nil
+	}
+	text, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return result, json.Unmarshal(text, &result)
+}
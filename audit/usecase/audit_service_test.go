@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/audit"
+	"go-echo-api/audit/repository"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/user"
+	"testing"
+)
+
+// tamperedRepository alter the events read back from the chain
+type tamperedRepository struct {
+	audit.Repository
+	tamper func(events []models.AuditEvent) []models.AuditEvent
+}
+
+func (r tamperedRepository) FindAfter(ctx context.Context, sequence int64, limit int64) ([]models.AuditEvent, error) {
+	events, err := r.Repository.FindAfter(ctx, sequence, limit)
+	return r.tamper(events), err
+}
+
+func TestAuditService(t *testing.T) {
+	source := audit.Source{ActorID: dbtest.UserUje.ID, IP: "192.0.2.1", UserAgent: "curl/8.4.0", RequestID: "req-1"}
+	before := user.Mapper{ID: dbtest.UserUje.ID, Name: "Uje", Email: "uje@email.com"}
+	after := user.Mapper{ID: dbtest.UserUje.ID, Name: "Ujang", Email: "uje@email.com"}
+
+	s := t.Run("success", func(t *testing.T) {
+		service := NewAuditService(repository.NewAuditMemoryRepository())
+		assert.NoError(t, service.Record(dbtest.Context(), source, audit.Entry{Action: audit.ActionUserCreated, TargetType: audit.TargetUser, TargetID: dbtest.UserUje.ID, After: before}))
+		assert.NoError(t, service.Record(dbtest.Context(), source, audit.Entry{Action: audit.ActionUserUpdated, TargetType: audit.TargetUser, TargetID: dbtest.UserUje.ID, Before: before, After: after}))
+		assert.NoError(t, service.Record(dbtest.Context(), audit.Source{}, audit.Entry{Action: audit.ActionLoginFailed, TargetType: audit.TargetEmail, TargetID: "nobody@email.com"}))
+
+		// the diff holds the fields changed only, all of them on a creation
+		result, total, err := service.FindAll(dbtest.Context(), audit.Filter{ActorID: dbtest.UserUje.ID}, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, audit.ActionUserUpdated, result[0].Action)
+		assert.Equal(t, `{"name":{"before":"Uje","after":"Ujang"}}`, result[0].Diff)
+		assert.Contains(t, result[1].Diff, `"email":{"before":null,"after":"uje@email.com"}`)
+		assert.Equal(t, result[1].Hash, result[0].PrevHash)
+
+		result, total, _ = service.FindAll(dbtest.Context(), audit.Filter{Action: audit.ActionLoginFailed}, 10, 0)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "", result[0].Diff)
+		_, total, _ = service.FindAll(dbtest.TenantContext(dbtest.TenantGlobex), audit.Filter{}, 10, 0)
+		assert.Equal(t, int64(0), total)
+
+		verification, err := service.Verify(dbtest.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, audit.Verification{Events: 3}, verification)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		memory := repository.NewAuditMemoryRepository()
+		for i := 0; i < 3; i++ {
+			_ = NewAuditService(memory).Record(dbtest.Context(), source, audit.Entry{Action: audit.ActionLogin, TargetType: audit.TargetUser, TargetID: dbtest.UserUje.ID})
+		}
+
+		// an event altered or removed breaks the chain
+		altered := NewAuditService(tamperedRepository{memory, func(events []models.AuditEvent) []models.AuditEvent {
+			if len(events) > 1 {
+				events[1].IP = "198.51.100.1"
+			}
+			return events
+		}})
+		verification, err := altered.Verify(dbtest.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, audit.Verification{Events: 1, BrokenAt: 2}, verification)
+
+		removed := NewAuditService(tamperedRepository{memory, func(events []models.AuditEvent) []models.AuditEvent {
+			if len(events) > 1 {
+				return append(events[:1], events[2:]...)
+			}
+			return events
+		}})
+		verification, _ = removed.Verify(dbtest.Context())
+		assert.Equal(t, audit.Verification{Events: 1, BrokenAt: 2}, verification)
+
+		_, err = (audit.FilterDto{From: "yesterday"}).Filter()
+		assert.Equal(t, audit.ErrInvalidFilter, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
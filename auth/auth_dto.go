@@ -9,4 +9,17 @@ type RegisterDto struct {
 	Name     string `json:"name" validate:"required"`
 	Email    string `json:"email" validate:"required"`
 	Password string `json:"password" validate:"required"`
-}
\ No newline at end of file
+	// InviteToken of an organization sent to the email, the user joins the organization once registered
+	InviteToken string `json:"invite_token,omitempty"`
+}
+
+type RefreshTokenDto struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// MFALoginDto finish the login of a user with two-factor authentication, Code is a code of its
+// authenticator app or one of its recovery codes
+type MFALoginDto struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
@@ -0,0 +1,9 @@
+package auth
+
+import "errors"
+
+var (
+	ErrNotFound           = errors.New("user not found")
+	ErrInvalidCredentials = errors.New("wrong username or password")
+	ErrEmailTaken         = errors.New("email is already taken")
+)
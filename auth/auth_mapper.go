@@ -1,6 +1,9 @@
 package auth
 
-import "go-echo-api/models"
+import (
+	"go-echo-api/models"
+	"time"
+)
 
 type Mapper struct {
 	ID    string `json:"id"`
@@ -18,3 +21,33 @@ func (m *Mapper) Map(model models.User) *Mapper {
 	return m
 }
 
+// TokenMapper is the token pair answered by the login and the refresh of the token
+type TokenMapper struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Expire       int64  `json:"expire"`
+}
+
+func NewTokenMapper(accessToken *string, refreshToken *string, expire interface{}) TokenMapper {
+	exp, _ := expire.(int64)
+	return TokenMapper{AccessToken: *accessToken, RefreshToken: *refreshToken, Expire: exp}
+}
+
+// MFAMapper is the login of a user with two-factor authentication, its mfa token is exchanged
+// with a code of the user on /auth/token/mfa before Expire
+type MFAMapper struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	Expire      int64  `json:"expire"`
+}
+
+func NewMFAMapper(token string, expiresAt time.Time) MFAMapper {
+	return MFAMapper{MFARequired: true, MFAToken: token, Expire: expiresAt.Unix()}
+}
+
+// LoginMapper document the answers of the login, a TokenMapper or a MFAMapper
+type LoginMapper struct {
+	TokenMapper
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
@@ -1,8 +1,11 @@
 package auth
 
-import "go-echo-api/models"
+import (
+	"context"
+	"go-echo-api/models"
+)
 
 type Repository interface {
-	Login(email string) (models.User, error)
-	Register(dto RegisterDto) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	Store(ctx context.Context, model *models.User) error
 }
@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+type Usecase interface {
+	Login(ctx context.Context, email string, password string) (models.User, error)
+	Register(ctx context.Context, dto RegisterDto) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+}
+
+// Login end the logins of the users, by password, magic link, external provider or passkey
+type Login interface {
+	// TokenPair start a session of the user logged in from ip with userAgent and return its token pair,
+	// in the active organization of the user
+	TokenPair(ctx context.Context, user models.User, ip string, userAgent string) (TokenMapper, error)
+}
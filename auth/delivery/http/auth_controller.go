@@ -4,24 +4,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
-	"github.com/jinzhu/gorm"
 	"github.com/labstack/echo"
+	"go-echo-api/audit"
 	"go-echo-api/auth"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/metrics"
 	"go-echo-api/infrastructure/response"
+	"go-echo-api/mfa"
 	"go-echo-api/middleware"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	"go-echo-api/session"
+	"go-echo-api/tenant"
 	"go-echo-api/utils"
 	"gopkg.in/go-playground/validator.v9"
 	"os"
 )
 
 type authController struct {
-	authRepository auth.Repository
-	authMapper     *auth.Mapper
+	authUsecase         auth.Usecase
+	organizationUsecase organization.Usecase
+	mfaUsecase          mfa.Usecase
+	sessionUsecase      session.Usecase
+	loginUsecase        auth.Login
+	auditUsecase        audit.Usecase
+	authMapper          *auth.Mapper
 }
 
-func NewAuthController(s auth.Repository) *authController {
-	return &authController{authRepository: s,
-		authMapper: auth.NewAuthMapper(),
+func NewAuthController(s auth.Usecase, o organization.Usecase, m mfa.Usecase, sessions session.Usecase, login auth.Login, a audit.Usecase) *authController {
+	return &authController{authUsecase: s,
+		organizationUsecase: o,
+		mfaUsecase:          m,
+		sessionUsecase:      sessions,
+		loginUsecase:        login,
+		auditUsecase:        a,
+		authMapper:          auth.NewAuthMapper(),
 	}
 }
 
@@ -37,20 +54,64 @@ func (c *authController) Login(ctx echo.Context) error {
 		}
 		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
 	}
-	result, err := c.authRepository.Login(dto.Email)
+	result, err := c.authUsecase.Login(ctx.Request().Context(), dto.Email, dto.Password)
+	if err == auth.ErrInvalidCredentials {
+		metrics.Logins.WithLabelValues(metrics.Failed).Inc()
+		c.record(ctx, "", audit.Entry{Action: audit.ActionLoginFailed, TargetType: audit.TargetEmail, TargetID: dto.Email})
+		return response.BadRequest(ctx, utils.BadRequest, nil, "Wrong username or password")
+	}
 	if err != nil {
+		metrics.Logins.WithLabelValues(metrics.Failed).Inc()
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("login failed")
 		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
 	}
-	if !utils.CheckPasswordHash(dto.Password, result.Password) {
-		return response.BadRequest(ctx, utils.BadRequest, nil, "Wrong username or password")
-	}
-	tokens, refreshToken, expire, err := middleware.GenerateTokenPair(result)
+	// the login of a user with two-factor authentication finishes on /token/mfa
+	token, expiresAt, err := c.mfaUsecase.Challenge(ctx.Request().Context(), result.ID)
 	if err != nil {
 		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
 	}
-	return response.SingleData(ctx, utils.OK, echo.Map{"access_token": tokens, "refresh_token": refreshToken, "expire": expire},
-		nil)
+	if token != "" {
+		c.record(ctx, result.ID, audit.Entry{Action: audit.ActionMFAChallenged, TargetType: audit.TargetUser, TargetID: result.ID})
+		return response.SingleData(ctx, utils.OK, auth.NewMFAMapper(token, expiresAt), nil)
+	}
+	metrics.Logins.WithLabelValues(metrics.Succeeded).Inc()
+	return c.tokenPair(ctx, result)
+}
+
+// LoginMFA finish the login of a user with two-factor authentication with a code of the user
+func (c *authController) LoginMFA(ctx echo.Context) error {
+	var dto auth.MFALoginDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.mfaUsecase.Verify(ctx.Request().Context(), dto.MFAToken, dto.Code)
+	metrics.Logins.WithLabelValues(metrics.Result(err)).Inc()
+	switch err {
+	case nil:
+		return c.tokenPair(ctx, *result)
+	case mfa.ErrInvalidCode:
+		c.record(ctx, "", audit.Entry{Action: audit.ActionLoginFailed})
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	case mfa.ErrInvalidToken:
+		c.record(ctx, "", audit.Entry{Action: audit.ActionLoginFailed})
+		return response.Unauthorized(ctx, utils.Unauthorized, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("login failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
 
+// tokenPair answer the token pair of a new session of the user logged in, in its active organization
+func (c *authController) tokenPair(ctx echo.Context, result models.User) error {
+	tokens, err := c.loginUsecase.TokenPair(ctx.Request().Context(), result, ctx.RealIP(), ctx.Request().UserAgent())
+	if err != nil {
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+	c.record(ctx, result.ID, audit.Entry{Action: audit.ActionLogin, TargetType: audit.TargetUser, TargetID: result.ID})
+	return response.SingleData(ctx, utils.OK, tokens, nil)
 }
 
 func (c *authController) Register(ctx echo.Context) error {
@@ -65,18 +126,30 @@ func (c *authController) Register(ctx echo.Context) error {
 		}
 		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
 	}
-	result, err := c.authRepository.Register(dto)
+	result, err := c.authUsecase.Register(ctx.Request().Context(), dto)
+	metrics.Registrations.WithLabelValues(metrics.Result(err)).Inc()
 	if err != nil {
+		c.record(ctx, "", audit.Entry{Action: audit.ActionRegisterFailed, TargetType: audit.TargetEmail, TargetID: dto.Email})
+	}
+	if err == auth.ErrEmailTaken || err == organization.ErrAlreadyMember {
+		return response.Conflict(ctx, utils.Conflict, nil, err.Error())
+	}
+	if err == organization.ErrInviteInvalid || err == organization.ErrNotFound {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err == organization.ErrInviteEmail {
+		return response.Forbidden(ctx, utils.Forbidden, nil, err.Error())
+	}
+	if err != nil {
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("register failed")
 		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
 	}
+	c.record(ctx, result.ID, audit.Entry{Action: audit.ActionRegistered, TargetType: audit.TargetUser, TargetID: result.ID, After: auth.NewAuthMapper().Map(result)})
 	return response.SingleData(ctx, utils.OK, c.authMapper.Map(result), nil)
 }
 
 func (c *authController) RefreshToken(ctx echo.Context) error {
-	type tokenReqBody struct {
-		RefreshToken string `json:"refresh_token"`
-	}
-	tokenReq := tokenReqBody{}
+	tokenReq := auth.RefreshTokenDto{}
 	if err := ctx.Bind(&tokenReq); err != nil {
 		errors := make([]echo.Map, 1)
 		if he, ok := err.(*echo.HTTPError); ok {
@@ -113,24 +186,76 @@ func (c *authController) RefreshToken(ctx echo.Context) error {
 	})
 
 	if err != nil {
+		metrics.Refreshes.WithLabelValues(metrics.Failed).Inc()
+		c.record(ctx, "", audit.Entry{Action: audit.ActionRefreshFailed})
 		return response.Unauthorized(ctx, utils.Unauthorized, nil, "Token not valid or expired")
 	}
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		// the email is only unique within a tenant, the token must belong to the tenant of the request
+		if tenantID, _ := tenant.ID(ctx.Request().Context()); claims["tenant_id"] != tenantID {
+			metrics.Refreshes.WithLabelValues(metrics.Failed).Inc()
+			c.record(ctx, "", audit.Entry{Action: audit.ActionRefreshFailed})
+			return response.Unauthorized(ctx, utils.Unauthorized, nil, tenant.ErrMismatch.Error())
+		}
+		// an access token, or a token issued to an OAuth client, cannot be exchanged for a token pair
+		if claims["typ"] == middleware.AccessTokenType || claims["client_id"] != nil {
+			metrics.Refreshes.WithLabelValues(metrics.Failed).Inc()
+			c.record(ctx, "", audit.Entry{Action: audit.ActionRefreshFailed})
+			return response.Unauthorized(ctx, utils.Unauthorized, nil, "Token not valid or expired")
+		}
 		// Get the user record from database or
 		// run through your business logic to verify if the user can log in
 		email := claims["email"]
-		result, err := c.authRepository.Login(email.(string))
-		if gorm.IsRecordNotFoundError(err) {
+		result, err := c.authUsecase.FindByEmail(ctx.Request().Context(), email.(string))
+		metrics.Refreshes.WithLabelValues(metrics.Result(err)).Inc()
+		if err == auth.ErrNotFound {
+			c.record(ctx, "", audit.Entry{Action: audit.ActionRefreshFailed})
 			return response.Unauthorized(ctx, utils.Unauthorized, nil, err.Error())
 		}
-		newTokenPair, newRefreshToken, newExpire, err := middleware.GenerateTokenPair(result)
+		if err != nil {
+			return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+		}
+		// the refresh is refused once the session is revoked, a token issued before the sessions starts one
+		sessionID, _ := claims[middleware.SessionClaim].(string)
+		if sessionID != "" {
+			err = c.sessionUsecase.Refresh(ctx.Request().Context(), result.ID, sessionID, ctx.RealIP(), ctx.Request().UserAgent())
+		} else {
+			var started models.Session
+			started, err = c.sessionUsecase.Start(ctx.Request().Context(), result.ID, ctx.RealIP(), ctx.Request().UserAgent())
+			sessionID = started.ID
+		}
+		if err == session.ErrNotFound {
+			c.record(ctx, result.ID, audit.Entry{Action: audit.ActionRefreshFailed, TargetType: audit.TargetUser, TargetID: result.ID})
+			return response.Unauthorized(ctx, utils.Unauthorized, nil, err.Error())
+		}
+		if err != nil {
+			return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+		}
+		// the user keeps its active organization unless it left it since
+		preferred, _ := claims["organization_id"].(string)
+		organizationID, err := c.organizationUsecase.ActiveOrganization(ctx.Request().Context(), result.ID, preferred)
+		if err != nil {
+			return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+		}
+		newTokenPair, newRefreshToken, newExpire, err := middleware.GenerateTokenPair(ctx.Request().Context(), result, organizationID, sessionID)
 		if err != nil {
 			return err
 		}
-		return response.SingleData(ctx, utils.OK, echo.Map{
-			"access_token": newTokenPair, "refresh_token": newRefreshToken, "expire": newExpire,
-		}, nil)
+		c.record(ctx, result.ID, audit.Entry{Action: audit.ActionTokenRefreshed, TargetType: audit.TargetUser, TargetID: result.ID})
+		return response.SingleData(ctx, utils.OK, auth.NewTokenMapper(newTokenPair, newRefreshToken, newExpire), nil)
 	}
 
 	return err
 }
+
+// record the entry in the audit log with actorID as the actor when not empty, the user logging in.
+// A failure is logged and leaves the response as is.
+func (c *authController) record(ctx echo.Context, actorID string, entry audit.Entry) {
+	source := middleware.AuditSource(ctx)
+	if actorID != "" {
+		source.ActorID = actorID
+	}
+	if err := c.auditUsecase.Record(ctx.Request().Context(), source, entry); err != nil {
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("audit failed")
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	auditRepository "go-echo-api/audit/repository"
+	auditUsecase "go-echo-api/audit/usecase"
+	"go-echo-api/auth/repository"
+	"go-echo-api/auth/usecase"
+	mfaRepository "go-echo-api/mfa/repository"
+	mfaUsecase "go-echo-api/mfa/usecase"
+	"go-echo-api/middleware"
+	organizationRepository "go-echo-api/organization/repository"
+	organizationUsecase "go-echo-api/organization/usecase"
+	outboxRepository "go-echo-api/outbox/repository"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+)
+
+// Module wire the auth controller to the database and register its routes under /auth
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/auth"
+}
+
+// Routes register the routes of the module, the users log in and register within the tenant of the request.
+// The users with two-factor authentication finish their login with a code on /token/mfa.
+func (m *Module) Routes(g *echo.Group) {
+	organizations := organizationUsecase.NewOrganizationService(organizationRepository.NewOrganizationRepository(m.db))
+	factors := mfaUsecase.NewMFAService(mfaRepository.NewMFARepository(m.db), userRepository.NewUserRepository(m.db), mfaUsecase.IssuerFromEnv())
+	sessions := sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL)
+	events := outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(m.db))
+	controller := NewAuthController(usecase.NewAuthService(repository.NewAuthRepository(m.db), organizations, events), organizations, factors, sessions, usecase.NewLoginService(organizations, sessions), auditUsecase.NewAuditService(auditRepository.NewAuditRepository(m.db)))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	g.POST("/token", controller.Login, tenantScope)
+	g.POST("/token/mfa", controller.LoginMFA, tenantScope)
+	g.POST("/register", controller.Register, tenantScope)
+	g.POST("/refresh-token", controller.RefreshToken, tenantScope)
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/middleware"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	g.Add(echo.POST, "/token", openapi.Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Log in with email and password",
+		OperationID: "login",
+		RequestBody: g.Body(auth.LoginDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Access and refresh tokens of the user, or the mfa token of a user with two-factor authentication", auth.LoginMapper{}),
+			"400": g.Error("Wrong email or password"),
+			"422": g.Error("Invalid body"),
+		},
+	})
+	g.Add(echo.POST, "/token/mfa", openapi.Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Finish the login of a user with two-factor authentication with a code of its app or a recovery code",
+		OperationID: "loginMFA",
+		RequestBody: g.Body(auth.MFALoginDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Access and refresh tokens of the user", auth.TokenMapper{}),
+			"400": g.Error("Invalid or already used code"),
+			"401": g.Error("Mfa token unknown or expired, or too many wrong codes"),
+			"422": g.Error("Invalid body"),
+		},
+	})
+	g.Add(echo.POST, "/register", openapi.Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Register a new user",
+		OperationID: "register",
+		RequestBody: g.Body(auth.RegisterDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Registered user", auth.Mapper{}),
+			"400": g.Error("No tenant named by the request, or invite token not valid or expired"),
+			"403": g.Error("Invite token sent to another email"),
+			"409": g.Error("Email already taken"),
+			"422": g.Error("Invalid body"),
+		},
+	})
+	g.Add(echo.POST, "/refresh-token", openapi.Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Exchange a refresh token for a new token pair",
+		OperationID: "refreshToken",
+		RequestBody: g.Body(auth.RefreshTokenDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("New access and refresh tokens", auth.TokenMapper{}),
+			"401": g.Error("Refresh token not valid or expired"),
+		},
+	})
+}
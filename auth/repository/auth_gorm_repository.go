@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+)
+
+type authGormRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthRepository(db *gorm.DB) auth.Repository {
+	return &authGormRepository{db: db}
+}
+
+func (r *authGormRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var model models.User
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return model, err
+	}
+	err = database.WithContext(ctx, r.db).Where("tenant_id = ? AND email = ?", tenantID, email).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return model, auth.ErrNotFound
+	}
+	return model, err
+}
+
+// Store create the user in the tenant of the context
+func (r *authGormRepository) Store(ctx context.Context, model *models.User) error {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return database.WithContext(ctx, r.db).Create(model).Error
+}
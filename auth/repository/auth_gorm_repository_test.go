@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"testing"
+)
+
+func TestAuthGormRepository_FindByEmail(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewAuthRepository(db)
+	s := t.Run("success", func(t *testing.T) {
+		data, err := r.FindByEmail(dbtest.Context(), dbtest.UserUje.Email)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserUje.ID, data.ID)
+	})
+	f := t.Run("error-not-found", func(t *testing.T) {
+		_, err := r.FindByEmail(dbtest.Context(), "nobody@email.com")
+		assert.Equal(t, auth.ErrNotFound, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestAuthGormRepository_Store(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewAuthRepository(db)
+	model := models.User{Name: "Ahmad", Email: "ahmad@email.com", Password: "hash"}
+	assert.NoError(t, r.Store(dbtest.Context(), &model))
+	assert.NotEmpty(t, model.ID)
+}
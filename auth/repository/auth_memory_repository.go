@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/auth"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sync"
+	"time"
+)
+
+// authMemoryRepository keeps the users in memory keyed by tenant and email, it backs the use-case unit tests
+type authMemoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+}
+
+func NewAuthMemoryRepository(users ...models.User) auth.Repository {
+	r := &authMemoryRepository{users: make(map[string]models.User)}
+	for _, u := range users {
+		r.users[u.TenantID+"/"+u.Email] = u
+	}
+	return r
+}
+
+func (r *authMemoryRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[tenantID+"/"+email]
+	if !ok {
+		return models.User{}, auth.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *authMemoryRepository) Store(ctx context.Context, model *models.User) error {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[tenantID+"/"+model.Email]; ok {
+		return auth.ErrEmailTaken
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.users[tenantID+"/"+model.Email] = *model
+	return nil
+}
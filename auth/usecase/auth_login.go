@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"context"
+	"go-echo-api/auth"
+	"go-echo-api/middleware"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	"go-echo-api/session"
+)
+
+type LoginService struct {
+	organizationUsecase organization.Usecase
+	sessionUsecase      session.Usecase
+}
+
+func NewLoginService(o organization.Usecase, sessions session.Usecase) auth.Login {
+	return LoginService{organizationUsecase: o, sessionUsecase: sessions}
+}
+
+func (s LoginService) TokenPair(ctx context.Context, user models.User, ip string, userAgent string) (auth.TokenMapper, error) {
+	organizationID, err := s.organizationUsecase.ActiveOrganization(ctx, user.ID, "")
+	if err != nil {
+		return auth.TokenMapper{}, err
+	}
+	started, err := s.sessionUsecase.Start(ctx, user.ID, ip, userAgent)
+	if err != nil {
+		return auth.TokenMapper{}, err
+	}
+	tokens, refreshToken, expire, err := middleware.GenerateTokenPair(ctx, user, organizationID, started.ID)
+	if err != nil {
+		return auth.TokenMapper{}, err
+	}
+	return auth.NewTokenMapper(tokens, refreshToken, expire), nil
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/middleware"
+	"go-echo-api/models"
+	organizationRepository "go-echo-api/organization/repository"
+	organizationUsecase "go-echo-api/organization/usecase"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	"testing"
+)
+
+func TestLoginService_TokenPair(t *testing.T) {
+	organizations := organizationRepository.NewOrganizationMemoryRepository(
+		[]models.Organization{dbtest.OrganizationAcme},
+		[]models.Membership{dbtest.MembershipUje},
+	)
+	sessions := sessionUsecase.NewSessionService(sessionRepository.NewSessionMemoryRepository(), middleware.TokenTTL)
+	l := NewLoginService(organizationUsecase.NewOrganizationService(organizations), sessions)
+
+	s := t.Run("success", func(t *testing.T) {
+		data, err := l.TokenPair(dbtest.Context(), dbtest.UserUje, "127.0.0.1", "Mozilla/5.0")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data.RefreshToken)
+		// the access token carries the active organization and the session started
+		claims, err := middleware.ParseToken(data.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.OrganizationAcme.ID, claims["organization_id"])
+		started, total, err := sessions.FindAll(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, started[0].ID, claims[middleware.SessionClaim])
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+}
@@ -1,35 +1,104 @@
 package usecase
 
 import (
-	"github.com/jinzhu/gorm"
+	"context"
+	"github.com/sirupsen/logrus"
 	"go-echo-api/auth"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/tracing"
 	"go-echo-api/models"
+	"go-echo-api/organization"
+	"go-echo-api/outbox"
 	"go-echo-api/utils"
+	"strings"
 )
 
 type AuthService struct {
-	*gorm.DB
+	authRepository      auth.Repository
+	organizationUsecase organization.Usecase
+	outboxUsecase       outbox.Usecase
 }
 
-func NewAuthService(db *gorm.DB) auth.Repository {
-	return AuthService{db}
+// NewAuthService return the service of the authentication, the users registered are written with their
+// domain event to the outbox events
+func NewAuthService(r auth.Repository, o organization.Usecase, events outbox.Usecase) auth.Usecase {
+	return AuthService{authRepository: r, organizationUsecase: o, outboxUsecase: events}
 }
 
-func (a AuthService) Login(email string) (models.User, error) {
-	var model models.User
-	err := a.DB.Find(&model, "email=?", email).Error
-	return model, err
+// Login return the user owning the email when the password matches
+func (a AuthService) Login(ctx context.Context, email string, password string) (models.User, error) {
+	log := logger.FromContext(ctx)
+	model, err := a.authRepository.FindByEmail(ctx, email)
+	if err == auth.ErrNotFound {
+		log.WithField("reason", "unknown email").Info("login failed")
+		return model, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return model, err
+	}
+	_, span := tracing.Start(ctx, "CheckPasswordHash")
+	checked := utils.CheckPasswordHash(password, model.Password)
+	span.End()
+	if !checked {
+		log.WithFields(logrus.Fields{"reason": "wrong password", logger.UserIDField: model.ID}).Info("login failed")
+		return models.User{}, auth.ErrInvalidCredentials
+	}
+	log.WithField(logger.UserIDField, model.ID).Info("login succeeded")
+	return model, nil
 }
 
-func (a AuthService) Register(dto auth.RegisterDto) (models.User, error) {
+// Register the user, with an invite token the user joins the organization of the invitation,
+// which must have been sent to the email of the user
+func (a AuthService) Register(ctx context.Context, dto auth.RegisterDto) (models.User, error) {
 	var model models.User
+	var invitation organization.Invitation
+	if dto.InviteToken != "" {
+		var err error
+		if invitation, err = a.organizationUsecase.ParseInvite(ctx, dto.InviteToken); err != nil {
+			return model, err
+		}
+		if !strings.EqualFold(invitation.Email, dto.Email) {
+			return model, organization.ErrInviteEmail
+		}
+	}
+	_, err := a.authRepository.FindByEmail(ctx, dto.Email)
+	if err == nil {
+		return model, auth.ErrEmailTaken
+	}
+	if err != auth.ErrNotFound {
+		return model, err
+	}
 	model.Name = dto.Name
 	model.Email = dto.Email
+	_, span := tracing.Start(ctx, "HashPassword")
 	hashPassword, err := utils.HashPassword(dto.Password)
+	tracing.End(span, err)
 	if err != nil {
 		return model, err
 	}
 	model.Password = hashPassword
-	err = a.DB.Save(&model).Error
-	return model, err
+	// the invited user joins in the transaction of its registration, an invitation accepted or revoked
+	// meanwhile leaves no account behind
+	err = a.outboxUsecase.Transaction(ctx, func(ctx context.Context) error {
+		if err := a.authRepository.Store(ctx, &model); err != nil {
+			return err
+		}
+		if err := a.outboxUsecase.Publish(ctx, outbox.EventUserRegistered, model.ID, outbox.NewUserData(model)); err != nil {
+			return err
+		}
+		if dto.InviteToken == "" {
+			return nil
+		}
+		_, err := a.organizationUsecase.Join(ctx, invitation, model)
+		return err
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, model.ID).Info("user registered")
+	return model, nil
+}
+
+func (a AuthService) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return a.authRepository.FindByEmail(ctx, email)
 }
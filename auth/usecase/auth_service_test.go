@@ -1 +1,141 @@
 package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/auth"
+	"go-echo-api/auth/repository"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	organizationRepository "go-echo-api/organization/repository"
+	organizationUsecase "go-echo-api/organization/usecase"
+	outboxRepository "go-echo-api/outbox/repository"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	"go-echo-api/utils"
+	"testing"
+	"time"
+)
+
+// newAuthService return a service backed by in-memory repositories holding a fixture user
+// and the fixture organization, and the organization service it registers the invited users with
+func newAuthService() (auth.Usecase, organization.Usecase) {
+	ipan := dbtest.NewUser(func(u *models.User) {
+		*u = dbtest.UserIpan
+	})
+	organizations := organizationRepository.NewOrganizationMemoryRepository(
+		[]models.Organization{dbtest.OrganizationAcme},
+		[]models.Membership{dbtest.MembershipUje},
+	)
+	o := organizationUsecase.NewOrganizationService(organizations)
+	return NewAuthService(repository.NewAuthMemoryRepository(ipan), o, outboxUsecase.NewOutboxService(outboxRepository.NewOutboxMemoryRepository())), o
+}
+
+func TestAuthService_Login(t *testing.T) {
+	a, _ := newAuthService()
+
+	s := t.Run("success", func(t *testing.T) {
+		data, err := a.Login(dbtest.Context(), dbtest.UserIpan.Email, dbtest.FixturePassword)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserIpan.ID, data.ID)
+	})
+	f := t.Run("error-wrong-password", func(t *testing.T) {
+		_, err := a.Login(dbtest.Context(), dbtest.UserIpan.Email, "wrong")
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+	n := t.Run("error-unknown-email", func(t *testing.T) {
+		_, err := a.Login(dbtest.Context(), "nobody@email.com", dbtest.FixturePassword)
+		assert.Equal(t, auth.ErrInvalidCredentials, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, n, "Unknown email scenario failed run")
+}
+
+func TestAuthService_Register(t *testing.T) {
+	a, o := newAuthService()
+	invite := func(email string) string {
+		token, _, err := o.Invite(dbtest.Context(), dbtest.UserUje.ID, dbtest.OrganizationAcme.ID, organization.InviteDto{Email: email, Role: models.RoleMember})
+		assert.NoError(t, err)
+		return token
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		data, err := a.Register(dbtest.Context(), auth.RegisterDto{Name: "Ahmad", Email: "ahmad@email.com", Password: "password"})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data.ID)
+		assert.True(t, utils.CheckPasswordHash("password", data.Password))
+	})
+	f := t.Run("error-duplicate", func(t *testing.T) {
+		_, err := a.Register(dbtest.Context(), auth.RegisterDto{Name: "Ahmad", Email: dbtest.UserIpan.Email, Password: "password"})
+		assert.Equal(t, auth.ErrEmailTaken, err)
+	})
+	i := t.Run("success-invite", func(t *testing.T) {
+		// the invited user joins the organization once registered
+		data, err := a.Register(dbtest.Context(), auth.RegisterDto{Name: "Budi", Email: "Budi@email.com", Password: "password", InviteToken: invite("budi@email.com")})
+		assert.NoError(t, err)
+		members, _, err := o.FindMembers(dbtest.Context(), data.ID, dbtest.OrganizationAcme.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Len(t, members, 2)
+	})
+	e := t.Run("error-invite", func(t *testing.T) {
+		_, err := a.Register(dbtest.Context(), auth.RegisterDto{Name: "Eve", Email: "eve@email.com", Password: "password", InviteToken: invite("budi@email.com")})
+		assert.Equal(t, organization.ErrInviteEmail, err)
+
+		_, err = a.Register(dbtest.Context(), auth.RegisterDto{Name: "Eve", Email: "eve@email.com", Password: "password", InviteToken: "not-a-token"})
+		assert.Equal(t, organization.ErrInviteInvalid, err)
+
+		// an invite of another tenant
+		_, err = a.Register(dbtest.TenantContext(dbtest.TenantGlobex), auth.RegisterDto{Name: "Eve", Email: "eve@email.com", Password: "password", InviteToken: invite("eve@email.com")})
+		assert.Equal(t, organization.ErrInviteInvalid, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, i, "Invite scenario failed run")
+	assert.Equal(t, true, e, "Invite failed scenario failed run")
+}
+
+// revokingOrganizations revoke the invitation once parsed, as its manager would before the user registers
+type revokingOrganizations struct {
+	organization.Usecase
+	organizationRepository organization.Repository
+}
+
+func (o revokingOrganizations) ParseInvite(ctx context.Context, token string) (organization.Invitation, error) {
+	invitation, err := o.Usecase.ParseInvite(ctx, token)
+	if err != nil {
+		return invitation, err
+	}
+	return invitation, o.organizationRepository.RevokeInvitation(ctx, invitation.OrganizationID, invitation.ID, time.Now())
+}
+
+func TestAuthService_RegisterRollback(t *testing.T) {
+	// a database of its own, the transaction of the registration is committed or rolled back for real
+	db, err := database.OpenDSN(database.SQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	database.AutoMigrate(db)
+	assert.NoError(t, dbtest.LoadFixtures(db, dbtest.Fixtures()...))
+	organizations := organizationRepository.NewOrganizationRepository(db)
+	o := organizationUsecase.NewOrganizationService(organizations)
+	a := NewAuthService(repository.NewAuthRepository(db), revokingOrganizations{Usecase: o, organizationRepository: organizations},
+		outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db)))
+
+	f := t.Run("error-failed", func(t *testing.T) {
+		token, _, err := o.Invite(dbtest.Context(), dbtest.UserUje.ID, dbtest.OrganizationAcme.ID, organization.InviteDto{Email: "budi@email.com", Role: models.RoleMember})
+		assert.NoError(t, err)
+		_, err = a.Register(dbtest.Context(), auth.RegisterDto{Name: "Budi", Email: "budi@email.com", Password: "password", InviteToken: token})
+		assert.Equal(t, organization.ErrInviteInvalid, err)
+
+		// neither the user nor its user.registered event outlive the invitation revoked
+		count := 0
+		assert.NoError(t, db.Model(&models.User{}).Where("email = ?", "budi@email.com").Count(&count).Error)
+		assert.Equal(t, 0, count)
+		assert.NoError(t, db.Model(&models.OutboxEvent{}).Count(&count).Error)
+		assert.Equal(t, 0, count)
+	})
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
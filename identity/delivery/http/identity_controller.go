@@ -0,0 +1,131 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/auth"
+	"go-echo-api/identity"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/metrics"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/mfa"
+	"go-echo-api/middleware"
+	"go-echo-api/utils"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type identityController struct {
+	identityUsecase identity.Usecase
+	mfaUsecase      mfa.Usecase
+	loginUsecase    auth.Login
+	identityMapper  *identity.Mapper
+}
+
+func NewIdentityController(s identity.Usecase, m mfa.Usecase, login auth.Login) *identityController {
+	return &identityController{identityUsecase: s,
+		mfaUsecase:     m,
+		loginUsecase:   login,
+		identityMapper: identity.NewIdentityMapper(),
+	}
+}
+
+// Start redirect the user to the provider for logging in
+func (c *identityController) Start(ctx echo.Context) error {
+	location, err := c.identityUsecase.Start(ctx.Request().Context(), ctx.Param("provider"), "", callbackURL(ctx))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return ctx.Redirect(http.StatusFound, location)
+}
+
+// Link return the URL of the provider where the logged in user authenticates the identity to link
+func (c *identityController) Link(ctx echo.Context) error {
+	location, err := c.identityUsecase.Start(ctx.Request().Context(), ctx.Param("provider"), middleware.UserID(ctx), callbackURL(ctx))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, identity.AuthorizationMapper{AuthorizationURL: location}, nil)
+}
+
+// Callback answer the token pair of the user logged in, its mfa token when it has two-factor authentication,
+// or the identity linked
+func (c *identityController) Callback(ctx echo.Context) error {
+	var dto identity.CallbackDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	result, err := c.identityUsecase.Callback(ctx.Request().Context(), ctx.Param("provider"), dto)
+	if err != nil {
+		metrics.Logins.WithLabelValues(metrics.Failed).Inc()
+		return errorResponse(ctx, err)
+	}
+	if result.Linked {
+		return response.SingleData(ctx, utils.OK, c.identityMapper.Map(result.Identity), nil)
+	}
+	if result.Created {
+		metrics.Registrations.WithLabelValues(metrics.Succeeded).Inc()
+	}
+	// a user with two-factor authentication finishes on /auth/token/mfa as with its password
+	token, expiresAt, err := c.mfaUsecase.Challenge(ctx.Request().Context(), result.User.ID)
+	if err != nil {
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+	if token != "" {
+		return response.SingleData(ctx, utils.OK, auth.NewMFAMapper(token, expiresAt), nil)
+	}
+	metrics.Logins.WithLabelValues(metrics.Succeeded).Inc()
+	tokens, err := c.loginUsecase.TokenPair(ctx.Request().Context(), result.User, ctx.RealIP(), ctx.Request().UserAgent())
+	if err != nil {
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+	return response.SingleData(ctx, utils.OK, tokens, nil)
+}
+
+func (c *identityController) FindAll(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.identityUsecase.FindIdentities(ctx.Request().Context(), middleware.UserID(ctx), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.identityMapper.MapList(result), nil)
+}
+
+func (c *identityController) Delete(ctx echo.Context) error {
+	if err := c.identityUsecase.Unlink(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+// callbackURL return the callback of the provider of the request under APP_IDENTITY_BASE_URL,
+// or under the URL of the module on the host of the request
+func callbackURL(ctx echo.Context) string {
+	provider := ctx.Param("provider")
+	if base := os.Getenv("APP_IDENTITY_BASE_URL"); base != "" {
+		return strings.TrimSuffix(base, "/") + "/" + provider + "/callback"
+	}
+	path := ctx.Path()
+	path = path[:strings.LastIndex(path, "/:provider/")]
+	return ctx.Scheme() + "://" + ctx.Request().Host + path + "/" + provider + "/callback"
+}
+
+// errorResponse map the errors of the identity use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case identity.ErrNotFound, identity.ErrUnknownProvider:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	case identity.ErrInvalidState:
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	case identity.ErrDenied, identity.ErrInvalidIdentity, identity.ErrEmailUnverified:
+		return response.Unauthorized(ctx, utils.Unauthorized, nil, err.Error())
+	case identity.ErrLinked, identity.ErrEmailTaken, identity.ErrLastLogin:
+		return response.Conflict(ctx, utils.Conflict, nil, err.Error())
+	case identity.ErrUnavailable:
+		return response.BadGateway(ctx, utils.BadGateway, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("identity use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	authUsecase "go-echo-api/auth/usecase"
+	"go-echo-api/identity"
+	"go-echo-api/identity/provider"
+	"go-echo-api/identity/repository"
+	"go-echo-api/identity/usecase"
+	"go-echo-api/infrastructure/logger"
+	mfaRepository "go-echo-api/mfa/repository"
+	mfaUsecase "go-echo-api/mfa/usecase"
+	"go-echo-api/middleware"
+	organizationRepository "go-echo-api/organization/repository"
+	organizationUsecase "go-echo-api/organization/usecase"
+	"go-echo-api/outbox"
+	outboxRepository "go-echo-api/outbox/repository"
+	"go-echo-api/outbox/sink"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+)
+
+// Module wire the login with the external identity providers of APP_IDENTITY_PROVIDERS to the database
+// and register its routes under /auth/oauth. The users log in with a provider, or link an identity
+// of a provider to their account once logged in with the access token of the user.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/auth/oauth"
+}
+
+// Subscribe delete the linked identities of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewIdentityRepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	providers, err := provider.FromEnv()
+	if err != nil {
+		logger.Default().WithError(err).Fatal("configure the identity providers")
+	}
+	organizations := organizationUsecase.NewOrganizationService(organizationRepository.NewOrganizationRepository(m.db))
+	factors := mfaUsecase.NewMFAService(mfaRepository.NewMFARepository(m.db), userRepository.NewUserRepository(m.db), mfaUsecase.IssuerFromEnv())
+	sessions := sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL)
+	events := outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(m.db))
+	controller := NewIdentityController(usecase.NewIdentityService(repository.NewIdentityRepository(m.db), userRepository.NewUserRepository(m.db), providers, events), factors, authUsecase.NewLoginService(organizations, sessions))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	loggedIn := middleware.IsLoggedIn(sessions)
+	g.GET("/:provider/start", controller.Start, tenantScope)
+	g.GET("/:provider/callback", controller.Callback, stateTenant, tenantScope)
+	g.POST("/:provider/link", controller.Link, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.GET("/identities", controller.FindAll, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.DELETE("/identities/:id", controller.Delete, tenantScope, loggedIn, middleware.RequireUnscoped)
+}
+
+// stateTenant name the tenant of the state in the X-Tenant-ID header of a callback without one,
+// the provider redirects the user to the callback without the headers of the start
+func stateTenant(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(middleware.HeaderTenantID) == "" {
+			if tenantID := identity.StateTenant(c.QueryParam("state")); tenantID != "" {
+				c.Request().Header.Set(middleware.HeaderTenantID, tenantID)
+			}
+		}
+		return next(c)
+	}
+}
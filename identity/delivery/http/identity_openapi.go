@@ -0,0 +1,94 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/identity"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+	provider := openapi.PathParam("provider", "Name of the identity provider in APP_IDENTITY_PROVIDERS")
+	unauthorized := g.Error("Missing or invalid access token")
+	scoped := g.Error("Token issued to an OAuth client")
+
+	g.Add(echo.GET, "/:provider/start", openapi.Operation{
+		Tags:        []string{"identity"},
+		Summary:     "Log in with an identity provider, the user is redirected to the provider then to the callback",
+		OperationID: "startIdentityLogin",
+		Parameters:  []openapi.Parameter{provider},
+		Responses: map[string]openapi.Response{
+			"302": {Description: "Redirect to the provider"},
+			"404": g.Error("Identity provider not configured"),
+			"502": g.Error("Identity provider unavailable"),
+		},
+	})
+	g.Add(echo.GET, "/:provider/callback", openapi.Operation{
+		Tags:        []string{"identity"},
+		Summary:     "Finish the login with the provider, or the link of the identity, the provider redirects the user here",
+		OperationID: "identityCallback",
+		Parameters: []openapi.Parameter{
+			provider,
+			openapi.QueryParam("code", "Authorization code of the provider", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("state", "State of the login", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("error", "Error of the provider", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("error_description", "Description of the error of the provider", &openapi.Schema{Type: "string"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Token pair of the user logged in, created on its first login, its mfa token when it has two-factor authentication, or the identity linked", nil),
+			"400": g.Error("State unknown, used or expired"),
+			"401": g.Error("Login denied, or identity invalid or without verified email"),
+			"404": g.Error("Identity provider not configured"),
+			"409": g.Error("Identity linked to another user, or email registered to a user who has to link the identity"),
+			"502": g.Error("Identity provider unavailable"),
+		},
+	})
+	g.Add(echo.POST, "/:provider/link", openapi.Operation{
+		Tags:        []string{"identity"},
+		Summary:     "Start the link of an identity of the provider to the logged in user, the user is sent to the returned URL",
+		OperationID: "linkIdentity",
+		Parameters:  []openapi.Parameter{provider},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("URL of the provider", identity.AuthorizationMapper{}),
+			"401": unauthorized,
+			"403": scoped,
+			"404": g.Error("Identity provider not configured"),
+			"502": g.Error("Identity provider unavailable"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.GET, "/identities", openapi.Operation{
+		Tags:        []string{"identity"},
+		Summary:     "List the identities linked to the logged in user",
+		OperationID: "listIdentities",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of identities skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of linked identities", identity.Mapper{}),
+			"401": unauthorized,
+			"403": scoped,
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.DELETE, "/identities/:id", openapi.Operation{
+		Tags:        []string{"identity"},
+		Summary:     "Unlink an identity of the logged in user",
+		OperationID: "unlinkIdentity",
+		Parameters:  []openapi.Parameter{openapi.PathParam("id", "ID of the linked identity")},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Identity unlinked", nil),
+			"401": unauthorized,
+			"403": scoped,
+			"404": g.Error("Linked identity not found"),
+			"409": g.Error("Identity is the only login of the user"),
+		},
+		Security: openapi.BearerAuth,
+	})
+}
@@ -0,0 +1,9 @@
+package identity
+
+// CallbackDto is the redirection of the provider to the callback, with a code or an error (RFC 6749 4.1.2)
+type CallbackDto struct {
+	Code             string `query:"code" json:"code"`
+	State            string `query:"state" json:"state"`
+	Error            string `query:"error" json:"error"`
+	ErrorDescription string `query:"error_description" json:"error_description"`
+}
@@ -0,0 +1,16 @@
+package identity
+
+import "errors"
+
+var (
+	ErrNotFound        = errors.New("linked identity not found")
+	ErrUnknownProvider = errors.New("identity provider not configured")
+	ErrInvalidState    = errors.New("login state unknown or expired, start again")
+	ErrDenied          = errors.New("the identity provider denied the login")
+	ErrInvalidIdentity = errors.New("the identity provider answered an invalid identity")
+	ErrUnavailable     = errors.New("the identity provider is unavailable")
+	ErrLinked          = errors.New("identity is linked to another user")
+	ErrEmailTaken      = errors.New("email is registered to a user, log in and link the identity")
+	ErrEmailUnverified = errors.New("identity provider shared no verified email")
+	ErrLastLogin       = errors.New("identity is the only login of the user")
+)
@@ -0,0 +1,40 @@
+package identity
+
+import (
+	"go-echo-api/models"
+	"time"
+)
+
+type Mapper struct {
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewIdentityMapper() *Mapper {
+	return &Mapper{}
+}
+
+func (m *Mapper) Map(model models.LinkedIdentity) *Mapper {
+	m.ID = model.ID
+	m.Provider = model.Provider
+	m.Subject = model.Subject
+	m.Email = model.Email
+	m.CreatedAt = model.CreatedAt
+	return m
+}
+
+func (m *Mapper) MapList(model []models.LinkedIdentity) interface{} {
+	serialized := make([]Mapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&Mapper{}).Map(v)
+	}
+	return serialized
+}
+
+// AuthorizationMapper is the URL of the provider a logged in user is sent to for linking an identity
+type AuthorizationMapper struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
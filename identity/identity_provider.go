@@ -0,0 +1,27 @@
+package identity
+
+import "context"
+
+// Claims of the user authenticated by an external identity provider
+type Claims struct {
+	// Subject is the stable ID of the user at the provider
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an external identity provider the users log in with, provider.NewOIDCProvider
+// implements it for the OpenID Connect providers, e.g. Google or a corporate identity provider
+type Provider interface {
+	// AuthCodeURL return the URL of the provider authenticating the user, the provider redirects
+	// the user back to redirectURI with a code and the state. challenge is the S256 PKCE challenge of the code.
+	AuthCodeURL(ctx context.Context, redirectURI string, state string, challenge string, nonce string) (string, error)
+
+	// Exchange the code for the claims of the user, ErrInvalidIdentity when the provider rejects the code
+	// or its ID token does not verify
+	Exchange(ctx context.Context, redirectURI string, code string, verifier string, nonce string) (Claims, error)
+}
+
+// Providers are the configured providers by name, the :provider of the routes
+type Providers map[string]Provider
@@ -0,0 +1,21 @@
+package identity
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+// Repository of the linked identities and login states of the tenant of the context
+type Repository interface {
+	FindIdentities(ctx context.Context, userID string, limit int64, offset int64) ([]models.LinkedIdentity, int64, error)
+	// FindIdentity return the identity of the subject at the provider, ErrNotFound when it is not linked
+	FindIdentity(ctx context.Context, provider string, subject string) (*models.LinkedIdentity, error)
+	StoreIdentity(ctx context.Context, model *models.LinkedIdentity) error
+	DeleteIdentity(ctx context.Context, userID string, id string) error
+
+	StoreState(ctx context.Context, model *models.IdentityState) error
+	// TakeState return and delete the state of the hash, ErrInvalidState when it is unknown
+	TakeState(ctx context.Context, hash string) (*models.IdentityState, error)
+	// DeleteByUser delete the linked identities of the user, once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,18 @@
+package identity
+
+import "strings"
+
+// NewState return the state of a login of the tenant. The provider redirects the user to the callback
+// without the X-Tenant-ID header, the callback reads the tenant of the state instead.
+func NewState(tenantID string, secret string) string {
+	return tenantID + "." + secret
+}
+
+// StateTenant return the tenant of a state of NewState, empty when state is not one
+func StateTenant(state string) string {
+	i := strings.Index(state, ".")
+	if i < 0 {
+		return ""
+	}
+	return state[:i]
+}
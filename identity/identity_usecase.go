@@ -0,0 +1,28 @@
+package identity
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+// Result of a callback, Linked tells a link of the identity to the logged in user from a login.
+// Created tells a login creating the user.
+type Result struct {
+	User     models.User
+	Identity models.LinkedIdentity
+	Linked   bool
+	Created  bool
+}
+
+type Usecase interface {
+	// Start a login with the provider, or the link of an identity to the user when userID is not empty,
+	// and return the URL of the provider. The provider sends the user back to redirectURI.
+	Start(ctx context.Context, provider string, userID string, redirectURI string) (string, error)
+	// Callback finish the login or link of the state. A login finds the user of the identity,
+	// or creates one with the verified email of the identity when the email is not registered.
+	Callback(ctx context.Context, provider string, dto CallbackDto) (Result, error)
+
+	FindIdentities(ctx context.Context, userID string, limit int64, offset int64) ([]models.LinkedIdentity, int64, error)
+	// Unlink an identity of the user, ErrLastLogin when the user has no password nor other identity
+	Unlink(ctx context.Context, userID string, id string) error
+}
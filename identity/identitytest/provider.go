@@ -0,0 +1,161 @@
+// Package identitytest runs a local OpenID Connect provider for the tests of the external identity login
+package identitytest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"go-echo-api/identity"
+	"go-echo-api/infrastructure/jwk"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ClientID and ClientSecret of the client the provider knows
+const (
+	ClientID     = "go-echo-api"
+	ClientSecret = "secret"
+)
+
+// Provider is a local OpenID Connect provider, it authenticates every authorization request as its User
+// and redirects it right back to the redirect uri with a code. Close it at the end of the test.
+type Provider struct {
+	*httptest.Server
+
+	// User is the identity of the next authorization requests, an empty subject denies them
+	User identity.Claims
+
+	// Tamper change the claims of the next ID tokens, e.g. to test their verification
+	Tamper func(claims map[string]interface{})
+
+	key   *jwk.Key
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+type grant struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+	user        identity.Claims
+}
+
+// NewProvider start a provider authenticating the users as user
+func NewProvider(user identity.Claims) *Provider {
+	key, err := jwk.Generate()
+	if err != nil {
+		panic(err)
+	}
+	p := &Provider{User: user, key: key, codes: make(map[string]grant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/jwks", p.jwks)
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+// Authorize follow the authorization URL of a login like the browser of the user and return the
+// callback URL the provider redirects to, with the code or the error
+func (p *Provider) Authorize(authorizationURL string) (*url.URL, error) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authorizationURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return resp.Location()
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+	})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirect.Scheme == "" || query.Get("client_id") != ClientID {
+		http.Error(w, "unknown client or redirect uri", http.StatusBadRequest)
+		return
+	}
+	values := redirect.Query()
+	values.Set("state", query.Get("state"))
+	switch {
+	case p.User.Subject == "":
+		values.Set("error", "access_denied")
+	case query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256":
+		values.Set("error", "invalid_request")
+	default:
+		code := uuid.New().String()
+		p.mu.Lock()
+		p.codes[code] = grant{redirectURI: query.Get("redirect_uri"), challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), user: p.User}
+		p.mu.Unlock()
+		values.Set("code", code)
+	}
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if clientID != ClientID || secret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	p.mu.Lock()
+	g, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || g.redirectURI != r.PostFormValue("redirect_uri") || base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":            p.URL,
+		"sub":            g.user.Subject,
+		"aud":            ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.user.Email,
+		"email_verified": g.user.EmailVerified,
+		"name":           g.user.Name,
+	}
+	if p.Tamper != nil {
+		p.Tamper(claims)
+	}
+	idToken, err := p.key.Sign(context.Background(), claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": uuid.New().String(), "token_type": "Bearer", "expires_in": 3600, "id_token": idToken})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, p.key.Set())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
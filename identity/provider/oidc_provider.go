@@ -0,0 +1,282 @@
+// Package provider holds the external identity providers the users log in with
+package provider
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sirupsen/logrus"
+	"go-echo-api/identity"
+	"go-echo-api/infrastructure/jwk"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultScopes are requested besides openid when the configuration names none
+var DefaultScopes = []string{"email", "profile"}
+
+// OIDCConfig of an OpenID Connect provider
+type OIDCConfig struct {
+	// Issuer of the ID tokens, the discovery document is read from Issuer + "/.well-known/openid-configuration"
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+
+	// Scopes requested besides openid, default DefaultScopes
+	Scopes []string
+
+	// Client of the requests to the provider, default a client with a timeout of 10s
+	Client *http.Client
+}
+
+// discovery is the part of the discovery document of the provider the login needs
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcProvider struct {
+	config OIDCConfig
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      jwk.Set
+}
+
+// NewOIDCProvider return the provider of config, its discovery document and keys are fetched on
+// first use so that the API starts while the provider is unreachable
+func NewOIDCProvider(config OIDCConfig) identity.Provider {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &oidcProvider{config: config}
+}
+
+// FromEnv return the providers named by APP_IDENTITY_PROVIDERS, separated by commas. Each provider is
+// configured by APP_IDENTITY_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and _SCOPES separated by spaces.
+func FromEnv() (identity.Providers, error) {
+	providers := identity.Providers{}
+	for _, name := range strings.Split(os.Getenv("APP_IDENTITY_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "APP_IDENTITY_" + strings.ToUpper(strings.Replace(name, "-", "_", -1)) + "_"
+		config := OIDCConfig{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if config.Issuer == "" || config.ClientID == "" {
+			return nil, fmt.Errorf("identity provider %s: %sISSUER and %sCLIENT_ID are required", name, prefix, prefix)
+		}
+		providers[name] = NewOIDCProvider(config)
+	}
+	return providers, nil
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, redirectURI string, state string, challenge string, nonce string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	values := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.config.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return d.AuthorizationEndpoint + separator + values.Encode(), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, redirectURI string, code string, verifier string, nonce string) (_ identity.Claims, err error) {
+	ctx, span := tracing.Start(ctx, "identity.Exchange", attribute.String("identity.issuer", p.config.Issuer))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	d, err := p.discover(ctx)
+	if err != nil {
+		return identity.Claims{}, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+	req, err := http.NewRequest(http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return identity.Claims{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+	resp, err := p.config.Client.Do(req)
+	if err != nil {
+		return identity.Claims{}, p.unavailable(ctx, err)
+	}
+	defer resp.Body.Close()
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return identity.Claims{}, p.unavailable(ctx, fmt.Errorf("token response: %v", err))
+	}
+	if resp.StatusCode != http.StatusOK || token.IDToken == "" {
+		logger.FromContext(ctx).WithFields(logrus.Fields{"error": token.Error, "error_description": token.ErrorDescription,
+			"status": resp.StatusCode}).Info("identity provider rejected the code")
+		return identity.Claims{}, identity.ErrInvalidIdentity
+	}
+	return p.verify(ctx, d, token.IDToken, nonce)
+}
+
+// verify the signature, issuer, audience, expiry and nonce of the ID token and return its claims
+func (p *oidcProvider) verify(ctx context.Context, d *discovery, idToken string, nonce string) (identity.Claims, error) {
+	var fetchErr error
+	parsed, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		var key *rsa.PublicKey
+		key, fetchErr = p.key(ctx, d, kid)
+		return key, fetchErr
+	})
+	if fetchErr == identity.ErrUnavailable {
+		return identity.Claims{}, fetchErr
+	}
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Info("identity provider answered an invalid ID token")
+		return identity.Claims{}, identity.ErrInvalidIdentity
+	}
+	claims := parsed.Claims.(jwt.MapClaims)
+	subject, _ := claims["sub"].(string)
+	tokenNonce, _ := claims["nonce"].(string)
+	if subject == "" || claims["exp"] == nil || !claims.VerifyIssuer(d.Issuer, true) ||
+		!audience(claims["aud"], p.config.ClientID) || tokenNonce != nonce {
+		logger.FromContext(ctx).Info("identity provider answered an ID token of another issuer, client or login")
+		return identity.Claims{}, identity.ErrInvalidIdentity
+	}
+	result := identity.Claims{Subject: subject}
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	// some providers answer the boolean as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+	return result, nil
+}
+
+var errUnknownKey = errors.New("identity provider: unknown key")
+
+// key return the key kid of the key set of the provider, the key set is fetched again on an unknown
+// key since the provider rotates its keys
+func (p *oidcProvider) key(ctx context.Context, d *discovery, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key, ok := p.keys.Find(kid)
+	if !ok {
+		var set jwk.Set
+		if err := p.get(ctx, d.JWKSURI, &set); err != nil {
+			return nil, p.unavailable(ctx, err)
+		}
+		p.keys = set
+		if key, ok = set.Find(kid); !ok {
+			return nil, errUnknownKey
+		}
+	}
+	return key.PublicKey()
+}
+
+// discover return the discovery document of the provider, fetched once
+func (p *oidcProvider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var d discovery
+	if err := p.get(ctx, p.config.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, p.unavailable(ctx, err)
+	}
+	// the issuer of the document must be the configured one (OpenID Connect Discovery 4.3)
+	if strings.TrimSuffix(d.Issuer, "/") != p.config.Issuer {
+		return nil, p.unavailable(ctx, fmt.Errorf("discovery document of issuer %s", d.Issuer))
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+func (p *oidcProvider) get(ctx context.Context, uri string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.config.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s answered %d", uri, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// unavailable log why the provider cannot be used and return identity.ErrUnavailable
+func (p *oidcProvider) unavailable(ctx context.Context, err error) error {
+	logger.FromContext(ctx).WithError(err).WithField("issuer", p.config.Issuer).Error("identity provider unavailable")
+	return identity.ErrUnavailable
+}
+
+// audience tell whether the aud claim, a string or an array, holds clientID
+func audience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/identity"
+	"go-echo-api/identity/identitytest"
+	"os"
+	"testing"
+)
+
+const (
+	redirectURI = "https://api.example.com/api/v1/auth/oauth/mock/callback"
+	verifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// code log in at the provider and return the code sent to the callback
+func code(t *testing.T, mock *identitytest.Provider, p identity.Provider, nonce string) string {
+	authorization, err := p.AuthCodeURL(context.Background(), redirectURI, "xyz", challenge(verifier), nonce)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	callback, err := mock.Authorize(authorization)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "xyz", callback.Query().Get("state"))
+	return callback.Query().Get("code")
+}
+
+func TestOIDCProvider(t *testing.T) {
+	mock := identitytest.NewProvider(identity.Claims{Subject: "1234", Email: "jane@example.com", EmailVerified: true, Name: "Jane"})
+	defer mock.Close()
+	p := NewOIDCProvider(OIDCConfig{Issuer: mock.URL, ClientID: identitytest.ClientID, ClientSecret: identitytest.ClientSecret})
+
+	s := t.Run("success", func(t *testing.T) {
+		claims, err := p.Exchange(context.Background(), redirectURI, code(t, mock, p, "nonce"), verifier, "nonce")
+		assert.NoError(t, err)
+		assert.Equal(t, identity.Claims{Subject: "1234", Email: "jane@example.com", EmailVerified: true, Name: "Jane"}, claims)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := p.Exchange(context.Background(), redirectURI, code(t, mock, p, "nonce"), "wrong", "nonce")
+		assert.Equal(t, identity.ErrInvalidIdentity, err, "the provider checks the verifier")
+
+		_, err = p.Exchange(context.Background(), redirectURI, code(t, mock, p, "nonce"), verifier, "other")
+		assert.Equal(t, identity.ErrInvalidIdentity, err, "the ID token of another login")
+
+		mock.Tamper = func(claims map[string]interface{}) {
+			claims["aud"] = []interface{}{"another-client"}
+		}
+		_, err = p.Exchange(context.Background(), redirectURI, code(t, mock, p, "nonce"), verifier, "nonce")
+		assert.Equal(t, identity.ErrInvalidIdentity, err, "the ID token of another client")
+		mock.Tamper = nil
+
+		// an unreachable provider is not an invalid identity
+		down := NewOIDCProvider(OIDCConfig{Issuer: "http://127.0.0.1:1", ClientID: identitytest.ClientID})
+		_, err = down.AuthCodeURL(context.Background(), redirectURI, "xyz", challenge(verifier), "nonce")
+		assert.Equal(t, identity.ErrUnavailable, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestFromEnv(t *testing.T) {
+	defer os.Unsetenv("APP_IDENTITY_PROVIDERS")
+	defer os.Unsetenv("APP_IDENTITY_CORP_SSO_ISSUER")
+	defer os.Unsetenv("APP_IDENTITY_CORP_SSO_CLIENT_ID")
+
+	os.Setenv("APP_IDENTITY_PROVIDERS", "corp-sso")
+	_, err := FromEnv()
+	assert.Error(t, err, "the issuer and client are required")
+
+	os.Setenv("APP_IDENTITY_CORP_SSO_ISSUER", "https://sso.example.com")
+	os.Setenv("APP_IDENTITY_CORP_SSO_CLIENT_ID", "go-echo-api")
+	providers, err := FromEnv()
+	assert.NoError(t, err)
+	assert.Contains(t, providers, "corp-sso")
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/identity"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+)
+
+type identityGormRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) identity.Repository {
+	return &identityGormRepository{db: db}
+}
+
+func (r *identityGormRepository) FindIdentities(ctx context.Context, userID string, limit int64, offset int64) ([]models.LinkedIdentity, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.LinkedIdentity{}).Where("user_id = ?", userID)
+	var model []models.LinkedIdentity
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("created_at").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *identityGormRepository) FindIdentity(ctx context.Context, provider string, subject string) (*models.LinkedIdentity, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.LinkedIdentity
+	err = db.Where("provider = ? AND subject = ?", provider, subject).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, identity.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *identityGormRepository) StoreIdentity(ctx context.Context, model *models.LinkedIdentity) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *identityGormRepository) DeleteIdentity(ctx context.Context, userID string, id string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&models.LinkedIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return identity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *identityGormRepository) StoreState(ctx context.Context, model *models.IdentityState) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *identityGormRepository) TakeState(ctx context.Context, hash string) (*models.IdentityState, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.IdentityState
+	err = db.Where("state_hash = ?", hash).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, identity.ErrInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+	// of two concurrent callbacks of the state only the one deleting it wins
+	result := db.Where("id = ?", model.ID).Delete(&models.IdentityState{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, identity.ErrInvalidState
+	}
+	return &model, nil
+}
+
+func (r *identityGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ?", userID).Delete(&models.LinkedIdentity{}).Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/identity"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"testing"
+	"time"
+)
+
+func TestIdentityGormRepository_Identity(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewIdentityRepository(db)
+	model := models.LinkedIdentity{UserID: dbtest.UserUje.ID, Provider: "google", Subject: "1234", Email: dbtest.UserUje.Email}
+	assert.NoError(t, r.StoreIdentity(dbtest.Context(), &model))
+	assert.Equal(t, dbtest.TenantAcme.ID, model.TenantID)
+
+	found, err := r.FindIdentity(dbtest.Context(), "google", "1234")
+	assert.NoError(t, err)
+	assert.Equal(t, model.ID, found.ID)
+	_, total, err := r.FindIdentities(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// the subject is linked once per provider and tenant
+	assert.Error(t, r.StoreIdentity(dbtest.Context(), &models.LinkedIdentity{UserID: dbtest.UserIpan.ID, Provider: "google", Subject: "1234"}))
+	assert.NoError(t, r.StoreIdentity(dbtest.TenantContext(dbtest.TenantGlobex), &models.LinkedIdentity{UserID: dbtest.UserGlobexUje.ID, Provider: "google", Subject: "1234"}))
+
+	// the identities of a tenant are not seen from another
+	found, err = r.FindIdentity(dbtest.TenantContext(dbtest.TenantGlobex), "google", "1234")
+	assert.NoError(t, err)
+	assert.Equal(t, dbtest.UserGlobexUje.ID, found.UserID)
+
+	assert.Equal(t, identity.ErrNotFound, r.DeleteIdentity(dbtest.Context(), dbtest.UserIpan.ID, model.ID))
+	assert.NoError(t, r.DeleteIdentity(dbtest.Context(), dbtest.UserUje.ID, model.ID))
+	_, err = r.FindIdentity(dbtest.Context(), "google", "1234")
+	assert.Equal(t, identity.ErrNotFound, err)
+}
+
+func TestIdentityGormRepository_State(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewIdentityRepository(db)
+	state := models.IdentityState{Provider: "google", StateHash: "state", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(time.Minute)}
+	assert.NoError(t, r.StoreState(dbtest.Context(), &state))
+
+	_, err := r.TakeState(dbtest.TenantContext(dbtest.TenantGlobex), "state")
+	assert.Equal(t, identity.ErrInvalidState, err)
+
+	// a state is taken once
+	taken, err := r.TakeState(dbtest.Context(), "state")
+	assert.NoError(t, err)
+	assert.Equal(t, "verifier", taken.CodeVerifier)
+	_, err = r.TakeState(dbtest.Context(), "state")
+	assert.Equal(t, identity.ErrInvalidState, err)
+}
+
+func TestIdentityGormRepository_DeleteByUser(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	ipan := models.LinkedIdentity{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID, Provider: "google", Subject: "1234"}
+	uje := models.LinkedIdentity{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserUje.ID, Provider: "google", Subject: "5678"}
+	assert.NoError(t, dbtest.LoadFixtures(db, &ipan, &uje))
+
+	r := NewIdentityRepository(db)
+	assert.NoError(t, r.DeleteByUser(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.LinkedIdentity{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Model(&models.LinkedIdentity{}).Where("user_id = ?", dbtest.UserUje.ID).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
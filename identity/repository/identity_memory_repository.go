@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/identity"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// identityMemoryRepository keeps the linked identities and login states in memory, it backs the use-case unit tests
+type identityMemoryRepository struct {
+	mu         sync.RWMutex
+	identities map[string]models.LinkedIdentity
+	states     map[string]models.IdentityState
+}
+
+func NewIdentityMemoryRepository(identities ...models.LinkedIdentity) identity.Repository {
+	r := &identityMemoryRepository{
+		identities: make(map[string]models.LinkedIdentity),
+		states:     make(map[string]models.IdentityState),
+	}
+	for _, i := range identities {
+		r.identities[i.ID] = i
+	}
+	return r
+}
+
+func (r *identityMemoryRepository) FindIdentities(ctx context.Context, userID string, limit int64, offset int64) ([]models.LinkedIdentity, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []models.LinkedIdentity
+	for _, i := range r.identities {
+		if i.TenantID == tenantID && i.UserID == userID {
+			all = append(all, i)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	total := int64(len(all))
+	if offset >= total {
+		return []models.LinkedIdentity{}, total, nil
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *identityMemoryRepository) FindIdentity(ctx context.Context, provider string, subject string) (*models.LinkedIdentity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range r.identities {
+		if i.TenantID == tenantID && i.Provider == provider && i.Subject == subject {
+			return &i, nil
+		}
+	}
+	return nil, identity.ErrNotFound
+}
+
+func (r *identityMemoryRepository) StoreIdentity(ctx context.Context, model *models.LinkedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.identities[model.ID] = *model
+	return nil
+}
+
+func (r *identityMemoryRepository) DeleteIdentity(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.identities[id]; !ok || existing.TenantID != tenantID || existing.UserID != userID {
+		return identity.ErrNotFound
+	}
+	delete(r.identities, id)
+	return nil
+}
+
+func (r *identityMemoryRepository) StoreState(ctx context.Context, model *models.IdentityState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	r.states[model.StateHash] = *model
+	return nil
+}
+
+func (r *identityMemoryRepository) TakeState(ctx context.Context, hash string) (*models.IdentityState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := r.states[hash]
+	if !ok || s.TenantID != tenantID {
+		return nil, identity.ErrInvalidState
+	}
+	delete(r.states, hash)
+	return &s, nil
+}
+
+func (r *identityMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, v := range r.identities {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.identities, id)
+		}
+	}
+	return nil
+}
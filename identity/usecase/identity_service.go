@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/sirupsen/logrus"
+	"go-echo-api/identity"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"go-echo-api/tenant"
+	"go-echo-api/user"
+	"time"
+)
+
+// StateTTL is the time the user has to log in at the provider
+const StateTTL = 10 * time.Minute
+
+type IdentityService struct {
+	identityRepository identity.Repository
+	userRepository     user.Repository
+	providers          identity.Providers
+	outboxUsecase      outbox.Usecase
+}
+
+func NewIdentityService(r identity.Repository, users user.Repository, providers identity.Providers, events outbox.Usecase) identity.Usecase {
+	return IdentityService{identityRepository: r, userRepository: users, providers: providers, outboxUsecase: events}
+}
+
+func (s IdentityService) Start(ctx context.Context, provider string, userID string, redirectURI string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", identity.ErrUnknownProvider
+	}
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	secret, err := random(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := random(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := random(16)
+	if err != nil {
+		return "", err
+	}
+	state := identity.NewState(tenantID, secret)
+	model := models.IdentityState{
+		Provider:     provider,
+		StateHash:    hash(state),
+		UserID:       userID,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		RedirectURI:  redirectURI,
+		ExpiresAt:    time.Now().Add(StateTTL),
+	}
+	if err := s.identityRepository.StoreState(ctx, &model); err != nil {
+		return "", err
+	}
+	return p.AuthCodeURL(ctx, redirectURI, state, challenge(verifier), nonce)
+}
+
+func (s IdentityService) Callback(ctx context.Context, provider string, dto identity.CallbackDto) (identity.Result, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return identity.Result{}, identity.ErrUnknownProvider
+	}
+	if dto.State == "" {
+		return identity.Result{}, identity.ErrInvalidState
+	}
+	state, err := s.identityRepository.TakeState(ctx, hash(dto.State))
+	if err != nil {
+		return identity.Result{}, err
+	}
+	if state.Provider != provider || state.Expired(time.Now()) {
+		return identity.Result{}, identity.ErrInvalidState
+	}
+	log := logger.FromContext(ctx).WithField("provider", provider)
+	if dto.Error != "" {
+		log.WithFields(logrus.Fields{"error": dto.Error, "error_description": dto.ErrorDescription}).Info("identity provider denied the login")
+		return identity.Result{}, identity.ErrDenied
+	}
+	claims, err := p.Exchange(ctx, state.RedirectURI, dto.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		return identity.Result{}, err
+	}
+	if state.UserID != "" {
+		return s.link(ctx, provider, state.UserID, claims)
+	}
+	return s.login(ctx, provider, claims)
+}
+
+// link the identity to the user, linking it again is not an error
+func (s IdentityService) link(ctx context.Context, provider string, userID string, claims identity.Claims) (identity.Result, error) {
+	owner, err := s.userRepository.FindById(ctx, userID)
+	if err == user.ErrNotFound {
+		return identity.Result{}, identity.ErrInvalidState
+	}
+	if err != nil {
+		return identity.Result{}, err
+	}
+	existing, err := s.identityRepository.FindIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return identity.Result{}, identity.ErrLinked
+		}
+		return identity.Result{User: *owner, Identity: *existing, Linked: true}, nil
+	}
+	if err != identity.ErrNotFound {
+		return identity.Result{}, err
+	}
+	model := models.LinkedIdentity{UserID: userID, Provider: provider, Subject: claims.Subject, Email: claims.Email}
+	if err := s.identityRepository.StoreIdentity(ctx, &model); err != nil {
+		return identity.Result{}, err
+	}
+	logger.FromContext(ctx).WithFields(logrus.Fields{"provider": provider, logger.UserIDField: userID}).Info("identity linked")
+	return identity.Result{User: *owner, Identity: model, Linked: true}, nil
+}
+
+// login return the user of the identity, or create it with the verified email of the identity
+func (s IdentityService) login(ctx context.Context, provider string, claims identity.Claims) (identity.Result, error) {
+	log := logger.FromContext(ctx).WithField("provider", provider)
+	existing, err := s.identityRepository.FindIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		owner, err := s.userRepository.FindById(ctx, existing.UserID)
+		if err != nil {
+			return identity.Result{}, err
+		}
+		log.WithField(logger.UserIDField, owner.ID).Info("login succeeded")
+		return identity.Result{User: *owner, Identity: *existing}, nil
+	}
+	if err != identity.ErrNotFound {
+		return identity.Result{}, err
+	}
+
+	// an unverified email may belong to someone else, and an email already registered is only linked
+	// by its user once logged in, not taken over by whoever controls the identity
+	if claims.Email == "" || !claims.EmailVerified {
+		return identity.Result{}, identity.ErrEmailUnverified
+	}
+	_, err = s.userRepository.FindByEmail(ctx, claims.Email)
+	if err == nil {
+		return identity.Result{}, identity.ErrEmailTaken
+	}
+	if err != user.ErrNotFound {
+		return identity.Result{}, err
+	}
+	now := time.Now()
+	owner := models.User{Name: claims.Name, Email: claims.Email, EmailVerifiedAt: &now}
+	if owner.Name == "" {
+		owner.Name = claims.Email
+	}
+	model := models.LinkedIdentity{Provider: provider, Subject: claims.Subject, Email: claims.Email}
+	err = s.outboxUsecase.Transaction(ctx, func(ctx context.Context) error {
+		// the user has no password, it logs in with the identity until it sets one
+		if err := s.userRepository.Store(ctx, &owner); err != nil {
+			return err
+		}
+		model.UserID = owner.ID
+		if err := s.identityRepository.StoreIdentity(ctx, &model); err != nil {
+			// a concurrent login of the identity created its user first
+			_ = s.userRepository.Delete(ctx, owner.ID)
+			return err
+		}
+		return s.outboxUsecase.Publish(ctx, outbox.EventUserRegistered, owner.ID, outbox.NewUserData(owner))
+	})
+	if err != nil {
+		return identity.Result{}, err
+	}
+	log.WithField(logger.UserIDField, owner.ID).Info("user registered")
+	return identity.Result{User: owner, Identity: model, Created: true}, nil
+}
+
+func (s IdentityService) FindIdentities(ctx context.Context, userID string, limit int64, offset int64) ([]models.LinkedIdentity, int64, error) {
+	return s.identityRepository.FindIdentities(ctx, userID, limit, offset)
+}
+
+func (s IdentityService) Unlink(ctx context.Context, userID string, id string) error {
+	owner, err := s.userRepository.FindById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if owner.Password == "" {
+		_, total, err := s.identityRepository.FindIdentities(ctx, userID, 0, 0)
+		if err != nil {
+			return err
+		}
+		if total <= 1 {
+			return identity.ErrLastLogin
+		}
+	}
+	if err := s.identityRepository.DeleteIdentity(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, userID).Info("identity unlinked")
+	return nil
+}
+
+// challenge return the S256 PKCE challenge of verifier
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// random return n random bytes encoded for the urls
+func random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
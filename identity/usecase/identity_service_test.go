@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/identity"
+	"go-echo-api/identity/identitytest"
+	"go-echo-api/identity/provider"
+	"go-echo-api/identity/repository"
+	"go-echo-api/infrastructure/database/dbtest"
+	outboxRepository "go-echo-api/outbox/repository"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	"go-echo-api/user"
+	userRepository "go-echo-api/user/repository"
+	"net/url"
+	"testing"
+)
+
+const redirectURI = "https://api.example.com/api/v1/auth/oauth/mock/callback"
+
+// newIdentityService return a service backed by in-memory repositories holding the fixture users,
+// Uje has a password, and logging in with the mock provider
+func newIdentityService(mock *identitytest.Provider) (identity.Usecase, user.Repository) {
+	uje := dbtest.UserUje
+	uje.Password = "hash"
+	users := userRepository.NewUserMemoryRepository(uje, dbtest.UserIpan)
+	providers := identity.Providers{"mock": provider.NewOIDCProvider(provider.OIDCConfig{
+		Issuer: mock.URL, ClientID: identitytest.ClientID, ClientSecret: identitytest.ClientSecret,
+	})}
+	events := outboxUsecase.NewOutboxService(outboxRepository.NewOutboxMemoryRepository())
+	return NewIdentityService(repository.NewIdentityMemoryRepository(), users, providers, events), users
+}
+
+// callback log in at the provider and return the redirection to the callback
+func callback(t *testing.T, i identity.Usecase, mock *identitytest.Provider, userID string) identity.CallbackDto {
+	authorization, err := i.Start(dbtest.Context(), "mock", userID, redirectURI)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	location, err := mock.Authorize(authorization)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return dto(location)
+}
+
+func dto(location *url.URL) identity.CallbackDto {
+	query := location.Query()
+	return identity.CallbackDto{Code: query.Get("code"), State: query.Get("state"), Error: query.Get("error")}
+}
+
+func TestIdentityService_Login(t *testing.T) {
+	mock := identitytest.NewProvider(identity.Claims{Subject: "1234", Email: "jane@example.com", EmailVerified: true, Name: "Jane"})
+	defer mock.Close()
+	i, users := newIdentityService(mock)
+
+	s := t.Run("success", func(t *testing.T) {
+		// the first login creates the user with its verified email
+		result, err := i.Callback(dbtest.Context(), "mock", callback(t, i, mock, ""))
+		assert.NoError(t, err)
+		assert.True(t, result.Created)
+		assert.False(t, result.Linked)
+		assert.Equal(t, "Jane", result.User.Name)
+		assert.True(t, result.User.EmailVerified())
+		created, err := users.FindByEmail(dbtest.Context(), "jane@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, created.ID, result.Identity.UserID)
+
+		// the next ones find it
+		result, err = i.Callback(dbtest.Context(), "mock", callback(t, i, mock, ""))
+		assert.NoError(t, err)
+		assert.False(t, result.Created)
+		assert.Equal(t, created.ID, result.User.ID)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := i.Start(dbtest.Context(), "unknown", "", redirectURI)
+		assert.Equal(t, identity.ErrUnknownProvider, err)
+
+		// a state is used once and by its provider only
+		redirection := callback(t, i, mock, "")
+		_, err = i.Callback(dbtest.Context(), "other", redirection)
+		assert.Equal(t, identity.ErrUnknownProvider, err)
+		_, err = i.Callback(dbtest.TenantContext(dbtest.TenantGlobex), "mock", redirection)
+		assert.Equal(t, identity.ErrInvalidState, err)
+		_, err = i.Callback(dbtest.Context(), "mock", redirection)
+		assert.NoError(t, err)
+		_, err = i.Callback(dbtest.Context(), "mock", redirection)
+		assert.Equal(t, identity.ErrInvalidState, err)
+
+		// the email of a registered user is not taken over, an unverified email is not trusted
+		mock.User = identity.Claims{Subject: "5678", Email: dbtest.UserIpan.Email, EmailVerified: true}
+		_, err = i.Callback(dbtest.Context(), "mock", callback(t, i, mock, ""))
+		assert.Equal(t, identity.ErrEmailTaken, err)
+		mock.User = identity.Claims{Subject: "5678", Email: "john@example.com"}
+		_, err = i.Callback(dbtest.Context(), "mock", callback(t, i, mock, ""))
+		assert.Equal(t, identity.ErrEmailUnverified, err)
+
+		mock.User = identity.Claims{}
+		_, err = i.Callback(dbtest.Context(), "mock", callback(t, i, mock, ""))
+		assert.Equal(t, identity.ErrDenied, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestIdentityService_Link(t *testing.T) {
+	mock := identitytest.NewProvider(identity.Claims{Subject: "1234", Email: "uje@corp.example.com"})
+	defer mock.Close()
+	i, _ := newIdentityService(mock)
+
+	s := t.Run("success", func(t *testing.T) {
+		result, err := i.Callback(dbtest.Context(), "mock", callback(t, i, mock, dbtest.UserUje.ID))
+		assert.NoError(t, err)
+		assert.True(t, result.Linked)
+		assert.Equal(t, dbtest.UserUje.ID, result.Identity.UserID)
+
+		// the identity logs in as Uje, even with an unverified email
+		result, err = i.Callback(dbtest.Context(), "mock", callback(t, i, mock, ""))
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserUje.ID, result.User.ID)
+
+		list, total, err := i.FindIdentities(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.NoError(t, i.Unlink(dbtest.Context(), dbtest.UserUje.ID, list[0].ID))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := i.Callback(dbtest.Context(), "mock", callback(t, i, mock, dbtest.UserIpan.ID))
+		assert.NoError(t, err)
+		_, err = i.Callback(dbtest.Context(), "mock", callback(t, i, mock, dbtest.UserUje.ID))
+		assert.Equal(t, identity.ErrLinked, err)
+
+		// Ipan has no password, its only identity cannot be unlinked
+		list, _, _ := i.FindIdentities(dbtest.Context(), dbtest.UserIpan.ID, 10, 0)
+		assert.Equal(t, identity.ErrLastLogin, i.Unlink(dbtest.Context(), dbtest.UserIpan.ID, list[0].ID))
+		assert.Equal(t, identity.ErrNotFound, i.Unlink(dbtest.Context(), dbtest.UserUje.ID, list[0].ID))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
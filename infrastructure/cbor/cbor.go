@@ -0,0 +1,325 @@
+// Package cbor encode and decode the subset of CBOR (RFC 8949) used by WebAuthn: integers, byte and
+// text strings, arrays, maps, booleans, null and floats, with definite lengths only. The maps decode to
+// map[interface{}]interface{} keyed by int64 or string, the integers to int64 and the tags are dropped.
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var (
+	ErrTruncated   = errors.New("cbor: data truncated")
+	ErrUnsupported = errors.New("cbor: unsupported item")
+	ErrTrailing    = errors.New("cbor: data after the item")
+)
+
+// maxDepth bounds the nesting of the items, the decoder is fed untrusted data
+const maxDepth = 16
+
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+)
+
+// Unmarshal decode data holding a single item
+func Unmarshal(data []byte) (interface{}, error) {
+	v, rest, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ErrTrailing
+	}
+	return v, nil
+}
+
+// Decode decode the first item of data and return the bytes following it
+func Decode(data []byte) (interface{}, []byte, error) {
+	return decode(data, 0)
+}
+
+func decode(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxDepth {
+		return nil, nil, ErrUnsupported
+	}
+	if len(data) == 0 {
+		return nil, nil, ErrTruncated
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	if major == majorSimple {
+		return decodeSimple(data, info)
+	}
+	n, data, err := argument(data[1:], info)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case majorUnsigned:
+		if n > math.MaxInt64 {
+			return nil, nil, ErrUnsupported
+		}
+		return int64(n), data, nil
+	case majorNegative:
+		if n > math.MaxInt64 {
+			return nil, nil, ErrUnsupported
+		}
+		return -1 - int64(n), data, nil
+	case majorBytes, majorText:
+		if n > uint64(len(data)) {
+			return nil, nil, ErrTruncated
+		}
+		if major == majorText {
+			return string(data[:n]), data[n:], nil
+		}
+		return append([]byte{}, data[:n]...), data[n:], nil
+	case majorArray:
+		// every item takes a byte at least
+		if n > uint64(len(data)) {
+			return nil, nil, ErrTruncated
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], data, err = decode(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+		}
+		return items, data, nil
+	case majorMap:
+		if n > uint64(len(data)) {
+			return nil, nil, ErrTruncated
+		}
+		m := make(map[interface{}]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			var key, value interface{}
+			if key, data, err = decode(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, ErrUnsupported
+			}
+			if value, data, err = decode(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			m[key] = value
+		}
+		return m, data, nil
+	default:
+		return decode(data, depth+1)
+	}
+}
+
+// argument read the value or length following the initial byte
+func argument(data []byte, info byte) (uint64, []byte, error) {
+	size := 0
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		// indefinite lengths and reserved values
+		return 0, nil, ErrUnsupported
+	}
+	if len(data) < size {
+		return 0, nil, ErrTruncated
+	}
+	var n uint64
+	for _, b := range data[:size] {
+		n = n<<8 | uint64(b)
+	}
+	return n, data[size:], nil
+}
+
+func decodeSimple(data []byte, info byte) (interface{}, []byte, error) {
+	switch info {
+	case 20:
+		return false, data[1:], nil
+	case 21:
+		return true, data[1:], nil
+	case 22, 23:
+		return nil, data[1:], nil
+	case 25:
+		if len(data) < 3 {
+			return nil, nil, ErrTruncated
+		}
+		return halfFloat(binary.BigEndian.Uint16(data[1:])), data[3:], nil
+	case 26:
+		if len(data) < 5 {
+			return nil, nil, ErrTruncated
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data[1:]))), data[5:], nil
+	case 27:
+		if len(data) < 9 {
+			return nil, nil, ErrTruncated
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(data[1:])), data[9:], nil
+	default:
+		return nil, nil, ErrUnsupported
+	}
+}
+
+func halfFloat(h uint16) float64 {
+	exponent := int(h >> 10 & 0x1f)
+	mantissa := float64(h & 0x3ff)
+	var value float64
+	switch exponent {
+	case 0:
+		value = math.Ldexp(mantissa, -24)
+	case 31:
+		if mantissa == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if h&0x8000 != 0 {
+		return -value
+	}
+	return value
+}
+
+// Marshal encode v, made of integers, []byte, string, bool, nil, []interface{} and maps keyed by
+// integers or strings. The map keys are sorted in the canonical order of RFC 7049.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encode(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(buf *bytes.Buffer, v interface{}) error {
+	switch value := v.(type) {
+	case nil:
+		buf.WriteByte(majorSimple<<5 | 22)
+	case bool:
+		if value {
+			buf.WriteByte(majorSimple<<5 | 21)
+		} else {
+			buf.WriteByte(majorSimple<<5 | 20)
+		}
+	case int:
+		encodeInt(buf, int64(value))
+	case int64:
+		encodeInt(buf, value)
+	case uint32:
+		head(buf, majorUnsigned, uint64(value))
+	case uint64:
+		head(buf, majorUnsigned, value)
+	case []byte:
+		head(buf, majorBytes, uint64(len(value)))
+		buf.Write(value)
+	case string:
+		head(buf, majorText, uint64(len(value)))
+		buf.WriteString(value)
+	case []interface{}:
+		head(buf, majorArray, uint64(len(value)))
+		for _, item := range value {
+			if err := encode(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(value))
+		for k, item := range value {
+			m[k] = item
+		}
+		return encodeMap(buf, m)
+	case map[interface{}]interface{}:
+		return encodeMap(buf, value)
+	default:
+		return fmt.Errorf("cbor: cannot encode %T", v)
+	}
+	return nil
+}
+
+func encodeInt(buf *bytes.Buffer, n int64) {
+	if n < 0 {
+		head(buf, majorNegative, uint64(-1-n))
+		return
+	}
+	head(buf, majorUnsigned, uint64(n))
+}
+
+func encodeMap(buf *bytes.Buffer, m map[interface{}]interface{}) error {
+	type entry struct {
+		key   []byte
+		value interface{}
+	}
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		switch k.(type) {
+		case int, int64, string:
+		default:
+			return fmt.Errorf("cbor: cannot encode a map key %T", k)
+		}
+		key, err := Marshal(k)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key, v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].key, entries[j].key
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return bytes.Compare(a, b) < 0
+	})
+	head(buf, majorMap, uint64(len(entries)))
+	for _, e := range entries {
+		buf.Write(e.key)
+		if err := encode(buf, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// head write the initial byte of major with the shortest encoding of n
+func head(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(n))
+		buf.Write(b[:])
+	case n <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(n))
+		buf.Write(b[:])
+	default:
+		buf.WriteByte(major<<5 | 27)
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], n)
+		buf.Write(b[:])
+	}
+}
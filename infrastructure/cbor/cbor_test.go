@@ -0,0 +1,68 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCBOR(t *testing.T) {
+	s := t.Run("success", func(t *testing.T) {
+		// examples of RFC 8949 appendix A
+		examples := map[string]interface{}{
+			"00":                 int64(0),
+			"1903e8":             int64(1000),
+			"3863":               int64(-100),
+			"f4":                 false,
+			"f6":                 nil,
+			"f93c00":             1.0,
+			"fb3ff199999999999a": 1.1,
+			"4401020304":         []byte{1, 2, 3, 4},
+			"6449455446":         "IETF",
+			"83010203":           []interface{}{int64(1), int64(2), int64(3)},
+			"a201020304":         map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)},
+			"c074323031332d30332d32315432303a30343a30305a": "2013-03-21T20:04:00Z",
+		}
+		for encoded, expected := range examples {
+			data, _ := hex.DecodeString(encoded)
+			v, err := Unmarshal(data)
+			assert.NoError(t, err, encoded)
+			assert.Equal(t, expected, v, encoded)
+		}
+
+		// a COSE key goes back and forth, the keys in canonical order
+		key := map[interface{}]interface{}{1: 2, 3: -7, -1: 1, -2: []byte{1}, -3: []byte{2}}
+		data, err := Marshal(key)
+		assert.NoError(t, err)
+		assert.Equal(t, "a5010203262001214101224102", hex.EncodeToString(data))
+		v, rest, err := Decode(append(data, 0xf5))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(-7), v.(map[interface{}]interface{})[int64(3)])
+		assert.Equal(t, []byte{0xf5}, rest)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		for encoded, expected := range map[string]error{
+			"":           ErrTruncated,
+			"19":         ErrTruncated,
+			"5a0000ffff": ErrTruncated,
+			"9f01ff":     ErrUnsupported,
+			"0001":       ErrTrailing,
+			"a1f600":     ErrUnsupported,
+		} {
+			data, _ := hex.DecodeString(encoded)
+			_, err := Unmarshal(data)
+			assert.Equal(t, expected, err, encoded)
+		}
+		nested := make([]byte, 100)
+		for i := range nested {
+			nested[i] = 0x81
+		}
+		_, err := Unmarshal(nested)
+		assert.Equal(t, ErrUnsupported, err)
+
+		_, err = Marshal(1.5)
+		assert.Error(t, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
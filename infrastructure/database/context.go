@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/logger"
+)
+
+// WithContext return a handle on db whose queries are bound to ctx,
+// they are cancelled as soon as ctx is done and logged with the entry of the request.
+// gorm v1 has no context support, the handle wraps the connection pool or the
+// transaction of db so every statement goes through its *Context variant.
+// When ctx carries the transaction of RunInTransaction the handle joins it instead of db.
+func WithContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if ctx == nil {
+		return db
+	}
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+	var common gorm.SQLCommon
+	switch conn := db.CommonDB().(type) {
+	case *sql.DB:
+		common = &contextDB{db: conn, ctx: ctx}
+	case *contextDB:
+		common = &contextDB{db: conn.db, ctx: ctx}
+	case *sql.Tx:
+		common = &contextTx{tx: conn, ctx: ctx}
+	case *contextTx:
+		common = &contextTx{tx: conn.tx, ctx: ctx}
+	default:
+		return db
+	}
+	scoped, err := gorm.Open(db.Dialect().GetName(), common)
+	if err != nil {
+		return db
+	}
+	scoped.SetLogger(logger.Gorm{Entry: logger.FromContext(ctx)})
+	scoped.LogMode(logMode)
+	// gorm.Open starts from the default callbacks, the handle reuses those db was opened with rather than
+	// registering them again on every call
+	if callbacks, ok := db.Get(callbacksKey); ok {
+		*scoped.Callback() = *callbacks.(*gorm.Callback)
+		scoped.InstantSet(callbacksKey, callbacks)
+	}
+	return scoped
+}
+
+// callbacksKey holds the callbacks of a database opened by OpenDSN, shared by the handles of WithContext
+const callbacksKey = "database:callbacks"
+
+// logMode mirror the log mode New set on the application database, gorm does not expose it
+var logMode bool
+
+// contextDB route the statements of gorm through the connection pool with a context
+type contextDB struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+func (c *contextDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(c.ctx, query, args...)
+}
+
+func (c *contextDB) Prepare(query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(c.ctx, query)
+}
+
+func (c *contextDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c *contextDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+func (c *contextDB) Context() context.Context {
+	return c.ctx
+}
+
+// Begin start a transaction bound to the context, gorm calls it from Begin and Transaction
+func (c *contextDB) Begin() (*sql.Tx, error) {
+	return c.db.BeginTx(c.ctx, nil)
+}
+
+func (c *contextDB) BeginTx(_ context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return c.db.BeginTx(c.ctx, opts)
+}
+
+// contextTx route the statements of gorm through a transaction with a context
+type contextTx struct {
+	tx  *sql.Tx
+	ctx context.Context
+}
+
+func (c *contextTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.tx.ExecContext(c.ctx, query, args...)
+}
+
+func (c *contextTx) Prepare(query string) (*sql.Stmt, error) {
+	return c.tx.PrepareContext(c.ctx, query)
+}
+
+func (c *contextTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.tx.QueryContext(c.ctx, query, args...)
+}
+
+func (c *contextTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.tx.QueryRowContext(c.ctx, query, args...)
+}
+
+func (c *contextTx) Context() context.Context {
+	return c.ctx
+}
+
+func (c *contextTx) Commit() error {
+	return c.tx.Commit()
+}
+
+func (c *contextTx) Rollback() error {
+	return c.tx.Rollback()
+}
+
+// Transaction run fn in a transaction of db, committed when fn returns nil and rolled back otherwise.
+// When db is already a transaction fn joins it, its outcome is left to the owner of the transaction.
+func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	switch db.CommonDB().(type) {
+	case *sql.Tx, *contextTx:
+		return fn(db)
+	default:
+		return db.Transaction(fn)
+	}
+}
+
+type transactionKey struct{}
+
+// RunInTransaction run fn with a context carrying a transaction of db, the repositories calling WithContext
+// with that context write in it. The transaction is committed when fn returns nil and rolled back otherwise,
+// fn joins the transaction of ctx when it already carries one.
+func RunInTransaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return Transaction(WithContext(ctx, db), func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
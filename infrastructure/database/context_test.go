@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/models"
+	"testing"
+)
+
+func TestWithContext(t *testing.T) {
+	db, err := OpenDSN(SQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	AutoMigrate(db)
+
+	s := t.Run("success", func(t *testing.T) {
+		count := 0
+		err := WithContext(context.Background(), db).Model(&models.User{}).Count(&count).Error
+		assert.NoError(t, err)
+	})
+
+	f := t.Run("error-cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		count := 0
+		err := WithContext(ctx, db).Model(&models.User{}).Count(&count).Error
+		assert.Equal(t, context.Canceled, err)
+	})
+
+	x := t.Run("transaction", func(t *testing.T) {
+		tx := WithContext(context.Background(), db).Begin()
+		assert.NoError(t, tx.Error)
+		assert.NoError(t, tx.Create(&models.User{Name: "Tx", Email: "tx@email.com"}).Error)
+		assert.NoError(t, tx.Rollback().Error)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, x, "Transaction scenario failed run")
+}
+
+func TestTransaction(t *testing.T) {
+	db, err := OpenDSN(SQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	AutoMigrate(db)
+	count := func(conn *gorm.DB) int {
+		n := 0
+		assert.NoError(t, conn.Model(&models.User{}).Count(&n).Error)
+		return n
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		err := Transaction(WithContext(context.Background(), db), func(tx *gorm.DB) error {
+			return tx.Create(&models.User{Name: "Tx", Email: "commit@email.com"}).Error
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count(db))
+	})
+	f := t.Run("error-rollback", func(t *testing.T) {
+		err := Transaction(db, func(tx *gorm.DB) error {
+			assert.NoError(t, tx.Create(&models.User{Name: "Tx", Email: "rollback@email.com"}).Error)
+			return errors.New("failed")
+		})
+		assert.EqualError(t, err, "failed")
+		assert.Equal(t, 1, count(db))
+	})
+	n := t.Run("nested", func(t *testing.T) {
+		// the outer transaction owns the outcome of the inner one
+		outer := db.Begin()
+		err := Transaction(WithContext(context.Background(), outer), func(tx *gorm.DB) error {
+			return tx.Create(&models.User{Name: "Tx", Email: "nested@email.com"}).Error
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count(outer))
+		assert.NoError(t, outer.Rollback().Error)
+		assert.Equal(t, 1, count(db))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Rollback scenario failed run")
+	assert.Equal(t, true, n, "Nested scenario failed run")
+}
+
+func TestRunInTransaction(t *testing.T) {
+	db, err := OpenDSN(SQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	AutoMigrate(db)
+	count := func() int {
+		n := 0
+		assert.NoError(t, db.Model(&models.User{}).Count(&n).Error)
+		return n
+	}
+	store := func(ctx context.Context, email string) error {
+		return WithContext(ctx, db).Create(&models.User{Name: "Tx", Email: email}).Error
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		err := RunInTransaction(context.Background(), db, func(ctx context.Context) error {
+			if err := store(ctx, "first@email.com"); err != nil {
+				return err
+			}
+			// a nested call joins the transaction
+			return RunInTransaction(ctx, db, func(ctx context.Context) error {
+				return store(ctx, "second@email.com")
+			})
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count())
+	})
+	f := t.Run("error-rollback", func(t *testing.T) {
+		err := RunInTransaction(context.Background(), db, func(ctx context.Context) error {
+			assert.NoError(t, store(ctx, "rollback@email.com"))
+			return errors.New("failed")
+		})
+		assert.EqualError(t, err, "failed")
+		assert.Equal(t, 2, count())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Rollback scenario failed run")
+}
@@ -3,31 +3,81 @@ package database
 import (
 	"fmt"
 	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/sirupsen/logrus"
+	"go-echo-api/infrastructure/logger"
 	"go-echo-api/models"
 	"os"
+	"strings"
 )
 
-var dataBase *gorm.DB
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+	SQLite   = "sqlite3"
+)
 
-// New : err check
-func New() *gorm.DB {
+var dataBase *gorm.DB
 
-	connection := "host=" + os.Getenv("DB_HOST") +
-		" port=" + os.Getenv("DB_PORT") +
-		" user=" + os.Getenv("DB_USERNAME") +
-		" dbname=" + os.Getenv("DB_NAME") +
-		" password=" + os.Getenv("DB_PASSWORD") +
-		" sslmode=" + os.Getenv("DB_SSL")
-	db, err := gorm.Open(os.Getenv("DB_DRIVER"), connection)
+// dsnBuilders maps a supported driver to the function building its connection string
+var dsnBuilders = map[string]func() string{
+	Postgres: postgresDSN,
+	MySQL:    mysqlDSN,
+	SQLite:   sqliteDSN,
+}
 
+// New connect to the database of DB_DRIVER, the SQL statements are logged with log in debug mode
+func New(log *logrus.Logger) (*gorm.DB, error) {
+	db, err := Open(os.Getenv("DB_DRIVER"))
 	if err != nil {
-		fmt.Println("Error DB: ", err)
+		return nil, err
 	}
 	db.DB().SetMaxIdleConns(3)
-	db.LogMode(true)
+	db.SetLogger(logger.Gorm{Entry: logrus.NewEntry(log)})
+	logMode = log.IsLevelEnabled(logrus.DebugLevel)
+	db.LogMode(logMode)
 	dataBase = db
-	return db
+	return db, nil
+}
+
+// Open connect to the database of the given driver using the DB_* environment variables
+func Open(driver string) (*gorm.DB, error) {
+	driver = Driver(driver)
+	buildDSN, ok := dsnBuilders[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver: %q", driver)
+	}
+	return OpenDSN(driver, buildDSN())
+}
+
+// OpenDSN connect to the database of the given driver with an already built connection string
+func OpenDSN(driver string, dsn string) (*gorm.DB, error) {
+	driver = Driver(driver)
+	db, err := gorm.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	enableTracing(db)
+	if driver == SQLite && isMemoryDSN(dsn) {
+		// every connection to an in-memory database gets its own empty database,
+		// so the pool is pinned to a single connection
+		db.DB().SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+// Driver normalize the driver name to the dialect name registered by gorm
+func Driver(driver string) string {
+	switch driver {
+	case "", "postgresql", "pgsql":
+		return Postgres
+	case "sqlite":
+		return SQLite
+	default:
+		return driver
+	}
 }
 
 // GetLinkDb :
@@ -35,8 +85,165 @@ func GetLinkDb() *gorm.DB {
 	return dataBase
 }
 
+// DefaultTenantSlug is the slug of the tenant receiving the users created before multi-tenancy
+const DefaultTenantSlug = "default"
+
 func AutoMigrate(db *gorm.DB) {
 	db.AutoMigrate(
+		models.Tenant{},
 		models.User{},
+		models.Organization{},
+		models.Membership{},
+		models.Invitation{},
+		models.APIKey{},
+		models.OAuthClient{},
+		models.OAuthCode{},
+		models.OAuthRefreshToken{},
+		models.LinkedIdentity{},
+		models.IdentityState{},
+		models.TOTPFactor{},
+		models.RecoveryCode{},
+		models.MFAChallenge{},
+		models.WebAuthnCredential{},
+		models.WebAuthnSession{},
+		models.MagicLink{},
+		models.Session{},
+		models.AuditEvent{},
+		models.OutboxEvent{},
+		models.WebhookSubscription{},
+		models.WebhookDelivery{},
+		models.Job{},
 	)
+	dropGlobalEmailIndex(db)
+	assignDefaultTenant(db)
+}
+
+// dropGlobalEmailIndex drop the unique constraint on users.email of the databases created before the tenants,
+// an email is unique within its tenant only. gorm created it from the column definition so its name is the
+// one each dialect gives the constraint of a column, and SQLite cannot drop it but by rebuilding the table.
+func dropGlobalEmailIndex(db *gorm.DB) {
+	var err error
+	switch db.Dialect().GetName() {
+	case Postgres:
+		err = db.Exec("ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key").Error
+	case MySQL:
+		if db.Dialect().HasIndex("users", "email") {
+			err = db.Model(&models.User{}).RemoveIndex("email").Error
+		}
+	case SQLite:
+		err = rebuildUsers(db)
+	}
+	if err != nil {
+		logger.Default().WithError(err).Error("drop the unique index on users.email")
+	}
+}
+
+// rebuildUsers recreate the SQLite table of the users without the unique constraint of the email column,
+// the table is left as is when it has none
+func rebuildUsers(db *gorm.DB) error {
+	global, err := sqliteUniqueEmail(db)
+	if err != nil || !global {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE users RENAME TO users_old").Error; err != nil {
+			return err
+		}
+		// the indexes keep their name on the renamed table, the new table creates them again
+		var names []string
+		if err := tx.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'users_old' AND sql IS NOT NULL").Pluck("name", &names).Error; err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := tx.Exec(fmt.Sprintf("DROP INDEX %q", name)).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.CreateTable(&models.User{}).Error; err != nil {
+			return err
+		}
+		var columns []string
+		for _, field := range tx.NewScope(&models.User{}).Fields() {
+			if !field.IsIgnored {
+				columns = append(columns, fmt.Sprintf("%q", field.DBName))
+			}
+		}
+		list := strings.Join(columns, ", ")
+		if err := tx.Exec(fmt.Sprintf("INSERT INTO users (%s) SELECT %s FROM users_old", list, list)).Error; err != nil {
+			return err
+		}
+		return tx.Exec("DROP TABLE users_old").Error
+	})
+}
+
+// sqliteUniqueEmail tell whether the SQLite table of the users has a unique constraint on the email column alone
+func sqliteUniqueEmail(db *gorm.DB) (bool, error) {
+	type index struct {
+		Name   string
+		Unique bool
+		Origin string
+	}
+	var indexes []index
+	if err := db.Raw("SELECT name, \"unique\", origin FROM pragma_index_list('users')").Scan(&indexes).Error; err != nil {
+		return false, err
+	}
+	for _, i := range indexes {
+		if !i.Unique || i.Origin != "u" {
+			continue
+		}
+		var columns []string
+		if err := db.Raw("SELECT name FROM pragma_index_info(?)", i.Name).Pluck("name", &columns).Error; err != nil {
+			return false, err
+		}
+		if len(columns) == 1 && columns[0] == "email" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// assignDefaultTenant move the users without tenant to the default tenant, created on first need
+func assignDefaultTenant(db *gorm.DB) {
+	orphans := db.Model(&models.User{}).Where("tenant_id IS NULL OR tenant_id = ''")
+	count := 0
+	if err := orphans.Count(&count).Error; err != nil || count == 0 {
+		return
+	}
+	var tenant models.Tenant
+	err := db.Where(models.Tenant{Slug: DefaultTenantSlug}).
+		Attrs(models.Tenant{Name: "Default", Status: models.TenantActive}).
+		FirstOrCreate(&tenant).Error
+	if err == nil {
+		err = orphans.UpdateColumn("tenant_id", tenant.ID).Error
+	}
+	if err != nil {
+		logger.Default().WithError(err).Error("assign the users without tenant to the default tenant")
+	}
+}
+
+func postgresDSN() string {
+	return "host=" + os.Getenv("DB_HOST") +
+		" port=" + os.Getenv("DB_PORT") +
+		" user=" + os.Getenv("DB_USERNAME") +
+		" dbname=" + os.Getenv("DB_NAME") +
+		" password=" + os.Getenv("DB_PASSWORD") +
+		" sslmode=" + os.Getenv("DB_SSL")
+}
+
+func mysqlDSN() string {
+	return os.Getenv("DB_USERNAME") + ":" + os.Getenv("DB_PASSWORD") +
+		"@tcp(" + os.Getenv("DB_HOST") + ":" + os.Getenv("DB_PORT") + ")/" +
+		os.Getenv("DB_NAME") + "?charset=utf8mb4&parseTime=True&loc=UTC"
+}
+
+func sqliteDSN() string {
+	// DB_NAME is the path of the database file, left empty the database lives in memory
+	if name := os.Getenv("DB_NAME"); name != "" {
+		return name
+	}
+	return ":memory:"
+}
+
+func isMemoryDSN(dsn string) bool {
+	return dsn == ":memory:" || dsn == "file::memory:"
 }
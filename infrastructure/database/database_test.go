@@ -0,0 +1,36 @@
+package database
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/models"
+	"testing"
+)
+
+func TestAutoMigrate_GlobalEmailIndex(t *testing.T) {
+	db, err := OpenDSN(SQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// the table of the users as created before the tenants, with a unique email
+	assert.NoError(t, db.Exec(`CREATE TABLE users ("id" varchar(255), "name" varchar(255), "email" varchar(255) UNIQUE, "password" varchar(255), "created_at" datetime, "updated_at" datetime, PRIMARY KEY ("id"))`).Error)
+	assert.NoError(t, db.Exec(`INSERT INTO users (id, name, email, password) VALUES ('uje-id', 'Uje', 'uje@email.com', 'secret')`).Error)
+
+	AutoMigrate(db)
+
+	// the user is kept, moved to the default tenant, and the email registered in another tenant
+	var uje models.User
+	assert.NoError(t, db.Where("id = ?", "uje-id").First(&uje).Error)
+	assert.Equal(t, "uje@email.com", uje.Email)
+	assert.NotEmpty(t, uje.TenantID)
+	other := models.User{TenantID: "other-tenant", Name: "Uje", Email: "uje@email.com"}
+	assert.NoError(t, db.Create(&other).Error)
+	duplicate := models.User{TenantID: uje.TenantID, Name: "Uje", Email: "uje@email.com"}
+	assert.Error(t, db.Create(&duplicate).Error)
+
+	// a second migration leaves the table as is
+	AutoMigrate(db)
+	var count int
+	assert.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,72 @@
+// Package dbtest provides the database used by the tests.
+//
+// TEST_DB_DRIVER and TEST_DB_DSN select the database the tests run against,
+// left empty the tests run on an in-memory SQLite database so no database server is needed.
+// Every test works inside its own transaction which is rolled back by CleanTestDB.
+package dbtest
+
+import (
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"os"
+	"sync"
+	"testing"
+)
+
+var (
+	once    sync.Once
+	shared  *gorm.DB
+	openErr error
+)
+
+// Open return the connection shared by every test of the package, the schema is migrated on first use
+func Open() (*gorm.DB, error) {
+	once.Do(func() {
+		driver, dsn := Config()
+		shared, openErr = database.OpenDSN(driver, dsn)
+		if openErr != nil {
+			return
+		}
+		database.AutoMigrate(shared)
+		openErr = shared.Error
+	})
+	return shared, openErr
+}
+
+// Config return the driver and connection string of the test database
+func Config() (string, string) {
+	driver := os.Getenv("TEST_DB_DRIVER")
+	if driver == "" {
+		return database.SQLite, ":memory:"
+	}
+	return database.Driver(driver), os.Getenv("TEST_DB_DSN")
+}
+
+// PrepareTestDB begin a transaction on the test database and load the fixtures inside it.
+// The in-memory SQLite database has a single connection, held by the transaction until CleanTestDB:
+// a test must go through the returned handle only, a statement on Open() blocks forever, and must not
+// call t.Parallel, a parallel test waits for the connection held by another one.
+func PrepareTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := Open()
+	if err != nil {
+		t.Fatalf("dbtest: open test database: %v", err)
+	}
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("dbtest: begin transaction: %v", tx.Error)
+	}
+	if err := LoadFixtures(tx, Fixtures()...); err != nil {
+		tx.Rollback()
+		t.Fatalf("dbtest: load fixtures: %v", err)
+	}
+	return tx
+}
+
+// CleanTestDB roll back everything the test wrote
+func CleanTestDB(tx *gorm.DB) {
+	if tx == nil {
+		return
+	}
+	tx.Rollback()
+}
@@ -0,0 +1,43 @@
+package dbtest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/models"
+	"go-echo-api/utils"
+	"testing"
+)
+
+func TestPrepareTestDB(t *testing.T) {
+	db := PrepareTestDB(t)
+
+	// fixtures are loaded
+	var fixture models.User
+	assert.NoError(t, db.First(&fixture, "id = ?", UserUje.ID).Error)
+	assert.Equal(t, UserUje.Email, fixture.Email)
+	assert.True(t, utils.CheckPasswordHash(FixturePassword, fixture.Password))
+
+	created := CreateUser(t, db, func(u *models.User) {
+		u.Email = "isolated@email.com"
+	})
+	CleanTestDB(db)
+
+	// writes of the previous test are rolled back
+	db = PrepareTestDB(t)
+	defer CleanTestDB(db)
+	count := 0
+	assert.NoError(t, db.Model(&models.User{}).Where("id = ?", created.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+}
+
+func TestNewUser(t *testing.T) {
+	first := NewUser()
+	second := NewUser(func(u *models.User) {
+		u.Name = "Override"
+		u.Password = "secret"
+	})
+
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.NotEqual(t, first.Email, second.Email)
+	assert.Equal(t, "Override", second.Name)
+	assert.True(t, utils.CheckPasswordHash("secret", second.Password))
+}
@@ -0,0 +1,39 @@
+package dbtest
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/models"
+	"sync/atomic"
+	"testing"
+)
+
+var sequence int64
+
+// NewUser build a user of TenantAcme with unique name and email and FixturePassword as password,
+// overrides are applied in order before the password is hashed
+func NewUser(overrides ...func(*models.User)) models.User {
+	n := atomic.AddInt64(&sequence, 1)
+	user := models.User{
+		ID:       uuid.New().String(),
+		TenantID: TenantAcme.ID,
+		Name:     fmt.Sprintf("User %d", n),
+		Email:    fmt.Sprintf("user%d@email.com", n),
+		Password: FixturePassword,
+	}
+	for _, override := range overrides {
+		override(&user)
+	}
+	return *withPassword(user)
+}
+
+// CreateUser build a user with NewUser and insert it in the test database
+func CreateUser(t testing.TB, db *gorm.DB, overrides ...func(*models.User)) models.User {
+	t.Helper()
+	user := NewUser(overrides...)
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("dbtest: create user: %v", err)
+	}
+	return user
+}
@@ -0,0 +1,141 @@
+package dbtest
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"go-echo-api/utils"
+	"sync"
+)
+
+// FixturePassword is the plain password of every fixture and factory user
+const FixturePassword = "password"
+
+// Tenants loaded in every test database, the fixture users belong to TenantAcme
+var (
+	TenantAcme = models.Tenant{
+		ID:     "3f1c2a9e-5b7d-4e8f-9a0b-1c2d3e4f5a6b",
+		Name:   "Acme",
+		Slug:   "acme",
+		Status: models.TenantActive,
+	}
+	TenantGlobex = models.Tenant{
+		ID:     "9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b",
+		Name:   "Globex",
+		Slug:   "globex",
+		Status: models.TenantActive,
+	}
+)
+
+// Users loaded in every test database
+var (
+	UserUje = models.User{
+		ID:       "7dd77cc4-f786-4be0-b5a5-0c203b9c62c5",
+		TenantID: TenantAcme.ID,
+		Name:     "Uje",
+		Email:    "uje@email.com",
+	}
+	UserIpan = models.User{
+		ID:       "0b8f6bb0-4c5a-4b0e-9d1f-4a2f8a3c9e11",
+		TenantID: TenantAcme.ID,
+		Name:     "Ipan",
+		Email:    "ipan@email.com",
+	}
+	// UserGlobexUje has the email of UserUje in another tenant
+	UserGlobexUje = models.User{
+		ID:       "5c4b3a29-1807-4f6e-a5d4-c3b2a1908f7e",
+		TenantID: TenantGlobex.ID,
+		Name:     "Uje of Globex",
+		Email:    "uje@email.com",
+	}
+)
+
+// Organization loaded in every test database, UserUje owns it and UserIpan is a member
+var (
+	OrganizationAcme = models.Organization{
+		ID:       "2a7e4c1d-8b3f-4d6a-9e5c-7f1b3d5a9c2e",
+		TenantID: TenantAcme.ID,
+		Name:     "Acme Engineering",
+	}
+	MembershipUje = models.Membership{
+		ID:             "6d2f8a4c-1e3b-4a7d-b9c5-2e4f6a8c0d1b",
+		TenantID:       TenantAcme.ID,
+		OrganizationID: OrganizationAcme.ID,
+		UserID:         UserUje.ID,
+		Role:           models.RoleOwner,
+	}
+	MembershipIpan = models.Membership{
+		ID:             "8f4b0c6e-3a5d-4c9f-8b1e-4a6c8e0f2b3d",
+		TenantID:       TenantAcme.ID,
+		OrganizationID: OrganizationAcme.ID,
+		UserID:         UserIpan.ID,
+		Role:           models.RoleMember,
+	}
+)
+
+// Fixtures return the records loaded by PrepareTestDB
+func Fixtures() []interface{} {
+	acme, globex := TenantAcme, TenantGlobex
+	organization, uje, ipan := OrganizationAcme, MembershipUje, MembershipIpan
+	return []interface{}{
+		&acme,
+		&globex,
+		withPassword(UserUje),
+		withPassword(UserIpan),
+		withPassword(UserGlobexUje),
+		&organization,
+		&uje,
+		&ipan,
+	}
+}
+
+// Context return a context carrying TenantAcme, the tenant of the fixture users
+func Context() context.Context {
+	return TenantContext(TenantAcme)
+}
+
+// TenantContext return a context carrying the tenant the way the tenant middleware does
+func TenantContext(model models.Tenant) context.Context {
+	return tenant.WithContext(context.Background(), model)
+}
+
+// LoadFixtures insert the given records, records already present are left untouched
+func LoadFixtures(db *gorm.DB, fixtures ...interface{}) error {
+	for _, fixture := range fixtures {
+		if !db.NewRecord(fixture) {
+			scope := db.NewScope(fixture)
+			count := 0
+			if err := db.Model(fixture).Where(scope.PrimaryKey()+" = ?", scope.PrimaryKeyValue()).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+		}
+		if err := db.Create(fixture).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixtureHash is the hash of FixturePassword, computed once for every fixture and factory user
+var (
+	fixtureHashOnce sync.Once
+	fixtureHash     string
+)
+
+// withPassword hash the plain password of the user, FixturePassword when left empty
+func withPassword(user models.User) *models.User {
+	if user.Password == "" || user.Password == FixturePassword {
+		fixtureHashOnce.Do(func() {
+			fixtureHash, _ = utils.HashPassword(FixturePassword)
+		})
+		user.Password = fixtureHash
+		return &user
+	}
+	hashPassword, _ := utils.HashPassword(user.Password)
+	user.Password = hashPassword
+	return &user
+}
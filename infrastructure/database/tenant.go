@@ -0,0 +1,27 @@
+package database
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/tenant"
+)
+
+// TenantScope return the handle on db bound to ctx and scoped to the rows of the tenant of ctx by their
+// tenant_id column, with the ID of that tenant. It fails with tenant.ErrRequired when ctx carries no tenant.
+func TenantScope(ctx context.Context, db *gorm.DB) (*gorm.DB, string, error) {
+	scoped, tenantID, err := WithTenant(ctx, db)
+	if err != nil {
+		return nil, "", err
+	}
+	return scoped.Where("tenant_id = ?", tenantID), tenantID, nil
+}
+
+// WithTenant return the handle on db bound to ctx and the ID of the tenant of ctx, for the queries joining
+// tables which name the tenant_id column of each. It fails with tenant.ErrRequired when ctx carries no tenant.
+func WithTenant(ctx context.Context, db *gorm.DB) (*gorm.DB, string, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return WithContext(ctx, db), tenantID, nil
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"testing"
+)
+
+func TestTenantScope(t *testing.T) {
+	db, err := OpenDSN(SQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	AutoMigrate(db)
+	assert.NoError(t, db.Create(&models.User{TenantID: "acme", Name: "Uje", Email: "uje@email.com"}).Error)
+	assert.NoError(t, db.Create(&models.User{TenantID: "globex", Name: "Uje", Email: "uje@email.com"}).Error)
+
+	s := t.Run("success", func(t *testing.T) {
+		scoped, tenantID, err := TenantScope(tenant.WithContext(context.Background(), models.Tenant{ID: "acme"}), db)
+		assert.NoError(t, err)
+		assert.Equal(t, "acme", tenantID)
+		var users []models.User
+		assert.NoError(t, scoped.Find(&users).Error)
+		if assert.Len(t, users, 1) {
+			assert.Equal(t, "acme", users[0].TenantID)
+		}
+	})
+	f := t.Run("error-no-tenant", func(t *testing.T) {
+		_, _, err := TenantScope(context.Background(), db)
+		assert.Equal(t, tenant.ErrRequired, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const spanKey = "tracing:span"
+
+// contextual is implemented by the connections of WithContext
+type contextual interface {
+	Context() context.Context
+}
+
+// enableTracing register the tracing callbacks on the callbacks of db only, once when it is opened,
+// and keep them under callbacksKey for the handles of WithContext
+func enableTracing(db *gorm.DB) {
+	callbacks := db.Callback()
+	RegisterTracing(callbacks)
+	db.InstantSet(callbacksKey, callbacks)
+}
+
+// RegisterTracing add the callbacks creating a span per statement, child of the span of the
+// context the handle was bound to by WithContext. Statements without context are not traced.
+func RegisterTracing(callback *gorm.Callback) {
+	callback.Create().Before("gorm:begin_transaction").Register("tracing:before_create", startSpan("gorm.create"))
+	callback.Create().After("gorm:commit_or_rollback_transaction").Register("tracing:after_create", endSpan)
+	callback.Update().Before("gorm:begin_transaction").Register("tracing:before_update", startSpan("gorm.update"))
+	callback.Update().After("gorm:commit_or_rollback_transaction").Register("tracing:after_update", endSpan)
+	callback.Delete().Before("gorm:begin_transaction").Register("tracing:before_delete", startSpan("gorm.delete"))
+	callback.Delete().After("gorm:commit_or_rollback_transaction").Register("tracing:after_delete", endSpan)
+	callback.Query().Before("gorm:query").Register("tracing:before_query", startSpan("gorm.query"))
+	callback.Query().After("gorm:after_query").Register("tracing:after_query", endSpan)
+	callback.RowQuery().Before("gorm:row_query").Register("tracing:before_row_query", startSpan("gorm.row_query"))
+	callback.RowQuery().After("gorm:row_query").Register("tracing:after_row_query", endSpan)
+}
+
+func startSpan(name string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+		conn, ok := scope.SQLDB().(contextual)
+		if !ok || !trace.SpanContextFromContext(conn.Context()).IsValid() {
+			return
+		}
+		_, span := tracing.Start(conn.Context(), name,
+			attribute.String("db.system", scope.Dialect().GetName()),
+			attribute.String("db.sql.table", scope.TableName()),
+		)
+		scope.Set(spanKey, span)
+	}
+}
+
+func endSpan(scope *gorm.Scope) {
+	value, ok := scope.Get(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		attribute.String("db.statement", scope.SQL),
+		attribute.Int64("db.rows_affected", scope.DB().RowsAffected),
+	)
+	err := scope.DB().Error
+	if gorm.IsRecordNotFoundError(err) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/tracing"
+	"go-echo-api/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestRegisterTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	db, err := OpenDSN(SQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	AutoMigrate(db)
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	var users []models.User
+	assert.NoError(t, WithContext(ctx, db).Find(&users).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "gorm.query", spans[0].Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Contains(t, spans[0].Attributes(), attribute.String("db.sql.table", "users"))
+	}
+
+	// the handles bound to the transaction of a context run the callbacks of db as well
+	recorder = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	ctx, parent = tracing.Start(context.Background(), "parent")
+	assert.NoError(t, RunInTransaction(ctx, db, func(ctx context.Context) error {
+		return WithContext(ctx, db).Find(&users).Error
+	}))
+	parent.End()
+	if spans = recorder.Ended(); assert.Len(t, spans, 2) {
+		assert.Equal(t, "gorm.query", spans[0].Name())
+	}
+
+	// the callbacks are registered on the databases opened only, not on the default ones of gorm
+	assert.Nil(t, gorm.DefaultCallback.Query().Get("tracing:before_query"))
+}
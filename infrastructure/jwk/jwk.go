@@ -0,0 +1,160 @@
+// Package jwk holds the RSA key signing the ID tokens of OpenID Connect and publishes its public part
+// as a JSON Web Key Set (RFC 7517) for the clients to verify them. It reads the key sets of the
+// external identity providers the same way.
+package jwk
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/tracing"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+)
+
+// Algorithm of the signatures, the one every OpenID Connect client supports
+const Algorithm = "RS256"
+
+// JWK is the public part of an RSA key
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Set is the JSON Web Key Set served to the clients
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ErrNotRSA is the failure of PublicKey on a key of another type
+var ErrNotRSA = errors.New("jwk: not an RSA key")
+
+// PublicKey return the RSA key of j
+func (j JWK) PublicKey() (*rsa.PublicKey, error) {
+	if j.Kty != "RSA" {
+		return nil, ErrNotRSA
+	}
+	n, err := base64.RawURLEncoding.DecodeString(j.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(j.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+// Find return the key of the set named kid
+func (s Set) Find(kid string) (JWK, bool) {
+	for _, key := range s.Keys {
+		if key.Kid == kid {
+			return key, true
+		}
+	}
+	return JWK{}, false
+}
+
+type Key struct {
+	// ID is the thumbprint of the key (RFC 7638), the kid header of its signatures
+	ID      string
+	private *rsa.PrivateKey
+}
+
+// New return the key of private
+func New(private *rsa.PrivateKey) *Key {
+	key := &Key{private: private}
+	public := key.public()
+	thumbprint, _ := json.Marshal(map[string]string{"e": public.E, "kty": public.Kty, "n": public.N})
+	sum := sha256.Sum256(thumbprint)
+	key.ID = base64.RawURLEncoding.EncodeToString(sum[:])
+	return key
+}
+
+// Load return the key of a PEM file holding a PKCS #1 or PKCS #8 RSA private key
+func Load(path string) (*Key, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+	if err != nil {
+		return nil, err
+	}
+	return New(private), nil
+}
+
+// Generate return a new 2048 bits key
+func Generate() (*Key, error) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return New(private), nil
+}
+
+var (
+	once       sync.Once
+	defaultKey *Key
+	defaultErr error
+)
+
+// Default return the key of the PEM file of APP_OIDC_SIGNING_KEY_FILE, loaded once. Without it a key
+// is generated for the life of the process, the ID tokens it signed cannot be verified after a restart.
+func Default() (*Key, error) {
+	once.Do(func() {
+		path := os.Getenv("APP_OIDC_SIGNING_KEY_FILE")
+		if path != "" {
+			defaultKey, defaultErr = Load(path)
+			return
+		}
+		logger.Default().Warn("APP_OIDC_SIGNING_KEY_FILE is not set, the ID tokens are signed with a key generated for this process")
+		defaultKey, defaultErr = Generate()
+	})
+	return defaultKey, defaultErr
+}
+
+// Sign the claims with the key, the kid header names it in the key set
+func (k *Key) Sign(ctx context.Context, claims map[string]interface{}) (_ string, err error) {
+	_, span := tracing.Start(ctx, "jwk.Sign")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.private)
+}
+
+// Public return the public part of the key to verify its signatures
+func (k *Key) Public() *rsa.PublicKey {
+	return &k.private.PublicKey
+}
+
+// Set return the key set publishing the key
+func (k *Key) Set() Set {
+	public := k.public()
+	public.Kid = k.ID
+	return Set{Keys: []JWK{public}}
+}
+
+func (k *Key) public() JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: Algorithm,
+		N:   base64.RawURLEncoding.EncodeToString(k.private.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.private.PublicKey.E)).Bytes()),
+	}
+}
@@ -0,0 +1,74 @@
+package jwk
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	key, err := Generate()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		signed, err := key.Sign(context.Background(), map[string]interface{}{"sub": "user"})
+		assert.NoError(t, err)
+		token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, key.ID, token.Header["kid"])
+			return key.Public(), nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "user", token.Claims.(jwt.MapClaims)["sub"])
+
+		set := key.Set()
+		if assert.Len(t, set.Keys, 1) {
+			assert.Equal(t, key.ID, set.Keys[0].Kid)
+			assert.Equal(t, "AQAB", set.Keys[0].E)
+		}
+
+		// the key set gives back the public key
+		public, ok := set.Find(key.ID)
+		assert.True(t, ok)
+		parsed, err := public.PublicKey()
+		assert.NoError(t, err)
+		assert.Equal(t, key.Public(), parsed)
+
+		// the key of a PEM file keeps its id
+		dir, err := ioutil.TempDir("", "jwk")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "oidc.pem")
+		der := x509.MarshalPKCS1PrivateKey(key.private)
+		assert.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der}), 0600))
+		loaded, err := Load(path)
+		assert.NoError(t, err)
+		assert.Equal(t, key.ID, loaded.ID)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// a signature is not verified by another key
+		other, _ := Generate()
+		signed, _ := key.Sign(context.Background(), map[string]interface{}{"sub": "user"})
+		_, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+			return other.Public(), nil
+		})
+		assert.Error(t, err)
+
+		_, err = Load(filepath.Join(os.TempDir(), "missing.pem"))
+		assert.Error(t, err)
+
+		_, ok := key.Set().Find("unknown")
+		assert.False(t, ok)
+		_, err = JWK{Kty: "EC"}.PublicKey()
+		assert.Equal(t, ErrNotRSA, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
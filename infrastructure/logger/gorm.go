@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Gorm adapt the entry to the logger of gorm, statements are logged at debug level and errors at error level
+type Gorm struct {
+	Entry *logrus.Entry
+}
+
+func (g Gorm) Print(values ...interface{}) {
+	if len(values) < 2 {
+		g.Entry.Debug(values...)
+		return
+	}
+	entry := g.Entry.WithField("source", values[1])
+	switch {
+	case values[0] == "sql" && len(values) >= 6:
+		entry.WithFields(logrus.Fields{
+			"duration":      values[2],
+			"vars":          values[4],
+			"rows_affected": values[5],
+		}).Debug(values[3])
+	case values[0] == "error":
+		entry.Error(values[2:]...)
+	default:
+		entry.Debug(values[2:]...)
+	}
+}
@@ -0,0 +1,70 @@
+// Package logger provides the structured logger of the application.
+//
+// The logger writes JSON in production and colored text otherwise. The request-ID middleware
+// puts an entry carrying the request ID, the trace ID and the user ID once authenticated, in the
+// context of every request, FromContext returns it so every log line of the request can be correlated.
+package logger
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+const (
+	RequestIDField      = "request_id"
+	UserIDField         = "user_id"
+	TraceIDField        = "trace_id"
+	TenantIDField       = "tenant_id"
+	OrganizationIDField = "organization_id"
+	APIKeyIDField       = "api_key_id"
+	OAuthClientIDField  = "oauth_client_id"
+	WebhookIDField      = "webhook_id"
+	JobIDField          = "job_id"
+)
+
+type contextKey struct{}
+
+var std = logrus.StandardLogger()
+
+// New build the logger for the given APP_ENV, APP_LOG_LEVEL overrides the level
+func New(env string) *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(os.Stdout)
+	if env == "production" {
+		log.SetFormatter(&logrus.JSONFormatter{})
+		log.SetLevel(logrus.InfoLevel)
+	} else {
+		log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+		log.SetLevel(logrus.DebugLevel)
+	}
+	if level, err := logrus.ParseLevel(os.Getenv("APP_LOG_LEVEL")); err == nil {
+		log.SetLevel(level)
+	}
+	return log
+}
+
+// SetDefault set the logger FromContext falls back to when the context carries no entry
+func SetDefault(log *logrus.Logger) {
+	std = log
+}
+
+// Default return the logger FromContext falls back to
+func Default() *logrus.Logger {
+	return std
+}
+
+// WithContext return a copy of ctx carrying the entry
+func WithContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// FromContext return the entry of the request, or an entry of the default logger
+func FromContext(ctx context.Context) *logrus.Entry {
+	if ctx != nil {
+		if entry, ok := ctx.Value(contextKey{}).(*logrus.Entry); ok {
+			return entry
+		}
+	}
+	return logrus.NewEntry(std)
+}
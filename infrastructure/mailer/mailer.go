@@ -0,0 +1,117 @@
+// Package mailer send the emails of the API, through SMTP or, for the development and the tests,
+// by dropping them as .eml files in a directory
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+const (
+	DefaultDir  = "mail"
+	DefaultFrom = "no-reply@localhost"
+)
+
+// FromEnv return the mailer of APP_MAILER: "smtp" sends through APP_SMTP_ADDR (host:port) authenticated by
+// APP_SMTP_USERNAME and APP_SMTP_PASSWORD when set, "file" (default) drops the emails in APP_MAILER_DIR
+// (default "mail"). The emails are sent from APP_MAIL_FROM.
+func FromEnv() (Mailer, error) {
+	from := os.Getenv("APP_MAIL_FROM")
+	if from == "" {
+		from = DefaultFrom
+	}
+	switch kind := os.Getenv("APP_MAILER"); kind {
+	case "", "file":
+		dir := os.Getenv("APP_MAILER_DIR")
+		if dir == "" {
+			dir = DefaultDir
+		}
+		return NewFileMailer(dir, from), nil
+	case "smtp":
+		addr := os.Getenv("APP_SMTP_ADDR")
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("mailer: APP_SMTP_ADDR %q: %v", addr, err)
+		}
+		var auth smtp.Auth
+		if username := os.Getenv("APP_SMTP_USERNAME"); username != "" {
+			auth = smtp.PlainAuth("", username, os.Getenv("APP_SMTP_PASSWORD"), host)
+		}
+		return NewSMTPMailer(addr, auth, from), nil
+	default:
+		return nil, fmt.Errorf("mailer: unknown APP_MAILER %q", kind)
+	}
+}
+
+// FileMailer write each email to a file of its directory, named after the time it was sent
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir string, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+	return ioutil.WriteFile(filepath.Join(m.dir, name), format(m.from, message), 0600)
+}
+
+// SMTPMailer send the emails to a relay, with STARTTLS when the relay offers it
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(addr string, auth smtp.Auth, from string) *SMTPMailer {
+	return &SMTPMailer{addr: addr, auth: auth, from: from}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{message.To}, format(m.from, message))
+}
+
+// format return the message as an RFC 5322 email
+func format(from string, message Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.Replace(message.Body, "\n", "\r\n", -1))
+	return buf.Bytes()
+}
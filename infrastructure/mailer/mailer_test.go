@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mailer")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	s := t.Run("success", func(t *testing.T) {
+		m := NewFileMailer(filepath.Join(dir, "drop"), DefaultFrom)
+		assert.NoError(t, m.Send(context.Background(), Message{To: "uje@example.com", Subject: "Log in to Acme", Body: "line 1\nline 2"}))
+
+		files, err := ioutil.ReadDir(filepath.Join(dir, "drop"))
+		assert.NoError(t, err)
+		if !assert.Len(t, files, 1) {
+			t.FailNow()
+		}
+		f, _ := os.Open(filepath.Join(dir, "drop", files[0].Name()))
+		defer f.Close()
+		message, err := mail.ReadMessage(f)
+		assert.NoError(t, err)
+		assert.Equal(t, "uje@example.com", message.Header.Get("To"))
+		assert.Equal(t, DefaultFrom, message.Header.Get("From"))
+		body, _ := ioutil.ReadAll(message.Body)
+		assert.Equal(t, "line 1\r\nline 2", string(body))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		os.Setenv("APP_MAILER", "pigeon")
+		defer os.Unsetenv("APP_MAILER")
+		_, err := FromEnv()
+		assert.Error(t, err)
+
+		os.Setenv("APP_MAILER", "smtp")
+		_, err = FromEnv()
+		assert.Error(t, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
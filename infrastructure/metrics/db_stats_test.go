@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestDBStatsCollector(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(4)
+	assert.NoError(t, db.Ping())
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewDBStatsCollector(db, "test"))
+
+	expected := `
+# HELP go_echo_api_db_max_open_connections Maximum number of open connections to the database.
+# TYPE go_echo_api_db_max_open_connections gauge
+go_echo_api_db_max_open_connections{db="test"} 4
+# HELP go_echo_api_db_open_connections Number of established connections, in use and idle.
+# TYPE go_echo_api_db_open_connections gauge
+go_echo_api_db_open_connections{db="test"} 1
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"go_echo_api_db_max_open_connections", "go_echo_api_db_open_connections")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"go-echo-api/infrastructure/response"
+)
+
+const (
+	JSON = "application/json"
+	Form = "application/x-www-form-urlencoded"
+)
+
+// Body describe a required JSON request body of the type of v
+func (d *Document) Body(v interface{}) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{JSON: {Schema: d.Schema(v)}},
+	}
+}
+
+// FormBody describe a required form encoded request body of the type of v, its fields are named by their json tags
+func (d *Document) FormBody(v interface{}) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{Form: {Schema: d.Schema(v)}},
+	}
+}
+
+// Single describe a response.Single envelope whose data is of the type of data, nil data is any value
+func (d *Document) Single(description string, data interface{}) Response {
+	return d.envelope(description, d.Schema(response.Meta{}), d.Schema(data))
+}
+
+// Paging describe a response.Paging envelope whose data is a list of the type of item
+func (d *Document) Paging(description string, item interface{}) Response {
+	return d.envelope(description, d.Schema(response.MetaPaginator{}), &Schema{Type: "array", Items: d.Schema(item)})
+}
+
+// Error describe the response.Single envelope of a failure, its meta hold the error
+func (d *Document) Error(description string) Response {
+	return d.envelope(description, d.Schema(response.Meta{}), &Schema{Nullable: true})
+}
+
+// Plain describe a JSON response of the type of v without envelope, for the responses a standard defines
+func (d *Document) Plain(description string, v interface{}) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{JSON: {Schema: d.Schema(v)}},
+	}
+}
+
+func (d *Document) envelope(description string, meta *Schema, data *Schema) Response {
+	return Response{
+		Description: description,
+		Content: map[string]MediaType{JSON: {Schema: &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"meta": meta, "data": data},
+			Required:   []string{"meta", "data"},
+		}}},
+	}
+}
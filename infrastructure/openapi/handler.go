@@ -0,0 +1,48 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo"
+	"html"
+	"net/http"
+)
+
+// Handler return a handler serving the document as JSON, the document is encoded once
+func Handler(d *Document) echo.HandlerFunc {
+	body, err := json.Marshal(d)
+	return func(c echo.Context) error {
+		if err != nil {
+			return err
+		}
+		return c.JSONBlob(http.StatusOK, body)
+	}
+}
+
+// UI return a handler serving a Swagger UI page browsing the document served at specURL,
+// the page loads the assets of swagger-ui-dist from unpkg
+func UI(title string, specURL string) echo.HandlerFunc {
+	page := fmt.Sprintf(uiPage, html.EscapeString(title), specURL)
+	return func(c echo.Context) error {
+		return c.HTML(http.StatusOK, page)
+	}
+}
+
+const uiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({url: %q, dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+// BearerAuth is the security requirement of the routes behind middleware.IsLoggedIn
+var BearerAuth = []map[string][]string{{"bearerAuth": {}}}
+
+// BearerOrAPIKey is the security requirement of the routes behind middleware.Authenticate
+var BearerOrAPIKey = []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}}
+
+// ClientBasic is the security requirement of the OAuth endpoints the clients authenticate to,
+// with basic authorization or with their credentials in the form
+var ClientBasic = []map[string][]string{{"clientBasic": {}}, {}}
+
+// Document is an OpenAPI 3 document, the modules describe their routes on it and
+// the schemas are generated from the types of their DTOs and mappers
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem hold the operations of a path keyed by their lower case method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// New return an empty document with the bearer token, API key and OAuth client security schemes of the API
+func New(title string, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth":  {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"apiKeyAuth":  {Type: "apiKey", In: "header", Name: "X-API-Key"},
+				"clientBasic": {Type: "http", Scheme: "basic"},
+			},
+		},
+	}
+}
+
+// Add describe the operation of method on path, path is written the way echo registers it, e.g. "/api/v1/user/:id"
+func (d *Document) Add(method string, path string, operation Operation) {
+	path = Path(path)
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = &operation
+}
+
+// Group return a view of the document prefixing the paths with prefix, like echo.Group does for the routes
+func (d *Document) Group(prefix string) *Group {
+	return &Group{Document: d, prefix: prefix}
+}
+
+// Operations return the sorted "METHOD /path" of every operation of the document
+func (d *Document) Operations() []string {
+	var operations []string
+	for path, item := range d.Paths {
+		for method := range item {
+			operations = append(operations, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+// Operation return the operation of method on path, path is written the way echo registers it
+func (d *Document) Operation(method string, path string) (*Operation, bool) {
+	operation, ok := d.Paths[Path(path)][strings.ToLower(method)]
+	return operation, ok
+}
+
+type Group struct {
+	*Document
+	prefix     string
+	parameters []Parameter
+	responses  map[string]Response
+}
+
+// Use add the parameters and responses of a middleware to the operations added after on the group,
+// the responses an operation describes itself win
+func (g *Group) Use(parameters []Parameter, responses map[string]Response) {
+	g.parameters = append(g.parameters, parameters...)
+	if g.responses == nil {
+		g.responses = make(map[string]Response)
+	}
+	for status, response := range responses {
+		g.responses[status] = response
+	}
+}
+
+func (g *Group) Add(method string, path string, operation Operation) {
+	operation.Parameters = append(append([]Parameter(nil), g.parameters...), operation.Parameters...)
+	if len(g.responses) > 0 {
+		responses := make(map[string]Response, len(g.responses)+len(operation.Responses))
+		for status, response := range g.responses {
+			responses[status] = response
+		}
+		for status, response := range operation.Responses {
+			responses[status] = response
+		}
+		operation.Responses = responses
+	}
+	g.Document.Add(method, g.prefix+path, operation)
+}
+
+var pathParam = regexp.MustCompile(`:([^/]+)`)
+
+// Path convert the parameters of an echo route to the OpenAPI syntax, "/user/:id" become "/user/{id}"
+func Path(route string) string {
+	return pathParam.ReplaceAllString(route, "{$1}")
+}
+
+// PathParam describe a required parameter of the path
+func PathParam(name string, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+// HeaderParam describe an optional header
+func HeaderParam(name string, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// QueryParam describe an optional parameter of the query string
+func QueryParam(name string, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
@@ -0,0 +1,105 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type person struct {
+	Name     string   `json:"name" validate:"required,min=2,max=50"`
+	Email    string   `json:"email" validate:"required,email"`
+	Age      int      `json:"age,omitempty" validate:"gte=0,lte=130"`
+	Role     string   `json:"role" validate:"oneof=admin member"`
+	Tags     []string `json:"tags" validate:"max=3,dive,min=1"`
+	Address  *address `json:"address"`
+	Password string   `json:"-"`
+	internal string
+}
+
+func TestDocument_Schema(t *testing.T) {
+	doc := New("test", "1.0.0")
+	ref := doc.Schema(person{})
+	assert.Equal(t, "#/components/schemas/openapi.person", ref.Ref)
+
+	schema := doc.Resolve(ref)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"name", "email"}, schema.Required)
+	assert.Len(t, schema.Properties, 6)
+	assert.Equal(t, int64(2), *schema.Properties["name"].MinLength)
+	assert.Equal(t, int64(50), *schema.Properties["name"].MaxLength)
+	assert.Equal(t, "email", schema.Properties["email"].Format)
+	assert.Equal(t, float64(130), *schema.Properties["age"].Maximum)
+	assert.Equal(t, []interface{}{"admin", "member"}, schema.Properties["role"].Enum)
+	assert.Equal(t, int64(3), *schema.Properties["tags"].MaxItems)
+	assert.Nil(t, schema.Properties["tags"].Items.MinLength)
+	assert.Equal(t, []string{"city"}, doc.Resolve(schema.Properties["address"]).Required)
+}
+
+func TestDocument_Add(t *testing.T) {
+	doc := New("test", "1.0.0")
+	g := doc.Group("/api/v1/person")
+	g.Add(echo.GET, "/:id", Operation{Responses: map[string]Response{"200": g.Single("person", person{})}})
+	g.Add(echo.DELETE, "/:id", Operation{Responses: map[string]Response{"200": g.Error("deleted")}})
+
+	assert.Equal(t, []string{"DELETE /api/v1/person/{id}", "GET /api/v1/person/{id}"}, doc.Operations())
+	operation, ok := doc.Operation(echo.GET, "/api/v1/person/:id")
+	assert.True(t, ok)
+	envelope := operation.Responses["200"].Content[JSON].Schema
+	assert.Equal(t, []string{"meta", "data"}, envelope.Required)
+	assert.Equal(t, "#/components/schemas/response.Meta", envelope.Properties["meta"].Ref)
+	assert.Equal(t, "#/components/schemas/openapi.person", envelope.Properties["data"].Ref)
+}
+
+func TestHandler(t *testing.T) {
+	doc := New("test", "1.0.0")
+	doc.Add(echo.GET, "/api/v1/person", Operation{Responses: map[string]Response{"200": doc.Paging("persons", person{})}})
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	err := Handler(doc)(e.NewContext(httptest.NewRequest(echo.GET, "/api/v1/openapi.json", nil), rec))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, Version, body["openapi"])
+	assert.Contains(t, body["paths"], "/api/v1/person")
+	assert.Contains(t, body["components"].(map[string]interface{})["schemas"], "response.MetaPaginator")
+}
+
+func TestDocument_Validate(t *testing.T) {
+	doc := New("test", "1.0.0")
+	schema := doc.Schema(person{})
+
+	s := t.Run("success", func(t *testing.T) {
+		value := map[string]interface{}{
+			"name": "Uje", "email": "uje@email.com", "age": json.Number("30"), "role": "admin",
+			"tags": []interface{}{"a"}, "address": map[string]interface{}{"city": "Bandung"},
+		}
+		assert.Empty(t, doc.Validate(schema, "", value))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		value := map[string]interface{}{
+			"name": "U", "age": json.Number("131"), "role": "owner",
+			"tags": []interface{}{"a", "b", "c", "d"}, "address": map[string]interface{}{"city": json.Number("1")},
+		}
+		assert.Equal(t, []Violation{
+			{Field: "email", Type: "required", Message: "is required"},
+			{Field: "address.city", Type: "type", Message: "must be of type string"},
+			{Field: "age", Type: "maximum", Message: "must be at most 130"},
+			{Field: "name", Type: "min", Message: "must have at least 2 characters"},
+			{Field: "role", Type: "enum", Message: "must be one of [admin member]"},
+			{Field: "tags", Type: "max", Message: "must have at most 3 items"},
+		}, doc.Validate(schema, "", value))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
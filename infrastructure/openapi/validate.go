@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Violation is a value breaking its schema, Field is the JSON path of the value, e.g. "data.email"
+type Violation struct {
+	Field   string
+	Type    string
+	Message string
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Validate check a JSON value decoded with json.Decoder.UseNumber against schema
+func (d *Document) Validate(schema *Schema, field string, value interface{}) []Violation {
+	schema = d.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return []Violation{{Field: field, Type: "nullable", Message: "must not be null"}}
+	}
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return typeViolation(field, schema.Type)
+		}
+		return d.validateObject(schema, field, object)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return typeViolation(field, schema.Type)
+		}
+		violations := checkBounds(field, int64(len(items)), schema.MinItems, schema.MaxItems, "items")
+		for i, item := range items {
+			violations = append(violations, d.Validate(schema.Items, fmt.Sprintf("%s[%d]", field, i), item)...)
+		}
+		return violations
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return typeViolation(field, schema.Type)
+		}
+		return validateString(schema, field, text)
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return typeViolation(field, schema.Type)
+		}
+		return validateNumber(schema, field, number)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeViolation(field, schema.Type)
+		}
+	}
+	return nil
+}
+
+// ValidateParameter check the raw value of a path or query parameter against its schema
+func (d *Document) ValidateParameter(parameter Parameter, value string) []Violation {
+	schema := d.Resolve(parameter.Schema)
+	if schema == nil {
+		return nil
+	}
+	switch schema.Type {
+	case "integer", "number":
+		return d.Validate(schema, parameter.Name, json.Number(value))
+	case "boolean":
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return typeViolation(parameter.Name, schema.Type)
+		}
+		return d.Validate(schema, parameter.Name, parsed)
+	default:
+		return d.Validate(schema, parameter.Name, value)
+	}
+}
+
+func (d *Document) validateObject(schema *Schema, field string, object map[string]interface{}) []Violation {
+	var violations []Violation
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			violations = append(violations, Violation{Field: join(field, name), Type: "required", Message: "is required"})
+		}
+	}
+	// properties are checked by name so the violations come in a stable order
+	for _, name := range sortedKeys(schema.Properties) {
+		if value, ok := object[name]; ok {
+			violations = append(violations, d.Validate(schema.Properties[name], join(field, name), value)...)
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		for name, value := range object {
+			if _, ok := schema.Properties[name]; !ok {
+				violations = append(violations, d.Validate(schema.AdditionalProperties, join(field, name), value)...)
+			}
+		}
+	}
+	return violations
+}
+
+func validateString(schema *Schema, field string, text string) []Violation {
+	violations := checkBounds(field, int64(utf8.RuneCountInString(text)), schema.MinLength, schema.MaxLength, "characters")
+	if len(schema.Enum) > 0 && !contains(schema.Enum, text) {
+		violations = append(violations, Violation{Field: field, Type: "enum", Message: fmt.Sprintf("must be one of %v", schema.Enum)})
+	}
+	if !matchFormat(schema.Format, text) {
+		violations = append(violations, Violation{Field: field, Type: "format", Message: "must be a valid " + schema.Format})
+	}
+	return violations
+}
+
+func validateNumber(schema *Schema, field string, number json.Number) []Violation {
+	var value float64
+	if schema.Type == "integer" {
+		integer, err := number.Int64()
+		if err != nil {
+			return typeViolation(field, schema.Type)
+		}
+		value = float64(integer)
+	} else {
+		float, err := number.Float64()
+		if err != nil {
+			return typeViolation(field, schema.Type)
+		}
+		value = float
+	}
+	if schema.Minimum != nil && value < *schema.Minimum {
+		return []Violation{{Field: field, Type: "minimum", Message: fmt.Sprintf("must be at least %v", *schema.Minimum)}}
+	}
+	if schema.Maximum != nil && value > *schema.Maximum {
+		return []Violation{{Field: field, Type: "maximum", Message: fmt.Sprintf("must be at most %v", *schema.Maximum)}}
+	}
+	return nil
+}
+
+func matchFormat(format string, text string) bool {
+	switch format {
+	case "email":
+		address, err := mail.ParseAddress(text)
+		return err == nil && address.Address == text
+	case "uuid":
+		return uuidPattern.MatchString(text)
+	case "uri":
+		_, err := url.ParseRequestURI(text)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, text)
+		return err == nil
+	}
+	return true
+}
+
+func checkBounds(field string, length int64, min *int64, max *int64, unit string) []Violation {
+	if min != nil && length < *min {
+		return []Violation{{Field: field, Type: "min", Message: fmt.Sprintf("must have at least %d %s", *min, unit)}}
+	}
+	if max != nil && length > *max {
+		return []Violation{{Field: field, Type: "max", Message: fmt.Sprintf("must have at most %d %s", *max, unit)}}
+	}
+	return nil
+}
+
+func typeViolation(field string, kind string) []Violation {
+	return []Violation{{Field: field, Type: "type", Message: "must be of type " + kind}}
+}
+
+func join(field string, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func contains(values []interface{}, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(properties map[string]*Schema) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,437 @@
+// Package qrcode encode short texts, e.g. the otpauth URIs of the authenticator apps, as QR codes
+// (ISO/IEC 18004) in byte mode with the error correction level M, in the versions 1 to 10.
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ErrTooLong is the failure of Encode on a text longer than the 213 bytes of the version 10
+var ErrTooLong = errors.New("qrcode: text too long")
+
+// version describe the codewords of a version at the error correction level M
+type version struct {
+	total      int   // codewords of the symbol
+	ecPerBlock int   // error correction codewords of each block
+	blocks     int   // blocks of data, the last total % blocks ones hold a codeword more
+	alignment  []int // centers of the alignment patterns
+}
+
+var versions = []version{
+	{},
+	{26, 10, 1, nil},
+	{44, 16, 1, []int{6, 18}},
+	{70, 26, 1, []int{6, 22}},
+	{100, 18, 2, []int{6, 26}},
+	{134, 24, 2, []int{6, 30}},
+	{172, 16, 4, []int{6, 34}},
+	{196, 18, 4, []int{6, 22, 38}},
+	{242, 22, 4, []int{6, 24, 42}},
+	{292, 22, 5, []int{6, 26, 46}},
+	{346, 26, 5, []int{6, 28, 50}},
+}
+
+func (v version) data() int {
+	return v.total - v.ecPerBlock*v.blocks
+}
+
+// Code is the matrix of modules of a QR code
+type Code struct {
+	// Size is the number of modules of a side, without the quiet zone
+	Size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Encode return the QR code of text with the smallest version holding it
+func Encode(text string) (*Code, error) {
+	number := 0
+	for n := 1; n < len(versions); n++ {
+		if 4+countBits(n)+8*len(text) <= 8*versions[n].data() {
+			number = n
+			break
+		}
+	}
+	if number == 0 {
+		return nil, ErrTooLong
+	}
+	v := versions[number]
+	c := &Code{Size: 17 + 4*number}
+	c.modules = make([][]bool, c.Size)
+	c.function = make([][]bool, c.Size)
+	for y := range c.modules {
+		c.modules[y] = make([]bool, c.Size)
+		c.function[y] = make([]bool, c.Size)
+	}
+	c.drawFunctionPatterns(number, v)
+	c.drawCodewords(interleave(v, codewords(number, v, text)))
+
+	best, lowest := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if penalty := c.penalty(); lowest < 0 || penalty < lowest {
+			best, lowest = mask, penalty
+		}
+		c.applyMask(mask)
+	}
+	c.applyMask(best)
+	c.drawFormat(best)
+	return c, nil
+}
+
+// Black tell whether the module of column x and row y is dark
+func (c *Code) Black(x int, y int) bool {
+	return c.modules[y][x]
+}
+
+// PNG return the image of the code, scale pixels by module with a quiet zone of 4 modules
+func (c *Code) PNG(scale int) ([]byte, error) {
+	side := (c.Size + 8) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for py := 0; py < side; py++ {
+		for px := 0; px < side; px++ {
+			x, y := px/scale-4, py/scale-4
+			if x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y][x] {
+				img.SetGray(px, py, color.Gray{Y: 0})
+			} else {
+				img.SetGray(px, py, color.Gray{Y: 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// countBits is the length of the character count of the byte mode
+func countBits(number int) int {
+	if number < 10 {
+		return 8
+	}
+	return 16
+}
+
+// codewords return the data codewords of text in byte mode, padded to the capacity of the version
+func codewords(number int, v version, text string) []byte {
+	var bits []bool
+	appendBits := func(value int, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>uint(i)&1 == 1)
+		}
+	}
+	appendBits(0x4, 4)
+	appendBits(len(text), countBits(number))
+	for i := 0; i < len(text); i++ {
+		appendBits(int(text[i]), 8)
+	}
+	capacity := 8 * v.data()
+	for i := 0; i < 4 && len(bits) < capacity; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	result := make([]byte, len(bits)/8, v.data())
+	for i, bit := range bits {
+		if bit {
+			result[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	for pad := byte(0xEC); len(result) < v.data(); pad ^= 0xEC ^ 0x11 {
+		result = append(result, pad)
+	}
+	return result
+}
+
+// interleave split data in the blocks of the version, add their error correction codewords
+// and interleave the blocks
+func interleave(v version, data []byte) []byte {
+	short := v.blocks - v.total%v.blocks
+	shortLen := v.total / v.blocks
+	divisor := rsDivisor(v.ecPerBlock)
+	blocks := make([][]byte, v.blocks)
+	for i, k := 0, 0; i < v.blocks; i++ {
+		n := shortLen - v.ecPerBlock
+		if i >= short {
+			n++
+		}
+		block := append([]byte{}, data[k:k+n]...)
+		k += n
+		ec := rsRemainder(block, divisor)
+		if i < short {
+			// a placeholder keeps the error correction codewords of every block aligned
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ec...)
+	}
+	result := make([]byte, 0, v.total)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-v.ecPerBlock || j >= short {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// rsDivisor return the generator polynomial of the Reed-Solomon code of degree, highest coefficient
+// first and without the leading 1
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder return the error correction codewords of data
+func rsRemainder(data []byte, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiply in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x byte, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func (c *Code) set(x int, y int, black bool) {
+	c.modules[y][x] = black
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns(number int, v version) {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	// the finder patterns with their separators
+	for _, center := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x >= 0 && y >= 0 && x < c.Size && y < c.Size {
+					dist := max(abs(dx), abs(dy))
+					c.set(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+	last := len(v.alignment) - 1
+	for i, x := range v.alignment {
+		for j, y := range v.alignment {
+			// the corners of the finder patterns have none
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	// reserve the format areas, drawn with the mask
+	c.drawFormat(0)
+	if number >= 7 {
+		rem := number
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := number<<12 | rem
+		for i := 0; i < 18; i++ {
+			black := bits>>uint(i)&1 == 1
+			a, b := c.Size-11+i%3, i/3
+			c.set(a, b, black)
+			c.set(b, a, black)
+		}
+	}
+}
+
+// drawFormat draw both copies of the format information of the level M with mask
+func (c *Code) drawFormat(mask int) {
+	data := 0<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool {
+		return bits>>uint(i)&1 == 1
+	}
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	// the dark module
+	c.set(8, c.Size-8, true)
+}
+
+// drawCodewords place the bits of data in the modules left by the function patterns, in the
+// columns of two modules from the bottom right, going up and down in turn
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.function[y][x] && i < len(data)*8 {
+					c.modules[y][x] = data[i>>3]>>uint(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flip the data modules selected by mask, applying it twice restores them
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			default:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !c.function[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty score the patterns hard to scan, the mask of the lowest score is kept
+func (c *Code) penalty() int {
+	result := 0
+	line := func(get func(i int) bool) {
+		run := 1
+		for i := 1; i <= c.Size; i++ {
+			if i < c.Size && get(i) == get(i-1) {
+				run++
+				continue
+			}
+			if run >= 5 {
+				result += 3 + run - 5
+			}
+			run = 1
+		}
+		// dark-light-dark-dark-dark-light-dark with 4 light modules on a side looks like a finder pattern
+		finder := []bool{true, false, true, true, true, false, true}
+		for i := 0; i+7 <= c.Size; i++ {
+			match := true
+			for k, black := range finder {
+				if get(i+k) != black {
+					match = false
+					break
+				}
+			}
+			if match && (lightRun(get, i-4, i, c.Size) || lightRun(get, i+7, i+11, c.Size)) {
+				result += 40
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		line(func(i int) bool { return c.modules[y][i] })
+		line(func(i int) bool { return c.modules[i][y] })
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + k*10
+}
+
+// lightRun tell whether the modules from start to end are light, the modules outside the code are
+func lightRun(get func(i int) bool, start int, end int, size int) bool {
+	for i := start; i < end; i++ {
+		if i >= 0 && i < size && get(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
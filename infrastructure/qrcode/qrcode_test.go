@@ -0,0 +1,99 @@
+package qrcode
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	s := t.Run("success", func(t *testing.T) {
+		// the error correction of the 1-M code of HELLO WORLD in ISO/IEC 18004
+		data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+		assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, rsRemainder(data, rsDivisor(10)))
+
+		text := "otpauth://totp/go-echo-api:uje%40email.com?algorithm=SHA1&digits=6&issuer=go-echo-api&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+		code, err := Encode(text)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, 17+4*8, code.Size)
+
+		// the finder patterns are at three corners
+		for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+			assert.True(t, code.Black(corner[0], corner[1]))
+			assert.True(t, code.Black(corner[0]+3, corner[1]+3))
+			assert.False(t, code.Black(corner[0]+1, corner[1]+1))
+		}
+
+		// the format information gives the mask, unmasking gives back the codewords
+		format := 0
+		for i := 14; i >= 9; i-- {
+			format = format<<1 | bit(code.Black(14-i, 8))
+		}
+		format = format<<1 | bit(code.Black(7, 8))
+		format = format<<1 | bit(code.Black(8, 8))
+		format = format<<1 | bit(code.Black(8, 7))
+		for i := 5; i >= 0; i-- {
+			format = format<<1 | bit(code.Black(8, i))
+		}
+		format ^= 0x5412
+		assert.Equal(t, 0, format>>13, "level M")
+		mask := format >> 10 & 7
+		code.applyMask(mask)
+		read := &Code{Size: code.Size, modules: code.modules, function: code.function}
+		expected := interleave(versions[8], codewords(8, versions[8], text))
+		assert.Equal(t, expected, read.readCodewords(len(expected)))
+
+		image, err := code.PNG(4)
+		assert.NoError(t, err)
+		decoded, err := png.Decode(bytes.NewReader(image))
+		assert.NoError(t, err)
+		assert.Equal(t, (code.Size+8)*4, decoded.Bounds().Dx())
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := Encode(strings.Repeat("a", 214))
+		assert.Equal(t, ErrTooLong, err)
+		code, err := Encode(strings.Repeat("a", 213))
+		assert.NoError(t, err)
+		assert.Equal(t, 57, code.Size)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func bit(black bool) int {
+	if black {
+		return 1
+	}
+	return 0
+}
+
+// readCodewords read n codewords in the order drawCodewords places them
+func (c *Code) readCodewords(n int) []byte {
+	result := make([]byte, n)
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.function[y][x] && i < n*8 {
+					if c.modules[y][x] {
+						result[i>>3] |= 1 << uint(7-i&7)
+					}
+					i++
+				}
+			}
+		}
+	}
+	return result
+}
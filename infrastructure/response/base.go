@@ -1,9 +1,12 @@
 package response
 
 type Meta struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Error   interface{} `json:"error"`
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Error     interface{} `json:"error"`
+	RequestID string      `json:"request_id,omitempty"`
+	TraceID   string      `json:"trace_id,omitempty"`
+	UserID    string      `json:"user_id,omitempty"`
 }
 
 // APIError
@@ -35,10 +38,10 @@ type MetaPaginator struct {
 
 type Single struct {
 	Meta Meta        `json:"meta"`
-	Data interface{} `json:"data, omitempty"`
+	Data interface{} `json:"data"`
 }
 
 type Paging struct {
 	MetaPaginator MetaPaginator `json:"meta"`
-	Data          interface{}   `json:"data, omitempty"`
+	Data          interface{}   `json:"data"`
 }
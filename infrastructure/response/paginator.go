@@ -0,0 +1,56 @@
+package response
+
+import (
+	"github.com/labstack/echo"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// PageParams read the limit and offset query params, falling back to DefaultLimit and 0
+func PageParams(c echo.Context) (int64, int64) {
+	limit, err := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+	if err != nil || limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset, err := strconv.ParseInt(c.QueryParam("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// NewPaginator build the page meta with the links to the previous and next pages of the request
+func NewPaginator(c echo.Context, limit int64, offset int64, total int64) Paginator {
+	paginator := Paginator{
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if offset+limit < total {
+		paginator.Link.NextPageUrl = pageURL(c, limit, offset+limit)
+	}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		paginator.Link.PrevPageUrl = pageURL(c, limit, prev)
+	}
+	return paginator
+}
+
+func pageURL(c echo.Context, limit int64, offset int64) string {
+	u := *c.Request().URL
+	query := u.Query()
+	query.Set("limit", strconv.FormatInt(limit, 10))
+	query.Set("offset", strconv.FormatInt(offset, 10))
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
@@ -1,8 +1,9 @@
 package response
 
 import (
+	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
-	"github.com/ulule/paging"
+	"go-echo-api/infrastructure/tracing"
 	"net/http"
 )
 
@@ -17,10 +18,11 @@ func SingleData(c echo.Context, message string, data interface{}, error interfac
 	})
 }
 
-func NotFound(c echo.Context, message string, data interface{}, error interface{}) error {
-	return c.JSON(http.StatusNotFound, Single{
+// Accepted answer a request whose work goes on in the background, data tells how to follow it
+func Accepted(c echo.Context, message string, data interface{}, error interface{}) error {
+	return c.JSON(http.StatusAccepted, Single{
 		Meta: Meta{
-			Code:    http.StatusNotFound,
+			Code:    http.StatusAccepted,
 			Message: message,
 			Error:   error,
 		},
@@ -28,66 +30,118 @@ func NotFound(c echo.Context, message string, data interface{}, error interface{
 	})
 }
 
+func NotFound(c echo.Context, message string, data interface{}, error interface{}) error {
+	return c.JSON(http.StatusNotFound, Single{
+		Meta: errorMeta(c, http.StatusNotFound, message, error),
+		Data: data,
+	})
+}
+
 func BadRequest(c echo.Context, message string, data interface{}, error interface{}) error {
 	return c.JSON(http.StatusBadRequest, Single{
-		Meta: Meta{
-			Code:    http.StatusBadRequest,
-			Message: message,
-			Error:   error,
-		},
+		Meta: errorMeta(c, http.StatusBadRequest, message, error),
 		Data: data,
 	})
 }
 
 func ValidationError(c echo.Context, message string, data interface{}, error interface{}) error {
 	return c.JSON(http.StatusUnprocessableEntity, Single{
-		Meta: Meta{
-			Code:    http.StatusUnprocessableEntity,
-			Message: message,
-			Error:   error,
-		},
+		Meta: errorMeta(c, http.StatusUnprocessableEntity, message, error),
 		Data: data,
 	})
 }
 
 func InternalServerError(c echo.Context, message string, data interface{}, error interface{}) error {
 	return c.JSON(http.StatusInternalServerError, Single{
-		Meta: Meta{
-			Code:    http.StatusInternalServerError,
-			Message: message,
-			Error:   error,
-		},
+		Meta: errorMeta(c, http.StatusInternalServerError, message, error),
 		Data: data,
 	})
 }
 
 func Unauthorized(c echo.Context, message string, data interface{}, error interface{}) error {
 	return c.JSON(http.StatusUnauthorized, Single{
-		Meta: Meta{
-			Code:    http.StatusUnauthorized,
-			Message: message,
-			Error:   error,
-		},
+		Meta: errorMeta(c, http.StatusUnauthorized, message, error),
+		Data: data,
+	})
+}
+
+func Forbidden(c echo.Context, message string, data interface{}, error interface{}) error {
+	return c.JSON(http.StatusForbidden, Single{
+		Meta: errorMeta(c, http.StatusForbidden, message, error),
+		Data: data,
+	})
+}
+
+func Conflict(c echo.Context, message string, data interface{}, error interface{}) error {
+	return c.JSON(http.StatusConflict, Single{
+		Meta: errorMeta(c, http.StatusConflict, message, error),
+		Data: data,
+	})
+}
+
+func TooManyRequests(c echo.Context, message string, data interface{}, error interface{}) error {
+	return c.JSON(http.StatusTooManyRequests, Single{
+		Meta: errorMeta(c, http.StatusTooManyRequests, message, error),
+		Data: data,
+	})
+}
+
+func ServiceUnavailable(c echo.Context, message string, data interface{}, error interface{}) error {
+	return c.JSON(http.StatusServiceUnavailable, Single{
+		Meta: errorMeta(c, http.StatusServiceUnavailable, message, error),
 		Data: data,
 	})
 }
 
-func Paginate(c echo.Context, message string, paginator *paging.OffsetPaginator, data interface{}, error interface{}) error {
+func BadGateway(c echo.Context, message string, data interface{}, error interface{}) error {
+	return c.JSON(http.StatusBadGateway, Single{
+		Meta: errorMeta(c, http.StatusBadGateway, message, error),
+		Data: data,
+	})
+}
+
+func GatewayTimeout(c echo.Context, message string, data interface{}, error interface{}) error {
+	return c.JSON(http.StatusGatewayTimeout, Single{
+		Meta: errorMeta(c, http.StatusGatewayTimeout, message, error),
+		Data: data,
+	})
+}
+
+func Paginate(c echo.Context, message string, paginator Paginator, data interface{}, error interface{}) error {
 	return c.JSON(http.StatusOK, Paging{
 		MetaPaginator: MetaPaginator{
 			Code:    http.StatusOK,
 			Message: message,
 			Error:   error,
-			Page: Paginator{
-				Total:  paginator.Count,
-				Limit:  paginator.Limit,
-				Offset: paginator.Offset,
-				Link: Link{
-					NextPageUrl: paginator.NextURI.String,
-					PrevPageUrl: paginator.PreviousURI.String,
-				},
-			},
+			Page:    paginator,
 		},
 		Data: data,
 	})
 }
+
+// errorMeta build the meta of an error response, the request and trace IDs let the client quote the failed request,
+// with the user of the access token when the request has one
+func errorMeta(c echo.Context, code int, message string, error interface{}) Meta {
+	return Meta{
+		Code:      code,
+		Message:   message,
+		Error:     error,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		TraceID:   tracing.TraceID(c.Request().Context()),
+		UserID:    userID(c),
+	}
+}
+
+// userID return the id claimed by the access token the authentication middlewares validated, empty without one
+func userID(c echo.Context) string {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	id, _ := claims["id"].(string)
+	return id
+}
@@ -0,0 +1,97 @@
+// Package retry hold what the workers polling a table share: the backoff of the retries of a failed work
+// and the defaults of their configuration.
+package retry
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Policy of the retries of a failed work
+type Policy struct {
+	// MaxAttempts of a work before it is given up
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, doubled on every failure up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// WithDefaults return the policy with the values unset taken from defaults
+func (p Policy) WithDefaults(defaults Policy) Policy {
+	p.MaxAttempts = Int(p.MaxAttempts, defaults.MaxAttempts)
+	p.Backoff = Duration(p.Backoff, defaults.Backoff)
+	if p.MaxBackoff < p.Backoff {
+		p.MaxBackoff = defaults.MaxBackoff
+	}
+	return p
+}
+
+// FromEnv return the policy with the values of <prefix>_MAX_ATTEMPTS and <prefix>_BACKOFF, e.g. "2s",
+// when set
+func (p Policy) FromEnv(prefix string) Policy {
+	p.MaxAttempts = EnvInt(prefix+"_MAX_ATTEMPTS", p.MaxAttempts)
+	p.Backoff = EnvDuration(prefix+"_BACKOFF", p.Backoff)
+	return p
+}
+
+// Delay return the delay before the retry following the given number of failed attempts
+func (p Policy) Delay(attempts int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// Duration return value, or fallback when it is not positive
+func Duration(value time.Duration, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// Int return value, or fallback when it is not positive
+func Int(value int, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// Int64 return value, or fallback when it is not positive
+func Int64(value int64, fallback int64) int64 {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// EnvDuration return the positive duration of the environment variable name, else fallback
+func EnvDuration(name string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// EnvInt return the positive integer of the environment variable name, else fallback
+func EnvInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// EnvInt64 return the positive integer of the environment variable name, else fallback
+func EnvInt64(name string, fallback int64) int64 {
+	if value, err := strconv.ParseInt(os.Getenv(name), 10, 64); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
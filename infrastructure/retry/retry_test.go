@@ -0,0 +1,45 @@
+package retry
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPolicy(t *testing.T) {
+	defaults := Policy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: time.Hour}
+
+	s := t.Run("success", func(t *testing.T) {
+		p := Policy{Backoff: time.Minute, MaxBackoff: 3 * time.Minute}.WithDefaults(defaults)
+		assert.Equal(t, 5, p.MaxAttempts)
+		assert.Equal(t, time.Minute, p.Delay(1))
+		assert.Equal(t, 2*time.Minute, p.Delay(2))
+		// the delay doubles up to the max backoff
+		assert.Equal(t, 3*time.Minute, p.Delay(3))
+		assert.Equal(t, 3*time.Minute, p.Delay(30))
+
+		os.Setenv("APP_TEST_MAX_ATTEMPTS", "3")
+		os.Setenv("APP_TEST_BACKOFF", "2s")
+		defer os.Unsetenv("APP_TEST_MAX_ATTEMPTS")
+		defer os.Unsetenv("APP_TEST_BACKOFF")
+		assert.Equal(t, Policy{MaxAttempts: 3, Backoff: 2 * time.Second, MaxBackoff: time.Hour}, defaults.FromEnv("APP_TEST"))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// a max backoff below the backoff is replaced by the default
+		p := Policy{MaxAttempts: -1, Backoff: time.Minute, MaxBackoff: time.Second}.WithDefaults(defaults)
+		assert.Equal(t, defaults.MaxAttempts, p.MaxAttempts)
+		assert.Equal(t, time.Hour, p.MaxBackoff)
+
+		// the values invalid or not positive keep the fallback
+		os.Setenv("APP_TEST_BACKOFF", "-2s")
+		os.Setenv("APP_TEST_MAX_ATTEMPTS", "many")
+		defer os.Unsetenv("APP_TEST_MAX_ATTEMPTS")
+		defer os.Unsetenv("APP_TEST_BACKOFF")
+		assert.Equal(t, defaults, defaults.FromEnv("APP_TEST"))
+		assert.Equal(t, int64(7), EnvInt64("APP_TEST_UNSET", 7))
+		assert.Equal(t, time.Second, Duration(0, time.Second))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
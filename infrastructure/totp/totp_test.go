@@ -0,0 +1,63 @@
+package totp
+
+import (
+	"encoding/base32"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// the SHA1 secret of the test vectors of RFC 6238
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	s := t.Run("success", func(t *testing.T) {
+		vectors := map[int64]string{
+			59:         "287082",
+			1111111109: "081804",
+			1111111111: "050471",
+			1234567890: "005924",
+			2000000000: "279037",
+		}
+		for unix, expected := range vectors {
+			code, err := Code(secret, time.Unix(unix, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, code)
+		}
+
+		// the code of the previous period is still accepted, with its step
+		now := time.Unix(1111111111, 0)
+		matched, ok, err := Validate(secret, "081804", now)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, int64(1111111109/30), matched)
+
+		generated, err := GenerateSecret()
+		assert.NoError(t, err)
+		assert.Len(t, generated, 32)
+		uri, err := url.Parse(URI("go-echo-api", "uje@email.com", generated))
+		assert.NoError(t, err)
+		assert.Equal(t, "otpauth", uri.Scheme)
+		assert.Equal(t, "totp", uri.Host)
+		assert.Equal(t, "/go-echo-api:uje@email.com", uri.Path)
+		assert.Equal(t, generated, uri.Query().Get("secret"))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		now := time.Unix(1111111111, 0)
+		_, ok, err := Validate(secret, "287082", now)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		// the code of two periods ago is expired
+		previous, _ := Code(secret, now.Add(-2*Period))
+		_, ok, _ = Validate(secret, previous, now)
+		assert.False(t, ok)
+		_, ok, _ = Validate(secret, "12345", now)
+		assert.False(t, ok)
+
+		_, _, err = Validate("not base32!", "123456", now)
+		assert.Error(t, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
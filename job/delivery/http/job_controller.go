@@ -0,0 +1,37 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/job"
+	"go-echo-api/middleware"
+	"go-echo-api/utils"
+)
+
+type jobController struct {
+	jobUsecase job.Usecase
+}
+
+func NewJobController(s job.Usecase) *jobController {
+	return &jobController{jobUsecase: s}
+}
+
+func (c *jobController) FindById(ctx echo.Context) error {
+	result, err := c.jobUsecase.FindById(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, job.NewJobMapper().Map(*result), nil)
+}
+
+// errorResponse map the errors of the job use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case job.ErrNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("job use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	apiKeyRepository "go-echo-api/apikey/repository"
+	apiKeyUsecase "go-echo-api/apikey/usecase"
+	"go-echo-api/job/repository"
+	"go-echo-api/job/usecase"
+	"go-echo-api/middleware"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/sink"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+)
+
+// Module wire the jobs to the database and register their routes under /jobs, the user who enqueued
+// a job polls its status and progress with an access token or an API key
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/jobs"
+}
+
+// Subscribe delete the jobs of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewJobRepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	controller := NewJobController(usecase.NewJobService(repository.NewJobRepository(m.db)))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	authenticate := middleware.Authenticate(apiKeyUsecase.NewAPIKeyService(apiKeyRepository.NewAPIKeyRepository(m.db), userRepository.NewUserRepository(m.db)), sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL))
+	g.GET("/:id", controller.FindById, tenantScope, authenticate)
+}
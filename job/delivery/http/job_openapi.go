@@ -0,0 +1,26 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/job"
+	"go-echo-api/middleware"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+
+	g.Add(echo.GET, "/:id", openapi.Operation{
+		Tags:        []string{"job"},
+		Summary:     "Find a job enqueued by the logged in user, with its status and progress",
+		OperationID: "findJob",
+		Parameters:  []openapi.Parameter{openapi.PathParam("id", "ID of the job")},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Job", job.Mapper{}),
+			"401": g.Error("Missing or invalid access token or API key"),
+			"404": g.Error("Job not found"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+}
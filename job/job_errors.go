@@ -0,0 +1,9 @@
+package job
+
+import "errors"
+
+var (
+	ErrNotFound = errors.New("job not found")
+	// ErrUnknownType is a job no handler runs, it fails without retry
+	ErrUnknownType = errors.New("job type has no handler")
+)
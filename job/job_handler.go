@@ -0,0 +1,36 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Progress report the percentage of the job done, from 0 to 100, it also renews the lease of the worker
+type Progress func(percent int)
+
+// Handler run a job of its type with the JSON payload given to Enqueue and return its result, encoded
+// to JSON. The context carries the tenant of the job and is canceled when the pool stops for good.
+// A job failing is retried with a backoff unless its error is Permanent.
+type Handler func(ctx context.Context, payload json.RawMessage, progress Progress) (interface{}, error)
+
+// Handlers are the handlers of the pool by job type
+type Handlers map[string]Handler
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Permanent mark the failure of a job as final, e.g. an invalid payload, the job is not retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent tell whether err is a failure not worth a retry
+func IsPermanent(err error) bool {
+	_, ok := err.(permanentError)
+	return ok
+}
@@ -0,0 +1,50 @@
+package job
+
+import (
+	"encoding/json"
+	"go-echo-api/models"
+	"time"
+)
+
+type Mapper struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	// Progress is the percentage of the job done
+	Progress int `json:"progress"`
+	Attempts int `json:"attempts"`
+	// Result is the output of the job succeeded, null until then
+	Result    interface{} `json:"result"`
+	LastError string      `json:"last_error"`
+	// RunAt is when the job is due, null once it finished
+	RunAt      *time.Time `json:"run_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func NewJobMapper() *Mapper {
+	return &Mapper{}
+}
+
+func (m *Mapper) Map(model models.Job) *Mapper {
+	m.ID = model.ID
+	m.Type = model.Type
+	m.Status = model.Status
+	m.Progress = model.Progress
+	m.Attempts = model.Attempts
+	m.Result = nil
+	if model.Result != "" {
+		_ = json.Unmarshal([]byte(model.Result), &m.Result)
+	}
+	m.LastError = model.LastError
+	m.RunAt = nil
+	if !model.Finished() {
+		runAt := model.RunAt
+		m.RunAt = &runAt
+	}
+	m.StartedAt = model.StartedAt
+	m.FinishedAt = model.FinishedAt
+	m.CreatedAt = model.CreatedAt
+	return m
+}
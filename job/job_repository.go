@@ -0,0 +1,26 @@
+package job
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+// Repository of the jobs, FindById and Store are scoped to the tenant of the context, the methods
+// of the workers to none: the pool runs the jobs of every tenant
+type Repository interface {
+	FindById(ctx context.Context, id string) (*models.Job, error)
+	Store(ctx context.Context, model *models.Job) error
+
+	// FindDue return at most limit jobs queued, or running with a lease expired, due at now, the earliest first
+	FindDue(ctx context.Context, now time.Time, limit int64) ([]models.Job, error)
+	// Claim mark the job due at now running until the lease, counting an attempt, false when another
+	// worker claimed it first
+	Claim(ctx context.Context, id string, now time.Time, until time.Time) (bool, error)
+	// Heartbeat save the progress of the job running and renew its lease until
+	Heartbeat(ctx context.Context, id string, progress int, until time.Time) error
+	// Update save the outcome of a run
+	Update(ctx context.Context, model *models.Job) error
+	// DeleteByUser delete the jobs of the user, once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,16 @@
+package job
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+type Usecase interface {
+	// FindById return the job of the tenant enqueued by actorID
+	FindById(ctx context.Context, actorID string, id string) (*models.Job, error)
+	// Enqueue the job to run as soon as a worker is free, in the transaction of ctx if any
+	Enqueue(ctx context.Context, actorID string, jobType string, payload interface{}) (models.Job, error)
+	// Schedule the job to run at runAt
+	Schedule(ctx context.Context, actorID string, jobType string, payload interface{}, runAt time.Time) (models.Job, error)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/job"
+	"go-echo-api/models"
+	"time"
+)
+
+type jobGormRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) job.Repository {
+	return &jobGormRepository{db: db}
+}
+
+func (r *jobGormRepository) FindById(ctx context.Context, id string) (*models.Job, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.Job
+	err = db.Where("id = ?", id).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, job.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *jobGormRepository) Store(ctx context.Context, model *models.Job) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+// FindDue is not scoped to a tenant, the pool runs the jobs of every tenant
+func (r *jobGormRepository) FindDue(ctx context.Context, now time.Time, limit int64) ([]models.Job, error) {
+	var model []models.Job
+	err := database.WithContext(ctx, r.db).Where("status IN (?) AND run_at <= ?", []string{models.JobQueued, models.JobRunning}, now).
+		Order("run_at ASC").Limit(limit).Find(&model).Error
+	return model, err
+}
+
+func (r *jobGormRepository) Claim(ctx context.Context, id string, now time.Time, until time.Time) (bool, error) {
+	result := database.WithContext(ctx, r.db).Model(&models.Job{}).
+		Where("id = ? AND status IN (?) AND run_at <= ?", id, []string{models.JobQueued, models.JobRunning}, now).
+		Updates(map[string]interface{}{
+			"status":     models.JobRunning,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"run_at":     until,
+			"started_at": now,
+			"updated_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *jobGormRepository) Heartbeat(ctx context.Context, id string, progress int, until time.Time) error {
+	return database.WithContext(ctx, r.db).Model(&models.Job{}).Where("id = ? AND status = ?", id, models.JobRunning).
+		Updates(map[string]interface{}{
+			"progress":   progress,
+			"run_at":     until,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+func (r *jobGormRepository) Update(ctx context.Context, model *models.Job) error {
+	return database.WithContext(ctx, r.db).Model(&models.Job{}).Where("id = ?", model.ID).
+		Updates(map[string]interface{}{
+			"payload":     model.Payload,
+			"status":      model.Status,
+			"progress":    model.Progress,
+			"attempts":    model.Attempts,
+			"run_at":      model.RunAt,
+			"last_error":  model.LastError,
+			"result":      model.Result,
+			"finished_at": model.FinishedAt,
+			"updated_at":  time.Now(),
+		}).Error
+}
+
+func (r *jobGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ?", userID).Delete(&models.Job{}).Error
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/job"
+	"go-echo-api/models"
+	"testing"
+	"time"
+)
+
+func TestJobGormRepository(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewJobRepository(db)
+	now := time.Now().UTC().Truncate(time.Second)
+	first := models.Job{UserID: dbtest.UserUje.ID, Type: "user.import", Payload: "{}", Status: models.JobQueued, RunAt: now.Add(-time.Minute)}
+	delayed := models.Job{UserID: dbtest.UserUje.ID, Type: "user.import", Payload: "{}", Status: models.JobQueued, RunAt: now.Add(time.Hour)}
+	other := models.Job{Type: "user.import", Payload: "{}", Status: models.JobQueued, RunAt: now}
+	assert.NoError(t, r.Store(dbtest.Context(), &first))
+	assert.NoError(t, r.Store(dbtest.Context(), &delayed))
+	assert.NoError(t, r.Store(dbtest.TenantContext(dbtest.TenantGlobex), &other))
+
+	found, err := r.FindById(dbtest.Context(), first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, dbtest.TenantAcme.ID, found.TenantID)
+	_, err = r.FindById(dbtest.Context(), other.ID)
+	assert.Equal(t, job.ErrNotFound, err)
+
+	// the jobs due of every tenant, the earliest first
+	due, err := r.FindDue(context.Background(), now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 2) {
+		assert.Equal(t, first.ID, due[0].ID)
+		assert.Equal(t, other.ID, due[1].ID)
+	}
+	claimed, err := r.Claim(context.Background(), first.ID, now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, true, claimed)
+	claimed, _ = r.Claim(context.Background(), first.ID, now, now.Add(time.Minute))
+	assert.Equal(t, false, claimed)
+	found, _ = r.FindById(dbtest.Context(), first.ID)
+	assert.Equal(t, models.JobRunning, found.Status)
+	assert.Equal(t, 1, found.Attempts)
+
+	// the progress renews the lease, a job whose lease expired is due again
+	assert.NoError(t, r.Heartbeat(context.Background(), first.ID, 40, now.Add(2*time.Minute)))
+	due, _ = r.FindDue(context.Background(), now.Add(time.Minute), 10)
+	assert.Len(t, due, 1)
+	due, _ = r.FindDue(context.Background(), now.Add(2*time.Minute), 10)
+	assert.Len(t, due, 2)
+	found, _ = r.FindById(dbtest.Context(), first.ID)
+	assert.Equal(t, 40, found.Progress)
+
+	found.Status = models.JobSucceeded
+	found.Progress = 100
+	found.Result = `{"imported":1}`
+	found.FinishedAt = &now
+	found.Payload = ""
+	assert.NoError(t, r.Update(context.Background(), found))
+	found, _ = r.FindById(dbtest.Context(), first.ID)
+	assert.Equal(t, models.JobSucceeded, found.Status)
+	assert.Empty(t, found.Payload)
+	assert.Equal(t, `{"imported":1}`, found.Result)
+	assert.NotNil(t, found.FinishedAt)
+	due, _ = r.FindDue(context.Background(), now.Add(2*time.Hour), 10)
+	assert.Len(t, due, 2)
+	// a heartbeat of a job finished changes nothing
+	assert.NoError(t, r.Heartbeat(context.Background(), first.ID, 10, now))
+	found, _ = r.FindById(dbtest.Context(), first.ID)
+	assert.Equal(t, 100, found.Progress)
+}
+
+func TestJobGormRepository_DeleteByUser(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	ipan := models.Job{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID}
+	uje := models.Job{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserUje.ID}
+	assert.NoError(t, dbtest.LoadFixtures(db, &ipan, &uje))
+
+	r := NewJobRepository(db)
+	assert.NoError(t, r.DeleteByUser(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.Job{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Model(&models.Job{}).Where("user_id = ?", dbtest.UserUje.ID).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
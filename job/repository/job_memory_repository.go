@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/job"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// jobMemoryRepository keeps the jobs in memory, it backs the use-case unit tests
+type jobMemoryRepository struct {
+	mu   sync.RWMutex
+	jobs map[string]models.Job
+}
+
+func NewJobMemoryRepository(jobs ...models.Job) job.Repository {
+	r := &jobMemoryRepository{jobs: make(map[string]models.Job)}
+	for _, j := range jobs {
+		r.jobs[j.ID] = j
+	}
+	return r
+}
+
+func (r *jobMemoryRepository) FindById(ctx context.Context, id string) (*models.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	model, ok := r.jobs[id]
+	if !ok || model.TenantID != tenantID {
+		return nil, job.ErrNotFound
+	}
+	return &model, nil
+}
+
+func (r *jobMemoryRepository) Store(ctx context.Context, model *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.jobs[model.ID] = *model
+	return nil
+}
+
+// due tell whether a worker may claim the job at now
+func due(model models.Job, now time.Time) bool {
+	return (model.Status == models.JobQueued || model.Status == models.JobRunning) && !model.RunAt.After(now)
+}
+
+func (r *jobMemoryRepository) FindDue(ctx context.Context, now time.Time, limit int64) ([]models.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var model []models.Job
+	for _, j := range r.jobs {
+		if due(j, now) {
+			model = append(model, j)
+		}
+	}
+	sort.Slice(model, func(i, j int) bool {
+		return model[i].RunAt.Before(model[j].RunAt)
+	})
+	if limit >= 0 && int64(len(model)) > limit {
+		model = model[:limit]
+	}
+	return model, nil
+}
+
+func (r *jobMemoryRepository) Claim(ctx context.Context, id string, now time.Time, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	model, ok := r.jobs[id]
+	if !ok || !due(model, now) {
+		return false, nil
+	}
+	model.Status = models.JobRunning
+	model.Attempts++
+	model.RunAt = until
+	model.StartedAt = &now
+	model.UpdatedAt = now
+	r.jobs[id] = model
+	return true, nil
+}
+
+func (r *jobMemoryRepository) Heartbeat(ctx context.Context, id string, progress int, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	model, ok := r.jobs[id]
+	if !ok || model.Status != models.JobRunning {
+		return nil
+	}
+	model.Progress = progress
+	model.RunAt = until
+	model.UpdatedAt = time.Now()
+	r.jobs[id] = model
+	return nil
+}
+
+func (r *jobMemoryRepository) Update(ctx context.Context, model *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.jobs[model.ID]
+	if !ok {
+		return nil
+	}
+	existing.Payload = model.Payload
+	existing.Status = model.Status
+	existing.Progress = model.Progress
+	existing.Attempts = model.Attempts
+	existing.RunAt = model.RunAt
+	existing.LastError = model.LastError
+	existing.Result = model.Result
+	existing.FinishedAt = model.FinishedAt
+	existing.UpdatedAt = time.Now()
+	r.jobs[model.ID] = existing
+	return nil
+}
+
+func (r *jobMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, v := range r.jobs {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.jobs, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/retry"
+	"go-echo-api/job"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sync"
+	"time"
+)
+
+type PoolConfig struct {
+	// Concurrency is the number of jobs run at once, default 4
+	Concurrency int
+
+	// Interval between two polls of the queue, default 1s
+	Interval time.Duration
+
+	// Lease is how long a worker holds a job before another one may take it, renewed by the progress
+	// reported, default 5m
+	Lease time.Duration
+
+	// Policy of the retries of a job failed, marked failed after MaxAttempts, default 5 attempts and
+	// a backoff of 10s up to 1h
+	retry.Policy
+
+	// ShutdownTimeout is how long Run waits for the jobs running once stopped before canceling
+	// their context, default 30s
+	ShutdownTimeout time.Duration
+}
+
+var DefaultPoolConfig = PoolConfig{
+	Concurrency:     4,
+	Interval:        time.Second,
+	Lease:           5 * time.Minute,
+	Policy:          retry.Policy{MaxAttempts: 5, Backoff: 10 * time.Second, MaxBackoff: time.Hour},
+	ShutdownTimeout: 30 * time.Second,
+}
+
+// PoolConfigFromEnv read the configuration of the pool from APP_JOB_CONCURRENCY, APP_JOB_INTERVAL,
+// APP_JOB_MAX_ATTEMPTS, APP_JOB_BACKOFF and APP_JOB_SHUTDOWN_TIMEOUT, e.g. "2s"
+func PoolConfigFromEnv() PoolConfig {
+	config := DefaultPoolConfig
+	config.Concurrency = retry.EnvInt("APP_JOB_CONCURRENCY", config.Concurrency)
+	config.Interval = retry.EnvDuration("APP_JOB_INTERVAL", config.Interval)
+	config.Policy = config.Policy.FromEnv("APP_JOB")
+	config.ShutdownTimeout = retry.EnvDuration("APP_JOB_SHUTDOWN_TIMEOUT", config.ShutdownTimeout)
+	return config
+}
+
+// Pool runs the jobs due with their handlers, Concurrency at a time. The pools of several instances
+// share the queue, each job is claimed by one of them for the lease: a job whose worker died is run
+// again once its lease expired.
+type Pool struct {
+	jobRepository job.Repository
+	handlers      job.Handlers
+	config        PoolConfig
+	now           func() time.Time
+	// slots holds a token per job running
+	slots   chan struct{}
+	running sync.WaitGroup
+}
+
+func NewPool(r job.Repository, handlers job.Handlers, config PoolConfig) *Pool {
+	config.Concurrency = retry.Int(config.Concurrency, DefaultPoolConfig.Concurrency)
+	config.Interval = retry.Duration(config.Interval, DefaultPoolConfig.Interval)
+	config.Lease = retry.Duration(config.Lease, DefaultPoolConfig.Lease)
+	config.Policy = config.Policy.WithDefaults(DefaultPoolConfig.Policy)
+	config.ShutdownTimeout = retry.Duration(config.ShutdownTimeout, DefaultPoolConfig.ShutdownTimeout)
+	return &Pool{
+		jobRepository: r,
+		handlers:      handlers,
+		config:        config,
+		now:           time.Now,
+		slots:         make(chan struct{}, config.Concurrency),
+	}
+}
+
+// Run poll the queue every interval until ctx is done, then wait for the jobs running. Those still
+// running after the shutdown timeout have their context canceled, they are retried on the next start.
+func (p *Pool) Run(ctx context.Context) {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.start(ctx, jobCtx); err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).WithError(err).Error("poll jobs")
+		}
+		select {
+		case <-ctx.Done():
+			p.drain(cancelJobs)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain wait for the jobs running, canceling them after the shutdown timeout
+func (p *Pool) drain(cancelJobs context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(p.config.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		cancelJobs()
+		<-done
+	}
+}
+
+// Work run the jobs due and return the number of jobs run once they all ended
+func (p *Pool) Work(ctx context.Context) (int, error) {
+	started, err := p.start(ctx, ctx)
+	p.running.Wait()
+	return started, err
+}
+
+// start claim as many jobs due as there are free slots and run them in the background with jobCtx
+func (p *Pool) start(ctx context.Context, jobCtx context.Context) (int, error) {
+	free := cap(p.slots) - len(p.slots)
+	if free == 0 {
+		return 0, nil
+	}
+	now := p.now().UTC()
+	jobs, err := p.jobRepository.FindDue(ctx, now, int64(free))
+	if err != nil {
+		return 0, err
+	}
+	started := 0
+	for _, model := range jobs {
+		if ctx.Err() != nil {
+			return started, ctx.Err()
+		}
+		claimed, err := p.jobRepository.Claim(ctx, model.ID, now, now.Add(p.config.Lease))
+		if err != nil {
+			return started, err
+		}
+		if !claimed {
+			continue
+		}
+		model.Status = models.JobRunning
+		model.Attempts++
+		model.StartedAt = &now
+		p.slots <- struct{}{}
+		p.running.Add(1)
+		go func(model models.Job) {
+			defer func() {
+				<-p.slots
+				p.running.Done()
+			}()
+			p.run(jobCtx, model)
+		}(model)
+		started++
+	}
+	return started, nil
+}
+
+// run the job with its handler, in its tenant, and save the outcome
+func (p *Pool) run(ctx context.Context, model models.Job) {
+	ctx = tenant.WithContext(ctx, models.Tenant{ID: model.TenantID})
+	log := logger.FromContext(ctx).WithField(logger.JobIDField, model.ID).WithField(logger.TenantIDField, model.TenantID)
+	ctx = logger.WithContext(ctx, log)
+	var result interface{}
+	var err error
+	handler, ok := p.handlers[model.Type]
+	if ok {
+		result, err = p.call(ctx, handler, &model)
+	} else {
+		err = job.Permanent(job.ErrUnknownType)
+	}
+	if err != nil {
+		log.WithError(err).WithField("attempts", model.Attempts).Warn("job failed")
+	}
+	if err := p.finish(model, result, err); err != nil {
+		log.WithError(err).Error("save job outcome")
+	}
+}
+
+// call the handler, a panic fails the job rather than the process. The progress reported is kept in model.
+func (p *Pool) call(ctx context.Context, handler job.Handler, model *models.Job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	var mu sync.Mutex
+	progress := func(percent int) {
+		if percent < 0 {
+			percent = 0
+		}
+		if percent > 100 {
+			percent = 100
+		}
+		mu.Lock()
+		model.Progress = percent
+		mu.Unlock()
+		if err := p.jobRepository.Heartbeat(ctx, model.ID, percent, p.now().UTC().Add(p.config.Lease)); err != nil {
+			logger.FromContext(ctx).WithError(err).Warn("save job progress")
+		}
+	}
+	result, err = handler(ctx, json.RawMessage(model.Payload), progress)
+	mu.Lock()
+	defer mu.Unlock()
+	return result, err
+}
+
+// finish save the outcome of the run: the result of the job succeeded, else a retry after the backoff
+// or the failure for good
+func (p *Pool) finish(model models.Job, result interface{}, err error) error {
+	now := p.now().UTC()
+	if err == nil {
+		data, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			err = job.Permanent(marshalErr)
+		}
+		model.Result = string(data)
+	}
+	switch {
+	case err == nil:
+		model.Status = models.JobSucceeded
+		model.Progress = 100
+		model.LastError = ""
+		model.FinishedAt = &now
+	case job.IsPermanent(err) || model.Attempts >= p.config.MaxAttempts:
+		model.Status = models.JobFailed
+		model.LastError = err.Error()
+		model.FinishedAt = &now
+	default:
+		// the retry begins again
+		model.Status = models.JobQueued
+		model.Progress = 0
+		model.LastError = err.Error()
+		model.RunAt = now.Add(p.config.Delay(model.Attempts))
+	}
+	if model.Finished() {
+		// the input is not kept once the job ended, it may hold secrets such as the passwords of an import
+		model.Payload = ""
+	}
+	// the outcome is saved even when the pool stops
+	return p.jobRepository.Update(context.Background(), &model)
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/retry"
+	"go-echo-api/job"
+	"go-echo-api/job/repository"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestPool_Work(t *testing.T) {
+	r := repository.NewJobMemoryRepository()
+	u := NewJobService(r).(JobService)
+	now := time.Now().UTC()
+	u.now = func() time.Time { return now }
+	failures := 0
+	handlers := job.Handlers{
+		"sum": func(ctx context.Context, payload json.RawMessage, progress job.Progress) (interface{}, error) {
+			var numbers []int
+			if err := json.Unmarshal(payload, &numbers); err != nil {
+				return nil, job.Permanent(err)
+			}
+			sum := 0
+			for i, n := range numbers {
+				sum += n
+				progress((i + 1) * 100 / len(numbers))
+			}
+			// the handler runs in the tenant of the job
+			current, ok := tenant.FromContext(ctx)
+			if !ok {
+				return nil, tenant.ErrRequired
+			}
+			return map[string]interface{}{"sum": sum, "tenant": current.ID}, nil
+		},
+		"flaky": func(ctx context.Context, payload json.RawMessage, progress job.Progress) (interface{}, error) {
+			failures++
+			progress(50)
+			return nil, errFlaky
+		},
+		"panic": func(ctx context.Context, payload json.RawMessage, progress job.Progress) (interface{}, error) {
+			panic("boom")
+		},
+	}
+	p := NewPool(r, handlers, PoolConfig{Concurrency: 2, Policy: retry.Policy{MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: 90 * time.Second}})
+	p.now = u.now
+
+	s := t.Run("success", func(t *testing.T) {
+		enqueued, _ := u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "sum", []int{1, 2, 3, 4})
+		started, err := p.Work(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, started)
+		found, _ := r.FindById(dbtest.Context(), enqueued.ID)
+		assert.Equal(t, models.JobSucceeded, found.Status)
+		assert.Equal(t, 100, found.Progress)
+		assert.Equal(t, 1, found.Attempts)
+		assert.JSONEq(t, `{"sum":10,"tenant":"`+dbtest.TenantAcme.ID+`"}`, found.Result)
+		assert.NotNil(t, found.FinishedAt)
+		assert.Empty(t, found.Payload)
+		// nothing is left to run
+		started, _ = p.Work(context.Background())
+		assert.Equal(t, 0, started)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		flaky, _ := u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "flaky", nil)
+		_, _ = p.Work(context.Background())
+		found, _ := r.FindById(dbtest.Context(), flaky.ID)
+		assert.Equal(t, models.JobQueued, found.Status)
+		assert.Equal(t, 0, found.Progress)
+		assert.Equal(t, errFlaky.Error(), found.LastError)
+		assert.Equal(t, now.Add(time.Minute), found.RunAt)
+		// the retry runs with the same input
+		assert.Equal(t, "null", found.Payload)
+		// the retry waits for the backoff, doubled up to the max
+		started, _ := p.Work(context.Background())
+		assert.Equal(t, 0, started)
+		now = now.Add(time.Minute)
+		_, _ = p.Work(context.Background())
+		found, _ = r.FindById(dbtest.Context(), flaky.ID)
+		assert.Equal(t, now.Add(90*time.Second), found.RunAt)
+		now = now.Add(90 * time.Second)
+		_, _ = p.Work(context.Background())
+		found, _ = r.FindById(dbtest.Context(), flaky.ID)
+		assert.Equal(t, models.JobFailed, found.Status)
+		assert.Equal(t, 3, found.Attempts)
+		assert.Equal(t, 3, failures)
+		assert.NotNil(t, found.FinishedAt)
+		assert.Empty(t, found.Payload)
+
+		// a permanent error, an unknown type and a panic fail at once
+		invalid, _ := u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "sum", "not numbers")
+		unknown, _ := u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "unknown", nil)
+		panicked, _ := u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "panic", nil)
+		started, _ = p.Work(context.Background())
+		assert.Equal(t, 2, started)
+		started, _ = p.Work(context.Background())
+		assert.Equal(t, 1, started)
+		found, _ = r.FindById(dbtest.Context(), invalid.ID)
+		assert.Equal(t, models.JobFailed, found.Status)
+		found, _ = r.FindById(dbtest.Context(), unknown.ID)
+		assert.Equal(t, models.JobFailed, found.Status)
+		assert.Equal(t, job.ErrUnknownType.Error(), found.LastError)
+		found, _ = r.FindById(dbtest.Context(), panicked.ID)
+		assert.Equal(t, models.JobQueued, found.Status)
+		assert.Equal(t, "panic: boom", found.LastError)
+	})
+
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestPool_Run(t *testing.T) {
+	r := repository.NewJobMemoryRepository()
+	u := NewJobService(r)
+	started := make(chan struct{})
+	handlers := job.Handlers{
+		"wait": func(ctx context.Context, payload json.RawMessage, progress job.Progress) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	p := NewPool(r, handlers, PoolConfig{Interval: 10 * time.Millisecond, ShutdownTimeout: 50 * time.Millisecond})
+	enqueued, _ := u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "wait", nil)
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+	<-started
+	stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the pool did not stop")
+	}
+	// the job canceled by the stop is retried on the next start
+	found, _ := r.FindById(dbtest.Context(), enqueued.ID)
+	assert.Equal(t, models.JobQueued, found.Status)
+	assert.Equal(t, context.Canceled.Error(), found.LastError)
+}
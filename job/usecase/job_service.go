@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-echo-api/job"
+	"go-echo-api/models"
+	"time"
+)
+
+type JobService struct {
+	jobRepository job.Repository
+	now           func() time.Time
+}
+
+// NewJobService return the service enqueuing the jobs, the pool runs them
+func NewJobService(r job.Repository) job.Usecase {
+	return JobService{jobRepository: r, now: time.Now}
+}
+
+// FindById hide the jobs of the other users as not found
+func (u JobService) FindById(ctx context.Context, actorID string, id string) (*models.Job, error) {
+	model, err := u.jobRepository.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if model.UserID != actorID {
+		return nil, job.ErrNotFound
+	}
+	return model, nil
+}
+
+func (u JobService) Enqueue(ctx context.Context, actorID string, jobType string, payload interface{}) (models.Job, error) {
+	return u.Schedule(ctx, actorID, jobType, payload, u.now())
+}
+
+func (u JobService) Schedule(ctx context.Context, actorID string, jobType string, payload interface{}, runAt time.Time) (models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, err
+	}
+	model := models.Job{
+		UserID:  actorID,
+		Type:    jobType,
+		Payload: string(data),
+		Status:  models.JobQueued,
+		RunAt:   runAt.UTC(),
+	}
+	err = u.jobRepository.Store(ctx, &model)
+	return model, err
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/job"
+	"go-echo-api/job/repository"
+	"go-echo-api/models"
+	"testing"
+	"time"
+)
+
+func TestJobService_Schedule(t *testing.T) {
+	r := repository.NewJobMemoryRepository()
+	u := NewJobService(r).(JobService)
+	now := time.Now().UTC()
+	u.now = func() time.Time { return now }
+
+	s := t.Run("success", func(t *testing.T) {
+		enqueued, err := u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "user.import", map[string]string{"format": "csv"})
+		assert.NoError(t, err)
+		assert.Equal(t, models.JobQueued, enqueued.Status)
+		assert.Equal(t, `{"format":"csv"}`, enqueued.Payload)
+		assert.Equal(t, dbtest.TenantAcme.ID, enqueued.TenantID)
+
+		scheduled, err := u.Schedule(dbtest.Context(), dbtest.UserUje.ID, "user.import", nil, now.Add(time.Hour))
+		assert.NoError(t, err)
+		// a job scheduled is not due before its time
+		due, _ := r.FindDue(dbtest.Context(), now, 10)
+		if assert.Len(t, due, 1) {
+			assert.Equal(t, enqueued.ID, due[0].ID)
+		}
+		due, _ = r.FindDue(dbtest.Context(), now.Add(time.Hour), 10)
+		assert.Len(t, due, 2)
+
+		found, err := u.FindById(dbtest.Context(), dbtest.UserUje.ID, scheduled.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, scheduled.ID, found.ID)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		enqueued, _ := u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "user.import", nil)
+		// the job of another user
+		_, err := u.FindById(dbtest.Context(), "another-user", enqueued.ID)
+		assert.Equal(t, job.ErrNotFound, err)
+		// the job of another tenant
+		_, err = u.FindById(dbtest.TenantContext(dbtest.TenantGlobex), dbtest.UserUje.ID, enqueued.ID)
+		assert.Equal(t, job.ErrNotFound, err)
+		_, err = u.Enqueue(dbtest.Context(), dbtest.UserUje.ID, "user.import", func() {})
+		assert.Error(t, err)
+	})
+
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/metrics"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/magiclink"
+	"go-echo-api/magiclink/usecase"
+	"go-echo-api/mfa"
+	"go-echo-api/utils"
+	"strconv"
+)
+
+type magicLinkController struct {
+	magicLinkUsecase magiclink.Usecase
+	mfaUsecase       mfa.Usecase
+	loginUsecase     auth.Login
+	// verifyURL is the page of the links emailed
+	verifyURL string
+}
+
+func NewMagicLinkController(s magiclink.Usecase, m mfa.Usecase, login auth.Login, verifyURL string) *magicLinkController {
+	return &magicLinkController{magicLinkUsecase: s,
+		mfaUsecase:   m,
+		loginUsecase: login,
+		verifyURL:    verifyURL,
+	}
+}
+
+// Request email a login link to the user, the answer does not tell whether the email is registered
+func (c *magicLinkController) Request(ctx echo.Context) error {
+	var dto magiclink.RequestDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	if err := c.magicLinkUsecase.Request(ctx.Request().Context(), dto.Email, c.verifyURL); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+// Verify answer the token pair of the user of the link, or its mfa token when it has two-factor authentication
+func (c *magicLinkController) Verify(ctx echo.Context) error {
+	var dto magiclink.VerifyDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.magicLinkUsecase.Verify(ctx.Request().Context(), dto.Token)
+	if err != nil {
+		metrics.Logins.WithLabelValues(metrics.Failed).Inc()
+		return errorResponse(ctx, err)
+	}
+	// a user with two-factor authentication finishes on /auth/token/mfa as with its password
+	token, expiresAt, err := c.mfaUsecase.Challenge(ctx.Request().Context(), result.ID)
+	if err != nil {
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+	if token != "" {
+		return response.SingleData(ctx, utils.OK, auth.NewMFAMapper(token, expiresAt), nil)
+	}
+	metrics.Logins.WithLabelValues(metrics.Succeeded).Inc()
+	tokens, err := c.loginUsecase.TokenPair(ctx.Request().Context(), *result, ctx.RealIP(), ctx.Request().UserAgent())
+	if err != nil {
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+	return response.SingleData(ctx, utils.OK, tokens, nil)
+}
+
+// errorResponse map the errors of the magic link use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case magiclink.ErrInvalidToken:
+		return response.Unauthorized(ctx, utils.Unauthorized, nil, err.Error())
+	case magiclink.ErrRateLimited:
+		ctx.Response().Header().Set("Retry-After", strconv.Itoa(int(usecase.RateWindow.Seconds())))
+		return response.TooManyRequests(ctx, utils.TooManyRequests, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("magic link use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	authUsecase "go-echo-api/auth/usecase"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/mailer"
+	"go-echo-api/magiclink"
+	"go-echo-api/magiclink/repository"
+	"go-echo-api/magiclink/usecase"
+	mfaRepository "go-echo-api/mfa/repository"
+	mfaUsecase "go-echo-api/mfa/usecase"
+	"go-echo-api/middleware"
+	organizationRepository "go-echo-api/organization/repository"
+	organizationUsecase "go-echo-api/organization/usecase"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/sink"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+)
+
+// Module wire the passwordless login by email to the database and the mailer of APP_MAILER and register
+// its routes under /auth/magic-link. The user asks a link for its email and opens it to log in.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/auth/magic-link"
+}
+
+// Subscribe delete the magic links of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewMagicLinkRepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	sender, err := mailer.FromEnv()
+	if err != nil {
+		logger.Default().WithError(err).Fatal("configure the mailer")
+	}
+	verifyURL, err := usecase.VerifyURLFromEnv()
+	if err != nil {
+		logger.Default().WithError(err).Fatal("configure the magic links")
+	}
+	users := userRepository.NewUserRepository(m.db)
+	organizations := organizationUsecase.NewOrganizationService(organizationRepository.NewOrganizationRepository(m.db))
+	factors := mfaUsecase.NewMFAService(mfaRepository.NewMFARepository(m.db), users, mfaUsecase.IssuerFromEnv())
+	sessions := sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL)
+	controller := NewMagicLinkController(usecase.NewMagicLinkService(repository.NewMagicLinkRepository(m.db), users, sender, middleware.GetJwtSecretKey()), factors, authUsecase.NewLoginService(organizations, sessions), verifyURL)
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	g.POST("", controller.Request, tenantScope)
+	g.GET("/verify", controller.Verify, linkTenant, tenantScope)
+}
+
+// linkTenant name the tenant of the token in the X-Tenant-ID header of a verification without one,
+// the link is opened from the email without the headers of the request
+func linkTenant(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(middleware.HeaderTenantID) == "" {
+			if tenantID := magiclink.TokenTenant(c.QueryParam("token")); tenantID != "" {
+				c.Request().Header.Set(middleware.HeaderTenantID, tenantID)
+			}
+		}
+		return next(c)
+	}
+}
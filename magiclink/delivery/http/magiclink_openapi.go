@@ -0,0 +1,39 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/magiclink"
+	"go-echo-api/middleware"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+
+	g.Add(echo.POST, "", openapi.Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Email a single-use login link to the user of the email, the answer is the same for an unknown email",
+		OperationID: "requestMagicLink",
+		RequestBody: g.Body(magiclink.RequestDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Link sent when the email is registered", nil),
+			"422": g.Error("Invalid body"),
+			"429": g.Error("Too many links requested for the email, retry after the Retry-After header"),
+		},
+	})
+	g.Add(echo.GET, "/verify", openapi.Operation{
+		Tags:        []string{"auth"},
+		Summary:     "Log in with the token of a link, the link is opened once",
+		OperationID: "verifyMagicLink",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("token", "Token of the link", &openapi.Schema{Type: "string"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Token pair of the user logged in, or its mfa token when it has two-factor authentication", auth.LoginMapper{}),
+			"401": g.Error("Link invalid, used or expired"),
+			"422": g.Error("Missing token"),
+		},
+	})
+}
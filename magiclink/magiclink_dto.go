@@ -0,0 +1,11 @@
+package magiclink
+
+// RequestDto ask a login link for the email, it is answered the same whether the email is registered or not
+type RequestDto struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// VerifyDto is the link opened by the user
+type VerifyDto struct {
+	Token string `query:"token" json:"token" validate:"required"`
+}
@@ -0,0 +1,8 @@
+package magiclink
+
+import "errors"
+
+var (
+	ErrInvalidToken = errors.New("magic link invalid, used or expired")
+	ErrRateLimited  = errors.New("too many magic links requested for this email, try again later")
+)
@@ -0,0 +1,18 @@
+package magiclink
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+// Repository of the magic links of the tenant of the context
+type Repository interface {
+	Store(ctx context.Context, model *models.MagicLink) error
+	// CountSince return the number of links requested for the email since the time
+	CountSince(ctx context.Context, email string, since time.Time) (int64, error)
+	// Use mark the link of the token hash as used and return it, ErrInvalidToken when it is unknown or used
+	Use(ctx context.Context, hash string, usedAt time.Time) (*models.MagicLink, error)
+	// DeleteByUser delete the magic links of the user, once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,25 @@
+package magiclink
+
+import (
+	"context"
+	"go-echo-api/models"
+	"strings"
+)
+
+type Usecase interface {
+	// Request email a login link to the user of the email, made of verifyURL and the token of the link.
+	// Nothing is sent for an unknown email but the answer is the same, ErrRateLimited past the limit of the email.
+	Request(ctx context.Context, email string, verifyURL string) error
+	// Verify the token of a link and return its user, the link is used once
+	Verify(ctx context.Context, token string) (*models.User, error)
+}
+
+// TokenTenant return the tenant of a token of a link, empty when token is not one. The link is opened
+// without the X-Tenant-ID header, its verification reads the tenant of the token instead.
+func TokenTenant(token string) string {
+	i := strings.Index(token, ".")
+	if i < 0 {
+		return ""
+	}
+	return token[:i]
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/magiclink"
+	"go-echo-api/models"
+	"time"
+)
+
+type magicLinkGormRepository struct {
+	db *gorm.DB
+}
+
+func NewMagicLinkRepository(db *gorm.DB) magiclink.Repository {
+	return &magicLinkGormRepository{db: db}
+}
+
+func (r *magicLinkGormRepository) Store(ctx context.Context, model *models.MagicLink) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *magicLinkGormRepository) CountSince(ctx context.Context, email string, since time.Time) (int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	err = db.Model(&models.MagicLink{}).Where("email = ? AND created_at > ?", email, since).Count(&total).Error
+	return total, err
+}
+
+func (r *magicLinkGormRepository) Use(ctx context.Context, hash string, usedAt time.Time) (*models.MagicLink, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	// of two concurrent openings of the link only the one marking it used wins
+	result := db.Model(&models.MagicLink{}).Where("token_hash = ? AND used_at IS NULL", hash).UpdateColumn("used_at", usedAt)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, magiclink.ErrInvalidToken
+	}
+	var model models.MagicLink
+	if err := db.Where("token_hash = ?", hash).First(&model).Error; err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *magicLinkGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ?", userID).Delete(&models.MagicLink{}).Error
+}
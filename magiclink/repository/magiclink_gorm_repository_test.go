@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/magiclink"
+	"go-echo-api/models"
+	"testing"
+	"time"
+)
+
+func TestMagicLinkGormRepository(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewMagicLinkRepository(db)
+	since := time.Now().Add(-time.Minute)
+	for _, hash := range []string{"first", "second"} {
+		link := models.MagicLink{UserID: dbtest.UserUje.ID, Email: dbtest.UserUje.Email, TokenHash: hash, ExpiresAt: time.Now().Add(time.Minute)}
+		assert.NoError(t, r.Store(dbtest.Context(), &link))
+	}
+
+	total, err := r.CountSince(dbtest.Context(), dbtest.UserUje.Email, since)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	total, err = r.CountSince(dbtest.Context(), dbtest.UserUje.Email, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	total, err = r.CountSince(dbtest.TenantContext(dbtest.TenantGlobex), dbtest.UserUje.Email, since)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	// a link is used once, in its tenant only
+	_, err = r.Use(dbtest.TenantContext(dbtest.TenantGlobex), "first", time.Now())
+	assert.Equal(t, magiclink.ErrInvalidToken, err)
+	link, err := r.Use(dbtest.Context(), "first", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, dbtest.UserUje.ID, link.UserID)
+	assert.NotNil(t, link.UsedAt)
+	_, err = r.Use(dbtest.Context(), "first", time.Now())
+	assert.Equal(t, magiclink.ErrInvalidToken, err)
+	_, err = r.Use(dbtest.Context(), "unknown", time.Now())
+	assert.Equal(t, magiclink.ErrInvalidToken, err)
+}
+
+func TestMagicLinkGormRepository_DeleteByUser(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	link := models.MagicLink{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID, TokenHash: "hash"}
+	assert.NoError(t, dbtest.LoadFixtures(db, &link))
+
+	r := NewMagicLinkRepository(db)
+	assert.NoError(t, r.DeleteByUser(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.MagicLink{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+}
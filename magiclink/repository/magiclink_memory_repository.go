@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/magiclink"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sync"
+	"time"
+)
+
+// magicLinkMemoryRepository keeps the links in memory, it backs the use-case unit tests
+type magicLinkMemoryRepository struct {
+	mu    sync.RWMutex
+	links map[string]models.MagicLink
+}
+
+func NewMagicLinkMemoryRepository() magiclink.Repository {
+	return &magicLinkMemoryRepository{links: make(map[string]models.MagicLink)}
+}
+
+func (r *magicLinkMemoryRepository) Store(ctx context.Context, model *models.MagicLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	r.links[model.TokenHash] = *model
+	return nil
+}
+
+func (r *magicLinkMemoryRepository) CountSince(ctx context.Context, email string, since time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, link := range r.links {
+		if link.TenantID == tenantID && link.Email == email && link.CreatedAt.After(since) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *magicLinkMemoryRepository) Use(ctx context.Context, hash string, usedAt time.Time) (*models.MagicLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	link, ok := r.links[hash]
+	if !ok || link.TenantID != tenantID || link.UsedAt != nil {
+		return nil, magiclink.ErrInvalidToken
+	}
+	link.UsedAt = &usedAt
+	r.links[hash] = link
+	return &link, nil
+}
+
+func (r *magicLinkMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, v := range r.links {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.links, id)
+		}
+	}
+	return nil
+}
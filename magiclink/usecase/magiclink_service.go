@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/mailer"
+	"go-echo-api/magiclink"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"go-echo-api/user"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// LinkTTL is the time the user has to open its link
+	LinkTTL = 15 * time.Minute
+	// MaxLinks is the number of links requested for an email within RateWindow
+	MaxLinks   = 3
+	RateWindow = 15 * time.Minute
+)
+
+// VerifyURLFromEnv return APP_MAGIC_LINK_URL, the page of the links emailed: the verification of the module,
+// e.g. https://api.example.com/api/v1/auth/magic-link/verify, or the page of the web client opening them.
+// It is required, a link built from the host of the request would send the token where the requester says.
+func VerifyURLFromEnv() (string, error) {
+	verifyURL := os.Getenv("APP_MAGIC_LINK_URL")
+	parsed, err := url.Parse(verifyURL)
+	if verifyURL == "" || err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return "", fmt.Errorf("magic link: APP_MAGIC_LINK_URL %q must be an absolute URL", verifyURL)
+	}
+	return verifyURL, nil
+}
+
+type MagicLinkService struct {
+	magicLinkRepository magiclink.Repository
+	userRepository      user.Repository
+	mailer              mailer.Mailer
+	// key signs the tokens of the links
+	key []byte
+}
+
+func NewMagicLinkService(r magiclink.Repository, users user.Repository, m mailer.Mailer, key []byte) magiclink.Usecase {
+	return MagicLinkService{magicLinkRepository: r, userRepository: users, mailer: m, key: key}
+}
+
+func (s MagicLinkService) Request(ctx context.Context, email string, verifyURL string) error {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	email = strings.TrimSpace(email)
+	key := strings.ToLower(email)
+	now := time.Now()
+	count, err := s.magicLinkRepository.CountSince(ctx, key, now.Add(-RateWindow))
+	if err != nil {
+		return err
+	}
+	if count >= MaxLinks {
+		return magiclink.ErrRateLimited
+	}
+	owner, err := s.userRepository.FindByEmail(ctx, email)
+	if err != nil && err != user.ErrNotFound {
+		return err
+	}
+	token, err := s.token(tenantID)
+	if err != nil {
+		return err
+	}
+	// the request of an unknown email is kept too, it counts in the rate limit of the email
+	model := models.MagicLink{Email: key, TokenHash: hash(token), ExpiresAt: now.Add(LinkTTL)}
+	if owner != nil {
+		model.UserID = owner.ID
+	}
+	if err := s.magicLinkRepository.Store(ctx, &model); err != nil {
+		return err
+	}
+	if owner == nil {
+		return nil
+	}
+	separator := "?"
+	if strings.Contains(verifyURL, "?") {
+		separator = "&"
+	}
+	link := verifyURL + separator + "token=" + url.QueryEscape(token)
+	message := mailer.Message{
+		To:      owner.Email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf("Hello %s,\n\nOpen this link to log in, it works once within %d minutes:\n\n%s\n\n"+
+			"If you did not ask for it, ignore this email.\n", owner.Name, int(LinkTTL.Minutes()), link),
+	}
+	if err := s.mailer.Send(ctx, message); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, owner.ID).Info("magic link sent")
+	return nil
+}
+
+func (s MagicLinkService) Verify(ctx context.Context, token string) (*models.User, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// a forged token is rejected before reaching the database
+	i := strings.LastIndex(token, ".")
+	if i < 0 || magiclink.TokenTenant(token) != tenantID || !hmac.Equal([]byte(token[i+1:]), []byte(s.sign(token[:i]))) {
+		return nil, magiclink.ErrInvalidToken
+	}
+	now := time.Now()
+	link, err := s.magicLinkRepository.Use(ctx, hash(token), now)
+	if err != nil {
+		return nil, err
+	}
+	if link.Expired(now) || link.UserID == "" {
+		return nil, magiclink.ErrInvalidToken
+	}
+	owner, err := s.userRepository.FindById(ctx, link.UserID)
+	if err == user.ErrNotFound {
+		return nil, magiclink.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	// opening the link proves owning the email
+	if !owner.EmailVerified() {
+		owner.EmailVerifiedAt = &now
+		if err := s.userRepository.Update(ctx, owner); err != nil {
+			return nil, err
+		}
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, owner.ID).Info("magic link used")
+	return owner, nil
+}
+
+// token return a new token of a link of the tenant, formatted <tenant>.<nonce>.<signature>
+func (s MagicLinkService) token(tenantID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	payload := tenantID + "." + base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + s.sign(payload), nil
+}
+
+func (s MagicLinkService) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte("magic-link:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/mailer"
+	"go-echo-api/magiclink"
+	"go-echo-api/magiclink/repository"
+	userRepository "go-echo-api/user/repository"
+	"net/url"
+	"os"
+	"regexp"
+	"testing"
+)
+
+// outbox keep the messages sent
+type outbox struct {
+	messages []mailer.Message
+}
+
+func (o *outbox) Send(ctx context.Context, message mailer.Message) error {
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`https://app\.example\.com/login\S*`)
+
+// token return the token of the link of the last message sent
+func (o *outbox) token(t *testing.T) string {
+	if !assert.NotEmpty(t, o.messages) {
+		t.FailNow()
+	}
+	link, err := url.Parse(linkPattern.FindString(o.messages[len(o.messages)-1].Body))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return link.Query().Get("token")
+}
+
+func newMagicLinkService(o *outbox) magiclink.Usecase {
+	users := userRepository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan)
+	return NewMagicLinkService(repository.NewMagicLinkMemoryRepository(), users, o, []byte("secret"))
+}
+
+func TestMagicLinkService(t *testing.T) {
+	s := t.Run("success", func(t *testing.T) {
+		o := &outbox{}
+		m := newMagicLinkService(o)
+		assert.NoError(t, m.Request(dbtest.Context(), " "+dbtest.UserUje.Email+" ", "https://app.example.com/login?lang=en"))
+		assert.Equal(t, dbtest.UserUje.Email, o.messages[0].To)
+		token := o.token(t)
+		assert.Equal(t, dbtest.TenantAcme.ID, magiclink.TokenTenant(token))
+
+		owner, err := m.Verify(dbtest.Context(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserUje.ID, owner.ID)
+		assert.True(t, owner.EmailVerified())
+
+		// an unknown email is answered the same, without email
+		assert.NoError(t, m.Request(dbtest.Context(), "nobody@example.com", "https://app.example.com/login"))
+		assert.Len(t, o.messages, 1)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		o := &outbox{}
+		m := newMagicLinkService(o)
+		assert.NoError(t, m.Request(dbtest.Context(), dbtest.UserUje.Email, "https://app.example.com/login"))
+		token := o.token(t)
+
+		// a link of another tenant, a forged signature, a used link
+		_, err := m.Verify(dbtest.TenantContext(dbtest.TenantGlobex), token)
+		assert.Equal(t, magiclink.ErrInvalidToken, err)
+		_, err = m.Verify(dbtest.Context(), token[:len(token)-2]+"xx")
+		assert.Equal(t, magiclink.ErrInvalidToken, err)
+		_, err = m.Verify(dbtest.Context(), "garbage")
+		assert.Equal(t, magiclink.ErrInvalidToken, err)
+		_, err = m.Verify(dbtest.Context(), token)
+		assert.NoError(t, err)
+		_, err = m.Verify(dbtest.Context(), token)
+		assert.Equal(t, magiclink.ErrInvalidToken, err)
+
+		// the email is limited to MaxLinks links, whatever its case
+		for i := 1; i < MaxLinks; i++ {
+			assert.NoError(t, m.Request(dbtest.Context(), dbtest.UserUje.Email, "https://app.example.com/login"))
+		}
+		assert.Equal(t, magiclink.ErrRateLimited, m.Request(dbtest.Context(), "UJE"+dbtest.UserUje.Email[3:], "https://app.example.com/login"))
+		assert.NoError(t, m.Request(dbtest.Context(), dbtest.UserIpan.Email, "https://app.example.com/login"))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestVerifyURLFromEnv(t *testing.T) {
+	defer os.Unsetenv("APP_MAGIC_LINK_URL")
+	os.Setenv("APP_MAGIC_LINK_URL", "https://app.example.com/login")
+	verifyURL, err := VerifyURLFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://app.example.com/login", verifyURL)
+
+	for _, invalid := range []string{"", "/api/v1/auth/magic-link/verify", "app.example.com/login", "javascript:alert(1)"} {
+		os.Setenv("APP_MAGIC_LINK_URL", invalid)
+		_, err = VerifyURLFromEnv()
+		assert.Error(t, err, invalid)
+	}
+}
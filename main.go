@@ -1,22 +1,38 @@
 package main
 
 import (
+	"context"
 	"github.com/joho/godotenv"
-	"github.com/labstack/echo"
-	"github.com/labstack/echo/middleware"
-	"github.com/labstack/gommon/log"
-	authHandler "go-echo-api/auth/delivery/http"
-	authService "go-echo-api/auth/usecase"
+	auditRepository "go-echo-api/audit/repository"
+	auditUsecase "go-echo-api/audit/usecase"
 	"go-echo-api/infrastructure/database"
-	"go-echo-api/infrastructure/validator"
-	jwtMiddleware "go-echo-api/middleware"
-	userHandler "go-echo-api/user/delivery/http"
-	userService "go-echo-api/user/usecase"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/metrics"
+	"go-echo-api/infrastructure/tracing"
+	"go-echo-api/job"
+	jobRepository "go-echo-api/job/repository"
+	jobUsecase "go-echo-api/job/usecase"
+	outboxRepository "go-echo-api/outbox/repository"
+	"go-echo-api/outbox/sink"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	"go-echo-api/server"
+	"go-echo-api/user"
+	userRepository "go-echo-api/user/repository"
+	userUsecase "go-echo-api/user/usecase"
+	webhookRepository "go-echo-api/webhook/repository"
+	webhookUsecase "go-echo-api/webhook/usecase"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout bounds the wait for the requests in flight on SIGINT or SIGTERM
+const shutdownTimeout = 30 * time.Second
+
 func init() {
 	fileExecutable, _ := os.Executable()
 	basePath, _ := filepath.Split(fileExecutable)
@@ -27,40 +43,74 @@ func init() {
 }
 
 func main() {
-	e := echo.New()
-	e.Validator = validator.NewValidator()
-	db := database.New()
+	appLogger := logger.New(os.Getenv("APP_ENV"))
+	logger.SetDefault(appLogger)
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		appLogger.WithError(err).Fatal("setup tracing")
+	}
+	db, err := database.New(appLogger)
+	if err != nil {
+		appLogger.WithError(err).Fatal("connect database")
+	}
 	database.AutoMigrate(db)
-	e.Logger.SetLevel(log.DEBUG)
-	e.Pre(middleware.RemoveTrailingSlash())
-	e.Use(middleware.Logger())
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
-		AllowMethods: []string{echo.GET, echo.HEAD, echo.PUT, echo.PATCH, echo.POST, echo.DELETE},
-	}))
+	metrics.Registry.MustRegister(metrics.NewDBStatsCollector(db.DB(), os.Getenv("DB_NAME")))
 
-	api := e.Group("/api")
-	v1 := api.Group("/v1")
-	//AuthController
-	authController := authHandler.NewAuthController(authService.NewAuthService(db))
-	auth := v1.Group("/auth")
-	auth.POST("/token", authController.Login)
-	auth.POST("/register", authController.Register)
-	auth.POST("/refresh-token", authController.RefreshToken)
+	// publish the domain events of the outbox, to the modules and the webhooks, until the server stops
+	sinks, subscribers, closeSinks, err := sink.FromEnv()
+	if err != nil {
+		appLogger.WithError(err).Fatal("configure the outbox sinks")
+	}
+	modules := server.DefaultModules(db)
+	server.Subscribe(modules, subscribers)
+	webhooks := webhookUsecase.NewWebhookService(webhookRepository.NewWebhookRepository(db), webhookUsecase.DeliveryConfigFromEnv())
+	sinks = append(sinks, webhookUsecase.NewWebhookSink(webhooks))
+	dispatcher := outboxUsecase.NewDispatcher(outboxRepository.NewOutboxRepository(db), sinks, outboxUsecase.DispatcherConfigFromEnv())
+	ctx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	run(&workers, func() { dispatcher.Run(ctx) })
+	run(&workers, func() { webhookUsecase.NewWorker(webhooks, 0).Run(ctx) })
 
-	//UserController
-	userController := userHandler.NewUserController(userService.NewUserService(db),db)
-	user := v1.Group("/user")
-	user.GET("", userController.FindAll, jwtMiddleware.IsLoggedIn)
-	user.GET("/:id", userController.FindById, jwtMiddleware.IsLoggedIn)
-	user.POST("", userController.Store, jwtMiddleware.IsLoggedIn)
-	user.PUT("/:id", userController.Update, jwtMiddleware.IsLoggedIn)
-	user.DELETE("/:id", userController.Delete, jwtMiddleware.IsLoggedIn)
+	// run the jobs until the server stops, then let those running end
+	users := userUsecase.NewUserService(userRepository.NewUserRepository(db), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db)))
+	pool := jobUsecase.NewPool(jobRepository.NewJobRepository(db), job.Handlers{
+		user.JobImport: userUsecase.NewImportJob(users, auditUsecase.NewAuditService(auditRepository.NewAuditRepository(db))),
+	}, jobUsecase.PoolConfigFromEnv())
+	run(&workers, func() { pool.Run(ctx) })
 
-	e.GET("/", func(c echo.Context) error {
-		return c.String(http.StatusOK, "Hello, World!")
-	})
-	e.Logger.Fatal(e.Start(os.Getenv("APP_PORT")))
+	config := server.ConfigFromEnv(appLogger)
+	config.Modules = modules
+	e := server.NewServer(config, db)
+	// stop on SIGINT or SIGTERM, after the requests in flight
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		<-quit
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := e.Shutdown(shutdownCtx); err != nil {
+			appLogger.WithError(err).Error("shutdown server")
+		}
+	}()
+	err = e.Start(os.Getenv("APP_PORT"))
+	// the sinks are closed once the workers publishing to them returned
+	stopWorkers()
+	workers.Wait()
+	if closeSinks != nil {
+		_ = closeSinks.Close()
+	}
+	// flush the spans still buffered before exiting
+	_ = shutdownTracing(context.Background())
+	if err != http.ErrServerClosed {
+		appLogger.Fatal(err)
+	}
+}
 
+// run fn in the background, done with workers once it returned
+func run(workers *sync.WaitGroup, fn func()) {
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		fn()
+	}()
 }
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/mfa"
+	"go-echo-api/middleware"
+	"go-echo-api/utils"
+)
+
+type mfaController struct {
+	mfaUsecase mfa.Usecase
+}
+
+func NewMFAController(s mfa.Usecase) *mfaController {
+	return &mfaController{mfaUsecase: s}
+}
+
+func (c *mfaController) Status(ctx echo.Context) error {
+	factor, recoveryCodes, err := c.mfaUsecase.Status(ctx.Request().Context(), middleware.UserID(ctx))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, mfa.NewStatusMapper(factor, recoveryCodes), nil)
+}
+
+// Enroll answer the secret of a new pending factor with its otpauth URI and QR code
+func (c *mfaController) Enroll(ctx echo.Context) error {
+	enrollment, err := c.mfaUsecase.Enroll(ctx.Request().Context(), middleware.UserID(ctx))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, mfa.NewEnrollmentMapper(enrollment), nil)
+}
+
+func (c *mfaController) Confirm(ctx echo.Context) error {
+	var dto mfa.CodeDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	codes, err := c.mfaUsecase.Confirm(ctx.Request().Context(), middleware.UserID(ctx), dto.Code)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, mfa.RecoveryCodesMapper{RecoveryCodes: codes}, nil)
+}
+
+func (c *mfaController) Disable(ctx echo.Context) error {
+	var dto mfa.CodeDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	if err := c.mfaUsecase.Disable(ctx.Request().Context(), middleware.UserID(ctx), dto.Code); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+func (c *mfaController) RegenerateRecoveryCodes(ctx echo.Context) error {
+	var dto mfa.CodeDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	codes, err := c.mfaUsecase.RegenerateRecoveryCodes(ctx.Request().Context(), middleware.UserID(ctx), dto.Code)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, mfa.RecoveryCodesMapper{RecoveryCodes: codes}, nil)
+}
+
+// errorResponse map the errors of the mfa use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case mfa.ErrInvalidCode:
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	case mfa.ErrInvalidToken:
+		return response.Unauthorized(ctx, utils.Unauthorized, nil, err.Error())
+	case mfa.ErrNotEnrolled, mfa.ErrNotEnabled, mfa.ErrEnabled:
+		return response.Conflict(ctx, utils.Conflict, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("mfa use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/mfa/repository"
+	"go-echo-api/mfa/usecase"
+	"go-echo-api/middleware"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/sink"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+)
+
+// Module wire the two-factor authentication of the logged in user to the database and register its routes
+// under /me/mfa. The user enrolls an authenticator app, confirms it with a first code, then logs in
+// with a code of the app after its password.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/me/mfa"
+}
+
+// Subscribe delete the factors, recovery codes and challenges of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewMFARepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	controller := NewMFAController(usecase.NewMFAService(repository.NewMFARepository(m.db), userRepository.NewUserRepository(m.db), usecase.IssuerFromEnv()))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	loggedIn := middleware.IsLoggedIn(sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL))
+	g.GET("", controller.Status, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/totp", controller.Enroll, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/totp/confirm", controller.Confirm, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/totp/disable", controller.Disable, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/recovery-codes", controller.RegenerateRecoveryCodes, tenantScope, loggedIn, middleware.RequireUnscoped)
+}
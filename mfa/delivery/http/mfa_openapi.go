@@ -0,0 +1,76 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/mfa"
+	"go-echo-api/middleware"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	g.Use(nil, map[string]openapi.Response{
+		"401": g.Error("Missing or invalid access token"),
+		"403": g.Error("Token issued to an OAuth client"),
+	})
+
+	g.Add(echo.GET, "", openapi.Operation{
+		Tags:        []string{"mfa"},
+		Summary:     "Two-factor authentication of the logged in user",
+		OperationID: "getMFAStatus",
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Two-factor authentication of the user", mfa.StatusMapper{}),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/totp", openapi.Operation{
+		Tags:        []string{"mfa"},
+		Summary:     "Enroll an authenticator app, the factor is pending until confirmed with a first code",
+		OperationID: "enrollTOTP",
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Secret of the app with its otpauth URI and QR code", mfa.EnrollmentMapper{}),
+			"409": g.Error("Two-factor authentication already enabled"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/totp/confirm", openapi.Operation{
+		Tags:        []string{"mfa"},
+		Summary:     "Enable the two-factor authentication with a code of the enrolled app, the recovery codes are in this response only",
+		OperationID: "confirmTOTP",
+		RequestBody: g.Body(mfa.CodeDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Recovery codes of the user", mfa.RecoveryCodesMapper{}),
+			"400": g.Error("Invalid or already used code"),
+			"409": g.Error("No app enrolled, or two-factor authentication already enabled"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/totp/disable", openapi.Operation{
+		Tags:        []string{"mfa"},
+		Summary:     "Disable the two-factor authentication with a code of the app or a recovery code",
+		OperationID: "disableTOTP",
+		RequestBody: g.Body(mfa.CodeDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Two-factor authentication disabled", nil),
+			"400": g.Error("Invalid or already used code"),
+			"409": g.Error("Two-factor authentication not enabled"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/recovery-codes", openapi.Operation{
+		Tags:        []string{"mfa"},
+		Summary:     "Replace the recovery codes with a code of the app or a recovery code, the codes are in this response only",
+		OperationID: "regenerateRecoveryCodes",
+		RequestBody: g.Body(mfa.CodeDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("New recovery codes of the user", mfa.RecoveryCodesMapper{}),
+			"400": g.Error("Invalid or already used code"),
+			"409": g.Error("Two-factor authentication not enabled"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerAuth,
+	})
+}
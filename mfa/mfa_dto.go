@@ -0,0 +1,6 @@
+package mfa
+
+// CodeDto is a code of the authenticator app of the user, or one of its recovery codes where accepted
+type CodeDto struct {
+	Code string `json:"code" validate:"required"`
+}
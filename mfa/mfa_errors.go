@@ -0,0 +1,11 @@
+package mfa
+
+import "errors"
+
+var (
+	ErrNotEnrolled  = errors.New("no authenticator enrolled, enroll one first")
+	ErrEnabled      = errors.New("two-factor authentication is already enabled")
+	ErrNotEnabled   = errors.New("two-factor authentication is not enabled")
+	ErrInvalidCode  = errors.New("invalid or already used two-factor code")
+	ErrInvalidToken = errors.New("mfa token unknown or expired, log in again")
+)
@@ -0,0 +1,46 @@
+package mfa
+
+import (
+	"encoding/base64"
+	"go-echo-api/infrastructure/qrcode"
+	"go-echo-api/models"
+	"time"
+)
+
+// StatusMapper is the two-factor authentication of a user
+type StatusMapper struct {
+	Enabled     bool       `json:"enabled"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// RecoveryCodes is the number of unused recovery codes
+	RecoveryCodes int64 `json:"recovery_codes"`
+}
+
+func NewStatusMapper(factor *models.TOTPFactor, recoveryCodes int64) StatusMapper {
+	if factor == nil || !factor.Confirmed() {
+		return StatusMapper{}
+	}
+	return StatusMapper{Enabled: true, ConfirmedAt: factor.ConfirmedAt, RecoveryCodes: recoveryCodes}
+}
+
+// EnrollmentMapper is the secret of a pending factor, QRCode is the otpauth URI as a PNG data URI
+type EnrollmentMapper struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code,omitempty"`
+}
+
+func NewEnrollmentMapper(enrollment Enrollment) EnrollmentMapper {
+	m := EnrollmentMapper{Secret: enrollment.Secret, OTPAuthURI: enrollment.URI}
+	// an URI too long for a QR code is still entered by hand
+	if code, err := qrcode.Encode(enrollment.URI); err == nil {
+		if image, err := code.PNG(4); err == nil {
+			m.QRCode = "data:image/png;base64," + base64.StdEncoding.EncodeToString(image)
+		}
+	}
+	return m
+}
+
+// RecoveryCodesMapper is the recovery codes of a user, shown once
+type RecoveryCodesMapper struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
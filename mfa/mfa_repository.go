@@ -0,0 +1,33 @@
+package mfa
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+// Repository of the second factors and pending logins of the tenant of the context
+type Repository interface {
+	// FindFactor return the TOTP factor of the user, ErrNotEnrolled when it has none
+	FindFactor(ctx context.Context, userID string) (*models.TOTPFactor, error)
+	// StoreFactor create the factor, or save it when it has an ID
+	StoreFactor(ctx context.Context, model *models.TOTPFactor) error
+	// UseStep record the step of a code of the factor, ErrInvalidCode when a code of this step or a later one was used
+	UseStep(ctx context.Context, id string, step int64) error
+	// DeleteFactor delete the factor of the user with its recovery codes
+	DeleteFactor(ctx context.Context, userID string) error
+
+	// ReplaceRecoveryCodes replace the recovery codes of the user with the hashes
+	ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error
+	// UseRecoveryCode mark the code of the hash as used, ErrInvalidCode when it is unknown or used
+	UseRecoveryCode(ctx context.Context, userID string, hash string) error
+	CountRecoveryCodes(ctx context.Context, userID string) (int64, error)
+
+	StoreChallenge(ctx context.Context, model *models.MFAChallenge) error
+	// FindChallenge return the challenge of the token hash, ErrInvalidToken when it is unknown
+	FindChallenge(ctx context.Context, hash string) (*models.MFAChallenge, error)
+	// AttemptChallenge count an attempt on the challenge, ErrInvalidToken once it had max attempts
+	AttemptChallenge(ctx context.Context, id string, max int) error
+	DeleteChallenge(ctx context.Context, id string) error
+	// DeleteByUser delete the factor, recovery codes and challenges of the user, once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,33 @@
+package mfa
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+// Enrollment is the secret of a pending factor, to add to an authenticator app from its otpauth URI
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+type Usecase interface {
+	// Status return the factor of the user, nil when it has none, and its number of unused recovery codes
+	Status(ctx context.Context, userID string) (*models.TOTPFactor, int64, error)
+	// Enroll a new secret for the user, pending until confirmed, ErrEnabled when its factor is confirmed
+	Enroll(ctx context.Context, userID string) (Enrollment, error)
+	// Confirm the pending factor with a code of the app and return the recovery codes of the user,
+	// they are only shown here
+	Confirm(ctx context.Context, userID string, code string) ([]string, error)
+	// Disable the two-factor authentication of the user with a code of the app or a recovery code
+	Disable(ctx context.Context, userID string, code string) error
+	// RegenerateRecoveryCodes replace the recovery codes of the user, with a code of the app or a recovery code
+	RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error)
+
+	// Challenge return the mfa token finishing the login of the user with a code, or an empty token
+	// when the user has no two-factor authentication
+	Challenge(ctx context.Context, userID string) (string, time.Time, error)
+	// Verify the code of the login of the mfa token and return its user, the token is used once
+	Verify(ctx context.Context, token string, code string) (*models.User, error)
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/mfa"
+	"go-echo-api/models"
+	"time"
+)
+
+type mfaGormRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) mfa.Repository {
+	return &mfaGormRepository{db: db}
+}
+
+func (r *mfaGormRepository) FindFactor(ctx context.Context, userID string) (*models.TOTPFactor, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.TOTPFactor
+	err = db.Where("user_id = ?", userID).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, mfa.ErrNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *mfaGormRepository) StoreFactor(ctx context.Context, model *models.TOTPFactor) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	if model.ID == "" {
+		return db.Create(model).Error
+	}
+	return db.Save(model).Error
+}
+
+func (r *mfaGormRepository) UseStep(ctx context.Context, id string, step int64) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	// of two concurrent uses of a code only the first one moves the step
+	result := db.Model(&models.TOTPFactor{}).Where("id = ? AND last_step < ?", id, step).UpdateColumn("last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return mfa.ErrInvalidCode
+	}
+	return nil
+}
+
+func (r *mfaGormRepository) DeleteFactor(ctx context.Context, userID string) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.TOTPFactor{}, &models.RecoveryCode{}} {
+			if err := tx.New().Where("tenant_id = ? AND user_id = ?", tenantID, userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *mfaGormRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		if err := tx.New().Where("tenant_id = ? AND user_id = ?", tenantID, userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			if err := tx.New().Create(&models.RecoveryCode{TenantID: tenantID, UserID: userID, CodeHash: hash}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *mfaGormRepository) UseRecoveryCode(ctx context.Context, userID string, hash string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Model(&models.RecoveryCode{}).Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		UpdateColumn("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return mfa.ErrInvalidCode
+	}
+	return nil
+}
+
+func (r *mfaGormRepository) CountRecoveryCodes(ctx context.Context, userID string) (int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	err = db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&total).Error
+	return total, err
+}
+
+func (r *mfaGormRepository) StoreChallenge(ctx context.Context, model *models.MFAChallenge) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *mfaGormRepository) FindChallenge(ctx context.Context, hash string) (*models.MFAChallenge, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.MFAChallenge
+	err = db.Where("token_hash = ?", hash).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, mfa.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *mfaGormRepository) AttemptChallenge(ctx context.Context, id string, max int) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	// the attempts are counted before checking the code, concurrent guesses cannot go past max
+	result := db.Model(&models.MFAChallenge{}).Where("id = ? AND attempts < ?", id, max).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return mfa.ErrInvalidToken
+	}
+	return nil
+}
+
+func (r *mfaGormRepository) DeleteChallenge(ctx context.Context, id string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Where("id = ?", id).Delete(&models.MFAChallenge{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return mfa.ErrInvalidToken
+	}
+	return nil
+}
+
+func (r *mfaGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.TOTPFactor{}, &models.RecoveryCode{}, &models.MFAChallenge{}} {
+			if err := tx.New().Where("tenant_id = ? AND user_id = ?", tenantID, userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/mfa"
+	"go-echo-api/models"
+	"testing"
+	"time"
+)
+
+func TestMFAGormRepository_Factor(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewMFARepository(db)
+	_, err := r.FindFactor(dbtest.Context(), dbtest.UserUje.ID)
+	assert.Equal(t, mfa.ErrNotEnrolled, err)
+
+	factor := models.TOTPFactor{UserID: dbtest.UserUje.ID, Secret: "secret"}
+	assert.NoError(t, r.StoreFactor(dbtest.Context(), &factor))
+	now := time.Now()
+	factor.ConfirmedAt = &now
+	assert.NoError(t, r.StoreFactor(dbtest.Context(), &factor))
+	found, err := r.FindFactor(dbtest.Context(), dbtest.UserUje.ID)
+	assert.NoError(t, err)
+	assert.True(t, found.Confirmed())
+	_, err = r.FindFactor(dbtest.TenantContext(dbtest.TenantGlobex), dbtest.UserUje.ID)
+	assert.Equal(t, mfa.ErrNotEnrolled, err)
+
+	// a step is used once, and not before a later one
+	assert.NoError(t, r.UseStep(dbtest.Context(), factor.ID, 10))
+	assert.Equal(t, mfa.ErrInvalidCode, r.UseStep(dbtest.Context(), factor.ID, 10))
+	assert.Equal(t, mfa.ErrInvalidCode, r.UseStep(dbtest.Context(), factor.ID, 9))
+	assert.NoError(t, r.UseStep(dbtest.Context(), factor.ID, 11))
+
+	// a recovery code is used once, and replaced ones are gone
+	assert.NoError(t, r.ReplaceRecoveryCodes(dbtest.Context(), dbtest.UserUje.ID, []string{"a", "b"}))
+	assert.NoError(t, r.UseRecoveryCode(dbtest.Context(), dbtest.UserUje.ID, "a"))
+	assert.Equal(t, mfa.ErrInvalidCode, r.UseRecoveryCode(dbtest.Context(), dbtest.UserUje.ID, "a"))
+	assert.Equal(t, mfa.ErrInvalidCode, r.UseRecoveryCode(dbtest.Context(), dbtest.UserIpan.ID, "b"))
+	total, err := r.CountRecoveryCodes(dbtest.Context(), dbtest.UserUje.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.NoError(t, r.ReplaceRecoveryCodes(dbtest.Context(), dbtest.UserUje.ID, []string{"c"}))
+	assert.Equal(t, mfa.ErrInvalidCode, r.UseRecoveryCode(dbtest.Context(), dbtest.UserUje.ID, "b"))
+
+	assert.NoError(t, r.DeleteFactor(dbtest.Context(), dbtest.UserUje.ID))
+	_, err = r.FindFactor(dbtest.Context(), dbtest.UserUje.ID)
+	assert.Equal(t, mfa.ErrNotEnrolled, err)
+	total, _ = r.CountRecoveryCodes(dbtest.Context(), dbtest.UserUje.ID)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestMFAGormRepository_Challenge(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewMFARepository(db)
+	challenge := models.MFAChallenge{UserID: dbtest.UserUje.ID, TokenHash: "token", ExpiresAt: time.Now().Add(time.Minute)}
+	assert.NoError(t, r.StoreChallenge(dbtest.Context(), &challenge))
+
+	_, err := r.FindChallenge(dbtest.TenantContext(dbtest.TenantGlobex), "token")
+	assert.Equal(t, mfa.ErrInvalidToken, err)
+	found, err := r.FindChallenge(dbtest.Context(), "token")
+	assert.NoError(t, err)
+	assert.Equal(t, dbtest.UserUje.ID, found.UserID)
+
+	// the attempts stop at max
+	assert.NoError(t, r.AttemptChallenge(dbtest.Context(), challenge.ID, 2))
+	assert.NoError(t, r.AttemptChallenge(dbtest.Context(), challenge.ID, 2))
+	assert.Equal(t, mfa.ErrInvalidToken, r.AttemptChallenge(dbtest.Context(), challenge.ID, 2))
+
+	assert.NoError(t, r.DeleteChallenge(dbtest.Context(), challenge.ID))
+	assert.Equal(t, mfa.ErrInvalidToken, r.DeleteChallenge(dbtest.Context(), challenge.ID))
+}
+
+func TestMFAGormRepository_DeleteByUser(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	factor := models.TOTPFactor{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID, Secret: "secret"}
+	code := models.RecoveryCode{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID, CodeHash: "hash"}
+	challenge := models.MFAChallenge{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID, TokenHash: "hash"}
+	assert.NoError(t, dbtest.LoadFixtures(db, &factor, &code, &challenge))
+
+	r := NewMFARepository(db)
+	assert.NoError(t, r.DeleteByUser(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.TOTPFactor{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Model(&models.RecoveryCode{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Model(&models.MFAChallenge{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/mfa"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sync"
+	"time"
+)
+
+// mfaMemoryRepository keeps the factors, recovery codes and challenges in memory, it backs the use-case unit tests
+type mfaMemoryRepository struct {
+	mu         sync.RWMutex
+	factors    map[string]models.TOTPFactor
+	codes      map[string]models.RecoveryCode
+	challenges map[string]models.MFAChallenge
+}
+
+func NewMFAMemoryRepository() mfa.Repository {
+	return &mfaMemoryRepository{
+		factors:    make(map[string]models.TOTPFactor),
+		codes:      make(map[string]models.RecoveryCode),
+		challenges: make(map[string]models.MFAChallenge),
+	}
+}
+
+func (r *mfaMemoryRepository) FindFactor(ctx context.Context, userID string) (*models.TOTPFactor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range r.factors {
+		if f.TenantID == tenantID && f.UserID == userID {
+			return &f, nil
+		}
+	}
+	return nil, mfa.ErrNotEnrolled
+}
+
+func (r *mfaMemoryRepository) StoreFactor(ctx context.Context, model *models.TOTPFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+		model.CreatedAt = time.Now()
+	}
+	model.TenantID = tenantID
+	model.UpdatedAt = time.Now()
+	r.factors[model.ID] = *model
+	return nil
+}
+
+func (r *mfaMemoryRepository) UseStep(ctx context.Context, id string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	f, ok := r.factors[id]
+	if !ok || f.TenantID != tenantID || f.LastStep >= step {
+		return mfa.ErrInvalidCode
+	}
+	f.LastStep = step
+	r.factors[id] = f
+	return nil
+}
+
+func (r *mfaMemoryRepository) DeleteFactor(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, f := range r.factors {
+		if f.TenantID == tenantID && f.UserID == userID {
+			delete(r.factors, id)
+		}
+	}
+	r.deleteCodes(tenantID, userID)
+	return nil
+}
+
+func (r *mfaMemoryRepository) deleteCodes(tenantID string, userID string) {
+	for id, c := range r.codes {
+		if c.TenantID == tenantID && c.UserID == userID {
+			delete(r.codes, id)
+		}
+	}
+}
+
+func (r *mfaMemoryRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	r.deleteCodes(tenantID, userID)
+	for _, hash := range hashes {
+		id := uuid.New().String()
+		r.codes[id] = models.RecoveryCode{ID: id, TenantID: tenantID, UserID: userID, CodeHash: hash, CreatedAt: time.Now()}
+	}
+	return nil
+}
+
+func (r *mfaMemoryRepository) UseRecoveryCode(ctx context.Context, userID string, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, c := range r.codes {
+		if c.TenantID == tenantID && c.UserID == userID && c.CodeHash == hash && c.UsedAt == nil {
+			now := time.Now()
+			c.UsedAt = &now
+			r.codes[id] = c
+			return nil
+		}
+	}
+	return mfa.ErrInvalidCode
+}
+
+func (r *mfaMemoryRepository) CountRecoveryCodes(ctx context.Context, userID string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, c := range r.codes {
+		if c.TenantID == tenantID && c.UserID == userID && c.UsedAt == nil {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *mfaMemoryRepository) StoreChallenge(ctx context.Context, model *models.MFAChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	r.challenges[model.ID] = *model
+	return nil
+}
+
+func (r *mfaMemoryRepository) FindChallenge(ctx context.Context, hash string) (*models.MFAChallenge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range r.challenges {
+		if c.TenantID == tenantID && c.TokenHash == hash {
+			return &c, nil
+		}
+	}
+	return nil, mfa.ErrInvalidToken
+}
+
+func (r *mfaMemoryRepository) AttemptChallenge(ctx context.Context, id string, max int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	c, ok := r.challenges[id]
+	if !ok || c.TenantID != tenantID || c.Attempts >= max {
+		return mfa.ErrInvalidToken
+	}
+	c.Attempts++
+	r.challenges[id] = c
+	return nil
+}
+
+func (r *mfaMemoryRepository) DeleteChallenge(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if c, ok := r.challenges[id]; !ok || c.TenantID != tenantID {
+		return mfa.ErrInvalidToken
+	}
+	delete(r.challenges, id)
+	return nil
+}
+
+func (r *mfaMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, v := range r.factors {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.factors, id)
+		}
+	}
+	for id, v := range r.codes {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.codes, id)
+		}
+	}
+	for id, v := range r.challenges {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.challenges, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/totp"
+	"go-echo-api/mfa"
+	"go-echo-api/models"
+	"go-echo-api/user"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// ChallengeTTL is the time the user has to enter its code once its password checked
+	ChallengeTTL = 5 * time.Minute
+	// MaxAttempts is the number of codes tried on a challenge before the user logs in again
+	MaxAttempts = 5
+	// RecoveryCodes is the number of recovery codes of a user
+	RecoveryCodes = 10
+	// DefaultIssuer names the accounts in the authenticator apps when APP_MFA_ISSUER is empty
+	DefaultIssuer = "go-echo-api"
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type MFAService struct {
+	mfaRepository  mfa.Repository
+	userRepository user.Repository
+	// issuer names the account of the user in its authenticator app
+	issuer string
+}
+
+func NewMFAService(r mfa.Repository, users user.Repository, issuer string) mfa.Usecase {
+	return MFAService{mfaRepository: r, userRepository: users, issuer: issuer}
+}
+
+func (s MFAService) Status(ctx context.Context, userID string) (*models.TOTPFactor, int64, error) {
+	factor, err := s.mfaRepository.FindFactor(ctx, userID)
+	if err == mfa.ErrNotEnrolled {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.mfaRepository.CountRecoveryCodes(ctx, userID)
+	return factor, total, err
+}
+
+func (s MFAService) Enroll(ctx context.Context, userID string) (mfa.Enrollment, error) {
+	owner, err := s.userRepository.FindById(ctx, userID)
+	if err != nil {
+		return mfa.Enrollment{}, err
+	}
+	factor, err := s.mfaRepository.FindFactor(ctx, userID)
+	if err == mfa.ErrNotEnrolled {
+		factor = &models.TOTPFactor{UserID: userID}
+	} else if err != nil {
+		return mfa.Enrollment{}, err
+	} else if factor.Confirmed() {
+		return mfa.Enrollment{}, mfa.ErrEnabled
+	}
+	// enrolling again replaces the secret of the pending factor
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return mfa.Enrollment{}, err
+	}
+	factor.Secret = secret
+	factor.LastStep = 0
+	if err := s.mfaRepository.StoreFactor(ctx, factor); err != nil {
+		return mfa.Enrollment{}, err
+	}
+	return mfa.Enrollment{Secret: secret, URI: totp.URI(s.issuer, owner.Email, secret)}, nil
+}
+
+func (s MFAService) Confirm(ctx context.Context, userID string, code string) ([]string, error) {
+	factor, err := s.mfaRepository.FindFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if factor.Confirmed() {
+		return nil, mfa.ErrEnabled
+	}
+	if err := s.checkTOTP(ctx, factor, code); err != nil {
+		return nil, err
+	}
+	factor, err = s.mfaRepository.FindFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	factor.ConfirmedAt = &now
+	if err := s.mfaRepository.StoreFactor(ctx, factor); err != nil {
+		return nil, err
+	}
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, userID).Info("two-factor authentication enabled")
+	return codes, nil
+}
+
+func (s MFAService) Disable(ctx context.Context, userID string, code string) error {
+	factor, err := s.enabledFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.check(ctx, factor, code); err != nil {
+		return err
+	}
+	if err := s.mfaRepository.DeleteFactor(ctx, userID); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, userID).Info("two-factor authentication disabled")
+	return nil
+}
+
+func (s MFAService) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error) {
+	factor, err := s.enabledFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, factor, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+func (s MFAService) Challenge(ctx context.Context, userID string) (string, time.Time, error) {
+	factor, err := s.mfaRepository.FindFactor(ctx, userID)
+	if err == mfa.ErrNotEnrolled || (err == nil && !factor.Confirmed()) {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token, err := random(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	model := models.MFAChallenge{UserID: userID, TokenHash: hash(token), ExpiresAt: time.Now().Add(ChallengeTTL)}
+	if err := s.mfaRepository.StoreChallenge(ctx, &model); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, model.ExpiresAt, nil
+}
+
+func (s MFAService) Verify(ctx context.Context, token string, code string) (*models.User, error) {
+	challenge, err := s.mfaRepository.FindChallenge(ctx, hash(token))
+	if err != nil {
+		return nil, err
+	}
+	if challenge.Expired(time.Now()) {
+		_ = s.mfaRepository.DeleteChallenge(ctx, challenge.ID)
+		return nil, mfa.ErrInvalidToken
+	}
+	if err := s.mfaRepository.AttemptChallenge(ctx, challenge.ID, MaxAttempts); err != nil {
+		return nil, err
+	}
+	log := logger.FromContext(ctx).WithField(logger.UserIDField, challenge.UserID)
+	factor, err := s.enabledFactor(ctx, challenge.UserID)
+	if err == mfa.ErrNotEnabled {
+		// disabled since the password was checked
+		return nil, mfa.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, factor, code); err != nil {
+		log.Info("two-factor code rejected")
+		return nil, err
+	}
+	// of two concurrent logins of the token only the one deleting it wins
+	if err := s.mfaRepository.DeleteChallenge(ctx, challenge.ID); err != nil {
+		return nil, err
+	}
+	owner, err := s.userRepository.FindById(ctx, challenge.UserID)
+	if err == user.ErrNotFound {
+		return nil, mfa.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Info("login succeeded")
+	return owner, nil
+}
+
+// enabledFactor return the confirmed factor of the user, ErrNotEnabled without one
+func (s MFAService) enabledFactor(ctx context.Context, userID string) (*models.TOTPFactor, error) {
+	factor, err := s.mfaRepository.FindFactor(ctx, userID)
+	if err == mfa.ErrNotEnrolled || (err == nil && !factor.Confirmed()) {
+		return nil, mfa.ErrNotEnabled
+	}
+	return factor, err
+}
+
+// check accept a code of the app or, for a longer one, a recovery code of the user
+func (s MFAService) check(ctx context.Context, factor *models.TOTPFactor, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return s.checkTOTP(ctx, factor, code)
+	}
+	err := s.mfaRepository.UseRecoveryCode(ctx, factor.UserID, hash(normalize(code)))
+	if err == nil {
+		logger.FromContext(ctx).WithField(logger.UserIDField, factor.UserID).Info("recovery code used")
+	}
+	return err
+}
+
+// checkTOTP accept a code of the app once, a code seen is not accepted again
+func (s MFAService) checkTOTP(ctx context.Context, factor *models.TOTPFactor, code string) error {
+	step, ok, err := totp.Validate(factor.Secret, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return mfa.ErrInvalidCode
+	}
+	return s.mfaRepository.UseStep(ctx, factor.ID, step)
+}
+
+// replaceRecoveryCodes generate the recovery codes of the user, formatted xxxxx-xxxxx, and keep their hashes
+func (s MFAService) replaceRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, RecoveryCodes)
+	hashes := make([]string, RecoveryCodes)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hash(code)
+	}
+	if err := s.mfaRepository.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalize a recovery code as typed by the user
+func normalize(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// random return n random bytes encoded for the urls
+func random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// IssuerFromEnv return the name of the accounts in the authenticator apps, APP_MFA_ISSUER or DefaultIssuer
+func IssuerFromEnv() string {
+	if issuer := os.Getenv("APP_MFA_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return DefaultIssuer
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/totp"
+	"go-echo-api/mfa"
+	"go-echo-api/mfa/repository"
+	userRepository "go-echo-api/user/repository"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newMFAService() mfa.Usecase {
+	users := userRepository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan)
+	return NewMFAService(repository.NewMFAMemoryRepository(), users, "go-echo-api")
+}
+
+// code return the code of the enrollment in the period of now plus offset periods
+func code(t *testing.T, enrollment mfa.Enrollment, offset int) string {
+	result, err := totp.Code(enrollment.Secret, time.Now().Add(time.Duration(offset)*totp.Period))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return result
+}
+
+func TestMFAService(t *testing.T) {
+	m := newMFAService()
+
+	s := t.Run("success", func(t *testing.T) {
+		enrollment, err := m.Enroll(dbtest.Context(), dbtest.UserUje.ID)
+		assert.NoError(t, err)
+		uri, err := url.Parse(enrollment.URI)
+		assert.NoError(t, err)
+		assert.Equal(t, "/go-echo-api:"+dbtest.UserUje.Email, uri.Path)
+
+		// the factor is pending, the login is not challenged yet
+		token, _, err := m.Challenge(dbtest.Context(), dbtest.UserUje.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, token)
+
+		codes, err := m.Confirm(dbtest.Context(), dbtest.UserUje.ID, code(t, enrollment, 0))
+		assert.NoError(t, err)
+		assert.Len(t, codes, RecoveryCodes)
+		factor, remaining, err := m.Status(dbtest.Context(), dbtest.UserUje.ID)
+		assert.NoError(t, err)
+		assert.True(t, factor.Confirmed())
+		assert.Equal(t, int64(RecoveryCodes), remaining)
+
+		// the login finishes with a recovery code, typed loosely, and the token is used once
+		token, expiresAt, err := m.Challenge(dbtest.Context(), dbtest.UserUje.ID)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		assert.True(t, expiresAt.After(time.Now()))
+		owner, err := m.Verify(dbtest.Context(), token, " "+codes[0][:5]+codes[0][6:]+" ")
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserUje.ID, owner.ID)
+		_, err = m.Verify(dbtest.Context(), token, codes[1])
+		assert.Equal(t, mfa.ErrInvalidToken, err)
+
+		// the code of the next period is accepted once
+		regenerated, err := m.RegenerateRecoveryCodes(dbtest.Context(), dbtest.UserUje.ID, code(t, enrollment, 1))
+		assert.NoError(t, err)
+		assert.Len(t, regenerated, RecoveryCodes)
+		assert.NoError(t, m.Disable(dbtest.Context(), dbtest.UserUje.ID, regenerated[0]))
+		factor, _, err = m.Status(dbtest.Context(), dbtest.UserUje.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, factor)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := m.Confirm(dbtest.Context(), dbtest.UserIpan.ID, "123456")
+		assert.Equal(t, mfa.ErrNotEnrolled, err)
+		assert.Equal(t, mfa.ErrNotEnabled, m.Disable(dbtest.Context(), dbtest.UserIpan.ID, "123456"))
+
+		enrollment, err := m.Enroll(dbtest.Context(), dbtest.UserIpan.ID)
+		assert.NoError(t, err)
+		_, err = m.Confirm(dbtest.Context(), dbtest.UserIpan.ID, code(t, enrollment, 3))
+		assert.Equal(t, mfa.ErrInvalidCode, err)
+		codes, err := m.Confirm(dbtest.Context(), dbtest.UserIpan.ID, code(t, enrollment, 0))
+		assert.NoError(t, err)
+		_, err = m.Confirm(dbtest.Context(), dbtest.UserIpan.ID, code(t, enrollment, 1))
+		assert.Equal(t, mfa.ErrEnabled, err)
+		_, err = m.Enroll(dbtest.Context(), dbtest.UserIpan.ID)
+		assert.Equal(t, mfa.ErrEnabled, err)
+
+		// a code is not replayed, nor a recovery code used twice
+		assert.Equal(t, mfa.ErrInvalidCode, m.Disable(dbtest.Context(), dbtest.UserIpan.ID, code(t, enrollment, 0)))
+		assert.NoError(t, m.Disable(dbtest.Context(), dbtest.UserIpan.ID, codes[0]))
+		enrollment, _ = m.Enroll(dbtest.Context(), dbtest.UserIpan.ID)
+		codes, _ = m.Confirm(dbtest.Context(), dbtest.UserIpan.ID, code(t, enrollment, 0))
+		token, _, _ := m.Challenge(dbtest.Context(), dbtest.UserIpan.ID)
+		_, err = m.Verify(dbtest.Context(), token, codes[0])
+		assert.NoError(t, err)
+		token, _, _ = m.Challenge(dbtest.Context(), dbtest.UserIpan.ID)
+		_, err = m.Verify(dbtest.Context(), token, codes[0])
+		assert.Equal(t, mfa.ErrInvalidCode, err)
+
+		// the token is of its tenant, and dropped after too many wrong codes
+		_, err = m.Verify(dbtest.TenantContext(dbtest.TenantGlobex), token, codes[1])
+		assert.Equal(t, mfa.ErrInvalidToken, err)
+		for i := 1; i < MaxAttempts; i++ {
+			_, err = m.Verify(dbtest.Context(), token, "wrong-code")
+			assert.Equal(t, mfa.ErrInvalidCode, err)
+		}
+		_, err = m.Verify(dbtest.Context(), token, codes[1])
+		assert.Equal(t, mfa.ErrInvalidToken, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/utils"
+	"os"
+)
+
+const HeaderAdminKey = "X-Admin-Key"
+
+// IsAdmin require the X-Admin-Key header to match APP_ADMIN_KEY, the routes are closed while it is empty
+func IsAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := os.Getenv("APP_ADMIN_KEY")
+		if key == "" {
+			return response.Forbidden(c, utils.Forbidden, nil, "admin endpoints are disabled, set APP_ADMIN_KEY")
+		}
+		given := c.Request().Header.Get(HeaderAdminKey)
+		if subtle.ConstantTimeCompare([]byte(given), []byte(key)) != 1 {
+			return response.Unauthorized(c, utils.Unauthorized, nil, "missing or wrong "+HeaderAdminKey)
+		}
+		return next(c)
+	}
+}
+
+// AdminOpenAPI describe the header and the failures of IsAdmin on the operations of g
+func AdminOpenAPI(g *openapi.Group) {
+	g.Use([]openapi.Parameter{
+		{Name: HeaderAdminKey, In: "header", Description: "Admin key of the deployment", Required: true, Schema: &openapi.Schema{Type: "string"}},
+	}, map[string]openapi.Response{
+		"401": g.Error("Missing or wrong admin key"),
+		"403": g.Error("Admin endpoints disabled"),
+	})
+}
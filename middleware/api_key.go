@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"go-echo-api/apikey"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/session"
+	"go-echo-api/utils"
+	"strings"
+)
+
+// ErrScopedToken is the error of RequireUnscoped
+var ErrScopedToken = errors.New("route requires the access token of the user")
+
+const (
+	HeaderAPIKey = "X-API-Key"
+	apiKeyClaim  = "api_key_id"
+	// scopeClaim hold the space separated scopes of an API key or of a token issued to an OAuth client
+	scopeClaim  = "scope"
+	clientClaim = "client_id"
+)
+
+// Authenticate return a middleware accepting either the bearer access token IsLoggedIn accepts or
+// an API key in the X-API-Key header. The user of the key gets the claims of an access token so the
+// handlers read it with Claims and UserID either way, with the scopes of the key for RequireScope.
+// The key is looked up in the tenant of the request, Tenant must run first.
+func Authenticate(keys apikey.Usecase, sessions session.Usecase) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		loggedIn := IsLoggedIn(sessions)(next)
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderAPIKey)
+			if key == "" {
+				return loggedIn(c)
+			}
+			ctx := c.Request().Context()
+			model, owner, err := keys.Authenticate(ctx, key)
+			if err == apikey.ErrInvalid || err == apikey.ErrExpired {
+				return response.Unauthorized(c, utils.Unauthorized, nil, err.Error())
+			}
+			if err != nil {
+				logger.FromContext(ctx).WithError(err).Error("authenticate api key failed")
+				return response.InternalServerError(c, utils.InternalServerError, nil, err.Error())
+			}
+			c.Set("user", &jwt.Token{Valid: true, Claims: jwt.MapClaims{
+				"id":              owner.ID,
+				tenantClaim:       owner.TenantID,
+				organizationClaim: "",
+				"email":           owner.Email,
+				"name":            owner.Name,
+				apiKeyClaim:       model.ID,
+				scopeClaim:        strings.Join(model.ScopeList(), " "),
+			}})
+			entry := logger.FromContext(ctx).WithField(logger.APIKeyIDField, model.ID)
+			c.SetRequest(c.Request().WithContext(logger.WithContext(ctx, entry)))
+			return withUserLogger(next)(c)
+		}
+	}
+}
+
+// RequireScope return a middleware refusing the API keys and the OAuth tokens restricted to scopes
+// without scope with 403, the access tokens and the unrestricted keys have the access of their user
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			text, _ := Claims(c)[scopeClaim].(string)
+			scopes := strings.Fields(text)
+			if len(scopes) == 0 {
+				return next(c)
+			}
+			for _, s := range scopes {
+				if s == scope {
+					return next(c)
+				}
+			}
+			return response.Forbidden(c, utils.Forbidden, nil, apikey.ErrScopeDenied.Error())
+		}
+	}
+}
+
+// RequireUnscoped return a middleware refusing with 403 the tokens issued to an OAuth client, for the
+// routes minting credentials which only the user itself may call
+func RequireUnscoped(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := Claims(c)
+		if claims[clientClaim] != nil || claims[scopeClaim] != nil {
+			return response.Forbidden(c, utils.Forbidden, nil, ErrScopedToken.Error())
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/apikey"
+	"go-echo-api/apikey/repository"
+	"go-echo-api/apikey/usecase"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/response"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	userRepository "go-echo-api/user/repository"
+	"go-echo-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	keys := usecase.NewAPIKeyService(repository.NewAPIKeyMemoryRepository(), userRepository.NewUserMemoryRepository(dbtest.UserUje))
+	_, readOnly, _ := keys.Create(dbtest.Context(), dbtest.UserUje.ID, apikey.Dto{Name: "read", Scopes: []string{apikey.ScopeUsersRead}})
+	_, full, _ := keys.Create(dbtest.Context(), dbtest.UserUje.ID, apikey.Dto{Name: "full"})
+	access, _, _, _ := GenerateTokenPair(dbtest.Context(), dbtest.UserUje, "", "")
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(c.Request().WithContext(dbtest.Context()))
+			return next(c)
+		}
+	})
+	me := func(c echo.Context) error {
+		return response.SingleData(c, utils.OK, UserID(c), nil)
+	}
+	sessions := sessionUsecase.NewSessionService(sessionRepository.NewSessionMemoryRepository(), TokenTTL)
+	e.GET("/read", me, Authenticate(keys, sessions), RequireScope(apikey.ScopeUsersRead))
+	e.GET("/write", me, Authenticate(keys, sessions), RequireScope(apikey.ScopeUsersWrite))
+	serve := func(path string, header string, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		// the key and the access token populate the same user
+		rec := serve("/read", HeaderAPIKey, readOnly)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), dbtest.UserUje.ID)
+
+		rec = serve("/write", HeaderAPIKey, full)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = serve("/write", echo.HeaderAuthorization, "Bearer "+*access)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), dbtest.UserUje.ID)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec := serve("/write", HeaderAPIKey, readOnly)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = serve("/read", HeaderAPIKey, "gea_unknown_secret")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/audit"
+)
+
+// AuditSource return the source of the audit events of the request: the logged in user and its API key,
+// empty before IsLoggedIn or Authenticate, and the IP, user agent and request ID
+func AuditSource(c echo.Context) audit.Source {
+	apiKeyID, _ := Claims(c)[apiKeyClaim].(string)
+	return audit.Source{
+		ActorID:   UserID(c),
+		APIKeyID:  apiKeyID,
+		IP:        c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+}
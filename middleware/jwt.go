@@ -1,10 +1,20 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/joho/godotenv"
+	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/infrastructure/tracing"
 	"go-echo-api/models"
+	"go-echo-api/session"
+	"go-echo-api/utils"
+	"go.opentelemetry.io/otel/attribute"
 	"os"
 	"path/filepath"
 	"time"
@@ -24,7 +34,7 @@ func GetJwtSecretKey() []byte {
 	return []byte(jwtSecretKey)
 }
 
-var IsLoggedIn = middleware.JWTWithConfig(
+var jwtAuth = middleware.JWTWithConfig(
 	middleware.JWTConfig{
 		SigningKey:  GetJwtSecretKey(),
 		ContextKey:  "user",
@@ -33,28 +43,103 @@ var IsLoggedIn = middleware.JWTWithConfig(
 		Claims:      jwt.MapClaims{},
 	})
 
-func GenerateTokenPair(user models.User) (*string, *string, interface{}, error) {
+// Types of the tokens in their typ claim
+const (
+	tokenTypeClaim   = "typ"
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+)
+
+// ErrNotAccessToken is the error of IsLoggedIn for a token of another type, e.g. a refresh token
+var ErrNotAccessToken = errors.New("route requires an access token")
+
+// TokenTTL is the lifetime of the access and refresh tokens of GenerateTokenPair
+const TokenTTL = 24 * time.Hour
+
+// IsLoggedIn return a middleware requiring a valid access token and adding the id of its user to the log of
+// the request. An access token naming a session is refused once sessions tells the session revoked or expired.
+func IsLoggedIn(sessions session.Usecase) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return jwtAuth(accessOnly(activeSession(sessions, withUserLogger(next))))
+	}
+}
+
+// activeSession refuse the access token of a session revoked or expired, the tokens without session such as
+// those of the OAuth clients are left to their expiry
+func activeSession(sessions session.Usecase, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := SessionID(c)
+		if id == "" {
+			return next(c)
+		}
+		ctx := c.Request().Context()
+		err := sessions.Check(ctx, UserID(c), id)
+		if err == session.ErrNotFound {
+			return response.Unauthorized(c, utils.Unauthorized, nil, err.Error())
+		}
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Error("check session failed")
+			return response.InternalServerError(c, utils.InternalServerError, nil, err.Error())
+		}
+		return next(c)
+	}
+}
+
+// accessOnly refuse the tokens signed with the same key which are not access tokens, such as the refresh
+// and invitation tokens. The access tokens issued before the typ claim are told apart by their id claim.
+func accessOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := Claims(c)
+		typ, typed := claims[tokenTypeClaim]
+		if typed && typ != AccessTokenType || !typed && UserID(c) == "" {
+			return response.Unauthorized(c, utils.Unauthorized, nil, ErrNotAccessToken.Error())
+		}
+		return next(c)
+	}
+}
+
+// GenerateTokenPair return the access and refresh tokens of the user with organizationID as its
+// active organization, empty when the user belongs to none, and the expiry of the access token.
+// The tokens name the session of the login in their sid claim, when sessionID is not empty.
+func GenerateTokenPair(ctx context.Context, user models.User, organizationID string, sessionID string) (_ *string, _ *string, _ interface{}, err error) {
+	_, span := tracing.Start(ctx, "GenerateTokenPair", attribute.String("user.id", user.ID))
+	defer func() {
+		tracing.End(span, err)
+	}()
 
 	// Create token with claims
 	token := jwt.New(jwt.SigningMethodHS256)
 	tokenClaims := token.Claims.(jwt.MapClaims)
 
 	tokenClaims["id"] = user.ID
+	tokenClaims[tokenTypeClaim] = AccessTokenType
+	tokenClaims[tenantClaim] = user.TenantID
+	tokenClaims[organizationClaim] = organizationID
 	tokenClaims["email"] = user.Email
 	tokenClaims["name"] = user.Name
-	tokenClaims["exp"] = time.Now().Add(time.Hour * 24).Unix()
+	tokenClaims["exp"] = time.Now().Add(TokenTTL).Unix()
 
 	// Generate encoded token and send it as response.
 	refreshToken := jwt.New(jwt.SigningMethodHS256)
 
 	rtClaims := refreshToken.Claims.(jwt.MapClaims)
 	rtClaims["email"] = user.Email
-	rtClaims["exp"] = time.Now().Add(time.Hour * 24).Unix()
+	rtClaims[tokenTypeClaim] = RefreshTokenType
+	rtClaims[tenantClaim] = user.TenantID
+	rtClaims[organizationClaim] = organizationID
+	rtClaims["exp"] = time.Now().Add(TokenTTL).Unix()
+	if sessionID != "" {
+		tokenClaims[SessionClaim] = sessionID
+		rtClaims[SessionClaim] = sessionID
+	}
 
 	//Encode Token
-	accessToken, err := token.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+	accessToken, err := token.SignedString(signingKey())
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	//Encode Refresh Token
-	rt, err := refreshToken.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+	rt, err := refreshToken.SignedString(signingKey())
 
 	if err != nil {
 		return nil, nil, nil, err
@@ -62,3 +147,46 @@ func GenerateTokenPair(user models.User) (*string, *string, interface{}, error)
 
 	return &accessToken, &rt, tokenClaims["exp"], nil
 }
+
+// SignToken sign the claims with the key of the tokens of GenerateTokenPair, IsLoggedIn accepts them
+func SignToken(ctx context.Context, claims jwt.MapClaims) (_ string, err error) {
+	_, span := tracing.Start(ctx, "SignToken")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(signingKey())
+}
+
+// ParseToken return the claims of a token signed by SignToken or GenerateTokenPair
+// once its signature and expiry are verified
+func ParseToken(token string) (jwt.MapClaims, error) {
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return signingKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || !parsed.Valid {
+		return nil, errors.New("token not valid")
+	}
+	return claims, nil
+}
+
+// JWTSigner sign and verify the access tokens issued to the OAuth clients with SignToken and ParseToken
+type JWTSigner struct{}
+
+func (JWTSigner) Sign(ctx context.Context, claims map[string]interface{}) (string, error) {
+	return SignToken(ctx, claims)
+}
+
+func (JWTSigner) Verify(token string) (map[string]interface{}, error) {
+	return ParseToken(token)
+}
+
+func signingKey() []byte {
+	return []byte(os.Getenv("JWT_SECRET_KEY"))
+}
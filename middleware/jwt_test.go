@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/session"
+	"go-echo-api/session/repository"
+	"go-echo-api/session/usecase"
+	"go-echo-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsLoggedIn(t *testing.T) {
+	sessions := usecase.NewSessionService(repository.NewSessionMemoryRepository(), TokenTTL)
+	access, refresh, _, _ := GenerateTokenPair(dbtest.Context(), dbtest.UserUje, "", "")
+	started, _ := sessions.Start(dbtest.Context(), dbtest.UserUje.ID, "127.0.0.1", "curl/7.68.0")
+	active, _, _, _ := GenerateTokenPair(dbtest.Context(), dbtest.UserUje, "", started.ID)
+	revoked, _ := sessions.Start(dbtest.Context(), dbtest.UserUje.ID, "127.0.0.1", "curl/7.68.0")
+	revokedToken, _, _, _ := GenerateTokenPair(dbtest.Context(), dbtest.UserUje, "", revoked.ID)
+	_ = sessions.Revoke(dbtest.Context(), dbtest.UserUje.ID, revoked.ID)
+	// a token issued before the typ claim
+	legacy, _ := SignToken(dbtest.Context(), jwt.MapClaims{"id": dbtest.UserUje.ID, "exp": time.Now().Add(time.Hour).Unix()})
+	invite, _ := SignToken(dbtest.Context(), jwt.MapClaims{tokenTypeClaim: "invite", "exp": time.Now().Add(time.Hour).Unix()})
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(c.Request().WithContext(dbtest.Context()))
+			return next(c)
+		}
+	})
+	e.GET("/me", func(c echo.Context) error {
+		return response.SingleData(c, utils.OK, UserID(c), nil)
+	}, IsLoggedIn(sessions))
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, "/me", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		rec := serve(*access)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), dbtest.UserUje.ID)
+		rec = serve(legacy)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec = serve(*active)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec := serve(*refresh)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), ErrNotAccessToken.Error())
+		rec = serve(invite)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		// the access token of a session revoked is refused before its expiry
+		rec = serve(*revokedToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), session.ErrNotFound.Error())
+		rec = serve("forged")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
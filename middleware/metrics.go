@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics record the count, latency and in-flight requests of every route.
+// Requests are labelled with the route pattern echo matched, e.g. /api/v1/user/:id,
+// so the cardinality of the labels stays bounded.
+func Metrics() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			method := c.Request().Method
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			inFlight := metrics.RequestsInFlight.WithLabelValues(method, route)
+			inFlight.Inc()
+			start := time.Now()
+
+			err := next(c)
+
+			inFlight.Dec()
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				if he, ok := err.(*echo.HTTPError); ok {
+					status = he.Code
+				}
+			}
+			metrics.RequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+			metrics.RequestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+			return err
+		}
+	}
+}
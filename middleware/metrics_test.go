@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	e := echo.New()
+	e.Use(Metrics())
+	e.GET("/api/v1/user/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/metrics", metrics.Handler())
+
+	// the collectors are shared by the whole process, the test asserts what its request added
+	requests := metrics.RequestsTotal.WithLabelValues(echo.GET, "/api/v1/user/:id", "204")
+	durations := metrics.RequestDuration.WithLabelValues(echo.GET, "/api/v1/user/:id")
+	before := testutil.ToFloat64(requests)
+	observed := sampleCount(t, durations)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/api/v1/user/7dd77cc4-f786-4be0-b5a5-0c203b9c62c5", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(requests))
+	assert.Equal(t, observed+1, sampleCount(t, durations))
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/metrics", nil))
+	body := rec.Body.String()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, body, `go_echo_api_http_requests_total{method="GET",route="/api/v1/user/:id",status="204"}`)
+	assert.Contains(t, body, `go_echo_api_http_requests_in_flight{method="GET",route="/metrics"} 1`)
+	assert.NotContains(t, body, "7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+}
+
+// sampleCount return the number of observations of the histogram
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	var metric dto.Metric
+	if err := observer.(prometheus.Metric).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
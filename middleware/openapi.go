@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/utils"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type OpenAPIConfig struct {
+	// Document describing the routes, the routes it does not describe are not validated
+	Document *openapi.Document
+
+	// Requests validate the path params, query params and JSON body of the requests,
+	// a violation is answered with the 422 envelope before reaching the handler
+	Requests bool
+
+	// Responses validate the status and JSON body answered by the handlers, a violation is a
+	// broken contract of the service and is answered with the 500 envelope listing it
+	Responses bool
+}
+
+// OpenAPIConfigFromEnv read what to validate from APP_OPENAPI_VALIDATION, "requests" or "all"
+// to validate the responses too, anything else disables the validation
+func OpenAPIConfigFromEnv(doc *openapi.Document) OpenAPIConfig {
+	mode := strings.ToLower(os.Getenv("APP_OPENAPI_VALIDATION"))
+	return OpenAPIConfig{
+		Document:  doc,
+		Requests:  mode == "requests" || mode == "all",
+		Responses: mode == "all",
+	}
+}
+
+// OpenAPI return a middleware validating the requests against doc
+func OpenAPI(doc *openapi.Document) echo.MiddlewareFunc {
+	return OpenAPIWithConfig(OpenAPIConfig{Document: doc, Requests: true})
+}
+
+// OpenAPIWithConfig return a middleware validating the requests and the responses of the routes
+// described by the document. It must run after the router matched the route, with echo.Use.
+func OpenAPIWithConfig(config OpenAPIConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if config.Document == nil || (!config.Requests && !config.Responses) {
+			return next
+		}
+		return func(c echo.Context) error {
+			operation, ok := config.Document.Operation(c.Request().Method, c.Path())
+			if !ok {
+				return next(c)
+			}
+			if config.Requests {
+				violations, err := validateRequest(c, config.Document, operation)
+				if err != nil {
+					return response.BadRequest(c, utils.BadRequest, nil, err.Error())
+				}
+				if len(violations) > 0 {
+					return response.ValidationError(c, utils.ValidationError, nil, apiErrors(violations))
+				}
+			}
+			if !config.Responses {
+				return next(c)
+			}
+
+			res := c.Response()
+			writer := res.Writer
+			unbuffer(c, writer)
+			buffer := &bufferedWriter{header: cloneHeader(writer.Header())}
+			res.Writer = buffer
+			err := next(c)
+			res.Writer = writer
+			if err != nil || streamed(c) {
+				return err
+			}
+			violations := validateResponse(config.Document, operation, buffer)
+			if len(violations) == 0 {
+				buffer.flushTo(writer)
+				return nil
+			}
+			logger.FromContext(c.Request().Context()).WithField("violations", violations).
+				Error("response does not match the OpenAPI document")
+			res.Committed = false
+			res.Status = 0
+			res.Size = 0
+			return response.InternalServerError(c, utils.InternalServerError, nil, apiErrors(violations))
+		}
+	}
+}
+
+func validateRequest(c echo.Context, doc *openapi.Document, operation *openapi.Operation) ([]openapi.Violation, error) {
+	var violations []openapi.Violation
+	for _, parameter := range operation.Parameters {
+		var value string
+		switch parameter.In {
+		case "path":
+			value = c.Param(parameter.Name)
+		case "query":
+			value = c.QueryParam(parameter.Name)
+		default:
+			continue
+		}
+		if value == "" {
+			if parameter.Required {
+				violations = append(violations, openapi.Violation{Field: parameter.Name, Type: "required", Message: "is required"})
+			}
+			continue
+		}
+		violations = append(violations, doc.ValidateParameter(parameter, value)...)
+	}
+
+	if operation.RequestBody == nil {
+		return violations, nil
+	}
+	media, ok := operation.RequestBody.Content[openapi.JSON]
+	if !ok {
+		return violations, nil
+	}
+	req := c.Request()
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	// the handler binds the body again
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		if operation.RequestBody.Required {
+			violations = append(violations, openapi.Violation{Field: "body", Type: "required", Message: "is required"})
+		}
+		return violations, nil
+	}
+	if !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return append(violations, openapi.Violation{Field: "body", Type: "content_type", Message: "must be " + echo.MIMEApplicationJSON}), nil
+	}
+	value, err := decodeJSON(body)
+	if err != nil {
+		return append(violations, openapi.Violation{Field: "body", Type: "syntax", Message: err.Error()}), nil
+	}
+	return append(violations, doc.Validate(media.Schema, "", value)...), nil
+}
+
+func validateResponse(doc *openapi.Document, operation *openapi.Operation, buffer *bufferedWriter) []openapi.Violation {
+	status := buffer.status
+	if status == 0 {
+		return nil
+	}
+	described, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		described, ok = operation.Responses["default"]
+	}
+	if !ok {
+		return []openapi.Violation{{Field: "status", Type: "undocumented", Message: "status " + strconv.Itoa(status) + " is not documented"}}
+	}
+	media, ok := described.Content[openapi.JSON]
+	if !ok {
+		return nil
+	}
+	value, err := decodeJSON(buffer.body.Bytes())
+	if err != nil {
+		return []openapi.Violation{{Field: "body", Type: "syntax", Message: err.Error()}}
+	}
+	return doc.Validate(media.Schema, "", value)
+}
+
+// decodeJSON decode a JSON document keeping the numbers as json.Number for the validation
+func decodeJSON(body []byte) (interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	return value, err
+}
+
+func apiErrors(violations []openapi.Violation) []response.APIError {
+	errors := make([]response.APIError, len(violations))
+	for i, violation := range violations {
+		errors[i] = response.APIError{Type: violation.Type, Field: violation.Field, Message: violation.Message}
+	}
+	return errors
+}
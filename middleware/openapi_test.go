@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type petDto struct {
+	Name  string `json:"name" validate:"required,max=10"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+type petMapper struct {
+	ID   string `json:"id" validate:"required"`
+	Name string `json:"name"`
+}
+
+func petDocument() *openapi.Document {
+	doc := openapi.New("test", "1.0.0")
+	g := doc.Group("/pet")
+	g.Add(echo.POST, "", openapi.Operation{
+		RequestBody: g.Body(petDto{}),
+		Responses:   map[string]openapi.Response{"200": g.Single("pet", petMapper{})},
+	})
+	g.Add(echo.GET, "", openapi.Operation{
+		Parameters: []openapi.Parameter{openapi.QueryParam("limit", "", &openapi.Schema{Type: "integer"})},
+		Responses:  map[string]openapi.Response{"200": g.Paging("pets", petMapper{})},
+	})
+	return doc
+}
+
+func TestOpenAPIWithConfig(t *testing.T) {
+	e := echo.New()
+	e.Use(OpenAPIWithConfig(OpenAPIConfig{Document: petDocument(), Requests: true, Responses: true}))
+	e.POST("/pet", func(c echo.Context) error {
+		var dto petDto
+		if err := c.Bind(&dto); err != nil {
+			return err
+		}
+		if dto.Name == "broken" {
+			return response.SingleData(c, utils.OK, petMapper{Name: dto.Name}, nil)
+		}
+		return response.SingleData(c, utils.OK, petMapper{ID: "1", Name: dto.Name}, nil)
+	})
+	e.GET("/pet", func(c echo.Context) error {
+		return response.NotFound(c, utils.NotFound, nil, "no pets")
+	})
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.POST, "/pet", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		rec := post(`{"name":"rex","email":"rex@email.com"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"name":"rex"`)
+	})
+	f := t.Run("error-invalid-body", func(t *testing.T) {
+		rec := post(`{"name":"a very long name","email":"rex"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"field":"email","message":"must be a valid email"`)
+		assert.Contains(t, rec.Body.String(), `"field":"name","message":"must have at most 10 characters"`)
+	})
+	r := t.Run("error-missing-field", func(t *testing.T) {
+		rec := post(`{"name":""}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"type":"required","field":"email"`)
+		assert.Contains(t, rec.Body.String(), `"type":"min","field":"name"`)
+	})
+	q := t.Run("error-invalid-query", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/pet?limit=ten", nil))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"field":"limit","message":"must be of type integer"`)
+	})
+	b := t.Run("error-broken-response", func(t *testing.T) {
+		rec := post(`{"name":"broken","email":"rex@email.com"}`)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"field":"data.id"`)
+	})
+	u := t.Run("error-undocumented-status", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/pet", nil))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), "status 404 is not documented")
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Invalid body scenario failed run")
+	assert.Equal(t, true, r, "Missing field scenario failed run")
+	assert.Equal(t, true, q, "Invalid query scenario failed run")
+	assert.Equal(t, true, b, "Broken response scenario failed run")
+	assert.Equal(t, true, u, "Undocumented status scenario failed run")
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+	"go-echo-api/infrastructure/logger"
+	"regexp"
+	"time"
+)
+
+// validRequestID bound what a client can put in the logs through X-Request-ID
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID accept the X-Request-ID of the client or generate one, echo it back in the response
+// and put in the request context an entry of log carrying it, see logger.FromContext
+func RequestID(log *logrus.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID.MatchString(id) {
+				id = uuid.New().String()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+			entry := log.WithField(logger.RequestIDField, id)
+			c.SetRequest(req.WithContext(logger.WithContext(req.Context(), entry)))
+			return next(c)
+		}
+	}
+}
+
+// withUserLogger add the id of the logged in user to the entry of log of the request
+func withUserLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if id := UserID(c); id != "" {
+			req := c.Request()
+			entry := logger.FromContext(req.Context()).WithField(logger.UserIDField, id)
+			c.SetRequest(req.WithContext(logger.WithContext(req.Context(), entry)))
+		}
+		return next(c)
+	}
+}
+
+// AccessLog write one line of log per request with the entry of the request
+func AccessLog() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			req := c.Request()
+			res := c.Response()
+			entry := logger.FromContext(req.Context()).WithFields(logrus.Fields{
+				"method":     req.Method,
+				"uri":        req.RequestURI,
+				"route":      c.Path(),
+				"status":     res.Status,
+				"latency_ms": float64(time.Since(start).Nanoseconds()) / 1e6,
+				"remote_ip":  c.RealIP(),
+				"user_agent": req.UserAgent(),
+				"bytes_out":  res.Size,
+			})
+			if id := UserID(c); id != "" {
+				entry = entry.WithField(logger.UserIDField, id)
+			}
+			switch {
+			case res.Status >= 500:
+				entry.WithError(err).Error("request")
+			case res.Status >= 400:
+				entry.Warn("request")
+			default:
+				entry.Info("request")
+			}
+			return nil
+		}
+	}
+}
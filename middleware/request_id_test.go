@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	e := echo.New()
+	e.Use(RequestID(log))
+	e.Use(AccessLog())
+	e.GET("/ok", func(c echo.Context) error {
+		logger.FromContext(c.Request().Context()).Info("inside handler")
+		return response.SingleData(c, utils.OK, nil, nil)
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		return response.BadRequest(c, utils.BadRequest, nil, "failed")
+	})
+
+	s := t.Run("success-accept", func(t *testing.T) {
+		hook.Reset()
+		req := httptest.NewRequest(echo.GET, "/ok", nil)
+		req.Header.Set(echo.HeaderXRequestID, "client-id-1")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, "client-id-1", rec.Header().Get(echo.HeaderXRequestID))
+		if assert.Len(t, hook.AllEntries(), 2) {
+			for _, entry := range hook.AllEntries() {
+				assert.Equal(t, "client-id-1", entry.Data[logger.RequestIDField])
+			}
+			assert.Equal(t, "/ok", hook.LastEntry().Data["route"])
+		}
+	})
+
+	g := t.Run("success-generate", func(t *testing.T) {
+		req := httptest.NewRequest(echo.GET, "/fail", nil)
+		req.Header.Set(echo.HeaderXRequestID, "not valid\nid")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		id := rec.Header().Get(echo.HeaderXRequestID)
+		assert.Len(t, id, 36)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"request_id":"`+id+`"`)
+	})
+	u := t.Run("success-user", func(t *testing.T) {
+		// the failures of a logged in user carry its id, in the meta and on the line of log
+		hook.Reset()
+		e.GET("/user/fail", func(c echo.Context) error {
+			c.Set("user", &jwt.Token{Valid: true, Claims: jwt.MapClaims{"id": "user-1"}})
+			return response.BadRequest(c, utils.BadRequest, nil, "failed")
+		})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/user/fail", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"user_id":"user-1"`)
+		if assert.NotNil(t, hook.LastEntry()) {
+			assert.Equal(t, "user-1", hook.LastEntry().Data[logger.UserIDField])
+		}
+	})
+	assert.Equal(t, true, s, "Accept scenario failed run")
+	assert.Equal(t, true, g, "Generate scenario failed run")
+	assert.Equal(t, true, u, "User scenario failed run")
+}
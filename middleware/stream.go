@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"net/http"
+)
+
+const (
+	// unbufferedKey holds the writer of the client, before the first middleware buffering the response
+	unbufferedKey = "middleware.unbuffered"
+	streamedKey   = "middleware.streamed"
+)
+
+// unbuffer remember the writer of the client before a middleware buffers the response, the outermost wins
+func unbuffer(c echo.Context, writer http.ResponseWriter) {
+	if c.Get(unbufferedKey) == nil {
+		c.Set(unbufferedKey, writer)
+	}
+}
+
+// Stream write the response of c straight to the client, past the buffers of the Timeout and OpenAPI middleware,
+// for the handlers streaming a response too large to hold in memory. The deadline still cancels the context of
+// the request, but a response begun is neither replaced on expiry nor validated against the OpenAPI document.
+func Stream(c echo.Context) {
+	if writer, ok := c.Get(unbufferedKey).(http.ResponseWriter); ok {
+		c.Response().Writer = writer
+	}
+	c.Set(streamedKey, true)
+}
+
+func streamed(c echo.Context) bool {
+	ok, _ := c.Get(streamedKey).(bool)
+	return ok
+}
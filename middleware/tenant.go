@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"go-echo-api/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	HeaderTenantID = "X-Tenant-ID"
+	tenantClaim    = "tenant_id"
+)
+
+type TenantConfig struct {
+	// Tenants resolve the ID, slug or claim of the request to its tenant
+	Tenants tenant.Repository
+
+	// BaseDomain of the API, the first label of a host under it is the slug of the tenant,
+	// e.g. "acme" for acme.api.example.com with "api.example.com". Empty disables the subdomains
+	BaseDomain string
+
+	// DefaultSlug is the tenant of the requests naming none, empty answers them 400
+	DefaultSlug string
+}
+
+// TenantConfigFromEnv read the base domain from APP_BASE_DOMAIN and the default tenant from APP_DEFAULT_TENANT
+func TenantConfigFromEnv(tenants tenant.Repository) TenantConfig {
+	return TenantConfig{
+		Tenants:     tenants,
+		BaseDomain:  strings.ToLower(os.Getenv("APP_BASE_DOMAIN")),
+		DefaultSlug: os.Getenv("APP_DEFAULT_TENANT"),
+	}
+}
+
+// Tenant return a middleware resolving the tenant of the request and putting it in the context
+// of the request, where the repositories of the tenant-scoped modules read it.
+// The tenant is taken from the X-Tenant-ID header, then the subdomain, then the tenant_id claim
+// of the access token, then the default tenant. A valid access token of another tenant than
+// the resolved one is answered 403, as are the requests of a suspended tenant.
+func Tenant(config TenantConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			claimID, hasToken := tenantOfToken(c)
+
+			var model *models.Tenant
+			var err error
+			switch id, slug := c.Request().Header.Get(HeaderTenantID), subdomain(c.Request().Host, config.BaseDomain); {
+			case id != "":
+				model, err = config.Tenants.FindById(ctx, id)
+			case slug != "":
+				model, err = config.Tenants.FindBySlug(ctx, slug)
+			case claimID != "":
+				model, err = config.Tenants.FindById(ctx, claimID)
+			case config.DefaultSlug != "":
+				model, err = config.Tenants.FindBySlug(ctx, config.DefaultSlug)
+			default:
+				err = tenant.ErrRequired
+			}
+			switch {
+			case err == tenant.ErrRequired:
+				return response.BadRequest(c, utils.BadRequest, nil, err.Error())
+			case err == tenant.ErrNotFound:
+				return response.NotFound(c, utils.NotFound, nil, err.Error())
+			case err != nil:
+				logger.FromContext(ctx).WithError(err).Error("resolve tenant failed")
+				return response.InternalServerError(c, utils.InternalServerError, nil, err.Error())
+			case !model.Active():
+				return response.Forbidden(c, utils.Forbidden, nil, tenant.ErrSuspended.Error())
+			case hasToken && claimID != model.ID:
+				return response.Forbidden(c, utils.Forbidden, nil, tenant.ErrMismatch.Error())
+			}
+
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("tenant.id", model.ID))
+			ctx = logger.WithContext(ctx, logger.FromContext(ctx).WithField(logger.TenantIDField, model.ID))
+			c.SetRequest(c.Request().WithContext(tenant.WithContext(ctx, *model)))
+			return next(c)
+		}
+	}
+}
+
+// TenantOpenAPI describe the header and the failures of the Tenant middleware on the operations of g
+func TenantOpenAPI(g *openapi.Group) {
+	g.Use([]openapi.Parameter{
+		openapi.HeaderParam(HeaderTenantID, "ID of the tenant, the subdomain or the access token name it when absent"),
+	}, map[string]openapi.Response{
+		"400": g.Error("No tenant named by the request"),
+		"403": g.Error("Tenant suspended or access token of another tenant"),
+		"404": g.Error("Tenant not found"),
+	})
+}
+
+// tenantOfToken return the tenant claim of a valid bearer access token, and whether the request has one.
+// An invalid token is left to IsLoggedIn.
+func tenantOfToken(c echo.Context) (string, bool) {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", false
+	}
+	claims, err := ParseToken(strings.TrimPrefix(auth, "Bearer "))
+	if err != nil {
+		return "", false
+	}
+	id, _ := claims[tenantClaim].(string)
+	return id, true
+}
+
+// subdomain return the first label of host when host is a direct subdomain of baseDomain
+func subdomain(host string, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, "."+baseDomain) {
+		return ""
+	}
+	label := strings.TrimSuffix(host, "."+baseDomain)
+	if strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
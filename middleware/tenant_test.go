@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"go-echo-api/tenant/repository"
+	"go-echo-api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenant(t *testing.T) {
+	suspended := models.Tenant{ID: "suspended-id", Name: "Umbrella", Slug: "umbrella", Status: models.TenantSuspended}
+	e := echo.New()
+	e.Use(Tenant(TenantConfig{
+		Tenants:     repository.NewTenantMemoryRepository(dbtest.TenantAcme, dbtest.TenantGlobex, suspended),
+		BaseDomain:  "api.example.com",
+		DefaultSlug: dbtest.TenantAcme.Slug,
+	}))
+	e.GET("/ok", func(c echo.Context) error {
+		model, _ := tenant.FromContext(c.Request().Context())
+		return response.SingleData(c, utils.OK, model.Slug, nil)
+	})
+	serve := func(host string, header string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.GET, "/ok", nil)
+		req.Host = host
+		if header != "" {
+			req.Header.Set(HeaderTenantID, header)
+		}
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	token, _, _, _ := GenerateTokenPair(dbtest.Context(), dbtest.UserGlobexUje, "", "")
+	globexToken := *token
+
+	s := t.Run("success", func(t *testing.T) {
+		// header, then subdomain, then token claim, then default tenant
+		rec := serve("acme.api.example.com", dbtest.TenantGlobex.ID, "")
+		assert.Contains(t, rec.Body.String(), `"data":"globex"`)
+
+		rec = serve("globex.api.example.com:1300", "", "")
+		assert.Contains(t, rec.Body.String(), `"data":"globex"`)
+
+		rec = serve("localhost", "", globexToken)
+		assert.Contains(t, rec.Body.String(), `"data":"globex"`)
+
+		rec = serve("localhost", "", "")
+		assert.Contains(t, rec.Body.String(), `"data":"acme"`)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec := serve("initech.api.example.com", "", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = serve("umbrella.api.example.com", "", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = serve("acme.api.example.com", "", globexToken)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/utils"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+type TimeoutConfig struct {
+	// Timeout is the deadline of the routes without an entry in Routes, zero disables it
+	Timeout time.Duration
+
+	// Routes override the deadline per route, keyed by method and route path
+	// the way echo registered it, e.g. "GET /api/v1/user/:id"
+	Routes map[string]time.Duration
+
+	// StatusCode answered when the deadline expires, http.StatusGatewayTimeout
+	// or http.StatusServiceUnavailable. Default http.StatusGatewayTimeout
+	StatusCode int
+}
+
+var DefaultTimeoutConfig = TimeoutConfig{
+	Timeout:    30 * time.Second,
+	StatusCode: http.StatusGatewayTimeout,
+}
+
+// TimeoutConfigFromEnv read the deadlines from APP_REQUEST_TIMEOUT, e.g. "30s",
+// and APP_ROUTE_TIMEOUTS, e.g. "GET /api/v1/user=5s,POST /api/v1/auth/register=10s"
+func TimeoutConfigFromEnv() TimeoutConfig {
+	config := DefaultTimeoutConfig
+	if timeout, err := time.ParseDuration(os.Getenv("APP_REQUEST_TIMEOUT")); err == nil {
+		config.Timeout = timeout
+	}
+	config.Routes = make(map[string]time.Duration)
+	for _, entry := range strings.Split(os.Getenv("APP_ROUTE_TIMEOUTS"), ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if timeout, err := time.ParseDuration(parts[1]); err == nil {
+			config.Routes[strings.TrimSpace(parts[0])] = timeout
+		}
+	}
+	return config
+}
+
+// Timeout return a middleware bounding every request by the given deadline
+func Timeout(timeout time.Duration) echo.MiddlewareFunc {
+	config := DefaultTimeoutConfig
+	config.Timeout = timeout
+	return TimeoutWithConfig(config)
+}
+
+// TimeoutWithConfig return a middleware putting a deadline on the context of the request.
+// The context reaches the repositories so the database queries are cancelled on expiry,
+// the response of the handler is buffered and replaced by the timeout envelope when the deadline expired.
+func TimeoutWithConfig(config TimeoutConfig) echo.MiddlewareFunc {
+	if config.StatusCode == 0 {
+		config.StatusCode = DefaultTimeoutConfig.StatusCode
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout, ok := config.Routes[c.Request().Method+" "+c.Path()]
+			if !ok {
+				timeout = config.Timeout
+			}
+			if timeout <= 0 {
+				return next(c)
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			res := c.Response()
+			writer := res.Writer
+			unbuffer(c, writer)
+			buffer := &bufferedWriter{header: cloneHeader(writer.Header())}
+			res.Writer = buffer
+			err := next(c)
+			res.Writer = writer
+
+			if streamed(c) {
+				return err
+			}
+			if ctx.Err() != context.DeadlineExceeded {
+				buffer.flushTo(writer)
+				return err
+			}
+			res.Committed = false
+			res.Status = 0
+			res.Size = 0
+			message := "request exceeded its deadline of " + timeout.String()
+			if config.StatusCode == http.StatusServiceUnavailable {
+				return response.ServiceUnavailable(c, utils.RequestTimeout, nil, message)
+			}
+			return response.GatewayTimeout(c, utils.RequestTimeout, nil, message)
+		}
+	}
+}
+
+func cloneHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for key, values := range header {
+		clone[key] = append([]string(nil), values...)
+	}
+	return clone
+}
+
+// bufferedWriter hold the response of the handler until the middleware knows whether the deadline expired
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) flushTo(writer http.ResponseWriter) {
+	if w.status == 0 {
+		return
+	}
+	for key, values := range w.header {
+		writer.Header()[key] = values
+	}
+	writer.WriteHeader(w.status)
+	_, _ = w.body.WriteTo(writer)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutWithConfig(t *testing.T) {
+	e := echo.New()
+	e.Use(TimeoutWithConfig(TimeoutConfig{
+		Timeout: time.Second,
+		Routes:  map[string]time.Duration{"GET /slow": 10 * time.Millisecond},
+	}))
+	e.GET("/slow", func(c echo.Context) error {
+		<-c.Request().Context().Done()
+		return c.String(http.StatusOK, "too late")
+	})
+	e.GET("/fast", func(c echo.Context) error {
+		return c.String(http.StatusCreated, "done")
+	})
+
+	s := t.Run("success", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/fast", nil))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "done", rec.Body.String())
+	})
+
+	f := t.Run("error-timeout", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/slow", nil))
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":504`)
+		assert.NotContains(t, rec.Body.String(), "too late")
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestStream(t *testing.T) {
+	e := echo.New()
+	e.Use(TimeoutWithConfig(TimeoutConfig{Timeout: time.Second}))
+	rec := httptest.NewRecorder()
+	e.GET("/stream", func(c echo.Context) error {
+		Stream(c)
+		c.Response().WriteHeader(http.StatusOK)
+		_, _ = c.Response().Write([]byte("first"))
+		// the client has the first chunk before the handler returns
+		assert.Equal(t, "first", rec.Body.String())
+		_, _ = c.Response().Write([]byte(" second"))
+		return nil
+	})
+
+	e.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/stream", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "first second", rec.Body.String())
+}
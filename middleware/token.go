@@ -1 +1,43 @@
-package middleware
\ No newline at end of file
+package middleware
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+// organizationClaim is the active organization of the user in the tokens
+const organizationClaim = "organization_id"
+
+// SessionClaim is the session of the login in the tokens of GenerateTokenPair
+const SessionClaim = "sid"
+
+// Claims return the claims of the access token validated by IsLoggedIn, nil when the request has none
+func Claims(ctx echo.Context) jwt.MapClaims {
+	token, ok := ctx.Get("user").(*jwt.Token)
+	if !ok {
+		return nil
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
+
+// UserID return the id of the logged in user, empty when the request has no access token
+func UserID(ctx echo.Context) string {
+	id, _ := Claims(ctx)["id"].(string)
+	return id
+}
+
+// OrganizationID return the active organization of the logged in user, empty when the user belongs to none
+func OrganizationID(ctx echo.Context) string {
+	id, _ := Claims(ctx)[organizationClaim].(string)
+	return id
+}
+
+// SessionID return the session of the access token of the logged in user, empty for a token without session
+func SessionID(ctx echo.Context) string {
+	id, _ := Claims(ctx)[SessionClaim].(string)
+	return id
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Tracing start a server span per request, child of the W3C traceparent of the client when present,
+// and add the trace ID to the entry of log of the request
+func Tracing() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			route := c.Path()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := tracing.Tracer().Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.method", req.Method),
+					attribute.String("http.route", route),
+					attribute.String("http.target", req.URL.Path),
+					attribute.String("http.user_agent", req.UserAgent()),
+					attribute.String("net.peer.ip", c.RealIP()),
+				),
+			)
+			defer span.End()
+
+			if traceID := tracing.TraceID(ctx); traceID != "" {
+				ctx = logger.WithContext(ctx, logger.FromContext(ctx).WithField(logger.TraceIDField, traceID))
+			}
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				if he, ok := err.(*echo.HTTPError); ok {
+					status = he.Code
+				}
+				span.RecordError(err)
+			}
+			span.SetAttributes(attribute.Int("http.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			if id := UserID(c); id != "" {
+				span.SetAttributes(attribute.String("enduser.id", id))
+			}
+			return err
+		}
+	}
+}
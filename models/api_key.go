@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+// APIKey authenticate the requests of a user without interactive login, only the hash of the key is kept
+type APIKey struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;index"`
+	UserID   string `gorm:"column:user_id;index"`
+	Name     string `gorm:"column:name"`
+	// Prefix is the public part of the key, it finds the key before its hash is compared
+	Prefix string `gorm:"column:prefix;unique_index"`
+	Hash   string `gorm:"column:hash"`
+	// Scopes separated by spaces, empty grants the access of the user
+	Scopes     string     `gorm:"column:scopes"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at"`
+}
+
+func (c *APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList return the scopes of the key, empty when the key has the access of its user
+func (c *APIKey) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+// Expired tell whether the key has an expiry before now
+func (c *APIKey) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && !now.Before(*c.ExpiresAt)
+}
+
+func (c *APIKey) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// AuditEvent is an action recorded in the audit log of a tenant. The events are only appended, each one
+// chained to the previous event of the tenant by the hash of its content, an event changed or removed
+// breaks the chain.
+type AuditEvent struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;unique_index:idx_audit_sequence"`
+	// Sequence numbers the events of the tenant from 1
+	Sequence int64 `gorm:"column:sequence;unique_index:idx_audit_sequence"`
+	// ActorID is the user acting, empty for an anonymous request such as a failed login,
+	// APIKeyID the API key it acted with
+	ActorID  string `gorm:"column:actor_id;index"`
+	APIKeyID string `gorm:"column:api_key_id"`
+	// Action is what was done, e.g. "user.updated"
+	Action     string `gorm:"column:action;index"`
+	TargetType string `gorm:"column:target_type"`
+	TargetID   string `gorm:"column:target_id;index"`
+	IP         string `gorm:"column:ip"`
+	UserAgent  string `gorm:"column:user_agent"`
+	RequestID  string `gorm:"column:request_id"`
+	// Diff is the JSON object of the fields changed, {"name":{"before":"Uje","after":"Ujang"}}, empty without change
+	Diff string `gorm:"column:diff;type:text"`
+	// CreatedAt is truncated to the second, every database keeps it as hashed
+	CreatedAt time.Time `gorm:"column:created_at;index"`
+	// PrevHash is the hash of the previous event of the tenant, empty for the first one
+	PrevHash string `gorm:"column:prev_hash"`
+	Hash     string `gorm:"column:hash"`
+}
+
+func (c *AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+func (c *AuditEvent) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// LinkedIdentity link the subject of an external identity provider to a user, the user logs in with it
+type LinkedIdentity struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;unique_index:idx_linked_identity_subject"`
+	UserID   string `gorm:"column:user_id;index"`
+	// Provider is the name of the provider in the configuration, e.g. "google"
+	Provider string `gorm:"column:provider;unique_index:idx_linked_identity_subject"`
+	// Subject is the stable ID of the user at the provider, the sub claim of OpenID Connect
+	Subject string `gorm:"column:subject;unique_index:idx_linked_identity_subject"`
+	// Email of the user at the provider when it was linked, for display only
+	Email     string    `gorm:"column:email"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (c *LinkedIdentity) TableName() string {
+	return "linked_identities"
+}
+
+func (c *LinkedIdentity) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
+
+// IdentityState is a login or a link started with an external identity provider, taken once by its callback
+type IdentityState struct {
+	ID        string `gorm:"column:id;primary_key:true"`
+	TenantID  string `gorm:"column:tenant_id;index"`
+	Provider  string `gorm:"column:provider"`
+	StateHash string `gorm:"column:state_hash;unique_index"`
+	// UserID is the logged in user linking the identity, empty for a login
+	UserID string `gorm:"column:user_id"`
+	// CodeVerifier is the PKCE verifier of the code, Nonce the one expected in the ID token
+	CodeVerifier string    `gorm:"column:code_verifier"`
+	Nonce        string    `gorm:"column:nonce"`
+	RedirectURI  string    `gorm:"column:redirect_uri"`
+	ExpiresAt    time.Time `gorm:"column:expires_at"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+}
+
+func (c *IdentityState) TableName() string {
+	return "identity_states"
+}
+
+// Expired tell whether the state has an expiry before now
+func (c *IdentityState) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+func (c *IdentityState) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
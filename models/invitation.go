@@ -0,0 +1,39 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// Invitation to join an organization, its ID is the jti of the signed invite token. An invitation is
+// accepted once, and is pending until accepted, revoked or expired.
+type Invitation struct {
+	ID             string `gorm:"column:id;primary_key:true"`
+	TenantID       string `gorm:"column:tenant_id;index"`
+	OrganizationID string `gorm:"column:organization_id;index"`
+	// Email is the email invited, lower-cased
+	Email      string     `gorm:"column:email"`
+	Role       string     `gorm:"column:role"`
+	InvitedBy  string     `gorm:"column:invited_by"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	AcceptedAt *time.Time `gorm:"column:accepted_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+}
+
+func (c *Invitation) TableName() string {
+	return "invitations"
+}
+
+// Pending tell whether the invitation may still be accepted at now
+func (c *Invitation) Pending(now time.Time) bool {
+	return c.AcceptedAt == nil && c.RevokedAt == nil && now.Before(c.ExpiresAt)
+}
+
+func (c *Invitation) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
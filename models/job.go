@@ -0,0 +1,58 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// status of the jobs
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is an operation run in the background by the worker pool, out of the request enqueuing it
+type Job struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;index"`
+	// UserID is the user who enqueued the job, the only one to see it, empty for a job of the service
+	UserID string `gorm:"column:user_id;index"`
+	// Type selects the handler running the job, e.g. "user.import", Payload is its JSON input, cleared
+	// once the job succeeded or failed for good
+	Type    string `gorm:"column:type"`
+	Payload string `gorm:"column:payload;type:text"`
+	// Status is JobQueued until a worker claims it, then JobRunning until it succeeds or runs out of attempts
+	Status string `gorm:"column:status;index"`
+	// Progress is the percentage of the job done, as reported by its handler
+	Progress int `gorm:"column:progress"`
+	// Attempts counts the runs begun, RunAt is when the job is due: the schedule of a delayed job,
+	// pushed back after a failure and while a worker holds the job
+	Attempts  int       `gorm:"column:attempts"`
+	RunAt     time.Time `gorm:"column:run_at;index"`
+	LastError string    `gorm:"column:last_error;type:text"`
+	// Result is the JSON output of the job succeeded
+	Result     string     `gorm:"column:result;type:text"`
+	StartedAt  *time.Time `gorm:"column:started_at"`
+	FinishedAt *time.Time `gorm:"column:finished_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at"`
+}
+
+func (c *Job) TableName() string {
+	return "jobs"
+}
+
+// Finished tell whether the job succeeded or failed for good
+func (c *Job) Finished() bool {
+	return c.Status == JobSucceeded || c.Status == JobFailed
+}
+
+func (c *Job) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
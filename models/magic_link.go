@@ -0,0 +1,37 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// MagicLink is a login link emailed to a user, used once before its expiry. The links requested for an
+// unknown email are kept too, without user, the requests of an email are rate limited from them.
+type MagicLink struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;index"`
+	UserID   string `gorm:"column:user_id"`
+	// Email is the email the link was requested for, lower-cased
+	Email     string     `gorm:"column:email;index"`
+	TokenHash string     `gorm:"column:token_hash;unique_index"`
+	ExpiresAt time.Time  `gorm:"column:expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+}
+
+func (c *MagicLink) TableName() string {
+	return "magic_links"
+}
+
+// Expired tell whether the link has an expiry before now
+func (c *MagicLink) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+func (c *MagicLink) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// TOTPFactor is the authenticator app of a user, asked at login once confirmed
+type TOTPFactor struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;unique_index:idx_totp_factor_user"`
+	UserID   string `gorm:"column:user_id;unique_index:idx_totp_factor_user"`
+	// Secret is the base32 shared secret of RFC 6238
+	Secret string `gorm:"column:secret"`
+	// ConfirmedAt is set by the first valid code, the factor is pending before
+	ConfirmedAt *time.Time `gorm:"column:confirmed_at"`
+	// LastStep is the time step of the last code accepted, a code is not accepted twice
+	LastStep  int64     `gorm:"column:last_step"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (c *TOTPFactor) TableName() string {
+	return "totp_factors"
+}
+
+// Confirmed tell whether the factor protects the login
+func (c *TOTPFactor) Confirmed() bool {
+	return c.ConfirmedAt != nil
+}
+
+func (c *TOTPFactor) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
+
+// RecoveryCode replace a TOTP code once, when the user lost its authenticator, only its hash is kept
+type RecoveryCode struct {
+	ID        string     `gorm:"column:id;primary_key:true"`
+	TenantID  string     `gorm:"column:tenant_id;index"`
+	UserID    string     `gorm:"column:user_id;index"`
+	CodeHash  string     `gorm:"column:code_hash"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+}
+
+func (c *RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+func (c *RecoveryCode) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
+
+// MFAChallenge is a login whose password was checked, waiting for the second factor of the user
+type MFAChallenge struct {
+	ID        string `gorm:"column:id;primary_key:true"`
+	TenantID  string `gorm:"column:tenant_id;index"`
+	UserID    string `gorm:"column:user_id;index"`
+	TokenHash string `gorm:"column:token_hash;unique_index"`
+	// Attempts counts the wrong codes, the challenge is dropped after too many
+	Attempts  int       `gorm:"column:attempts"`
+	ExpiresAt time.Time `gorm:"column:expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (c *MFAChallenge) TableName() string {
+	return "mfa_challenges"
+}
+
+// Expired tell whether the challenge has an expiry before now
+func (c *MFAChallenge) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+func (c *MFAChallenge) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+// OAuthClient is an application registered by a user to act on behalf of the users of its tenant,
+// only the hash of its secret is kept
+type OAuthClient struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;index"`
+	// UserID is the user managing the client
+	UserID string `gorm:"column:user_id;index"`
+	Name   string `gorm:"column:name"`
+	// SecretHash is empty for a public client, e.g. a mobile or single page application
+	SecretHash string `gorm:"column:secret_hash"`
+	// RedirectURIs, GrantTypes and Scopes are separated by spaces
+	RedirectURIs string    `gorm:"column:redirect_uris"`
+	GrantTypes   string    `gorm:"column:grant_types"`
+	Scopes       string    `gorm:"column:scopes"`
+	CreatedAt    time.Time `gorm:"column:created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+}
+
+func (c *OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// Public tell whether the client has no secret
+func (c *OAuthClient) Public() bool {
+	return c.SecretHash == ""
+}
+
+func (c *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(c.RedirectURIs)
+}
+
+func (c *OAuthClient) GrantTypeList() []string {
+	return strings.Fields(c.GrantTypes)
+}
+
+func (c *OAuthClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+func (c *OAuthClient) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
+
+// OAuthCode is an authorization code granted by a user to a client, it is exchanged once for tokens
+type OAuthCode struct {
+	ID             string `gorm:"column:id;primary_key:true"`
+	TenantID       string `gorm:"column:tenant_id;index"`
+	ClientID       string `gorm:"column:client_id;index"`
+	UserID         string `gorm:"column:user_id;index"`
+	OrganizationID string `gorm:"column:organization_id"`
+	CodeHash       string `gorm:"column:code_hash;unique_index"`
+	RedirectURI    string `gorm:"column:redirect_uri"`
+	Scopes         string `gorm:"column:scopes"`
+	// CodeChallenge is the S256 PKCE challenge the code verifier must match
+	CodeChallenge string `gorm:"column:code_challenge"`
+	// Nonce of the OpenID Connect request, copied to the ID token
+	Nonce     string    `gorm:"column:nonce"`
+	ExpiresAt time.Time `gorm:"column:expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (c *OAuthCode) TableName() string {
+	return "oauth_codes"
+}
+
+func (c *OAuthCode) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
+
+// OAuthRefreshToken is the refresh token of a client, it is replaced by a new one on every use
+type OAuthRefreshToken struct {
+	ID             string    `gorm:"column:id;primary_key:true"`
+	TenantID       string    `gorm:"column:tenant_id;index"`
+	ClientID       string    `gorm:"column:client_id;index"`
+	UserID         string    `gorm:"column:user_id;index"`
+	OrganizationID string    `gorm:"column:organization_id"`
+	TokenHash      string    `gorm:"column:token_hash;unique_index"`
+	Scopes         string    `gorm:"column:scopes"`
+	ExpiresAt      time.Time `gorm:"column:expires_at"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+}
+
+func (c *OAuthRefreshToken) TableName() string {
+	return "oauth_refresh_tokens"
+}
+
+func (c *OAuthRefreshToken) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
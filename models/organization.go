@@ -0,0 +1,61 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// Roles of the members of an organization, from the most to the least privileged
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Organization is a team of users of a tenant
+type Organization struct {
+	ID        string    `gorm:"column:id;primary_key:true"`
+	TenantID  string    `gorm:"column:tenant_id;index"`
+	Name      string    `gorm:"column:name"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (c *Organization) TableName() string {
+	return "organizations"
+}
+
+func (c *Organization) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
+
+// Membership give a user a role in an organization
+type Membership struct {
+	ID             string    `gorm:"column:id;primary_key:true"`
+	TenantID       string    `gorm:"column:tenant_id;index"`
+	OrganizationID string    `gorm:"column:organization_id;unique_index:uix_memberships_organization_user"`
+	UserID         string    `gorm:"column:user_id;unique_index:uix_memberships_organization_user"`
+	Role           string    `gorm:"column:role"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at"`
+}
+
+func (c *Membership) TableName() string {
+	return "memberships"
+}
+
+// CanManage tell whether the member can rename the organization, manage its members and invite
+func (c *Membership) CanManage() bool {
+	return c.Role == RoleOwner || c.Role == RoleAdmin
+}
+
+func (c *Membership) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// OutboxEvent is a domain event written in the transaction of the change it tells, then published
+// to the sinks by the dispatcher of the outbox, at least once
+type OutboxEvent struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;index"`
+	// Type is the event, e.g. "user.registered", AggregateID the entity it tells about
+	Type        string `gorm:"column:type"`
+	AggregateID string `gorm:"column:aggregate_id;index"`
+	// Payload is the JSON data of the event
+	Payload   string    `gorm:"column:payload;type:text"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	// Attempts counts the failed publications, NextAttemptAt is when the event is due,
+	// pushed back after a failure and while a dispatcher publishes it
+	Attempts      int        `gorm:"column:attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index"`
+	LastError     string     `gorm:"column:last_error;type:text"`
+	PublishedAt   *time.Time `gorm:"column:published_at;index"`
+	// FailedAt is when the event gave up after its last attempt, it is not published after
+	FailedAt *time.Time `gorm:"column:failed_at"`
+}
+
+func (c *OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+func (c *OutboxEvent) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// Session is a login of a user on a device, its refresh token keeps it alive until revoked.
+// The tokens of the session carry its ID in their sid claim.
+type Session struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;index"`
+	UserID   string `gorm:"column:user_id;index"`
+	// Device is the browser and system of the user agent, e.g. "Firefox on Linux"
+	Device    string    `gorm:"column:device"`
+	IP        string    `gorm:"column:ip"`
+	UserAgent string    `gorm:"column:user_agent"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	// LastUsedAt is the login or the last refresh of the tokens, with the IP and user agent of that request
+	LastUsedAt time.Time `gorm:"column:last_used_at"`
+	// ExpiresAt is the expiry of the last refresh token, the session ends with it
+	ExpiresAt time.Time `gorm:"column:expires_at"`
+}
+
+func (c *Session) TableName() string {
+	return "sessions"
+}
+
+// Expired tell whether the session has an expiry before now
+func (c *Session) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+func (c *Session) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+const (
+	TenantActive    = "active"
+	TenantSuspended = "suspended"
+)
+
+// Tenant is a client organization of the deployment, its users are isolated from the other tenants
+type Tenant struct {
+	ID          string     `gorm:"column:id;primary_key:true"`
+	Name        string     `gorm:"column:name"`
+	Slug        string     `gorm:"column:slug;unique_index"`
+	Status      string     `gorm:"column:status"`
+	SuspendedAt *time.Time `gorm:"column:suspended_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at"`
+}
+
+func (c *Tenant) TableName() string {
+	return "tenants"
+}
+
+func (c *Tenant) Active() bool {
+	return c.Status == TenantActive
+}
+
+func (c *Tenant) BeforeCreate(scope *gorm.Scope) error {
+	if c.Status == "" {
+		if err := scope.SetColumn("status", TenantActive); err != nil {
+			return err
+		}
+	}
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
@@ -3,26 +3,33 @@ package models
 import (
 	"github.com/google/uuid"
 	"github.com/jinzhu/gorm"
-	"github.com/labstack/gommon/log"
 	"time"
 )
 
 type User struct {
 	ID        string    `gorm:"column:id;primary_key:true"`
+	TenantID  string    `gorm:"column:tenant_id;unique_index:uix_users_tenant_email"`
 	Name      string    `gorm:"column:name"`
-	Email     string    `gorm:"unique;column:email"`
+	Email     string    `gorm:"column:email;unique_index:uix_users_tenant_email"`
 	Password  string    `gorm:"column:password"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at"`
+	// EmailVerifiedAt is when the user proved owning its email, nil until then
+	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at"`
 }
 
 func (c *User) TableName() string {
 	return "users"
 }
 
+// EmailVerified tell whether the user proved owning its email
+func (c *User) EmailVerified() bool {
+	return c.EmailVerifiedAt != nil
+}
+
 func (c *User) BeforeCreate(scope *gorm.Scope) error {
-	if err := scope.SetColumn("id", uuid.New().String()); err != nil {
-		log.Fatal("Error UUID Generate")
+	if c.ID != "" {
+		return nil
 	}
-	return nil
+	return scope.SetColumn("id", uuid.New().String())
 }
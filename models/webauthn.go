@@ -0,0 +1,73 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// WebAuthnCredential is a public key credential (passkey) of a user, it logs the user in without password
+type WebAuthnCredential struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;unique_index:idx_webauthn_credential"`
+	UserID   string `gorm:"column:user_id;index"`
+	// CredentialID is the base64url ID chosen by the authenticator
+	CredentialID string `gorm:"column:credential_id;unique_index:idx_webauthn_credential"`
+	// PublicKey is the COSE key of the credential, Algorithm its COSE algorithm
+	PublicKey []byte `gorm:"column:public_key"`
+	Algorithm int64  `gorm:"column:algorithm"`
+	// SignCount is the signature counter of the last assertion, it only grows on an authenticator not cloned
+	SignCount int64 `gorm:"column:sign_count"`
+	// Name given by the user, e.g. "MacBook"
+	Name string `gorm:"column:name"`
+	// AAGUID is the model of the authenticator, zero when the attestation is not conveyed
+	AAGUID     string     `gorm:"column:aaguid"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at"`
+}
+
+func (c *WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+func (c *WebAuthnCredential) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
+
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+)
+
+// WebAuthnSession is a registration or login ceremony begun, taken once by the response of the authenticator
+type WebAuthnSession struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;index"`
+	// Ceremony is WebAuthnRegistration or WebAuthnLogin
+	Ceremony      string `gorm:"column:ceremony"`
+	ChallengeHash string `gorm:"column:challenge_hash;unique_index"`
+	// UserID is the user registering a credential, or the user named by the login, empty for a passkey login
+	UserID    string    `gorm:"column:user_id"`
+	ExpiresAt time.Time `gorm:"column:expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (c *WebAuthnSession) TableName() string {
+	return "webauthn_sessions"
+}
+
+// Expired tell whether the session has an expiry before now
+func (c *WebAuthnSession) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+func (c *WebAuthnSession) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
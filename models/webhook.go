@@ -0,0 +1,87 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+// WebhookSubscription is an endpoint of a partner notified of the domain events of its tenant
+type WebhookSubscription struct {
+	ID       string `gorm:"column:id;primary_key:true"`
+	TenantID string `gorm:"column:tenant_id;index"`
+	URL      string `gorm:"column:url"`
+	// EventTypes separated by spaces, "*" subscribes to every event
+	EventTypes string `gorm:"column:event_types"`
+	// Secret signs the deliveries, the receiver checks the signature with it
+	Secret    string    `gorm:"column:secret"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (c *WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// EventTypeList return the event types of the subscription
+func (c *WebhookSubscription) EventTypeList() []string {
+	return strings.Fields(c.EventTypes)
+}
+
+// Subscribes tell whether the subscription is notified of the events of eventType
+func (c *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, subscribed := range c.EventTypeList() {
+		if subscribed == "*" || subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *WebhookSubscription) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
+
+// status of the webhook deliveries
+const (
+	WebhookPending   = "pending"
+	WebhookSucceeded = "succeeded"
+	WebhookFailed    = "failed"
+)
+
+// WebhookDelivery is an event sent to a subscription, it logs the outcome of the last attempt
+type WebhookDelivery struct {
+	ID             string `gorm:"column:id;primary_key:true"`
+	TenantID       string `gorm:"column:tenant_id;index"`
+	SubscriptionID string `gorm:"column:subscription_id;unique_index:idx_webhook_delivery_event"`
+	// EventID is the domain event delivered, once per subscription
+	EventID   string `gorm:"column:event_id;unique_index:idx_webhook_delivery_event"`
+	EventType string `gorm:"column:event_type"`
+	// Payload is the JSON body sent
+	Payload string `gorm:"column:payload;type:text"`
+	// Status is WebhookPending until the receiver answers a 2xx or the attempts run out
+	Status   string `gorm:"column:status;index"`
+	Attempts int    `gorm:"column:attempts"`
+	// ResponseCode of the last attempt, 0 when the receiver was not reached
+	ResponseCode  int        `gorm:"column:response_code"`
+	LastError     string     `gorm:"column:last_error;type:text"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at"`
+}
+
+func (c *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (c *WebhookDelivery) BeforeCreate(scope *gorm.Scope) error {
+	if c.ID != "" {
+		return nil
+	}
+	return scope.SetColumn("id", uuid.New().String())
+}
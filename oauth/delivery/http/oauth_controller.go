@@ -0,0 +1,193 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/jwk"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/oauth"
+	"go-echo-api/utils"
+	"net/http"
+	"net/url"
+)
+
+type oauthController struct {
+	oauthUsecase oauth.Usecase
+	clientMapper *oauth.ClientMapper
+	// issuer of the ID tokens, the URL of the module
+	issuer string
+}
+
+func NewOAuthController(s oauth.Usecase, issuer string) *oauthController {
+	return &oauthController{oauthUsecase: s,
+		clientMapper: oauth.NewClientMapper(),
+		issuer:       issuer,
+	}
+}
+
+func (c *oauthController) FindClients(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.oauthUsecase.FindClients(ctx.Request().Context(), middleware.UserID(ctx), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.clientMapper.MapList(result), nil)
+}
+
+func (c *oauthController) StoreClient(ctx echo.Context) error {
+	var dto oauth.ClientDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, secret, err := c.oauthUsecase.CreateClient(ctx.Request().Context(), middleware.UserID(ctx), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, oauth.NewClientCreatedMapper(result, secret), nil)
+}
+
+func (c *oauthController) DeleteClient(ctx echo.Context) error {
+	if err := c.oauthUsecase.DeleteClient(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+// Authorize grant a code to the client on behalf of the logged in user and redirect to the client with it,
+// calling it is the consent of the user. The errors are reported to the redirect uri once it is trusted.
+func (c *oauthController) Authorize(ctx echo.Context) error {
+	var dto oauth.AuthorizeDto
+	if err := ctx.Bind(&dto); err != nil {
+		return ctx.JSON(http.StatusBadRequest, oauth.NewErrorMapper(oauth.ErrInvalidRequest))
+	}
+	location, err := c.oauthUsecase.Authorize(ctx.Request().Context(), middleware.UserID(ctx), middleware.OrganizationID(ctx), dto)
+	if err == oauth.ErrUnknownClient || err == oauth.ErrInvalidRedirect {
+		return ctx.JSON(http.StatusBadRequest, oauth.NewErrorMapper(err.(*oauth.Error)))
+	}
+	if e, ok := err.(*oauth.Error); ok {
+		location = oauth.Redirect(dto.RedirectURI, url.Values{"error": {e.Code}, "error_description": {e.Description}, "state": {dto.State}})
+	} else if err != nil {
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("oauth authorize failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+	return ctx.Redirect(http.StatusFound, location)
+}
+
+func (c *oauthController) Token(ctx echo.Context) error {
+	var dto oauth.TokenDto
+	if err := ctx.Bind(&dto); err != nil {
+		return protocolError(ctx, oauth.ErrInvalidRequest)
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return protocolError(ctx, oauth.ErrInvalidRequest)
+	}
+	result, err := c.oauthUsecase.Token(ctx.Request().Context(), c.issuer, credentials(ctx, dto.ClientID, dto.ClientSecret), dto)
+	if err != nil {
+		return protocolError(ctx, err)
+	}
+	ctx.Response().Header().Set("Cache-Control", "no-store")
+	ctx.Response().Header().Set("Pragma", "no-cache")
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (c *oauthController) Introspect(ctx echo.Context) error {
+	var dto oauth.TokenRequestDto
+	if err := ctx.Bind(&dto); err != nil {
+		return protocolError(ctx, oauth.ErrInvalidRequest)
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return protocolError(ctx, oauth.ErrInvalidRequest)
+	}
+	result, err := c.oauthUsecase.Introspect(ctx.Request().Context(), credentials(ctx, dto.ClientID, dto.ClientSecret), dto.Token)
+	if err != nil {
+		return protocolError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (c *oauthController) Revoke(ctx echo.Context) error {
+	var dto oauth.TokenRequestDto
+	if err := ctx.Bind(&dto); err != nil {
+		return protocolError(ctx, oauth.ErrInvalidRequest)
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return protocolError(ctx, oauth.ErrInvalidRequest)
+	}
+	if err := c.oauthUsecase.Revoke(ctx.Request().Context(), credentials(ctx, dto.ClientID, dto.ClientSecret), dto.Token); err != nil {
+		return protocolError(ctx, err)
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Discovery describe the OpenID Connect provider, the issuer is the URL of the module
+func (c *oauthController) Discovery(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, oauth.NewDiscoveryMapper(c.issuer, jwk.Algorithm))
+}
+
+// JWKS publish the keys verifying the ID tokens
+func (c *oauthController) JWKS(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, c.oauthUsecase.Keys())
+}
+
+// UserInfo return the claims of the user of the access token, within the scopes granted to the client
+func (c *oauthController) UserInfo(ctx echo.Context) error {
+	scope, _ := middleware.Claims(ctx)["scope"].(string)
+	result, err := c.oauthUsecase.UserInfo(ctx.Request().Context(), middleware.UserID(ctx), scope)
+	if err == oauth.ErrInvalidToken {
+		ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		return response.Unauthorized(ctx, utils.Unauthorized, nil, err.Error())
+	}
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// credentials return the client credentials of the basic authorization, or those of the form without it
+func credentials(ctx echo.Context, clientID string, clientSecret string) oauth.Credentials {
+	id, secret, ok := ctx.Request().BasicAuth()
+	if !ok {
+		return oauth.Credentials{ClientID: clientID, ClientSecret: clientSecret}
+	}
+	// RFC 6749 form encodes the credentials before the basic encoding
+	if unescaped, err := url.QueryUnescape(id); err == nil {
+		id = unescaped
+	}
+	if unescaped, err := url.QueryUnescape(secret); err == nil {
+		secret = unescaped
+	}
+	return oauth.Credentials{ClientID: id, ClientSecret: secret}
+}
+
+// errorResponse map the errors of the client management to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case oauth.ErrNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	case oauth.ErrRedirectRequired, oauth.ErrPublicGrant:
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("oauth use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
+
+// protocolError answer the errors of the token, introspection and revocation endpoints the way
+// RFC 6749 section 5.2 does, without the envelope of the API
+func protocolError(ctx echo.Context, err error) error {
+	e, ok := err.(*oauth.Error)
+	if !ok {
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("oauth use-case failed")
+		return ctx.JSON(http.StatusInternalServerError, oauth.ErrorMapper{Error: "server_error"})
+	}
+	if e.Code == oauth.ErrInvalidClient.Code {
+		ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+		return ctx.JSON(http.StatusUnauthorized, oauth.NewErrorMapper(e))
+	}
+	return ctx.JSON(http.StatusBadRequest, oauth.NewErrorMapper(e))
+}
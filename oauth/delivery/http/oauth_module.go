@@ -0,0 +1,67 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/jwk"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/middleware"
+	"go-echo-api/oauth"
+	"go-echo-api/oauth/repository"
+	"go-echo-api/oauth/usecase"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/sink"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+)
+
+// Module wire the authorization server and OpenID Connect provider to the database and register its
+// routes under /oauth, the issuer of the ID tokens. The clients are managed and the codes granted with the
+// access token of the user, the clients authenticate themselves on the token, introspection and revocation endpoints.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/oauth"
+}
+
+// wellKnown is the path of the OpenID Connect discovery document under the issuer
+const wellKnown = "/.well-known/openid-configuration"
+
+// Subscribe delete the OAuth clients and grants of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewOAuthRepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	keys, err := jwk.Default()
+	if err != nil {
+		logger.Default().WithError(err).Fatal("load the key signing the ID tokens")
+	}
+	issuer, err := usecase.IssuerFromEnv()
+	if err != nil {
+		logger.Default().WithError(err).Fatal("configure the issuer of the ID tokens")
+	}
+	controller := NewOAuthController(usecase.NewOAuthService(repository.NewOAuthRepository(m.db), userRepository.NewUserRepository(m.db), middleware.JWTSigner{}, keys), issuer)
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	loggedIn := middleware.IsLoggedIn(sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL))
+	g.GET("/clients", controller.FindClients, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/clients", controller.StoreClient, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.DELETE("/clients/:id", controller.DeleteClient, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.GET("/authorize", controller.Authorize, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/token", controller.Token, tenantScope)
+	g.POST("/introspect", controller.Introspect, tenantScope)
+	g.POST("/revoke", controller.Revoke, tenantScope)
+	// OpenID Connect
+	g.GET(wellKnown, controller.Discovery)
+	g.GET("/jwks", controller.JWKS)
+	g.GET("/userinfo", controller.UserInfo, tenantScope, loggedIn, middleware.RequireScope(oauth.ScopeOpenID))
+	g.POST("/userinfo", controller.UserInfo, tenantScope, loggedIn, middleware.RequireScope(oauth.ScopeOpenID))
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/jwk"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/oauth"
+	"strings"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix, the endpoints of the
+// protocol answer the bodies of RFC 6749, 7662 and 7009 without the envelope of the API
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+	unauthorized := g.Error("Missing or invalid access token")
+	scoped := g.Error("Token issued to an OAuth client")
+	protocolError := g.Plain("OAuth error", oauth.ErrorMapper{})
+
+	g.Add(echo.GET, "/clients", openapi.Operation{
+		Tags:        []string{"oauth"},
+		Summary:     "List the OAuth clients of the logged in user, without their secrets",
+		OperationID: "listOAuthClients",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of clients skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of OAuth clients", oauth.ClientMapper{}),
+			"401": unauthorized,
+			"403": scoped,
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/clients", openapi.Operation{
+		Tags:        []string{"oauth"},
+		Summary:     "Register an OAuth client, the secret of a confidential client is in this response only",
+		OperationID: "createOAuthClient",
+		RequestBody: g.Body(oauth.ClientDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Registered client with its secret", oauth.ClientCreatedMapper{}),
+			"401": unauthorized,
+			"403": scoped,
+			"422": g.Error("Invalid body, or grant types not allowed for the client"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.DELETE, "/clients/:id", openapi.Operation{
+		Tags:        []string{"oauth"},
+		Summary:     "Delete an OAuth client of the logged in user with its codes and refresh tokens",
+		OperationID: "deleteOAuthClient",
+		Parameters:  []openapi.Parameter{openapi.PathParam("id", "ID of the client")},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Client deleted", nil),
+			"401": unauthorized,
+			"403": scoped,
+			"404": g.Error("Client not found"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.GET, "/authorize", openapi.Operation{
+		Tags:        []string{"oauth"},
+		Summary:     "Grant an authorization code to a client on behalf of the logged in user, PKCE with S256 is required",
+		OperationID: "authorize",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("response_type", "Must be code", &openapi.Schema{Type: "string", Enum: []interface{}{oauth.ResponseTypeCode}}),
+			openapi.QueryParam("client_id", "ID of the client", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("redirect_uri", "One of the redirect uris of the client", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("scope", "Scopes separated by spaces, default all the scopes of the client", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("state", "Value sent back to the redirect uri", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("code_challenge", "PKCE challenge, the base64url SHA-256 of the code verifier", &openapi.Schema{Type: "string"}),
+			openapi.QueryParam("code_challenge_method", "Must be S256", &openapi.Schema{Type: "string"}),
+		},
+		Responses: map[string]openapi.Response{
+			"302": {Description: "Redirect to the redirect uri with the code, or with the error once the redirect uri is trusted"},
+			"400": g.Plain("Client unknown or redirect uri not registered", oauth.ErrorMapper{}),
+			"401": unauthorized,
+			"403": scoped,
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/token", openapi.Operation{
+		Tags:        []string{"oauth"},
+		Summary:     "Exchange an authorization code, a refresh token or the client credentials for an access token",
+		OperationID: "oauthToken",
+		RequestBody: g.FormBody(oauth.TokenDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Plain("Access token, the refresh token replaces the one used", oauth.TokenMapper{}),
+			"400": protocolError,
+			"401": g.Plain("Client authentication failed", oauth.ErrorMapper{}),
+		},
+		Security: openapi.ClientBasic,
+	})
+	g.Add(echo.POST, "/introspect", openapi.Operation{
+		Tags:        []string{"oauth"},
+		Summary:     "Describe a token issued to a client, for a confidential client",
+		OperationID: "oauthIntrospect",
+		RequestBody: g.FormBody(oauth.TokenRequestDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Plain("Token description, inactive when unknown, expired or not issued to a client", oauth.IntrospectionMapper{}),
+			"400": protocolError,
+			"401": g.Plain("Client authentication failed", oauth.ErrorMapper{}),
+		},
+		Security: openapi.ClientBasic,
+	})
+	g.Add(echo.POST, "/revoke", openapi.Operation{
+		Tags:        []string{"oauth"},
+		Summary:     "Revoke a refresh token of the client",
+		OperationID: "oauthRevoke",
+		RequestBody: g.FormBody(oauth.TokenRequestDto{}),
+		Responses: map[string]openapi.Response{
+			"200": {Description: "Token revoked, or unknown"},
+			"400": g.Plain("Access tokens cannot be revoked", oauth.ErrorMapper{}),
+			"401": g.Plain("Client authentication failed", oauth.ErrorMapper{}),
+		},
+		Security: openapi.ClientBasic,
+	})
+	g.Add(echo.GET, wellKnown, openapi.Operation{
+		Tags:        []string{"oidc"},
+		Summary:     "OpenID Connect discovery document of the provider",
+		OperationID: "oidcDiscovery",
+		Responses: map[string]openapi.Response{
+			"200": g.Plain("Provider metadata", oauth.DiscoveryMapper{}),
+		},
+	})
+	g.Add(echo.GET, "/jwks", openapi.Operation{
+		Tags:        []string{"oidc"},
+		Summary:     "Key set verifying the ID tokens",
+		OperationID: "oidcKeys",
+		Responses: map[string]openapi.Response{
+			"200": g.Plain("JSON Web Key Set", jwk.Set{}),
+		},
+	})
+	for _, method := range []string{echo.GET, echo.POST} {
+		g.Add(method, "/userinfo", openapi.Operation{
+			Tags:        []string{"oidc"},
+			Summary:     "Claims of the user of the access token, within the profile and email scopes granted to the client",
+			OperationID: "oidcUserInfo" + strings.Title(strings.ToLower(method)),
+			Responses: map[string]openapi.Response{
+				"200": g.Plain("Claims of the user", oauth.UserInfoMapper{}),
+				"401": g.Error("Missing or invalid access token, or access token without user"),
+				"403": g.Error("Access token without the openid scope"),
+			},
+			Security: openapi.BearerAuth,
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package oauth
+
+// ClientDto register an OAuth client of the logged in user
+type ClientDto struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// RedirectURIs the codes are sent to, required by the authorization_code grant
+	RedirectURIs []string `json:"redirect_uris,omitempty" validate:"omitempty,dive,url"`
+	GrantTypes   []string `json:"grant_types" validate:"required,min=1,dive,oneof=authorization_code refresh_token client_credentials"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,oneof=users:read users:write orgs:read orgs:write openid profile email"`
+	// Confidential clients get a secret, public clients authenticate with PKCE alone
+	Confidential bool `json:"confidential"`
+}
+
+// AuthorizeDto is the authorization request of RFC 6749 with the PKCE parameters of RFC 7636,
+// its errors are reported to the redirect uri so it has no validation rules
+type AuthorizeDto struct {
+	ResponseType        string `query:"response_type" json:"response_type"`
+	ClientID            string `query:"client_id" json:"client_id"`
+	RedirectURI         string `query:"redirect_uri" json:"redirect_uri"`
+	Scope               string `query:"scope" json:"scope"`
+	State               string `query:"state" json:"state"`
+	CodeChallenge       string `query:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method" json:"code_challenge_method"`
+	// Nonce of OpenID Connect, copied to the ID token
+	Nonce string `query:"nonce" json:"nonce"`
+}
+
+// TokenDto is the form of the token request of every grant, the client credentials
+// are read from it when the request has no basic authorization
+type TokenDto struct {
+	GrantType    string `form:"grant_type" json:"grant_type" validate:"required"`
+	Code         string `form:"code" json:"code,omitempty"`
+	RedirectURI  string `form:"redirect_uri" json:"redirect_uri,omitempty"`
+	CodeVerifier string `form:"code_verifier" json:"code_verifier,omitempty"`
+	RefreshToken string `form:"refresh_token" json:"refresh_token,omitempty"`
+	Scope        string `form:"scope" json:"scope,omitempty"`
+	ClientID     string `form:"client_id" json:"client_id,omitempty"`
+	ClientSecret string `form:"client_secret" json:"client_secret,omitempty"`
+}
+
+// TokenRequestDto is the form of the introspection (RFC 7662) and revocation (RFC 7009) requests
+type TokenRequestDto struct {
+	Token         string `form:"token" json:"token" validate:"required"`
+	TokenTypeHint string `form:"token_type_hint" json:"token_type_hint,omitempty"`
+	ClientID      string `form:"client_id" json:"client_id,omitempty"`
+	ClientSecret  string `form:"client_secret" json:"client_secret,omitempty"`
+}
+
+// Credentials of the client calling the token, introspection and revocation endpoints
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+}
@@ -0,0 +1,36 @@
+package oauth
+
+import "errors"
+
+// Error is an error of the OAuth 2.0 protocol, Code is one of the error codes of RFC 6749
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+var (
+	ErrNotFound         = errors.New("oauth client not found")
+	ErrRedirectRequired = errors.New("the authorization_code grant requires a redirect uri")
+	ErrPublicGrant      = errors.New("a public client cannot use the client_credentials grant")
+
+	// ErrUnknownClient and ErrInvalidRedirect are not reported to the redirect uri of the authorization request
+	ErrUnknownClient   = &Error{Code: "invalid_client", Description: "client not found"}
+	ErrInvalidRedirect = &Error{Code: "invalid_request", Description: "redirect_uri not registered for the client"}
+
+	ErrInvalidRequest          = &Error{Code: "invalid_request", Description: "a parameter is missing or malformed"}
+	ErrInvalidClient           = &Error{Code: "invalid_client", Description: "client authentication failed"}
+	ErrUnsupportedResponseType = &Error{Code: "unsupported_response_type", Description: "response_type must be code"}
+	ErrPKCERequired            = &Error{Code: "invalid_request", Description: "code_challenge with code_challenge_method S256 is required"}
+	ErrInvalidScope            = &Error{Code: "invalid_scope", Description: "scope not allowed for the client"}
+	ErrUnauthorizedClient      = &Error{Code: "unauthorized_client", Description: "grant type not allowed for the client"}
+	ErrUnsupportedGrantType    = &Error{Code: "unsupported_grant_type", Description: "grant type not supported"}
+	ErrInvalidGrant            = &Error{Code: "invalid_grant", Description: "code or refresh token not valid, expired or issued to another client"}
+	ErrInvalidVerifier         = &Error{Code: "invalid_grant", Description: "code_verifier does not match the code_challenge"}
+	// ErrInvalidToken is the error of RFC 6750 for an access token without user at the UserInfo endpoint
+	ErrInvalidToken         = &Error{Code: "invalid_token", Description: "access token not issued for a user"}
+	ErrUnsupportedTokenType = &Error{Code: "unsupported_token_type", Description: "only the refresh tokens can be revoked, the access tokens expire"}
+)
@@ -0,0 +1,42 @@
+package oauth
+
+import "net/url"
+
+// Grant types and response type of RFC 6749 the authorization server supports
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+	ResponseTypeCode       = "code"
+	// ChallengeS256 is the only PKCE method accepted, the plain method exposes the verifier
+	ChallengeS256   = "S256"
+	TokenTypeBearer = "Bearer"
+	// Token type hints of RFC 7009
+	HintAccessToken  = "access_token"
+	HintRefreshToken = "refresh_token"
+)
+
+// Scopes of OpenID Connect, openid asks for an ID token and profile and email for the claims of the user
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// Redirect return uri with params added to its query, the empty params are left out
+func Redirect(uri string, params url.Values) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := parsed.Query()
+	for key, values := range params {
+		for _, value := range values {
+			if value != "" {
+				query.Add(key, value)
+			}
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
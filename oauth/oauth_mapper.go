@@ -0,0 +1,131 @@
+package oauth
+
+import (
+	"go-echo-api/models"
+	"time"
+)
+
+type ClientMapper struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Confidential bool      `json:"confidential"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	GrantTypes   []string  `json:"grant_types"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func NewClientMapper() *ClientMapper {
+	return &ClientMapper{}
+}
+
+func (m *ClientMapper) Map(model models.OAuthClient) *ClientMapper {
+	m.ID = model.ID
+	m.Name = model.Name
+	m.Confidential = !model.Public()
+	m.RedirectURIs = append([]string{}, model.RedirectURIList()...)
+	m.GrantTypes = append([]string{}, model.GrantTypeList()...)
+	m.Scopes = append([]string{}, model.ScopeList()...)
+	m.CreatedAt = model.CreatedAt
+	return m
+}
+
+func (m *ClientMapper) MapList(model []models.OAuthClient) interface{} {
+	serialized := make([]ClientMapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&ClientMapper{}).Map(v)
+	}
+	return serialized
+}
+
+// ClientCreatedMapper is the only response holding the secret of a confidential client
+type ClientCreatedMapper struct {
+	ClientMapper
+	Secret string `json:"secret,omitempty"`
+}
+
+func NewClientCreatedMapper(model models.OAuthClient, secret string) *ClientCreatedMapper {
+	return &ClientCreatedMapper{ClientMapper: *NewClientMapper().Map(model), Secret: secret}
+}
+
+// TokenMapper is the successful token response of RFC 6749 section 5.1, it is not wrapped in the envelope
+type TokenMapper struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+	// IDToken of OpenID Connect when the openid scope is granted
+	IDToken string `json:"id_token,omitempty"`
+}
+
+// IntrospectionMapper is the introspection response of RFC 7662, an inactive token only has Active
+type IntrospectionMapper struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+// ErrorMapper is the error response of RFC 6749 section 5.2
+type ErrorMapper struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+func NewErrorMapper(err *Error) ErrorMapper {
+	return ErrorMapper{Error: err.Code, ErrorDescription: err.Description}
+}
+
+// UserInfoMapper is the UserInfo response of OpenID Connect, the claims are those of the granted scopes
+type UserInfoMapper struct {
+	Sub           string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// DiscoveryMapper is the provider metadata of OpenID Connect Discovery
+type DiscoveryMapper struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// NewDiscoveryMapper describe the provider of issuer, the URL of the module, signing the ID tokens with algorithm
+func NewDiscoveryMapper(issuer string, algorithm string) DiscoveryMapper {
+	return DiscoveryMapper{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/authorize",
+		TokenEndpoint:                     issuer + "/token",
+		UserinfoEndpoint:                  issuer + "/userinfo",
+		JwksURI:                           issuer + "/jwks",
+		IntrospectionEndpoint:             issuer + "/introspect",
+		RevocationEndpoint:                issuer + "/revoke",
+		ScopesSupported:                   []string{ScopeOpenID, ScopeProfile, ScopeEmail, "users:read", "users:write", "orgs:read", "orgs:write"},
+		ResponseTypesSupported:            []string{ResponseTypeCode},
+		GrantTypesSupported:               []string{GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{algorithm},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{ChallengeS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "nonce", "name", "email", "email_verified"},
+	}
+}
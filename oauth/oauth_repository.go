@@ -0,0 +1,28 @@
+package oauth
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+// Repository of the OAuth clients and of their grants in the tenant of the context
+type Repository interface {
+	FindClients(ctx context.Context, userID string, limit int64, offset int64) ([]models.OAuthClient, int64, error)
+	FindClient(ctx context.Context, id string) (*models.OAuthClient, error)
+	StoreClient(ctx context.Context, model *models.OAuthClient) error
+	// DeleteClient delete the client of userID with its codes and refresh tokens
+	DeleteClient(ctx context.Context, userID string, id string) error
+
+	StoreCode(ctx context.Context, model *models.OAuthCode) error
+	// TakeCode delete and return the code of hash, a code is exchanged once. ErrInvalidGrant when it is unknown.
+	TakeCode(ctx context.Context, hash string) (*models.OAuthCode, error)
+
+	StoreRefreshToken(ctx context.Context, model *models.OAuthRefreshToken) error
+	// FindRefreshToken return the refresh token of hash, ErrInvalidGrant when it is unknown
+	FindRefreshToken(ctx context.Context, hash string) (*models.OAuthRefreshToken, error)
+	// DeleteRefreshToken delete the refresh token, ErrInvalidGrant when it was already deleted
+	DeleteRefreshToken(ctx context.Context, id string) error
+	// DeleteByUser delete the clients the user manages and the grants of the user and of those clients,
+	// once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,43 @@
+package oauth
+
+import (
+	"context"
+	"go-echo-api/infrastructure/jwk"
+	"go-echo-api/models"
+)
+
+// Signer sign and verify the access tokens, middleware.JWTSigner signs them like the tokens of the users
+// so that the routes accept both
+type Signer interface {
+	Sign(ctx context.Context, claims map[string]interface{}) (string, error)
+	Verify(token string) (map[string]interface{}, error)
+}
+
+// KeySigner sign the ID tokens with an asymmetric key the clients verify with its key set, e.g. *jwk.Key
+type KeySigner interface {
+	Sign(ctx context.Context, claims map[string]interface{}) (string, error)
+	Set() jwk.Set
+}
+
+type Usecase interface {
+	FindClients(ctx context.Context, userID string, limit int64, offset int64) ([]models.OAuthClient, int64, error)
+	// CreateClient return the client and the only clear copy of its secret, empty for a public client
+	CreateClient(ctx context.Context, userID string, dto ClientDto) (models.OAuthClient, string, error)
+	DeleteClient(ctx context.Context, userID string, id string) error
+
+	// Authorize grant the request of the client on behalf of the user and return the redirect uri
+	// with the code. ErrUnknownClient and ErrInvalidRedirect must not be redirected, the other *Error are
+	// reported to the redirect uri of the request.
+	Authorize(ctx context.Context, userID string, organizationID string, dto AuthorizeDto) (string, error)
+	// Token exchange a grant of the client for an access token, and an ID token of issuer when the openid scope is granted
+	Token(ctx context.Context, issuer string, client Credentials, dto TokenDto) (TokenMapper, error)
+	// Introspect describe a token to a confidential client
+	Introspect(ctx context.Context, client Credentials, token string) (IntrospectionMapper, error)
+	// Revoke a refresh token of the client, an unknown token is not an error
+	Revoke(ctx context.Context, client Credentials, token string) error
+
+	// UserInfo return the claims of the user the granted scope allows, an empty scope allows all of them
+	UserInfo(ctx context.Context, userID string, scope string) (UserInfoMapper, error)
+	// Keys return the key set verifying the ID tokens
+	Keys() jwk.Set
+}
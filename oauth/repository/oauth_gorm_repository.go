@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/oauth"
+)
+
+type oauthGormRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthRepository(db *gorm.DB) oauth.Repository {
+	return &oauthGormRepository{db: db}
+}
+
+func (r *oauthGormRepository) FindClients(ctx context.Context, userID string, limit int64, offset int64) ([]models.OAuthClient, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.OAuthClient{}).Where("user_id = ?", userID)
+	var model []models.OAuthClient
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("created_at").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *oauthGormRepository) FindClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.OAuthClient
+	err = db.Where("id = ?", id).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, oauth.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *oauthGormRepository) StoreClient(ctx context.Context, model *models.OAuthClient) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *oauthGormRepository) DeleteClient(ctx context.Context, userID string, id string) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND id = ?", userID, id).Delete(&models.OAuthClient{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return oauth.ErrNotFound
+		}
+		if err := tx.New().Where("tenant_id = ? AND client_id = ?", tenantID, id).Delete(&models.OAuthCode{}).Error; err != nil {
+			return err
+		}
+		return tx.New().Where("tenant_id = ? AND client_id = ?", tenantID, id).Delete(&models.OAuthRefreshToken{}).Error
+	})
+}
+
+func (r *oauthGormRepository) StoreCode(ctx context.Context, model *models.OAuthCode) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *oauthGormRepository) TakeCode(ctx context.Context, hash string) (*models.OAuthCode, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.OAuthCode
+	err = db.Where("code_hash = ?", hash).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, oauth.ErrInvalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+	// of two concurrent exchanges of the code only the one deleting it wins
+	result := db.Where("id = ?", model.ID).Delete(&models.OAuthCode{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, oauth.ErrInvalidGrant
+	}
+	return &model, nil
+}
+
+func (r *oauthGormRepository) StoreRefreshToken(ctx context.Context, model *models.OAuthRefreshToken) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *oauthGormRepository) FindRefreshToken(ctx context.Context, hash string) (*models.OAuthRefreshToken, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.OAuthRefreshToken
+	err = db.Where("token_hash = ?", hash).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, oauth.ErrInvalidGrant
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *oauthGormRepository) DeleteRefreshToken(ctx context.Context, id string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Where("id = ?", id).Delete(&models.OAuthRefreshToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return oauth.ErrInvalidGrant
+	}
+	return nil
+}
+
+func (r *oauthGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		clients := tx.New().Model(&models.OAuthClient{}).Select("id").Where("tenant_id = ? AND user_id = ?", tenantID, userID).SubQuery()
+		for _, grant := range []interface{}{&models.OAuthCode{}, &models.OAuthRefreshToken{}} {
+			if err := tx.New().Where("tenant_id = ? AND (user_id = ? OR client_id IN ?)", tenantID, userID, clients).Delete(grant).Error; err != nil {
+				return err
+			}
+		}
+		return tx.New().Where("tenant_id = ? AND user_id = ?", tenantID, userID).Delete(&models.OAuthClient{}).Error
+	})
+}
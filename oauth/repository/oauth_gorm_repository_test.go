@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/oauth"
+	"testing"
+	"time"
+)
+
+func TestOAuthGormRepository_Client(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewOAuthRepository(db)
+	model := models.OAuthClient{UserID: dbtest.UserUje.ID, Name: "Dashboard", GrantTypes: oauth.GrantClientCredentials, Scopes: "users:read"}
+	assert.NoError(t, r.StoreClient(dbtest.Context(), &model))
+	assert.Equal(t, dbtest.TenantAcme.ID, model.TenantID)
+
+	found, err := r.FindClient(dbtest.Context(), model.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"users:read"}, found.ScopeList())
+	_, total, err := r.FindClients(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// the clients of a tenant are not seen from another
+	_, err = r.FindClient(dbtest.TenantContext(dbtest.TenantGlobex), model.ID)
+	assert.Equal(t, oauth.ErrNotFound, err)
+
+	// the grants of the client go with it
+	token := models.OAuthRefreshToken{ClientID: model.ID, UserID: dbtest.UserUje.ID, TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, r.StoreRefreshToken(dbtest.Context(), &token))
+	assert.Equal(t, oauth.ErrNotFound, r.DeleteClient(dbtest.Context(), dbtest.UserIpan.ID, model.ID))
+	assert.NoError(t, r.DeleteClient(dbtest.Context(), dbtest.UserUje.ID, model.ID))
+	_, err = r.FindRefreshToken(dbtest.Context(), "hash")
+	assert.Equal(t, oauth.ErrInvalidGrant, err)
+}
+
+func TestOAuthGormRepository_Grants(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewOAuthRepository(db)
+	code := models.OAuthCode{ClientID: "client", UserID: dbtest.UserIpan.ID, CodeHash: "code", ExpiresAt: time.Now().Add(time.Minute)}
+	assert.NoError(t, r.StoreCode(dbtest.Context(), &code))
+
+	// a code is taken once
+	taken, err := r.TakeCode(dbtest.Context(), "code")
+	assert.NoError(t, err)
+	assert.Equal(t, code.ID, taken.ID)
+	_, err = r.TakeCode(dbtest.Context(), "code")
+	assert.Equal(t, oauth.ErrInvalidGrant, err)
+
+	token := models.OAuthRefreshToken{ClientID: "client", UserID: dbtest.UserIpan.ID, TokenHash: "refresh", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, r.StoreRefreshToken(dbtest.Context(), &token))
+	_, err = r.FindRefreshToken(dbtest.TenantContext(dbtest.TenantGlobex), "refresh")
+	assert.Equal(t, oauth.ErrInvalidGrant, err)
+	assert.NoError(t, r.DeleteRefreshToken(dbtest.Context(), token.ID))
+	assert.Equal(t, oauth.ErrInvalidGrant, r.DeleteRefreshToken(dbtest.Context(), token.ID))
+}
+
+func TestOAuthGormRepository_DeleteByUser(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	// a client of Ipan and a refresh token Uje granted to it
+	client := models.OAuthClient{ID: "client", TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID, Name: "Dashboard"}
+	token := models.OAuthRefreshToken{TenantID: dbtest.TenantAcme.ID, ClientID: client.ID, UserID: dbtest.UserUje.ID, TokenHash: "hash"}
+	assert.NoError(t, dbtest.LoadFixtures(db, &client, &token))
+
+	r := NewOAuthRepository(db)
+	assert.NoError(t, r.DeleteByUser(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.OAuthClient{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Model(&models.OAuthRefreshToken{}).Where("client_id = ?", client.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/models"
+	"go-echo-api/oauth"
+	"go-echo-api/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// oauthMemoryRepository keeps the OAuth clients and their grants in memory, it backs the use-case unit tests
+type oauthMemoryRepository struct {
+	mu      sync.RWMutex
+	clients map[string]models.OAuthClient
+	codes   map[string]models.OAuthCode
+	tokens  map[string]models.OAuthRefreshToken
+}
+
+func NewOAuthMemoryRepository(clients ...models.OAuthClient) oauth.Repository {
+	r := &oauthMemoryRepository{
+		clients: make(map[string]models.OAuthClient),
+		codes:   make(map[string]models.OAuthCode),
+		tokens:  make(map[string]models.OAuthRefreshToken),
+	}
+	for _, c := range clients {
+		r.clients[c.ID] = c
+	}
+	return r
+}
+
+func (r *oauthMemoryRepository) FindClients(ctx context.Context, userID string, limit int64, offset int64) ([]models.OAuthClient, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []models.OAuthClient
+	for _, c := range r.clients {
+		if c.TenantID == tenantID && c.UserID == userID {
+			all = append(all, c)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	total := int64(len(all))
+	if offset >= total {
+		return []models.OAuthClient{}, total, nil
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *oauthMemoryRepository) FindClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := r.clients[id]
+	if !ok || c.TenantID != tenantID {
+		return nil, oauth.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (r *oauthMemoryRepository) StoreClient(ctx context.Context, model *models.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.clients[model.ID] = *model
+	return nil
+}
+
+func (r *oauthMemoryRepository) DeleteClient(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.clients[id]; !ok || existing.TenantID != tenantID || existing.UserID != userID {
+		return oauth.ErrNotFound
+	}
+	delete(r.clients, id)
+	for hash, c := range r.codes {
+		if c.ClientID == id {
+			delete(r.codes, hash)
+		}
+	}
+	for hash, t := range r.tokens {
+		if t.ClientID == id {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
+
+func (r *oauthMemoryRepository) StoreCode(ctx context.Context, model *models.OAuthCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	r.codes[model.CodeHash] = *model
+	return nil
+}
+
+func (r *oauthMemoryRepository) TakeCode(ctx context.Context, hash string) (*models.OAuthCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := r.codes[hash]
+	if !ok || c.TenantID != tenantID {
+		return nil, oauth.ErrInvalidGrant
+	}
+	delete(r.codes, hash)
+	return &c, nil
+}
+
+func (r *oauthMemoryRepository) StoreRefreshToken(ctx context.Context, model *models.OAuthRefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	r.tokens[model.TokenHash] = *model
+	return nil
+}
+
+func (r *oauthMemoryRepository) FindRefreshToken(ctx context.Context, hash string) (*models.OAuthRefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := r.tokens[hash]
+	if !ok || t.TenantID != tenantID {
+		return nil, oauth.ErrInvalidGrant
+	}
+	return &t, nil
+}
+
+func (r *oauthMemoryRepository) DeleteRefreshToken(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for hash, t := range r.tokens {
+		if t.ID == id && t.TenantID == tenantID {
+			delete(r.tokens, hash)
+			return nil
+		}
+	}
+	return oauth.ErrInvalidGrant
+}
+
+func (r *oauthMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	managed := make(map[string]bool)
+	for id, c := range r.clients {
+		if c.TenantID == tenantID && c.UserID == userID {
+			managed[id] = true
+			delete(r.clients, id)
+		}
+	}
+	for hash, c := range r.codes {
+		if c.TenantID == tenantID && (c.UserID == userID || managed[c.ClientID]) {
+			delete(r.codes, hash)
+		}
+	}
+	for hash, t := range r.tokens {
+		if t.TenantID == tenantID && (t.UserID == userID || managed[t.ClientID]) {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
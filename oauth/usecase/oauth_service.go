@@ -0,0 +1,544 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"go-echo-api/infrastructure/jwk"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/models"
+	"go-echo-api/oauth"
+	"go-echo-api/tenant"
+	"go-echo-api/user"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAccessTTL is the lifetime of the access tokens when APP_OAUTH_ACCESS_TTL is not set,
+	// they cannot be revoked so it is kept short
+	DefaultAccessTTL = time.Hour
+	// DefaultRefreshTTL is the lifetime of the refresh tokens when APP_OAUTH_REFRESH_TTL is not set
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+	// CodeTTL is the lifetime of the authorization codes
+	CodeTTL = 10 * time.Minute
+)
+
+type OAuthService struct {
+	oauthRepository oauth.Repository
+	userRepository  user.Repository
+	signer          oauth.Signer
+	keys            oauth.KeySigner
+	accessTTL       time.Duration
+	refreshTTL      time.Duration
+}
+
+// NewOAuthService return the use-case of the authorization server, signer signs the access tokens and keys
+// the ID tokens. The access and ID tokens live for APP_OAUTH_ACCESS_TTL, the refresh tokens for APP_OAUTH_REFRESH_TTL.
+func NewOAuthService(r oauth.Repository, users user.Repository, signer oauth.Signer, keys oauth.KeySigner) oauth.Usecase {
+	return OAuthService{
+		oauthRepository: r,
+		userRepository:  users,
+		signer:          signer,
+		keys:            keys,
+		accessTTL:       ttl("APP_OAUTH_ACCESS_TTL", DefaultAccessTTL),
+		refreshTTL:      ttl("APP_OAUTH_REFRESH_TTL", DefaultRefreshTTL),
+	}
+}
+
+func (s OAuthService) FindClients(ctx context.Context, userID string, limit int64, offset int64) ([]models.OAuthClient, int64, error) {
+	return s.oauthRepository.FindClients(ctx, userID, limit, offset)
+}
+
+func (s OAuthService) CreateClient(ctx context.Context, userID string, dto oauth.ClientDto) (models.OAuthClient, string, error) {
+	if contains(dto.GrantTypes, oauth.GrantAuthorizationCode) && len(dto.RedirectURIs) == 0 {
+		return models.OAuthClient{}, "", oauth.ErrRedirectRequired
+	}
+	if !dto.Confidential && contains(dto.GrantTypes, oauth.GrantClientCredentials) {
+		return models.OAuthClient{}, "", oauth.ErrPublicGrant
+	}
+	model := models.OAuthClient{
+		UserID:       userID,
+		Name:         dto.Name,
+		RedirectURIs: strings.Join(dto.RedirectURIs, " "),
+		GrantTypes:   strings.Join(dto.GrantTypes, " "),
+		Scopes:       strings.Join(dto.Scopes, " "),
+	}
+	var secret string
+	if dto.Confidential {
+		var err error
+		if secret, err = random(32); err != nil {
+			return model, "", err
+		}
+		model.SecretHash = hash(secret)
+	}
+	if err := s.oauthRepository.StoreClient(ctx, &model); err != nil {
+		return model, "", err
+	}
+	logger.FromContext(ctx).WithField(logger.OAuthClientIDField, model.ID).Info("oauth client created")
+	return model, secret, nil
+}
+
+func (s OAuthService) DeleteClient(ctx context.Context, userID string, id string) error {
+	if err := s.oauthRepository.DeleteClient(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.OAuthClientIDField, id).Info("oauth client deleted")
+	return nil
+}
+
+func (s OAuthService) Authorize(ctx context.Context, userID string, organizationID string, dto oauth.AuthorizeDto) (string, error) {
+	client, err := s.oauthRepository.FindClient(ctx, dto.ClientID)
+	if err == oauth.ErrNotFound {
+		return "", oauth.ErrUnknownClient
+	}
+	if err != nil {
+		return "", err
+	}
+	// the redirect uri is compared exactly, a prefix match would let the codes leak to another page
+	if !contains(client.RedirectURIList(), dto.RedirectURI) {
+		return "", oauth.ErrInvalidRedirect
+	}
+	if dto.ResponseType != oauth.ResponseTypeCode {
+		return "", oauth.ErrUnsupportedResponseType
+	}
+	if !contains(client.GrantTypeList(), oauth.GrantAuthorizationCode) {
+		return "", oauth.ErrUnauthorizedClient
+	}
+	if dto.CodeChallenge == "" || dto.CodeChallengeMethod != oauth.ChallengeS256 {
+		return "", oauth.ErrPKCERequired
+	}
+	scopes, err := grantedScopes(client.ScopeList(), dto.Scope)
+	if err != nil {
+		return "", err
+	}
+	code, err := random(32)
+	if err != nil {
+		return "", err
+	}
+	model := models.OAuthCode{
+		ClientID:       client.ID,
+		UserID:         userID,
+		OrganizationID: organizationID,
+		CodeHash:       hash(code),
+		RedirectURI:    dto.RedirectURI,
+		Scopes:         scopes,
+		CodeChallenge:  dto.CodeChallenge,
+		Nonce:          dto.Nonce,
+		ExpiresAt:      time.Now().Add(CodeTTL),
+	}
+	if err := s.oauthRepository.StoreCode(ctx, &model); err != nil {
+		return "", err
+	}
+	logger.FromContext(ctx).WithField(logger.OAuthClientIDField, client.ID).Info("oauth code granted")
+	return oauth.Redirect(dto.RedirectURI, url.Values{"code": {code}, "state": {dto.State}}), nil
+}
+
+func (s OAuthService) Token(ctx context.Context, issuer string, credentials oauth.Credentials, dto oauth.TokenDto) (oauth.TokenMapper, error) {
+	client, err := s.authenticate(ctx, credentials)
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	switch dto.GrantType {
+	case oauth.GrantAuthorizationCode, oauth.GrantRefreshToken, oauth.GrantClientCredentials:
+		if !contains(client.GrantTypeList(), dto.GrantType) {
+			return oauth.TokenMapper{}, oauth.ErrUnauthorizedClient
+		}
+	default:
+		return oauth.TokenMapper{}, oauth.ErrUnsupportedGrantType
+	}
+
+	switch dto.GrantType {
+	case oauth.GrantAuthorizationCode:
+		return s.exchangeCode(ctx, issuer, client, dto)
+	case oauth.GrantRefreshToken:
+		return s.refresh(ctx, issuer, client, dto)
+	default:
+		return s.clientCredentials(ctx, client, dto)
+	}
+}
+
+func (s OAuthService) exchangeCode(ctx context.Context, issuer string, client *models.OAuthClient, dto oauth.TokenDto) (oauth.TokenMapper, error) {
+	code, err := s.oauthRepository.TakeCode(ctx, hash(dto.Code))
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	if code.ClientID != client.ID || code.RedirectURI != dto.RedirectURI || !time.Now().Before(code.ExpiresAt) {
+		return oauth.TokenMapper{}, oauth.ErrInvalidGrant
+	}
+	if !verifyChallenge(dto.CodeVerifier, code.CodeChallenge) {
+		return oauth.TokenMapper{}, oauth.ErrInvalidVerifier
+	}
+	owner, err := s.owner(ctx, code.UserID)
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	result, err := s.issue(ctx, client, owner, code.OrganizationID, code.Scopes)
+	if err != nil {
+		return result, err
+	}
+	return s.withIDToken(ctx, result, issuer, client, owner, code.Nonce)
+}
+
+// refresh rotate the refresh token, the one used cannot be used again
+func (s OAuthService) refresh(ctx context.Context, issuer string, client *models.OAuthClient, dto oauth.TokenDto) (oauth.TokenMapper, error) {
+	token, err := s.oauthRepository.FindRefreshToken(ctx, hash(dto.RefreshToken))
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	if token.ClientID != client.ID || !time.Now().Before(token.ExpiresAt) {
+		return oauth.TokenMapper{}, oauth.ErrInvalidGrant
+	}
+	// the scope of the new tokens can only narrow the scope granted by the user
+	scopes, err := grantedScopes(strings.Fields(token.Scopes), dto.Scope)
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	if err := s.oauthRepository.DeleteRefreshToken(ctx, token.ID); err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	owner, err := s.owner(ctx, token.UserID)
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	result, err := s.issue(ctx, client, owner, token.OrganizationID, scopes)
+	if err != nil {
+		return result, err
+	}
+	return s.withIDToken(ctx, result, issuer, client, owner, "")
+}
+
+// clientCredentials issue an access token to the client itself, it has no user and no refresh token
+func (s OAuthService) clientCredentials(ctx context.Context, client *models.OAuthClient, dto oauth.TokenDto) (oauth.TokenMapper, error) {
+	if client.Public() {
+		return oauth.TokenMapper{}, oauth.ErrUnauthorizedClient
+	}
+	scopes, err := grantedScopes(client.ScopeList(), dto.Scope)
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	return s.issue(ctx, client, nil, "", scopes)
+}
+
+// issue the access token of the client acting for owner, nil for the client itself, with a refresh token
+// when the client may use the refresh_token grant
+func (s OAuthService) issue(ctx context.Context, client *models.OAuthClient, owner *models.User, organizationID string, scopes string) (oauth.TokenMapper, error) {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"sub":             client.ID,
+		"tenant_id":       client.TenantID,
+		"organization_id": organizationID,
+		"client_id":       client.ID,
+		"scope":           scopes,
+		"typ":             "access",
+		"jti":             uuid.New().String(),
+		"iat":             now.Unix(),
+		"exp":             now.Add(s.accessTTL).Unix(),
+	}
+	if owner != nil {
+		claims["sub"] = owner.ID
+		claims["id"] = owner.ID
+		claims["email"] = owner.Email
+		claims["name"] = owner.Name
+	}
+	access, err := s.signer.Sign(ctx, claims)
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	result := oauth.TokenMapper{
+		AccessToken: access,
+		TokenType:   oauth.TokenTypeBearer,
+		ExpiresIn:   int64(s.accessTTL.Seconds()),
+		Scope:       scopes,
+	}
+	if owner == nil || !contains(client.GrantTypeList(), oauth.GrantRefreshToken) {
+		return result, nil
+	}
+	refresh, err := random(32)
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	model := models.OAuthRefreshToken{
+		ClientID:       client.ID,
+		UserID:         owner.ID,
+		OrganizationID: organizationID,
+		TokenHash:      hash(refresh),
+		Scopes:         scopes,
+		ExpiresAt:      now.Add(s.refreshTTL),
+	}
+	if err := s.oauthRepository.StoreRefreshToken(ctx, &model); err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	result.RefreshToken = refresh
+	return result, nil
+}
+
+// withIDToken add the ID token of OpenID Connect to result when the openid scope is granted,
+// with the nonce of the authorization request
+func (s OAuthService) withIDToken(ctx context.Context, result oauth.TokenMapper, issuer string, client *models.OAuthClient, owner *models.User, nonce string) (oauth.TokenMapper, error) {
+	scopes := strings.Fields(result.Scope)
+	if !contains(scopes, oauth.ScopeOpenID) {
+		return result, nil
+	}
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss": issuer,
+		"sub": owner.ID,
+		"aud": client.ID,
+		"azp": client.ID,
+		"iat": now.Unix(),
+		"exp": now.Add(s.accessTTL).Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	info := userInfo(owner, scopes)
+	if info.Name != "" {
+		claims["name"] = info.Name
+	}
+	if info.EmailVerified != nil {
+		claims["email"] = info.Email
+		claims["email_verified"] = *info.EmailVerified
+	}
+	idToken, err := s.keys.Sign(ctx, claims)
+	if err != nil {
+		return oauth.TokenMapper{}, err
+	}
+	result.IDToken = idToken
+	return result, nil
+}
+
+func (s OAuthService) UserInfo(ctx context.Context, userID string, scope string) (oauth.UserInfoMapper, error) {
+	// a client_credentials token has no user
+	if userID == "" {
+		return oauth.UserInfoMapper{}, oauth.ErrInvalidToken
+	}
+	owner, err := s.userRepository.FindById(ctx, userID)
+	if err == user.ErrNotFound {
+		return oauth.UserInfoMapper{}, oauth.ErrInvalidToken
+	}
+	if err != nil {
+		return oauth.UserInfoMapper{}, err
+	}
+	return userInfo(owner, strings.Fields(scope)), nil
+}
+
+func (s OAuthService) Keys() jwk.Set {
+	return s.keys.Set()
+}
+
+func (s OAuthService) Introspect(ctx context.Context, credentials oauth.Credentials, token string) (oauth.IntrospectionMapper, error) {
+	client, err := s.authenticate(ctx, credentials)
+	if err != nil {
+		return oauth.IntrospectionMapper{}, err
+	}
+	// the resource servers introspecting the tokens hold a secret
+	if client.Public() {
+		return oauth.IntrospectionMapper{}, oauth.ErrInvalidClient
+	}
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return oauth.IntrospectionMapper{}, err
+	}
+	if claims, err := s.signer.Verify(token); err == nil {
+		clientID, _ := claims["client_id"].(string)
+		// only the access tokens issued to the clients are described, not those of the users
+		if clientID == "" || claims["tenant_id"] != tenantID {
+			return oauth.IntrospectionMapper{}, nil
+		}
+		scope, _ := claims["scope"].(string)
+		email, _ := claims["email"].(string)
+		sub, _ := claims["sub"].(string)
+		jti, _ := claims["jti"].(string)
+		return oauth.IntrospectionMapper{
+			Active:    true,
+			Scope:     scope,
+			ClientID:  clientID,
+			Username:  email,
+			TokenType: oauth.TokenTypeBearer,
+			Exp:       unix(claims["exp"]),
+			Iat:       unix(claims["iat"]),
+			Sub:       sub,
+			Jti:       jti,
+		}, nil
+	}
+	model, err := s.oauthRepository.FindRefreshToken(ctx, hash(token))
+	if err == oauth.ErrInvalidGrant {
+		return oauth.IntrospectionMapper{}, nil
+	}
+	if err != nil {
+		return oauth.IntrospectionMapper{}, err
+	}
+	if !time.Now().Before(model.ExpiresAt) {
+		return oauth.IntrospectionMapper{}, nil
+	}
+	return oauth.IntrospectionMapper{
+		Active:    true,
+		Scope:     model.Scopes,
+		ClientID:  model.ClientID,
+		TokenType: oauth.HintRefreshToken,
+		Exp:       model.ExpiresAt.Unix(),
+		Iat:       model.CreatedAt.Unix(),
+		Sub:       model.UserID,
+	}, nil
+}
+
+func (s OAuthService) Revoke(ctx context.Context, credentials oauth.Credentials, token string) error {
+	client, err := s.authenticate(ctx, credentials)
+	if err != nil {
+		return err
+	}
+	if _, err := s.signer.Verify(token); err == nil {
+		return oauth.ErrUnsupportedTokenType
+	}
+	model, err := s.oauthRepository.FindRefreshToken(ctx, hash(token))
+	if err == oauth.ErrInvalidGrant {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// the token of another client is left as is without telling it exists
+	if model.ClientID != client.ID {
+		return nil
+	}
+	if err := s.oauthRepository.DeleteRefreshToken(ctx, model.ID); err != nil && err != oauth.ErrInvalidGrant {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.OAuthClientIDField, client.ID).Info("oauth refresh token revoked")
+	return nil
+}
+
+// authenticate return the client of the credentials, a public client is identified by its id alone
+func (s OAuthService) authenticate(ctx context.Context, credentials oauth.Credentials) (*models.OAuthClient, error) {
+	client, err := s.oauthRepository.FindClient(ctx, credentials.ClientID)
+	if err == oauth.ErrNotFound {
+		return nil, oauth.ErrInvalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if client.Public() {
+		if credentials.ClientSecret != "" {
+			return nil, oauth.ErrInvalidClient
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hash(credentials.ClientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, oauth.ErrInvalidClient
+	}
+	return client, nil
+}
+
+// owner return the user a grant was given by, the grants of a deleted user are not valid
+func (s OAuthService) owner(ctx context.Context, userID string) (*models.User, error) {
+	owner, err := s.userRepository.FindById(ctx, userID)
+	if err == user.ErrNotFound {
+		return nil, oauth.ErrInvalidGrant
+	}
+	return owner, err
+}
+
+// userInfo return the claims of owner the scopes allow, all of them without scopes
+func userInfo(owner *models.User, scopes []string) oauth.UserInfoMapper {
+	info := oauth.UserInfoMapper{Sub: owner.ID}
+	all := len(scopes) == 0
+	if all || contains(scopes, oauth.ScopeProfile) {
+		info.Name = owner.Name
+	}
+	if all || contains(scopes, oauth.ScopeEmail) {
+		verified := owner.EmailVerified()
+		info.Email = owner.Email
+		info.EmailVerified = &verified
+	}
+	return info
+}
+
+// grantedScopes return the requested scopes separated by spaces, all the allowed scopes when none is requested
+func grantedScopes(allowed []string, requested string) (string, error) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return strings.Join(allowed, " "), nil
+	}
+	for _, scope := range scopes {
+		if !contains(allowed, scope) {
+			return "", oauth.ErrInvalidScope
+		}
+	}
+	return strings.Join(scopes, " "), nil
+}
+
+// verifyChallenge tell whether the verifier matches the S256 challenge of RFC 7636
+func verifyChallenge(verifier string, challenge string) bool {
+	if verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
+
+// unix return a time claim of a token, the decoded JSON numbers are float64
+func unix(claim interface{}) int64 {
+	switch v := claim.(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	default:
+		return 0
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// hash of a secret, code or refresh token as stored, they are random enough for a fast hash
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// random return n random bytes encoded for the urls
+func random(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// IssuerFromEnv return APP_OIDC_ISSUER, the URL of the oauth module as the clients see it, e.g.
+// https://api.example.com/api/v1/oauth. It is required, the issuer is never taken from the host of a request.
+func IssuerFromEnv() (string, error) {
+	issuer := os.Getenv("APP_OIDC_ISSUER")
+	parsed, err := url.Parse(issuer)
+	if issuer == "" || err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return "", fmt.Errorf("oauth: APP_OIDC_ISSUER %q must be an absolute URL", issuer)
+	}
+	return strings.TrimSuffix(issuer, "/"), nil
+}
+
+func ttl(env string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(env))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,304 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/jwk"
+	"go-echo-api/middleware"
+	"go-echo-api/models"
+	"go-echo-api/oauth"
+	"go-echo-api/oauth/repository"
+	userRepository "go-echo-api/user/repository"
+	"net/url"
+	"os"
+	"testing"
+)
+
+const (
+	issuer      = "https://api.example.com/api/v1/oauth"
+	redirectURI = "https://app.example.com/callback"
+	verifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// key sign the ID tokens of the tests
+var key, _ = jwk.Generate()
+
+// newOAuthService return a service backed by in-memory repositories holding the fixture users
+func newOAuthService() oauth.Usecase {
+	return NewOAuthService(repository.NewOAuthMemoryRepository(), userRepository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan), middleware.JWTSigner{}, key)
+}
+
+// newClient register a client of Uje allowed every grant and its credentials
+func newClient(t *testing.T, o oauth.Usecase, confidential bool) (models.OAuthClient, oauth.Credentials) {
+	grants := []string{oauth.GrantAuthorizationCode, oauth.GrantRefreshToken}
+	if confidential {
+		grants = append(grants, oauth.GrantClientCredentials)
+	}
+	client, secret, err := o.CreateClient(dbtest.Context(), dbtest.UserUje.ID, oauth.ClientDto{
+		Name:         "Dashboard",
+		RedirectURIs: []string{redirectURI},
+		GrantTypes:   grants,
+		Scopes:       []string{"users:read", "orgs:read"},
+		Confidential: confidential,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return client, oauth.Credentials{ClientID: client.ID, ClientSecret: secret}
+}
+
+// authorize return the code Ipan grants to the client
+func authorize(t *testing.T, o oauth.Usecase, clientID string, scope string) string {
+	return authorizeWithNonce(t, o, clientID, scope, "")
+}
+
+func authorizeWithNonce(t *testing.T, o oauth.Usecase, clientID string, scope string, nonce string) string {
+	location, err := o.Authorize(dbtest.Context(), dbtest.UserIpan.ID, "", oauth.AuthorizeDto{
+		Nonce:               nonce,
+		ResponseType:        oauth.ResponseTypeCode,
+		ClientID:            clientID,
+		RedirectURI:         redirectURI,
+		Scope:               scope,
+		State:               "xyz",
+		CodeChallenge:       challenge(verifier),
+		CodeChallengeMethod: oauth.ChallengeS256,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	parsed, _ := url.Parse(location)
+	assert.Equal(t, "xyz", parsed.Query().Get("state"))
+	return parsed.Query().Get("code")
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestOAuthService_CreateClient(t *testing.T) {
+	o := newOAuthService()
+
+	s := t.Run("success", func(t *testing.T) {
+		data, credentials := newClient(t, o, true)
+		assert.NotEmpty(t, credentials.ClientSecret)
+		assert.NotContains(t, data.SecretHash, credentials.ClientSecret)
+
+		data, credentials = newClient(t, o, false)
+		assert.True(t, data.Public())
+		assert.Empty(t, credentials.ClientSecret)
+
+		_, total, err := o.FindClients(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, _, err := o.CreateClient(dbtest.Context(), dbtest.UserUje.ID, oauth.ClientDto{Name: "Dashboard",
+			GrantTypes: []string{oauth.GrantAuthorizationCode}, Scopes: []string{"users:read"}})
+		assert.Equal(t, oauth.ErrRedirectRequired, err)
+
+		_, _, err = o.CreateClient(dbtest.Context(), dbtest.UserUje.ID, oauth.ClientDto{Name: "Batch",
+			GrantTypes: []string{oauth.GrantClientCredentials}, Scopes: []string{"users:read"}})
+		assert.Equal(t, oauth.ErrPublicGrant, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestOAuthService_AuthorizationCode(t *testing.T) {
+	o := newOAuthService()
+	client, credentials := newClient(t, o, false)
+
+	s := t.Run("success", func(t *testing.T) {
+		code := authorize(t, o, client.ID, "users:read")
+		token, err := o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantAuthorizationCode,
+			Code: code, RedirectURI: redirectURI, CodeVerifier: verifier})
+		assert.NoError(t, err)
+		assert.Equal(t, "users:read", token.Scope)
+		assert.Empty(t, token.IDToken, "the openid scope is not granted")
+		assert.Equal(t, int64(DefaultAccessTTL.Seconds()), token.ExpiresIn)
+		claims, err := middleware.JWTSigner{}.Verify(token.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserIpan.ID, claims["id"])
+		assert.Equal(t, client.ID, claims["client_id"])
+
+		// the refresh token is rotated and can only narrow the scope
+		refreshed, err := o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantRefreshToken, RefreshToken: token.RefreshToken})
+		assert.NoError(t, err)
+		assert.NotEqual(t, token.RefreshToken, refreshed.RefreshToken)
+		_, err = o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantRefreshToken, RefreshToken: token.RefreshToken})
+		assert.Equal(t, oauth.ErrInvalidGrant, err)
+		_, err = o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantRefreshToken, RefreshToken: refreshed.RefreshToken, Scope: "orgs:read"})
+		assert.Equal(t, oauth.ErrInvalidScope, err)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		dto := oauth.AuthorizeDto{ResponseType: oauth.ResponseTypeCode, ClientID: client.ID, RedirectURI: redirectURI, CodeChallenge: challenge(verifier), CodeChallengeMethod: "plain"}
+		_, err := o.Authorize(dbtest.Context(), dbtest.UserIpan.ID, "", dto)
+		assert.Equal(t, oauth.ErrPKCERequired, err)
+
+		dto.CodeChallengeMethod, dto.Scope = oauth.ChallengeS256, "users:write"
+		_, err = o.Authorize(dbtest.Context(), dbtest.UserIpan.ID, "", dto)
+		assert.Equal(t, oauth.ErrInvalidScope, err)
+
+		dto.RedirectURI = "https://evil.example.com/callback"
+		_, err = o.Authorize(dbtest.Context(), dbtest.UserIpan.ID, "", dto)
+		assert.Equal(t, oauth.ErrInvalidRedirect, err)
+
+		dto.ClientID = "unknown"
+		_, err = o.Authorize(dbtest.Context(), dbtest.UserIpan.ID, "", dto)
+		assert.Equal(t, oauth.ErrUnknownClient, err)
+
+		// a wrong verifier burns the code
+		code := authorize(t, o, client.ID, "")
+		exchange := oauth.TokenDto{GrantType: oauth.GrantAuthorizationCode, Code: code, RedirectURI: redirectURI, CodeVerifier: "wrong"}
+		_, err = o.Token(dbtest.Context(), issuer, credentials, exchange)
+		assert.Equal(t, oauth.ErrInvalidVerifier, err)
+		exchange.CodeVerifier = verifier
+		_, err = o.Token(dbtest.Context(), issuer, credentials, exchange)
+		assert.Equal(t, oauth.ErrInvalidGrant, err)
+
+		_, err = o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantClientCredentials})
+		assert.Equal(t, oauth.ErrUnauthorizedClient, err)
+		_, err = o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: "password"})
+		assert.Equal(t, oauth.ErrUnsupportedGrantType, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestOAuthService_ClientCredentials(t *testing.T) {
+	o := newOAuthService()
+	client, credentials := newClient(t, o, true)
+
+	s := t.Run("success", func(t *testing.T) {
+		token, err := o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantClientCredentials})
+		assert.NoError(t, err)
+		assert.Empty(t, token.RefreshToken)
+		assert.Equal(t, "users:read orgs:read", token.Scope)
+
+		// the token belongs to the client, not to a user
+		claims, err := middleware.JWTSigner{}.Verify(token.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, client.ID, claims["sub"])
+		assert.Nil(t, claims["id"])
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := o.Token(dbtest.Context(), issuer, oauth.Credentials{ClientID: client.ID, ClientSecret: "wrong"}, oauth.TokenDto{GrantType: oauth.GrantClientCredentials})
+		assert.Equal(t, oauth.ErrInvalidClient, err)
+		_, err = o.Token(dbtest.TenantContext(dbtest.TenantGlobex), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantClientCredentials})
+		assert.Equal(t, oauth.ErrInvalidClient, err, "the client belongs to another tenant")
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestOAuthService_IntrospectRevoke(t *testing.T) {
+	o := newOAuthService()
+	client, credentials := newClient(t, o, true)
+	token, err := o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantAuthorizationCode,
+		Code: authorize(t, o, client.ID, ""), RedirectURI: redirectURI, CodeVerifier: verifier})
+	assert.NoError(t, err)
+
+	s := t.Run("success", func(t *testing.T) {
+		access, err := o.Introspect(dbtest.Context(), credentials, token.AccessToken)
+		assert.NoError(t, err)
+		assert.True(t, access.Active)
+		assert.Equal(t, dbtest.UserIpan.ID, access.Sub)
+		assert.Equal(t, dbtest.UserIpan.Email, access.Username)
+
+		refresh, err := o.Introspect(dbtest.Context(), credentials, token.RefreshToken)
+		assert.NoError(t, err)
+		assert.True(t, refresh.Active)
+		assert.Equal(t, oauth.HintRefreshToken, refresh.TokenType)
+
+		assert.NoError(t, o.Revoke(dbtest.Context(), credentials, token.RefreshToken))
+		refresh, err = o.Introspect(dbtest.Context(), credentials, token.RefreshToken)
+		assert.NoError(t, err)
+		assert.False(t, refresh.Active)
+		assert.NoError(t, o.Revoke(dbtest.Context(), credentials, "unknown"), "an unknown token is not an error")
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		assert.Equal(t, oauth.ErrUnsupportedTokenType, o.Revoke(dbtest.Context(), credentials, token.AccessToken))
+
+		// the tokens of the users are not described
+		user, _, _, _ := middleware.GenerateTokenPair(dbtest.Context(), dbtest.UserUje, "", "")
+		result, err := o.Introspect(dbtest.Context(), credentials, *user)
+		assert.NoError(t, err)
+		assert.False(t, result.Active)
+
+		public, publicCredentials := newClient(t, o, false)
+		assert.True(t, public.Public())
+		_, err = o.Introspect(dbtest.Context(), publicCredentials, token.AccessToken)
+		assert.Equal(t, oauth.ErrInvalidClient, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestOAuthService_OpenID(t *testing.T) {
+	o := newOAuthService()
+	client, _, err := o.CreateClient(dbtest.Context(), dbtest.UserUje.ID, oauth.ClientDto{
+		Name:         "Wiki",
+		RedirectURIs: []string{redirectURI},
+		GrantTypes:   []string{oauth.GrantAuthorizationCode},
+		Scopes:       []string{oauth.ScopeOpenID, oauth.ScopeProfile, oauth.ScopeEmail},
+	})
+	assert.NoError(t, err)
+	credentials := oauth.Credentials{ClientID: client.ID}
+
+	s := t.Run("success", func(t *testing.T) {
+		code := authorizeWithNonce(t, o, client.ID, "openid email", "n-0S6_WzA2Mj")
+		token, err := o.Token(dbtest.Context(), issuer, credentials, oauth.TokenDto{GrantType: oauth.GrantAuthorizationCode,
+			Code: code, RedirectURI: redirectURI, CodeVerifier: verifier})
+		assert.NoError(t, err)
+		assert.Empty(t, token.RefreshToken)
+
+		// the ID token is verified with the key set and has the claims of the email scope only
+		parsed, err := jwt.Parse(token.IDToken, func(token *jwt.Token) (interface{}, error) {
+			assert.Equal(t, o.Keys().Keys[0].Kid, token.Header["kid"])
+			return key.Public(), nil
+		})
+		assert.NoError(t, err)
+		claims := parsed.Claims.(jwt.MapClaims)
+		assert.Equal(t, issuer, claims["iss"])
+		assert.Equal(t, client.ID, claims["aud"])
+		assert.Equal(t, dbtest.UserIpan.ID, claims["sub"])
+		assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+		assert.Equal(t, dbtest.UserIpan.Email, claims["email"])
+		assert.Equal(t, false, claims["email_verified"])
+		assert.Nil(t, claims["name"])
+
+		info, err := o.UserInfo(dbtest.Context(), dbtest.UserIpan.ID, token.Scope)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserIpan.Email, info.Email)
+		assert.Empty(t, info.Name)
+		info, err = o.UserInfo(dbtest.Context(), dbtest.UserIpan.ID, "")
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserIpan.Name, info.Name, "the token of the user itself has every claim")
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := o.UserInfo(dbtest.Context(), "", "openid")
+		assert.Equal(t, oauth.ErrInvalidToken, err)
+		_, err = o.UserInfo(dbtest.Context(), "unknown", "openid")
+		assert.Equal(t, oauth.ErrInvalidToken, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestIssuerFromEnv(t *testing.T) {
+	defer os.Unsetenv("APP_OIDC_ISSUER")
+	os.Setenv("APP_OIDC_ISSUER", issuer+"/")
+	configured, err := IssuerFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, issuer, configured)
+
+	for _, invalid := range []string{"", "/api/v1/oauth", "api.example.com/api/v1/oauth"} {
+		os.Setenv("APP_OIDC_ISSUER", invalid)
+		_, err = IssuerFromEnv()
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,208 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	"go-echo-api/tenant"
+	"go-echo-api/utils"
+)
+
+type organizationController struct {
+	organizationUsecase organization.Usecase
+	organizationMapper  *organization.Mapper
+	memberMapper        *organization.MemberMapper
+	invitationMapper    *organization.InvitationMapper
+}
+
+func NewOrganizationController(s organization.Usecase) *organizationController {
+	return &organizationController{organizationUsecase: s,
+		organizationMapper: organization.NewOrganizationMapper(),
+		memberMapper:       organization.NewMemberMapper(),
+		invitationMapper:   organization.NewInvitationMapper(),
+	}
+}
+
+func (c *organizationController) FindAll(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.organizationUsecase.FindAll(ctx.Request().Context(), middleware.UserID(ctx), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.organizationMapper.MapList(result), nil)
+}
+
+func (c *organizationController) FindById(ctx echo.Context) error {
+	result, err := c.organizationUsecase.FindById(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.organizationMapper.Map(*result), nil)
+}
+
+func (c *organizationController) Store(ctx echo.Context) error {
+	var dto organization.Dto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.organizationUsecase.Create(ctx.Request().Context(), middleware.UserID(ctx), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.organizationMapper.Map(result), nil)
+}
+
+func (c *organizationController) Update(ctx echo.Context) error {
+	var dto organization.Dto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.organizationUsecase.Update(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.organizationMapper.Map(result), nil)
+}
+
+func (c *organizationController) Delete(ctx echo.Context) error {
+	if err := c.organizationUsecase.Delete(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+func (c *organizationController) FindMembers(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.organizationUsecase.FindMembers(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.memberMapper.MapList(result), nil)
+}
+
+func (c *organizationController) UpdateMember(ctx echo.Context) error {
+	var dto organization.MemberDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.organizationUsecase.UpdateMember(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"), ctx.Param("user_id"), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.memberMapper.Map(result), nil)
+}
+
+func (c *organizationController) RemoveMember(ctx echo.Context) error {
+	err := c.organizationUsecase.RemoveMember(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"), ctx.Param("user_id"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+func (c *organizationController) Invite(ctx echo.Context) error {
+	var dto organization.InviteDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	token, invitation, err := c.organizationUsecase.Invite(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, organization.NewInviteMapper(token, invitation), nil)
+}
+
+func (c *organizationController) FindInvitations(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.organizationUsecase.FindInvitations(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.invitationMapper.MapList(result), nil)
+}
+
+func (c *organizationController) RevokeInvitation(ctx echo.Context) error {
+	err := c.organizationUsecase.RevokeInvitation(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id"), ctx.Param("invitation_id"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+// Accept an invite token sent to the email of the logged in user
+func (c *organizationController) Accept(ctx echo.Context) error {
+	var dto organization.AcceptDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	invitation, err := c.organizationUsecase.ParseInvite(ctx.Request().Context(), dto.Token)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	result, err := c.organizationUsecase.Join(ctx.Request().Context(), invitation, loggedInUser(ctx))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.memberMapper.Map(result), nil)
+}
+
+// Token return a new token pair of the logged in user with the organization as its active organization
+func (c *organizationController) Token(ctx echo.Context) error {
+	user := loggedInUser(ctx)
+	if _, err := c.organizationUsecase.FindById(ctx.Request().Context(), user.ID, ctx.Param("id")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	tokens, refreshToken, expire, err := middleware.GenerateTokenPair(ctx.Request().Context(), user, ctx.Param("id"), middleware.SessionID(ctx))
+	if err != nil {
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+	return response.SingleData(ctx, utils.OK, auth.NewTokenMapper(tokens, refreshToken, expire), nil)
+}
+
+// loggedInUser return the user of the access token as its claims describe it
+func loggedInUser(ctx echo.Context) models.User {
+	claims := middleware.Claims(ctx)
+	email, _ := claims["email"].(string)
+	name, _ := claims["name"].(string)
+	tenantID, _ := tenant.ID(ctx.Request().Context())
+	return models.User{ID: middleware.UserID(ctx), TenantID: tenantID, Email: email, Name: name}
+}
+
+// errorResponse map the errors of the organization use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case organization.ErrNotFound, organization.ErrMemberNotFound, organization.ErrInvitationNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	case organization.ErrForbidden, organization.ErrInviteEmail:
+		return response.Forbidden(ctx, utils.Forbidden, nil, err.Error())
+	case organization.ErrAlreadyMember, organization.ErrLastOwner:
+		return response.Conflict(ctx, utils.Conflict, nil, err.Error())
+	case organization.ErrInviteInvalid:
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("organization use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
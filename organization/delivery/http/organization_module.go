@@ -0,0 +1,60 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/apikey"
+	apiKeyRepository "go-echo-api/apikey/repository"
+	apiKeyUsecase "go-echo-api/apikey/usecase"
+	"go-echo-api/middleware"
+	"go-echo-api/organization/repository"
+	"go-echo-api/organization/usecase"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/sink"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+)
+
+// Module wire the organization controller to the database and register its routes under /orgs,
+// every route requires an access token or an API key and only sees the organizations of the tenant of the request
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/orgs"
+}
+
+// Subscribe delete the memberships of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewOrganizationRepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	controller := NewOrganizationController(usecase.NewOrganizationService(repository.NewOrganizationRepository(m.db)))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	sessions := sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL)
+	authenticate := middleware.Authenticate(apiKeyUsecase.NewAPIKeyService(apiKeyRepository.NewAPIKeyRepository(m.db), userRepository.NewUserRepository(m.db)), sessions)
+	loggedIn := middleware.IsLoggedIn(sessions)
+	read, write := middleware.RequireScope(apikey.ScopeOrgsRead), middleware.RequireScope(apikey.ScopeOrgsWrite)
+	g.GET("", controller.FindAll, tenantScope, authenticate, read)
+	g.POST("", controller.Store, tenantScope, authenticate, write)
+	// joining and switching organizations mint access tokens, an API key or an OAuth client cannot
+	g.POST("/invitations/accept", controller.Accept, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.GET("/:id", controller.FindById, tenantScope, authenticate, read)
+	g.PUT("/:id", controller.Update, tenantScope, authenticate, write)
+	g.DELETE("/:id", controller.Delete, tenantScope, authenticate, write)
+	g.POST("/:id/token", controller.Token, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.GET("/:id/members", controller.FindMembers, tenantScope, authenticate, read)
+	g.PUT("/:id/members/:user_id", controller.UpdateMember, tenantScope, authenticate, write)
+	g.DELETE("/:id/members/:user_id", controller.RemoveMember, tenantScope, authenticate, write)
+	g.POST("/:id/invitations", controller.Invite, tenantScope, authenticate, write)
+	g.GET("/:id/invitations", controller.FindInvitations, tenantScope, authenticate, read)
+	g.DELETE("/:id/invitations/:invitation_id", controller.RevokeInvitation, tenantScope, authenticate, write)
+}
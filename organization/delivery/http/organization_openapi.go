@@ -0,0 +1,190 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/organization"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	g.Use(nil, map[string]openapi.Response{
+		"401": g.Error("Missing or invalid access token or API key"),
+	})
+	id := openapi.PathParam("id", "ID of the organization")
+	userID := openapi.PathParam("user_id", "ID of the user member of the organization")
+	invitationID := openapi.PathParam("invitation_id", "ID of the invitation")
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+	page := []openapi.Parameter{
+		openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+		openapi.QueryParam("offset", "Number of items skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+	}
+
+	g.Add(echo.GET, "", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "List the organizations of the logged in user",
+		OperationID: "listOrganizations",
+		Parameters:  page,
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of organizations", organization.Mapper{}),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.POST, "", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Create an organization owned by the logged in user",
+		OperationID: "createOrganization",
+		RequestBody: g.Body(organization.Dto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Created organization", organization.Mapper{}),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.POST, "/invitations/accept", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Join an organization with an invite token sent to the email of the logged in user",
+		OperationID: "acceptInvitation",
+		RequestBody: g.Body(organization.AcceptDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Membership of the logged in user", organization.MemberMapper{}),
+			"400": g.Error("No tenant named by the request, or invite token not valid, expired, revoked or used"),
+			"403": g.Error("Invite token sent to another email, or token issued to an OAuth client"),
+			"404": g.Error("Organization not found"),
+			"409": g.Error("Already a member of the organization"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.GET, "/:id", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Find an organization of the logged in user by ID",
+		OperationID: "findOrganization",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Organization", organization.Mapper{}),
+			"404": g.Error("Organization not found"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.PUT, "/:id", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Rename an organization, owners and admins only",
+		OperationID: "updateOrganization",
+		Parameters:  []openapi.Parameter{id},
+		RequestBody: g.Body(organization.Dto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Updated organization", organization.Mapper{}),
+			"403": g.Error("Role not allowed"),
+			"404": g.Error("Organization not found"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.DELETE, "/:id", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Delete an organization and its memberships, owners only",
+		OperationID: "deleteOrganization",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Organization deleted", nil),
+			"403": g.Error("Role not allowed"),
+			"404": g.Error("Organization not found"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.POST, "/:id/token", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Switch the active organization, a new token pair carries it in its organization_id claim",
+		OperationID: "switchOrganization",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Access and refresh tokens with the organization active", auth.TokenMapper{}),
+			"403": g.Error("Token issued to an OAuth client"),
+			"404": g.Error("Organization not found"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.GET, "/:id/members", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "List the members of an organization",
+		OperationID: "listMembers",
+		Parameters:  append([]openapi.Parameter{id}, page...),
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of members", organization.MemberMapper{}),
+			"404": g.Error("Organization not found"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.PUT, "/:id/members/:user_id", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Change the role of a member, owners and admins only, the owner role is granted and taken by owners",
+		OperationID: "updateMember",
+		Parameters:  []openapi.Parameter{id, userID},
+		RequestBody: g.Body(organization.MemberDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Updated membership", organization.MemberMapper{}),
+			"403": g.Error("Role not allowed"),
+			"404": g.Error("Organization or member not found"),
+			"409": g.Error("The organization would be left without owner"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.DELETE, "/:id/members/:user_id", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Remove a member, owners and admins only, or leave the organization",
+		OperationID: "removeMember",
+		Parameters:  []openapi.Parameter{id, userID},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Member removed", nil),
+			"403": g.Error("Role not allowed"),
+			"404": g.Error("Organization or member not found"),
+			"409": g.Error("The organization would be left without owner"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.POST, "/:id/invitations", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Invite an email to join the organization, owners and admins only",
+		OperationID: "inviteMember",
+		Parameters:  []openapi.Parameter{id},
+		RequestBody: g.Body(organization.InviteDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Signed invite token to send to the email", organization.InviteMapper{}),
+			"403": g.Error("Role not allowed"),
+			"404": g.Error("Organization not found"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.GET, "/:id/invitations", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "List the pending invitations of an organization, owners and admins only",
+		OperationID: "listInvitations",
+		Parameters:  append([]openapi.Parameter{id}, page...),
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of invitations", organization.InvitationMapper{}),
+			"403": g.Error("Role not allowed"),
+			"404": g.Error("Organization not found"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.DELETE, "/:id/invitations/:invitation_id", openapi.Operation{
+		Tags:        []string{"organization"},
+		Summary:     "Revoke a pending invitation, owners and admins only, the invitations of owners are revoked by owners",
+		OperationID: "revokeInvitation",
+		Parameters:  []openapi.Parameter{id, invitationID},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Invitation revoked", nil),
+			"403": g.Error("Role not allowed"),
+			"404": g.Error("Organization or pending invitation not found"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+}
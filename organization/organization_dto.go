@@ -0,0 +1,18 @@
+package organization
+
+type Dto struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type MemberDto struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type InviteDto struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type AcceptDto struct {
+	Token string `json:"token" validate:"required"`
+}
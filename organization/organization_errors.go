@@ -0,0 +1,14 @@
+package organization
+
+import "errors"
+
+var (
+	ErrNotFound           = errors.New("organization not found")
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrForbidden          = errors.New("your role in the organization does not allow this action")
+	ErrAlreadyMember      = errors.New("user is already a member of the organization")
+	ErrLastOwner          = errors.New("the organization must keep at least one owner")
+	ErrInviteInvalid      = errors.New("invite token not valid or expired")
+	ErrInviteEmail        = errors.New("invite token was sent to another email")
+	ErrInvitationNotFound = errors.New("invitation not found")
+)
@@ -0,0 +1,15 @@
+package organization
+
+import "time"
+
+// Invitation is the content of a signed invite token, whoever holds the token can join the
+// organization with the role once logged in or registered with the email, until the token expires.
+// ID is the stored invitation the token stands for, consumed by the first join.
+type Invitation struct {
+	ID             string
+	TenantID       string
+	OrganizationID string
+	Email          string
+	Role           string
+	ExpiresAt      time.Time
+}
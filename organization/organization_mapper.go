@@ -0,0 +1,113 @@
+package organization
+
+import (
+	"go-echo-api/models"
+	"time"
+)
+
+type Mapper struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewOrganizationMapper() *Mapper {
+	return &Mapper{}
+}
+
+func (m *Mapper) Map(model models.Organization) *Mapper {
+	m.ID = model.ID
+	m.Name = model.Name
+	m.CreatedAt = model.CreatedAt
+	m.UpdatedAt = model.UpdatedAt
+	return m
+}
+
+func (m *Mapper) MapList(model []models.Organization) interface{} {
+	serialized := make([]Mapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&Mapper{}).Map(v)
+	}
+	return serialized
+}
+
+type MemberMapper struct {
+	OrganizationID string    `json:"organization_id"`
+	UserID         string    `json:"user_id"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func NewMemberMapper() *MemberMapper {
+	return &MemberMapper{}
+}
+
+func (m *MemberMapper) Map(model models.Membership) *MemberMapper {
+	m.OrganizationID = model.OrganizationID
+	m.UserID = model.UserID
+	m.Role = model.Role
+	m.CreatedAt = model.CreatedAt
+	return m
+}
+
+func (m *MemberMapper) MapList(model []models.Membership) interface{} {
+	serialized := make([]MemberMapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&MemberMapper{}).Map(v)
+	}
+	return serialized
+}
+
+type InviteMapper struct {
+	ID             string    `json:"id"`
+	Token          string    `json:"token"`
+	OrganizationID string    `json:"organization_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+func NewInviteMapper(token string, invitation Invitation) *InviteMapper {
+	return &InviteMapper{
+		ID:             invitation.ID,
+		Token:          token,
+		OrganizationID: invitation.OrganizationID,
+		Email:          invitation.Email,
+		Role:           invitation.Role,
+		ExpiresAt:      invitation.ExpiresAt,
+	}
+}
+
+type InvitationMapper struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organization_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	InvitedBy      string    `json:"invited_by"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func NewInvitationMapper() *InvitationMapper {
+	return &InvitationMapper{}
+}
+
+func (m *InvitationMapper) Map(model models.Invitation) *InvitationMapper {
+	m.ID = model.ID
+	m.OrganizationID = model.OrganizationID
+	m.Email = model.Email
+	m.Role = model.Role
+	m.InvitedBy = model.InvitedBy
+	m.ExpiresAt = model.ExpiresAt
+	m.CreatedAt = model.CreatedAt
+	return m
+}
+
+func (m *InvitationMapper) MapList(model []models.Invitation) interface{} {
+	serialized := make([]InvitationMapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&InvitationMapper{}).Map(v)
+	}
+	return serialized
+}
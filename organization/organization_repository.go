@@ -0,0 +1,37 @@
+package organization
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+// Repository of the organizations and memberships of the tenant of the context
+type Repository interface {
+	FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.Organization, int64, error)
+	FindById(ctx context.Context, id string) (*models.Organization, error)
+	Store(ctx context.Context, model *models.Organization, owner *models.Membership) error
+	Update(ctx context.Context, model *models.Organization) error
+	Delete(ctx context.Context, id string) error
+
+	FindMembers(ctx context.Context, organizationID string, limit int64, offset int64) ([]models.Membership, int64, error)
+	FindMember(ctx context.Context, organizationID string, userID string) (*models.Membership, error)
+	FindFirstMembership(ctx context.Context, userID string) (*models.Membership, error)
+	CountOwners(ctx context.Context, organizationID string) (int64, error)
+	StoreMember(ctx context.Context, model *models.Membership) error
+	UpdateMember(ctx context.Context, model *models.Membership) error
+	DeleteMember(ctx context.Context, organizationID string, userID string) error
+
+	StoreInvitation(ctx context.Context, model *models.Invitation) error
+	FindInvitation(ctx context.Context, id string) (*models.Invitation, error)
+	// FindInvitations return the invitations of the organization pending at now
+	FindInvitations(ctx context.Context, organizationID string, now time.Time, limit int64, offset int64) ([]models.Invitation, int64, error)
+	// RevokeInvitation fail with ErrInvitationNotFound when the invitation is not pending anymore
+	RevokeInvitation(ctx context.Context, organizationID string, id string, at time.Time) error
+	// AcceptInvitation mark the invitation accepted at and insert the membership together, it fails
+	// with ErrInviteInvalid when the invitation is not pending anymore
+	AcceptInvitation(ctx context.Context, id string, at time.Time, member *models.Membership) error
+
+	// DeleteByUser delete the memberships of the user, once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,29 @@
+package organization
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+// Usecase of the organizations, userID is the user acting, its membership decides what it may do
+type Usecase interface {
+	FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.Organization, int64, error)
+	FindById(ctx context.Context, userID string, id string) (*models.Organization, error)
+	Create(ctx context.Context, userID string, dto Dto) (models.Organization, error)
+	Update(ctx context.Context, userID string, id string, dto Dto) (models.Organization, error)
+	Delete(ctx context.Context, userID string, id string) error
+
+	FindMembers(ctx context.Context, userID string, id string, limit int64, offset int64) ([]models.Membership, int64, error)
+	UpdateMember(ctx context.Context, userID string, id string, memberID string, dto MemberDto) (models.Membership, error)
+	RemoveMember(ctx context.Context, userID string, id string, memberID string) error
+
+	Invite(ctx context.Context, userID string, id string, dto InviteDto) (string, Invitation, error)
+	ParseInvite(ctx context.Context, token string) (Invitation, error)
+	Join(ctx context.Context, invitation Invitation, user models.User) (models.Membership, error)
+	FindInvitations(ctx context.Context, userID string, id string, limit int64, offset int64) ([]models.Invitation, int64, error)
+	RevokeInvitation(ctx context.Context, userID string, id string, invitationID string) error
+
+	// ActiveOrganization return preferred when the user is a member of it, else the first
+	// organization the user joined, empty when the user has none
+	ActiveOrganization(ctx context.Context, userID string, preferred string) (string, error)
+}
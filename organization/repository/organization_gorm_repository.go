@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	"time"
+)
+
+type organizationGormRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) organization.Repository {
+	return &organizationGormRepository{db: db}
+}
+
+func (r *organizationGormRepository) FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.Organization, int64, error) {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.Organization{}).
+		Joins("JOIN memberships ON memberships.organization_id = organizations.id").
+		Where("organizations.tenant_id = ? AND memberships.user_id = ?", tenantID, userID)
+	var model []models.Organization
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("organizations.created_at").Limit(limit).Offset(offset).Select("organizations.*").Find(&model).Error
+	return model, total, err
+}
+
+func (r *organizationGormRepository) FindById(ctx context.Context, id string) (*models.Organization, error) {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.Organization
+	err = db.Where("tenant_id = ? AND id = ?", tenantID, id).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, organization.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// Store insert the organization and the membership of its owner together
+func (r *organizationGormRepository) Store(ctx context.Context, model *models.Organization, owner *models.Membership) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		model.TenantID = tenantID
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		owner.TenantID = tenantID
+		owner.OrganizationID = model.ID
+		return tx.Create(owner).Error
+	})
+}
+
+func (r *organizationGormRepository) Update(ctx context.Context, model *models.Organization) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	if model.TenantID != tenantID {
+		return organization.ErrNotFound
+	}
+	return db.Save(model).Error
+}
+
+// Delete remove the organization, its memberships and invitations together
+func (r *organizationGormRepository) Delete(ctx context.Context, id string) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		result := tx.Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&models.Organization{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return organization.ErrNotFound
+		}
+		if err := tx.Where("tenant_id = ? AND organization_id = ?", tenantID, id).Delete(&models.Membership{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("tenant_id = ? AND organization_id = ?", tenantID, id).Delete(&models.Invitation{}).Error
+	})
+}
+
+func (r *organizationGormRepository) FindMembers(ctx context.Context, organizationID string, limit int64, offset int64) ([]models.Membership, int64, error) {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.Membership{}).Where("tenant_id = ? AND organization_id = ?", tenantID, organizationID)
+	var model []models.Membership
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("created_at").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *organizationGormRepository) FindMember(ctx context.Context, organizationID string, userID string) (*models.Membership, error) {
+	return r.firstMember(ctx, "organization_id = ? AND user_id = ?", organizationID, userID)
+}
+
+func (r *organizationGormRepository) FindFirstMembership(ctx context.Context, userID string) (*models.Membership, error) {
+	return r.firstMember(ctx, "user_id = ?", userID)
+}
+
+func (r *organizationGormRepository) firstMember(ctx context.Context, query string, values ...interface{}) (*models.Membership, error) {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.Membership
+	err = db.Where("tenant_id = ?", tenantID).Where(query, values...).Order("created_at").First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, organization.ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *organizationGormRepository) CountOwners(ctx context.Context, organizationID string) (int64, error) {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	err = db.Model(&models.Membership{}).
+		Where("tenant_id = ? AND organization_id = ? AND role = ?", tenantID, organizationID, models.RoleOwner).
+		Count(&total).Error
+	return total, err
+}
+
+func (r *organizationGormRepository) StoreMember(ctx context.Context, model *models.Membership) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *organizationGormRepository) UpdateMember(ctx context.Context, model *models.Membership) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	if model.TenantID != tenantID {
+		return organization.ErrMemberNotFound
+	}
+	return db.Save(model).Error
+}
+
+func (r *organizationGormRepository) DeleteMember(ctx context.Context, organizationID string, userID string) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Where("tenant_id = ? AND organization_id = ? AND user_id = ?", tenantID, organizationID, userID).
+		Delete(&models.Membership{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return organization.ErrMemberNotFound
+	}
+	return nil
+}
+
+func (r *organizationGormRepository) StoreInvitation(ctx context.Context, model *models.Invitation) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *organizationGormRepository) FindInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.Invitation
+	err = db.Where("tenant_id = ? AND id = ?", tenantID, id).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, organization.ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *organizationGormRepository) FindInvitations(ctx context.Context, organizationID string, now time.Time, limit int64, offset int64) ([]models.Invitation, int64, error) {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.Invitation{}).
+		Where("tenant_id = ? AND organization_id = ?", tenantID, organizationID).
+		Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	var model []models.Invitation
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("created_at").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *organizationGormRepository) RevokeInvitation(ctx context.Context, organizationID string, id string, at time.Time) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Model(&models.Invitation{}).
+		Where("tenant_id = ? AND organization_id = ? AND id = ?", tenantID, organizationID, id).
+		Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", at).
+		UpdateColumn("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return organization.ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *organizationGormRepository) AcceptInvitation(ctx context.Context, id string, at time.Time, member *models.Membership) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		// of two concurrent joins with the token only the one marking the invitation accepted wins
+		result := tx.Model(&models.Invitation{}).
+			Where("tenant_id = ? AND id = ?", tenantID, id).
+			Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", at).
+			UpdateColumn("accepted_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return organization.ErrInviteInvalid
+		}
+		member.TenantID = tenantID
+		return tx.Create(member).Error
+	})
+}
+
+func (r *organizationGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, tenantID, err := database.WithTenant(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return db.Where("tenant_id = ? AND user_id = ?", tenantID, userID).Delete(&models.Membership{}).Error
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	"testing"
+	"time"
+)
+
+func TestOrganizationGormRepository_FindAll(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewOrganizationRepository(db)
+	list, total, err := r.FindAll(dbtest.Context(), dbtest.UserIpan.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, dbtest.OrganizationAcme.Name, list[0].Name)
+	}
+
+	// the organizations of a tenant are not seen from another
+	_, total, err = r.FindAll(dbtest.TenantContext(dbtest.TenantGlobex), dbtest.UserIpan.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestOrganizationGormRepository_StoreDelete(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewOrganizationRepository(db)
+	model := models.Organization{Name: "Ipan's team"}
+	owner := models.Membership{UserID: dbtest.UserIpan.ID, Role: models.RoleOwner}
+	assert.NoError(t, r.Store(dbtest.Context(), &model, &owner))
+	assert.Equal(t, dbtest.TenantAcme.ID, model.TenantID)
+	assert.Equal(t, model.ID, owner.OrganizationID)
+
+	found, err := r.FindMember(dbtest.Context(), model.ID, dbtest.UserIpan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleOwner, found.Role)
+	first, err := r.FindFirstMembership(dbtest.Context(), dbtest.UserIpan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, dbtest.OrganizationAcme.ID, first.OrganizationID)
+	owners, err := r.CountOwners(dbtest.Context(), model.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), owners)
+
+	assert.NoError(t, r.Delete(dbtest.Context(), model.ID))
+	_, err = r.FindMember(dbtest.Context(), model.ID, dbtest.UserIpan.ID)
+	assert.Equal(t, organization.ErrMemberNotFound, err)
+	assert.Equal(t, organization.ErrNotFound, r.Delete(dbtest.Context(), model.ID))
+}
+
+func TestOrganizationGormRepository_Invitations(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewOrganizationRepository(db)
+	now := time.Now()
+	id := dbtest.OrganizationAcme.ID
+	invite := func(email string) models.Invitation {
+		model := models.Invitation{OrganizationID: id, Email: email, Role: models.RoleMember, InvitedBy: dbtest.UserUje.ID, ExpiresAt: now.Add(time.Hour)}
+		assert.NoError(t, r.StoreInvitation(dbtest.Context(), &model))
+		return model
+	}
+	accepted, revoked := invite("budi@email.com"), invite("carol@email.com")
+
+	pending, total, err := r.FindInvitations(dbtest.Context(), id, now, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, pending, 2)
+
+	// an invitation is accepted once, with the membership
+	member := models.Membership{OrganizationID: id, UserID: "budi-id", Role: accepted.Role}
+	assert.NoError(t, r.AcceptInvitation(dbtest.Context(), accepted.ID, now, &member))
+	_, err = r.FindMember(dbtest.Context(), id, "budi-id")
+	assert.NoError(t, err)
+	again := models.Membership{OrganizationID: id, UserID: "other-id", Role: accepted.Role}
+	assert.Equal(t, organization.ErrInviteInvalid, r.AcceptInvitation(dbtest.Context(), accepted.ID, now, &again))
+	_, err = r.FindMember(dbtest.Context(), id, "other-id")
+	assert.Equal(t, organization.ErrMemberNotFound, err)
+
+	// a revoked invitation is not accepted, nor revoked twice
+	assert.NoError(t, r.RevokeInvitation(dbtest.Context(), id, revoked.ID, now))
+	assert.Equal(t, organization.ErrInvitationNotFound, r.RevokeInvitation(dbtest.Context(), id, revoked.ID, now))
+	assert.Equal(t, organization.ErrInviteInvalid, r.AcceptInvitation(dbtest.Context(), revoked.ID, now, &again))
+	found, err := r.FindInvitation(dbtest.Context(), revoked.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, found.RevokedAt)
+
+	// an expired invitation is not pending anymore, nor seen from another tenant
+	_, total, err = r.FindInvitations(dbtest.Context(), id, now, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	expired := invite("dani@email.com")
+	assert.Equal(t, organization.ErrInviteInvalid, r.AcceptInvitation(dbtest.Context(), expired.ID, now.Add(2*time.Hour), &again))
+	_, err = r.FindInvitation(dbtest.TenantContext(dbtest.TenantGlobex), expired.ID)
+	assert.Equal(t, organization.ErrInvitationNotFound, err)
+}
+
+func TestOrganizationGormRepository_DeleteByUser(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewOrganizationRepository(db)
+	assert.NoError(t, r.DeleteByUser(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.Membership{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Model(&models.Membership{}).Where("user_id = ?", dbtest.UserUje.ID).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	"go-echo-api/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// organizationMemoryRepository keeps the organizations and memberships in memory, it backs the use-case unit tests
+type organizationMemoryRepository struct {
+	mu            sync.RWMutex
+	organizations map[string]models.Organization
+	memberships   map[string]models.Membership
+	invitations   map[string]models.Invitation
+}
+
+func NewOrganizationMemoryRepository(organizations []models.Organization, memberships []models.Membership) organization.Repository {
+	r := &organizationMemoryRepository{
+		organizations: make(map[string]models.Organization),
+		memberships:   make(map[string]models.Membership),
+		invitations:   make(map[string]models.Invitation),
+	}
+	for _, o := range organizations {
+		r.organizations[o.ID] = o
+	}
+	for _, m := range memberships {
+		r.memberships[m.ID] = m
+	}
+	return r
+}
+
+// tenantMemberships return the memberships of the tenant of the context matching keep ordered by creation,
+// callers hold the lock
+func (r *organizationMemoryRepository) tenantMemberships(ctx context.Context, keep func(models.Membership) bool) ([]models.Membership, string, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	var memberships []models.Membership
+	for _, m := range r.memberships {
+		if m.TenantID == tenantID && keep(m) {
+			memberships = append(memberships, m)
+		}
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		if memberships[i].CreatedAt.Equal(memberships[j].CreatedAt) {
+			return memberships[i].ID < memberships[j].ID
+		}
+		return memberships[i].CreatedAt.Before(memberships[j].CreatedAt)
+	})
+	return memberships, tenantID, nil
+}
+
+// page return the bounds of the page of limit items from offset among total
+func page(total int64, limit int64, offset int64) (int64, int64) {
+	if offset >= total {
+		return total, total
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return offset, end
+}
+
+func (r *organizationMemoryRepository) FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.Organization, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	memberships, _, err := r.tenantMemberships(ctx, func(m models.Membership) bool { return m.UserID == userID })
+	if err != nil {
+		return nil, 0, err
+	}
+	all := make([]models.Organization, 0, len(memberships))
+	for _, m := range memberships {
+		all = append(all, r.organizations[m.OrganizationID])
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+	total := int64(len(all))
+	start, end := page(total, limit, offset)
+	return all[start:end], total, nil
+}
+
+func (r *organizationMemoryRepository) FindById(ctx context.Context, id string) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o, ok := r.organizations[id]
+	if !ok || o.TenantID != tenantID {
+		return nil, organization.ErrNotFound
+	}
+	return &o, nil
+}
+
+func (r *organizationMemoryRepository) Store(ctx context.Context, model *models.Organization, owner *models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.organizations[model.ID] = *model
+	owner.TenantID = tenantID
+	owner.OrganizationID = model.ID
+	r.storeMember(owner)
+	return nil
+}
+
+func (r *organizationMemoryRepository) Update(ctx context.Context, model *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.organizations[model.ID]; !ok || existing.TenantID != tenantID {
+		return organization.ErrNotFound
+	}
+	model.UpdatedAt = time.Now()
+	r.organizations[model.ID] = *model
+	return nil
+}
+
+func (r *organizationMemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	memberships, tenantID, err := r.tenantMemberships(ctx, func(m models.Membership) bool { return m.OrganizationID == id })
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.organizations[id]; !ok || existing.TenantID != tenantID {
+		return organization.ErrNotFound
+	}
+	delete(r.organizations, id)
+	for _, m := range memberships {
+		delete(r.memberships, m.ID)
+	}
+	for _, i := range r.invitations {
+		if i.TenantID == tenantID && i.OrganizationID == id {
+			delete(r.invitations, i.ID)
+		}
+	}
+	return nil
+}
+
+func (r *organizationMemoryRepository) FindMembers(ctx context.Context, organizationID string, limit int64, offset int64) ([]models.Membership, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all, _, err := r.tenantMemberships(ctx, func(m models.Membership) bool { return m.OrganizationID == organizationID })
+	if err != nil {
+		return nil, 0, err
+	}
+	total := int64(len(all))
+	start, end := page(total, limit, offset)
+	return append([]models.Membership{}, all[start:end]...), total, nil
+}
+
+func (r *organizationMemoryRepository) FindMember(ctx context.Context, organizationID string, userID string) (*models.Membership, error) {
+	return r.firstMember(ctx, func(m models.Membership) bool {
+		return m.OrganizationID == organizationID && m.UserID == userID
+	})
+}
+
+func (r *organizationMemoryRepository) FindFirstMembership(ctx context.Context, userID string) (*models.Membership, error) {
+	return r.firstMember(ctx, func(m models.Membership) bool { return m.UserID == userID })
+}
+
+func (r *organizationMemoryRepository) firstMember(ctx context.Context, keep func(models.Membership) bool) (*models.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	memberships, _, err := r.tenantMemberships(ctx, keep)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return nil, organization.ErrMemberNotFound
+	}
+	return &memberships[0], nil
+}
+
+func (r *organizationMemoryRepository) CountOwners(ctx context.Context, organizationID string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	owners, _, err := r.tenantMemberships(ctx, func(m models.Membership) bool {
+		return m.OrganizationID == organizationID && m.Role == models.RoleOwner
+	})
+	return int64(len(owners)), err
+}
+
+func (r *organizationMemoryRepository) StoreMember(ctx context.Context, model *models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, tenantID, err := r.tenantMemberships(ctx, func(m models.Membership) bool {
+		return m.OrganizationID == model.OrganizationID && m.UserID == model.UserID
+	})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return organization.ErrAlreadyMember
+	}
+	model.TenantID = tenantID
+	r.storeMember(model)
+	return nil
+}
+
+// storeMember insert the membership, callers hold the lock
+func (r *organizationMemoryRepository) storeMember(model *models.Membership) {
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.memberships[model.ID] = *model
+}
+
+func (r *organizationMemoryRepository) UpdateMember(ctx context.Context, model *models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.memberships[model.ID]; !ok || existing.TenantID != tenantID {
+		return organization.ErrMemberNotFound
+	}
+	model.UpdatedAt = time.Now()
+	r.memberships[model.ID] = *model
+	return nil
+}
+
+func (r *organizationMemoryRepository) DeleteMember(ctx context.Context, organizationID string, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	memberships, _, err := r.tenantMemberships(ctx, func(m models.Membership) bool {
+		return m.OrganizationID == organizationID && m.UserID == userID
+	})
+	if err != nil {
+		return err
+	}
+	if len(memberships) == 0 {
+		return organization.ErrMemberNotFound
+	}
+	delete(r.memberships, memberships[0].ID)
+	return nil
+}
+
+func (r *organizationMemoryRepository) StoreInvitation(ctx context.Context, model *models.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	r.invitations[model.ID] = *model
+	return nil
+}
+
+func (r *organizationMemoryRepository) FindInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.invitation(ctx, id)
+}
+
+// invitation return the invitation of the tenant of the context, callers hold the lock
+func (r *organizationMemoryRepository) invitation(ctx context.Context, id string) (*models.Invitation, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i, ok := r.invitations[id]
+	if !ok || i.TenantID != tenantID {
+		return nil, organization.ErrInvitationNotFound
+	}
+	return &i, nil
+}
+
+func (r *organizationMemoryRepository) FindInvitations(ctx context.Context, organizationID string, now time.Time, limit int64, offset int64) ([]models.Invitation, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []models.Invitation
+	for _, i := range r.invitations {
+		if i.TenantID == tenantID && i.OrganizationID == organizationID && i.Pending(now) {
+			all = append(all, i)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+	total := int64(len(all))
+	start, end := page(total, limit, offset)
+	return append([]models.Invitation{}, all[start:end]...), total, nil
+}
+
+func (r *organizationMemoryRepository) RevokeInvitation(ctx context.Context, organizationID string, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, err := r.invitation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if i.OrganizationID != organizationID || !i.Pending(at) {
+		return organization.ErrInvitationNotFound
+	}
+	i.RevokedAt = &at
+	r.invitations[id] = *i
+	return nil
+}
+
+func (r *organizationMemoryRepository) AcceptInvitation(ctx context.Context, id string, at time.Time, member *models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, err := r.invitation(ctx, id)
+	if err == organization.ErrInvitationNotFound || (err == nil && !i.Pending(at)) {
+		return organization.ErrInviteInvalid
+	}
+	if err != nil {
+		return err
+	}
+	i.AcceptedAt = &at
+	r.invitations[id] = *i
+	member.TenantID = i.TenantID
+	r.storeMember(member)
+	return nil
+}
+
+func (r *organizationMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	memberships, _, err := r.tenantMemberships(ctx, func(m models.Membership) bool { return m.UserID == userID })
+	if err != nil {
+		return err
+	}
+	for _, m := range memberships {
+		delete(r.memberships, m.ID)
+	}
+	return nil
+}
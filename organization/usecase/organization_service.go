@@ -0,0 +1,360 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/sirupsen/logrus"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	"go-echo-api/tenant"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultInviteTTL is the lifetime of the invite tokens when APP_INVITE_TTL is not set
+	DefaultInviteTTL = 72 * time.Hour
+	inviteType       = "invite"
+)
+
+type OrganizationService struct {
+	organizationRepository organization.Repository
+	inviteTTL              time.Duration
+}
+
+// NewOrganizationService return the use-case of the organizations, the invite tokens live for APP_INVITE_TTL
+func NewOrganizationService(r organization.Repository) organization.Usecase {
+	ttl, err := time.ParseDuration(os.Getenv("APP_INVITE_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = DefaultInviteTTL
+	}
+	return OrganizationService{organizationRepository: r, inviteTTL: ttl}
+}
+
+func (s OrganizationService) FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.Organization, int64, error) {
+	return s.organizationRepository.FindAll(ctx, userID, limit, offset)
+}
+
+func (s OrganizationService) FindById(ctx context.Context, userID string, id string) (*models.Organization, error) {
+	if _, err := s.membership(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	return s.organizationRepository.FindById(ctx, id)
+}
+
+// Create the organization with userID as its owner
+func (s OrganizationService) Create(ctx context.Context, userID string, dto organization.Dto) (models.Organization, error) {
+	model := models.Organization{Name: dto.Name}
+	owner := models.Membership{UserID: userID, Role: models.RoleOwner}
+	if err := s.organizationRepository.Store(ctx, &model, &owner); err != nil {
+		return model, err
+	}
+	logger.FromContext(ctx).WithField(logger.OrganizationIDField, model.ID).Info("organization created")
+	return model, nil
+}
+
+func (s OrganizationService) Update(ctx context.Context, userID string, id string, dto organization.Dto) (models.Organization, error) {
+	member, err := s.membership(ctx, userID, id)
+	if err != nil {
+		return models.Organization{}, err
+	}
+	if !member.CanManage() {
+		return models.Organization{}, organization.ErrForbidden
+	}
+	existing, err := s.organizationRepository.FindById(ctx, id)
+	if err != nil {
+		return models.Organization{}, err
+	}
+	model := *existing
+	model.Name = dto.Name
+	err = s.organizationRepository.Update(ctx, &model)
+	return model, err
+}
+
+// Delete the organization and its memberships, only an owner may
+func (s OrganizationService) Delete(ctx context.Context, userID string, id string) error {
+	member, err := s.membership(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if member.Role != models.RoleOwner {
+		return organization.ErrForbidden
+	}
+	if err := s.organizationRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.OrganizationIDField, id).Info("organization deleted")
+	return nil
+}
+
+func (s OrganizationService) FindMembers(ctx context.Context, userID string, id string, limit int64, offset int64) ([]models.Membership, int64, error) {
+	if _, err := s.membership(ctx, userID, id); err != nil {
+		return nil, 0, err
+	}
+	return s.organizationRepository.FindMembers(ctx, id, limit, offset)
+}
+
+// UpdateMember change the role of a member, the owners and admins may change the roles
+// but only an owner may grant or take the owner role, and the last owner cannot be demoted
+func (s OrganizationService) UpdateMember(ctx context.Context, userID string, id string, memberID string, dto organization.MemberDto) (models.Membership, error) {
+	actor, err := s.membership(ctx, userID, id)
+	if err != nil {
+		return models.Membership{}, err
+	}
+	if !actor.CanManage() {
+		return models.Membership{}, organization.ErrForbidden
+	}
+	existing, err := s.organizationRepository.FindMember(ctx, id, memberID)
+	if err != nil {
+		return models.Membership{}, err
+	}
+	model := *existing
+	if model.Role == dto.Role {
+		return model, nil
+	}
+	if (model.Role == models.RoleOwner || dto.Role == models.RoleOwner) && actor.Role != models.RoleOwner {
+		return model, organization.ErrForbidden
+	}
+	if model.Role == models.RoleOwner {
+		if err := s.keepOwner(ctx, id); err != nil {
+			return model, err
+		}
+	}
+	model.Role = dto.Role
+	if err := s.organizationRepository.UpdateMember(ctx, &model); err != nil {
+		return model, err
+	}
+	return model, nil
+}
+
+// RemoveMember remove a member from the organization, the members may leave, the owners and
+// admins may remove the others but only an owner may remove an owner, and the last owner cannot leave
+func (s OrganizationService) RemoveMember(ctx context.Context, userID string, id string, memberID string) error {
+	actor, err := s.membership(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	target := actor
+	if memberID != userID {
+		if !actor.CanManage() {
+			return organization.ErrForbidden
+		}
+		if target, err = s.organizationRepository.FindMember(ctx, id, memberID); err != nil {
+			return err
+		}
+		if target.Role == models.RoleOwner && actor.Role != models.RoleOwner {
+			return organization.ErrForbidden
+		}
+	}
+	if target.Role == models.RoleOwner {
+		if err := s.keepOwner(ctx, id); err != nil {
+			return err
+		}
+	}
+	return s.organizationRepository.DeleteMember(ctx, id, memberID)
+}
+
+// Invite store the invitation and return a signed token letting the owner of the email join the organization
+// with the role once, the owners and admins may invite but only an owner may invite an owner
+func (s OrganizationService) Invite(ctx context.Context, userID string, id string, dto organization.InviteDto) (string, organization.Invitation, error) {
+	actor, err := s.membership(ctx, userID, id)
+	if err != nil {
+		return "", organization.Invitation{}, err
+	}
+	if !actor.CanManage() || (dto.Role == models.RoleOwner && actor.Role != models.RoleOwner) {
+		return "", organization.Invitation{}, organization.ErrForbidden
+	}
+	model := models.Invitation{
+		OrganizationID: id,
+		Email:          strings.ToLower(dto.Email),
+		Role:           dto.Role,
+		InvitedBy:      userID,
+		ExpiresAt:      time.Now().Add(s.inviteTTL).Truncate(time.Second),
+	}
+	if err := s.organizationRepository.StoreInvitation(ctx, &model); err != nil {
+		return "", organization.Invitation{}, err
+	}
+	invitation := invitationOf(model)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":             inviteType,
+		"jti":             invitation.ID,
+		"tenant_id":       invitation.TenantID,
+		"organization_id": invitation.OrganizationID,
+		"email":           invitation.Email,
+		"role":            invitation.Role,
+		"iat":             time.Now().Unix(),
+		"exp":             invitation.ExpiresAt.Unix(),
+	})
+	signed, err := token.SignedString(inviteKey())
+	if err != nil {
+		return "", invitation, err
+	}
+	logger.FromContext(ctx).WithField(logger.OrganizationIDField, id).Info("member invited")
+	return signed, invitation, nil
+}
+
+// ParseInvite verify the signature of the invite token, that it was issued in the tenant of ctx and that
+// its invitation is pending, the invitations accepted, revoked or expired are refused
+func (s OrganizationService) ParseInvite(ctx context.Context, signed string) (organization.Invitation, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return organization.Invitation{}, err
+	}
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return inviteKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return organization.Invitation{}, organization.ErrInviteInvalid
+	}
+	claims, _ := token.Claims.(jwt.MapClaims)
+	claim := func(name string) string {
+		value, _ := claims[name].(string)
+		return value
+	}
+	if claim("typ") != inviteType || claim("tenant_id") != tenantID || claim("jti") == "" {
+		return organization.Invitation{}, organization.ErrInviteInvalid
+	}
+	model, err := s.organizationRepository.FindInvitation(ctx, claim("jti"))
+	if err == organization.ErrInvitationNotFound {
+		return organization.Invitation{}, organization.ErrInviteInvalid
+	}
+	if err != nil {
+		return organization.Invitation{}, err
+	}
+	if !model.Pending(time.Now()) {
+		return organization.Invitation{}, organization.ErrInviteInvalid
+	}
+	return invitationOf(*model), nil
+}
+
+// Join add the user to the organization of the invitation and consume the invitation,
+// the invitation must have been sent to the email of the user
+func (s OrganizationService) Join(ctx context.Context, invitation organization.Invitation, user models.User) (models.Membership, error) {
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return models.Membership{}, organization.ErrInviteEmail
+	}
+	if _, err := s.organizationRepository.FindById(ctx, invitation.OrganizationID); err != nil {
+		return models.Membership{}, err
+	}
+	_, err := s.organizationRepository.FindMember(ctx, invitation.OrganizationID, user.ID)
+	if err == nil {
+		return models.Membership{}, organization.ErrAlreadyMember
+	}
+	if err != organization.ErrMemberNotFound {
+		return models.Membership{}, err
+	}
+	model := models.Membership{OrganizationID: invitation.OrganizationID, UserID: user.ID, Role: invitation.Role}
+	if err := s.organizationRepository.AcceptInvitation(ctx, invitation.ID, time.Now(), &model); err != nil {
+		return model, err
+	}
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		logger.OrganizationIDField: model.OrganizationID,
+		logger.UserIDField:         user.ID,
+	}).Info("invite accepted")
+	return model, nil
+}
+
+// FindInvitations return the pending invitations of the organization to its owners and admins
+func (s OrganizationService) FindInvitations(ctx context.Context, userID string, id string, limit int64, offset int64) ([]models.Invitation, int64, error) {
+	actor, err := s.membership(ctx, userID, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !actor.CanManage() {
+		return nil, 0, organization.ErrForbidden
+	}
+	return s.organizationRepository.FindInvitations(ctx, id, time.Now(), limit, offset)
+}
+
+// RevokeInvitation cancel a pending invitation, its token does not join anymore. The owners and admins
+// may revoke but only an owner may revoke the invitation of an owner
+func (s OrganizationService) RevokeInvitation(ctx context.Context, userID string, id string, invitationID string) error {
+	actor, err := s.membership(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !actor.CanManage() {
+		return organization.ErrForbidden
+	}
+	invitation, err := s.organizationRepository.FindInvitation(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation.OrganizationID != id {
+		return organization.ErrInvitationNotFound
+	}
+	if invitation.Role == models.RoleOwner && actor.Role != models.RoleOwner {
+		return organization.ErrForbidden
+	}
+	if err := s.organizationRepository.RevokeInvitation(ctx, id, invitationID, time.Now()); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.OrganizationIDField, id).Info("invitation revoked")
+	return nil
+}
+
+func (s OrganizationService) ActiveOrganization(ctx context.Context, userID string, preferred string) (string, error) {
+	if preferred != "" {
+		_, err := s.organizationRepository.FindMember(ctx, preferred, userID)
+		if err == nil {
+			return preferred, nil
+		}
+		if err != organization.ErrMemberNotFound {
+			return "", err
+		}
+	}
+	first, err := s.organizationRepository.FindFirstMembership(ctx, userID)
+	if err == organization.ErrMemberNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return first.OrganizationID, nil
+}
+
+// membership return the membership of the user in the organization, the organizations
+// of which the user is not a member are not found
+func (s OrganizationService) membership(ctx context.Context, userID string, id string) (*models.Membership, error) {
+	member, err := s.organizationRepository.FindMember(ctx, id, userID)
+	if err == organization.ErrMemberNotFound {
+		return nil, organization.ErrNotFound
+	}
+	return member, err
+}
+
+// keepOwner fail when the organization would be left without owner by removing one
+func (s OrganizationService) keepOwner(ctx context.Context, id string) error {
+	owners, err := s.organizationRepository.CountOwners(ctx, id)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return organization.ErrLastOwner
+	}
+	return nil
+}
+
+// invitationOf return the content of the invite token of the invitation
+func invitationOf(model models.Invitation) organization.Invitation {
+	return organization.Invitation{
+		ID:             model.ID,
+		TenantID:       model.TenantID,
+		OrganizationID: model.OrganizationID,
+		Email:          model.Email,
+		Role:           model.Role,
+		ExpiresAt:      model.ExpiresAt,
+	}
+}
+
+// inviteKey sign the invite tokens, it differs from the key of the access tokens
+// so an invite token is never accepted as an access token
+func inviteKey() []byte {
+	return []byte(inviteType + ":" + os.Getenv("JWT_SECRET_KEY"))
+}
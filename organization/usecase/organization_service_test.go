@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/organization"
+	"go-echo-api/organization/repository"
+	"testing"
+)
+
+// newOrganizationService return a service backed by an in-memory repository holding the fixture
+// organization, owned by UserUje with UserIpan as member
+func newOrganizationService() organization.Usecase {
+	return NewOrganizationService(repository.NewOrganizationMemoryRepository(
+		[]models.Organization{dbtest.OrganizationAcme},
+		[]models.Membership{dbtest.MembershipUje, dbtest.MembershipIpan},
+	))
+}
+
+func TestOrganizationService_Create(t *testing.T) {
+	o := newOrganizationService()
+
+	s := t.Run("success", func(t *testing.T) {
+		// the creator owns the organization
+		data, err := o.Create(dbtest.Context(), dbtest.UserIpan.ID, organization.Dto{Name: "Ipan's team"})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data.ID)
+		members, total, err := o.FindMembers(dbtest.Context(), dbtest.UserIpan.ID, data.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, models.RoleOwner, members[0].Role)
+
+		list, total, err := o.FindAll(dbtest.Context(), dbtest.UserIpan.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, list, 2)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// the organizations of other users are not found
+		_, err := o.FindById(dbtest.Context(), dbtest.UserGlobexUje.ID, dbtest.OrganizationAcme.ID)
+		assert.Equal(t, organization.ErrNotFound, err)
+
+		// nor those of other tenants
+		_, err = o.FindById(dbtest.TenantContext(dbtest.TenantGlobex), dbtest.UserUje.ID, dbtest.OrganizationAcme.ID)
+		assert.Equal(t, organization.ErrNotFound, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestOrganizationService_Roles(t *testing.T) {
+	o := newOrganizationService()
+	id := dbtest.OrganizationAcme.ID
+
+	f := t.Run("error-forbidden", func(t *testing.T) {
+		// a member may neither rename, delete, invite nor manage the others
+		_, err := o.Update(dbtest.Context(), dbtest.UserIpan.ID, id, organization.Dto{Name: "Renamed"})
+		assert.Equal(t, organization.ErrForbidden, err)
+		assert.Equal(t, organization.ErrForbidden, o.Delete(dbtest.Context(), dbtest.UserIpan.ID, id))
+		_, _, err = o.Invite(dbtest.Context(), dbtest.UserIpan.ID, id, organization.InviteDto{Email: "budi@email.com", Role: models.RoleMember})
+		assert.Equal(t, organization.ErrForbidden, err)
+		assert.Equal(t, organization.ErrForbidden, o.RemoveMember(dbtest.Context(), dbtest.UserIpan.ID, id, dbtest.UserUje.ID))
+	})
+	l := t.Run("error-last-owner", func(t *testing.T) {
+		_, err := o.UpdateMember(dbtest.Context(), dbtest.UserUje.ID, id, dbtest.UserUje.ID, organization.MemberDto{Role: models.RoleMember})
+		assert.Equal(t, organization.ErrLastOwner, err)
+		assert.Equal(t, organization.ErrLastOwner, o.RemoveMember(dbtest.Context(), dbtest.UserUje.ID, id, dbtest.UserUje.ID))
+	})
+	s := t.Run("success", func(t *testing.T) {
+		// an admin may rename, only an owner may grant the owner role
+		data, err := o.UpdateMember(dbtest.Context(), dbtest.UserUje.ID, id, dbtest.UserIpan.ID, organization.MemberDto{Role: models.RoleAdmin})
+		assert.NoError(t, err)
+		assert.Equal(t, models.RoleAdmin, data.Role)
+		_, err = o.Update(dbtest.Context(), dbtest.UserIpan.ID, id, organization.Dto{Name: "Renamed"})
+		assert.NoError(t, err)
+		_, err = o.UpdateMember(dbtest.Context(), dbtest.UserIpan.ID, id, dbtest.UserIpan.ID, organization.MemberDto{Role: models.RoleOwner})
+		assert.Equal(t, organization.ErrForbidden, err)
+
+		// a member may leave
+		assert.NoError(t, o.RemoveMember(dbtest.Context(), dbtest.UserIpan.ID, id, dbtest.UserIpan.ID))
+		_, err = o.FindById(dbtest.Context(), dbtest.UserIpan.ID, id)
+		assert.Equal(t, organization.ErrNotFound, err)
+
+		assert.NoError(t, o.Delete(dbtest.Context(), dbtest.UserUje.ID, id))
+	})
+	assert.Equal(t, true, f, "Forbidden scenario failed run")
+	assert.Equal(t, true, l, "Last owner scenario failed run")
+	assert.Equal(t, true, s, "Success scenario failed run")
+}
+
+func TestOrganizationService_Invite(t *testing.T) {
+	o := newOrganizationService()
+	id := dbtest.OrganizationAcme.ID
+	budi := models.User{ID: "budi-id", TenantID: dbtest.TenantAcme.ID, Email: "budi@email.com"}
+
+	s := t.Run("success", func(t *testing.T) {
+		token, invitation, err := o.Invite(dbtest.Context(), dbtest.UserUje.ID, id, organization.InviteDto{Email: "Budi@email.com", Role: models.RoleAdmin})
+		assert.NoError(t, err)
+		assert.Equal(t, "budi@email.com", invitation.Email)
+
+		parsed, err := o.ParseInvite(dbtest.Context(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, invitation, parsed)
+
+		data, err := o.Join(dbtest.Context(), parsed, budi)
+		assert.NoError(t, err)
+		assert.Equal(t, models.RoleAdmin, data.Role)
+		// the invitation is consumed by the join
+		_, err = o.ParseInvite(dbtest.Context(), token)
+		assert.Equal(t, organization.ErrInviteInvalid, err)
+
+		active, err := o.ActiveOrganization(dbtest.Context(), budi.ID, "")
+		assert.NoError(t, err)
+		assert.Equal(t, id, active)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		token, invitation, err := o.Invite(dbtest.Context(), dbtest.UserUje.ID, id, organization.InviteDto{Email: budi.Email, Role: models.RoleMember})
+		assert.NoError(t, err)
+		_, err = o.Join(dbtest.Context(), invitation, budi)
+		assert.Equal(t, organization.ErrAlreadyMember, err)
+		_, err = o.Join(dbtest.Context(), invitation, dbtest.UserIpan)
+		assert.Equal(t, organization.ErrInviteEmail, err)
+
+		// the tokens of another tenant, tampered tokens and access tokens are refused
+		_, err = o.ParseInvite(dbtest.TenantContext(dbtest.TenantGlobex), token)
+		assert.Equal(t, organization.ErrInviteInvalid, err)
+		_, err = o.ParseInvite(dbtest.Context(), token+"x")
+		assert.Equal(t, organization.ErrInviteInvalid, err)
+	})
+	r := t.Run("success-revoke", func(t *testing.T) {
+		o := newOrganizationService()
+		token, invitation, err := o.Invite(dbtest.Context(), dbtest.UserUje.ID, id, organization.InviteDto{Email: "carol@email.com", Role: models.RoleOwner})
+		assert.NoError(t, err)
+		pending, total, err := o.FindInvitations(dbtest.Context(), dbtest.UserUje.ID, id, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, invitation.ID, pending[0].ID)
+
+		// a member neither lists nor revokes the invitations
+		_, _, err = o.FindInvitations(dbtest.Context(), dbtest.UserIpan.ID, id, 10, 0)
+		assert.Equal(t, organization.ErrForbidden, err)
+		assert.Equal(t, organization.ErrForbidden, o.RevokeInvitation(dbtest.Context(), dbtest.UserIpan.ID, id, invitation.ID))
+
+		// the token of a revoked invitation does not join
+		assert.NoError(t, o.RevokeInvitation(dbtest.Context(), dbtest.UserUje.ID, id, invitation.ID))
+		_, err = o.ParseInvite(dbtest.Context(), token)
+		assert.Equal(t, organization.ErrInviteInvalid, err)
+		_, err = o.Join(dbtest.Context(), invitation, models.User{ID: "carol-id", TenantID: dbtest.TenantAcme.ID, Email: "carol@email.com"})
+		assert.Equal(t, organization.ErrInviteInvalid, err)
+		assert.Equal(t, organization.ErrInvitationNotFound, o.RevokeInvitation(dbtest.Context(), dbtest.UserUje.ID, id, invitation.ID))
+		_, total, err = o.FindInvitations(dbtest.Context(), dbtest.UserUje.ID, id, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, r, "Revoke scenario failed run")
+}
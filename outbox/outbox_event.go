@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"encoding/json"
+	"go-echo-api/models"
+	"time"
+)
+
+// domain events of the users
+const (
+	EventUserRegistered      = "user.registered"
+	EventUserUpdated         = "user.updated"
+	EventUserDeleted         = "user.deleted"
+	EventUserPasswordChanged = "user.password_changed"
+)
+
+// EventTypes are the domain events published
+var EventTypes = []string{EventUserRegistered, EventUserUpdated, EventUserDeleted, EventUserPasswordChanged}
+
+// Event is a domain event as published to the sinks, ID is the same on every delivery of the event
+// so that the consumers drop the duplicates
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	TenantID    string          `json:"tenant_id"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+func NewEvent(model models.OutboxEvent) Event {
+	return Event{
+		ID:          model.ID,
+		Type:        model.Type,
+		TenantID:    model.TenantID,
+		AggregateID: model.AggregateID,
+		OccurredAt:  model.CreatedAt,
+		Data:        json.RawMessage(model.Payload),
+	}
+}
+
+// UserData is the data of the events of the users, never their password
+type UserData struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func NewUserData(model models.User) UserData {
+	return UserData{ID: model.ID, Name: model.Name, Email: model.Email}
+}
@@ -0,0 +1,22 @@
+package outbox
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+type Repository interface {
+	// Transaction run fn with a context whose writes, in this repository and the others of the database,
+	// commit together when fn returns nil
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// Store the event in the tenant of the context, in its transaction if any
+	Store(ctx context.Context, model *models.OutboxEvent) error
+	// FindDue return at most limit events of every tenant neither published nor failed and due at now, the oldest first
+	FindDue(ctx context.Context, now time.Time, limit int64) ([]models.OutboxEvent, error)
+	// Claim push the next attempt of the event due at now back to until, false when another dispatcher
+	// claimed it first
+	Claim(ctx context.Context, id string, now time.Time, until time.Time) (bool, error)
+	// Update save the outcome of an attempt: the attempts, next attempt, last error, publication and failure
+	Update(ctx context.Context, model *models.OutboxEvent) error
+}
@@ -0,0 +1,10 @@
+package outbox
+
+import "context"
+
+// Sink is where the dispatcher publishes the events, an error has the event published again later
+type Sink interface {
+	// Name of the sink in the logs
+	Name() string
+	Publish(ctx context.Context, event Event) error
+}
@@ -0,0 +1,12 @@
+package outbox
+
+import "context"
+
+// Usecase write the domain events to the outbox with the changes they tell
+type Usecase interface {
+	// Transaction run fn with a context whose changes, and the events published with it, commit together
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// Publish write the event of eventType about aggregateID with data as its JSON payload,
+	// in the transaction of the context
+	Publish(ctx context.Context, eventType string, aggregateID string, data interface{}) error
+}
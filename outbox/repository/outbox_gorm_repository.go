@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"time"
+)
+
+type outboxGormRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) outbox.Repository {
+	return &outboxGormRepository{db: db}
+}
+
+func (r *outboxGormRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.RunInTransaction(ctx, r.db, fn)
+}
+
+func (r *outboxGormRepository) Store(ctx context.Context, model *models.OutboxEvent) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+// FindDue is not scoped to a tenant, the dispatcher publishes the events of every tenant
+func (r *outboxGormRepository) FindDue(ctx context.Context, now time.Time, limit int64) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := database.WithContext(ctx, r.db).
+		Where("published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", now).
+		Order("created_at ASC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *outboxGormRepository) Claim(ctx context.Context, id string, now time.Time, until time.Time) (bool, error) {
+	result := database.WithContext(ctx, r.db).Model(&models.OutboxEvent{}).
+		Where("id = ? AND published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", id, now).
+		Update("next_attempt_at", until)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *outboxGormRepository) Update(ctx context.Context, model *models.OutboxEvent) error {
+	return database.WithContext(ctx, r.db).Model(&models.OutboxEvent{}).Where("id = ?", model.ID).
+		Updates(map[string]interface{}{
+			"attempts":        model.Attempts,
+			"next_attempt_at": model.NextAttemptAt,
+			"last_error":      model.LastError,
+			"published_at":    model.PublishedAt,
+			"failed_at":       model.FailedAt,
+		}).Error
+}
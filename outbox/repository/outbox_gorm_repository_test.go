@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"testing"
+	"time"
+)
+
+func TestOutboxGormRepository(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewOutboxRepository(db)
+	now := time.Now().Truncate(time.Second)
+	first := models.OutboxEvent{Type: outbox.EventUserRegistered, AggregateID: dbtest.UserUje.ID, Payload: "{}", CreatedAt: now.Add(-time.Minute), NextAttemptAt: now.Add(-time.Minute)}
+	second := models.OutboxEvent{Type: outbox.EventUserUpdated, AggregateID: dbtest.UserUje.ID, Payload: "{}", CreatedAt: now, NextAttemptAt: now}
+	later := models.OutboxEvent{Type: outbox.EventUserDeleted, AggregateID: dbtest.UserUje.ID, Payload: "{}", CreatedAt: now.Add(time.Second), NextAttemptAt: now.Add(time.Hour)}
+	err := r.Transaction(dbtest.Context(), func(ctx context.Context) error {
+		assert.NoError(t, r.Store(ctx, &first))
+		return r.Store(ctx, &later)
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Store(dbtest.TenantContext(dbtest.TenantGlobex), &second))
+	assert.Equal(t, dbtest.TenantGlobex.ID, second.TenantID)
+
+	// the due events of every tenant, the oldest first
+	due, err := r.FindDue(context.Background(), now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 2) {
+		assert.Equal(t, first.ID, due[0].ID)
+		assert.Equal(t, second.ID, due[1].ID)
+	}
+
+	// a single dispatcher claims an event
+	claimed, err := r.Claim(context.Background(), first.ID, now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, true, claimed)
+	claimed, _ = r.Claim(context.Background(), first.ID, now, now.Add(time.Minute))
+	assert.Equal(t, false, claimed)
+
+	first.PublishedAt = &now
+	assert.NoError(t, r.Update(context.Background(), &first))
+	due, _ = r.FindDue(context.Background(), now.Add(time.Hour), 10)
+	if assert.Len(t, due, 2) {
+		assert.Equal(t, second.ID, due[0].ID)
+		assert.Equal(t, later.ID, due[1].ID)
+	}
+	due, _ = r.FindDue(context.Background(), now.Add(time.Hour), 1)
+	assert.Len(t, due, 1)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"go-echo-api/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// outboxMemoryRepository keeps the events in memory, it backs the use-case unit tests
+type outboxMemoryRepository struct {
+	mu     sync.RWMutex
+	events map[string]models.OutboxEvent
+}
+
+func NewOutboxMemoryRepository() outbox.Repository {
+	return &outboxMemoryRepository{events: make(map[string]models.OutboxEvent)}
+}
+
+type memoryTransactionKey struct{}
+
+// memoryTransaction holds the events stored in a transaction, they are kept when it commits
+type memoryTransaction struct {
+	events []models.OutboxEvent
+}
+
+func (r *outboxMemoryRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(memoryTransactionKey{}).(*memoryTransaction); ok {
+		return fn(ctx)
+	}
+	tx := &memoryTransaction{}
+	if err := fn(context.WithValue(ctx, memoryTransactionKey{}, tx)); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range tx.events {
+		r.events[event.ID] = event
+	}
+	return nil
+}
+
+func (r *outboxMemoryRepository) Store(ctx context.Context, model *models.OutboxEvent) error {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	model.ID = uuid.New().String()
+	model.TenantID = tenantID
+	if tx, ok := ctx.Value(memoryTransactionKey{}).(*memoryTransaction); ok {
+		tx.events = append(tx.events, *model)
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[model.ID] = *model
+	return nil
+}
+
+func (r *outboxMemoryRepository) FindDue(ctx context.Context, now time.Time, limit int64) ([]models.OutboxEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var events []models.OutboxEvent
+	for _, event := range r.events {
+		if event.PublishedAt == nil && event.FailedAt == nil && !event.NextAttemptAt.After(now) {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	if limit >= 0 && int64(len(events)) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (r *outboxMemoryRepository) Claim(ctx context.Context, id string, now time.Time, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[id]
+	if !ok || event.PublishedAt != nil || event.FailedAt != nil || event.NextAttemptAt.After(now) {
+		return false, nil
+	}
+	event.NextAttemptAt = until
+	r.events[id] = event
+	return true, nil
+}
+
+func (r *outboxMemoryRepository) Update(ctx context.Context, model *models.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.events[model.ID]; !ok {
+		return nil
+	}
+	r.events[model.ID] = *model
+	return nil
+}
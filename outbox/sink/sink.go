@@ -0,0 +1,33 @@
+// Package sink holds the sinks the outbox dispatcher publishes the domain events to:
+// the handlers of the process, a webhook and a writer of JSON lines.
+package sink
+
+import (
+	"go-echo-api/outbox"
+	"io"
+	"os"
+)
+
+// FromEnv return the sinks configured by APP_OUTBOX_WEBHOOK_URL and APP_OUTBOX_FILE, a path or "-" for stdout,
+// with the returned subscribers. The closer closes the file, if any.
+func FromEnv() ([]outbox.Sink, *Subscribers, io.Closer, error) {
+	subscribers := NewSubscribers()
+	sinks := []outbox.Sink{subscribers}
+	if url := os.Getenv("APP_OUTBOX_WEBHOOK_URL"); url != "" {
+		sinks = append(sinks, NewHTTPSink(url, nil))
+	}
+	var closer io.Closer
+	switch path := os.Getenv("APP_OUTBOX_FILE"); path {
+	case "":
+	case "-":
+		sinks = append(sinks, NewWriterSink(os.Stdout))
+	default:
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sinks = append(sinks, NewWriterSink(file))
+		closer = file
+	}
+	return sinks, subscribers, closer, nil
+}
@@ -0,0 +1,94 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/outbox"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var event = outbox.Event{
+	ID:          "7e0f9c51-8a45-4a2b-9d5c-3c1f2b7c4d10",
+	Type:        outbox.EventUserRegistered,
+	TenantID:    "tenant",
+	AggregateID: "user",
+	OccurredAt:  time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	Data:        json.RawMessage(`{"id":"user","name":"Uje","email":"uje@email.com"}`),
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewWriterSink(&buf)
+	assert.NoError(t, s.Publish(context.Background(), event))
+	assert.NoError(t, s.Publish(context.Background(), event))
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if assert.Len(t, lines, 2) {
+		var decoded outbox.Event
+		assert.NoError(t, json.Unmarshal(lines[0], &decoded))
+		assert.Equal(t, event.ID, decoded.ID)
+		assert.JSONEq(t, string(event.Data), string(decoded.Data))
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	status := http.StatusNoContent
+	var received outbox.Event
+	var eventType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventType = r.Header.Get("X-Event-Type")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	sink := NewHTTPSink(server.URL, nil)
+
+	s := t.Run("success", func(t *testing.T) {
+		assert.NoError(t, sink.Publish(context.Background(), event))
+		assert.Equal(t, event.ID, received.ID)
+		assert.Equal(t, outbox.EventUserRegistered, eventType)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+		assert.EqualError(t, sink.Publish(context.Background(), event), "webhook answered 503")
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestSubscribers(t *testing.T) {
+	subscribers := NewSubscribers()
+	var handled []string
+	subscribers.Subscribe(outbox.EventUserRegistered, func(ctx context.Context, e outbox.Event) error {
+		handled = append(handled, "registered")
+		return nil
+	})
+	subscribers.Subscribe(All, func(ctx context.Context, e outbox.Event) error {
+		handled = append(handled, "all")
+		return nil
+	})
+
+	s := t.Run("success", func(t *testing.T) {
+		assert.NoError(t, subscribers.Publish(context.Background(), event))
+		deleted := event
+		deleted.Type = outbox.EventUserDeleted
+		assert.NoError(t, subscribers.Publish(context.Background(), deleted))
+		assert.Equal(t, []string{"registered", "all", "all"}, handled)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		failure := errors.New("failure")
+		subscribers.Subscribe(outbox.EventUserDeleted, func(ctx context.Context, e outbox.Event) error {
+			return failure
+		})
+		deleted := event
+		deleted.Type = outbox.EventUserDeleted
+		assert.Equal(t, failure, subscribers.Publish(context.Background(), deleted))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
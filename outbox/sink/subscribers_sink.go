@@ -0,0 +1,54 @@
+package sink
+
+import (
+	"context"
+	"go-echo-api/outbox"
+	"sync"
+)
+
+// All subscribes a handler to every event type
+const All = "*"
+
+// Handler handles an event in the process, an error has the event published again later
+type Handler func(ctx context.Context, event outbox.Event) error
+
+// AggregateHandler return the handler calling fn with the aggregate of the event, e.g. the ID of the user deleted
+func AggregateHandler(fn func(ctx context.Context, aggregateID string) error) Handler {
+	return func(ctx context.Context, event outbox.Event) error {
+		return fn(ctx, event.AggregateID)
+	}
+}
+
+// Subscribers is a sink handing the events to the handlers subscribed in the process
+type Subscribers struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewSubscribers() *Subscribers {
+	return &Subscribers{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe handler to the events of eventType, or to every event with All
+func (s *Subscribers) Subscribe(eventType string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[eventType] = append(s.handlers[eventType], handler)
+}
+
+func (s *Subscribers) Name() string {
+	return "subscribers"
+}
+
+// Publish hand the event to its handlers, stopping at the first failure
+func (s *Subscribers) Publish(ctx context.Context, event outbox.Event) error {
+	s.mu.RLock()
+	handlers := append(append([]Handler{}, s.handlers[event.Type]...), s.handlers[All]...)
+	s.mu.RUnlock()
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
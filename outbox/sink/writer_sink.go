@@ -0,0 +1,34 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"go-echo-api/outbox"
+	"io"
+	"sync"
+)
+
+// writerSink writes the events as JSON lines, e.g. to stdout or to a file
+type writerSink struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewWriterSink(w io.Writer) outbox.Sink {
+	return &writerSink{writer: w}
+}
+
+func (s *writerSink) Name() string {
+	return "writer"
+}
+
+func (s *writerSink) Publish(_ context.Context, event outbox.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.writer.Write(append(line, '\n'))
+	return err
+}
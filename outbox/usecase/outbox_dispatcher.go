@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/retry"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"go-echo-api/tenant"
+	"strings"
+	"time"
+)
+
+type DispatcherConfig struct {
+	// Interval between two polls of the outbox, default 1s
+	Interval time.Duration
+
+	// Lease is how long a dispatcher holds an event it publishes before another one may take it, default 1m
+	Lease time.Duration
+
+	// BatchSize is the number of events published by a poll at most, default 100
+	BatchSize int64
+
+	// Policy of the retries of an event a sink failed, marked failed after MaxAttempts, default 10 attempts
+	// and a backoff of 1s up to 1h
+	retry.Policy
+}
+
+var DefaultDispatcherConfig = DispatcherConfig{
+	Interval:  time.Second,
+	Lease:     time.Minute,
+	BatchSize: 100,
+	Policy:    retry.Policy{MaxAttempts: 10, Backoff: time.Second, MaxBackoff: time.Hour},
+}
+
+// DispatcherConfigFromEnv read the configuration of the dispatcher from APP_OUTBOX_INTERVAL,
+// APP_OUTBOX_BATCH_SIZE, APP_OUTBOX_MAX_ATTEMPTS and APP_OUTBOX_BACKOFF, e.g. "2s"
+func DispatcherConfigFromEnv() DispatcherConfig {
+	config := DefaultDispatcherConfig
+	config.Interval = retry.EnvDuration("APP_OUTBOX_INTERVAL", config.Interval)
+	config.BatchSize = retry.EnvInt64("APP_OUTBOX_BATCH_SIZE", config.BatchSize)
+	config.Policy = config.Policy.FromEnv("APP_OUTBOX")
+	return config
+}
+
+// Dispatcher publishes the events of the outbox to the sinks, at least once: an event is published again,
+// to every sink, until they all accept it. The dispatchers of several instances share the outbox,
+// each event is claimed by one of them for the lease.
+type Dispatcher struct {
+	outboxRepository outbox.Repository
+	sinks            []outbox.Sink
+	config           DispatcherConfig
+	now              func() time.Time
+}
+
+func NewDispatcher(r outbox.Repository, sinks []outbox.Sink, config DispatcherConfig) *Dispatcher {
+	config.Interval = retry.Duration(config.Interval, DefaultDispatcherConfig.Interval)
+	config.Lease = retry.Duration(config.Lease, DefaultDispatcherConfig.Lease)
+	config.BatchSize = retry.Int64(config.BatchSize, DefaultDispatcherConfig.BatchSize)
+	config.Policy = config.Policy.WithDefaults(DefaultDispatcherConfig.Policy)
+	return &Dispatcher{outboxRepository: r, sinks: sinks, config: config, now: time.Now}
+}
+
+// Run poll the outbox every interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).WithError(err).Error("dispatch outbox")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch publish the events due and return the number of events published
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	now := d.now().UTC()
+	events, err := d.outboxRepository.FindDue(ctx, now, d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return published, ctx.Err()
+		}
+		claimed, err := d.outboxRepository.Claim(ctx, event.ID, now, now.Add(d.config.Lease))
+		if err != nil {
+			return published, err
+		}
+		if !claimed {
+			continue
+		}
+		ok, err := d.publish(ctx, event)
+		if err != nil {
+			return published, err
+		}
+		if ok {
+			published++
+		}
+	}
+	return published, nil
+}
+
+// publish the event to every sink and save the outcome, false when a sink failed
+func (d *Dispatcher) publish(ctx context.Context, model models.OutboxEvent) (bool, error) {
+	event := outbox.NewEvent(model)
+	sinkCtx := tenant.WithContext(ctx, models.Tenant{ID: model.TenantID})
+	var failures []string
+	for _, sink := range d.sinks {
+		if err := sink.Publish(sinkCtx, event); err != nil {
+			logger.FromContext(ctx).WithError(err).
+				WithField("event_id", event.ID).WithField("sink", sink.Name()).Warn("publish outbox event")
+			failures = append(failures, sink.Name()+": "+err.Error())
+		}
+	}
+	now := d.now().UTC()
+	if len(failures) == 0 {
+		model.PublishedAt = &now
+		model.LastError = ""
+		return true, d.outboxRepository.Update(ctx, &model)
+	}
+	model.Attempts++
+	model.LastError = strings.Join(failures, "; ")
+	if model.Attempts >= d.config.MaxAttempts {
+		model.FailedAt = &now
+	} else {
+		model.NextAttemptAt = now.Add(d.config.Delay(model.Attempts))
+	}
+	return false, d.outboxRepository.Update(ctx, &model)
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/retry"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/repository"
+	"go-echo-api/tenant"
+	"testing"
+	"time"
+)
+
+// recordingSink records the events published and the tenants of their context, it fails while err is set
+type recordingSink struct {
+	events  []outbox.Event
+	tenants []string
+	err     error
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event outbox.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	tenantID, _ := tenant.ID(ctx)
+	s.events = append(s.events, event)
+	s.tenants = append(s.tenants, tenantID)
+	return nil
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	r := repository.NewOutboxMemoryRepository()
+	o := NewOutboxService(r)
+	sink := &recordingSink{}
+	d := NewDispatcher(r, []outbox.Sink{sink}, DispatcherConfig{Policy: retry.Policy{MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: time.Hour}})
+	assert.NoError(t, o.Publish(dbtest.Context(), outbox.EventUserRegistered, dbtest.UserUje.ID, outbox.NewUserData(dbtest.UserUje)))
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	f := t.Run("error-failed", func(t *testing.T) {
+		// a failure backs the event off, exponentially
+		sink.err = errors.New("unavailable")
+		published, err := d.Dispatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, published)
+		due, _ := r.FindDue(context.Background(), now.Add(time.Minute), 10)
+		if assert.Len(t, due, 1) {
+			assert.Equal(t, 1, due[0].Attempts)
+			assert.Equal(t, "recording: unavailable", due[0].LastError)
+		}
+		now = now.Add(time.Minute)
+		_, _ = d.Dispatch(context.Background())
+		due, _ = r.FindDue(context.Background(), now.Add(time.Minute), 10)
+		assert.Len(t, due, 0)
+		due, _ = r.FindDue(context.Background(), now.Add(2*time.Minute), 10)
+		assert.Len(t, due, 1)
+	})
+	s := t.Run("success", func(t *testing.T) {
+		// the event is published once the sink is back, in the tenant of the event
+		sink.err = nil
+		now = now.Add(2 * time.Minute)
+		published, err := d.Dispatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, published)
+		if assert.Len(t, sink.events, 1) {
+			assert.Equal(t, outbox.EventUserRegistered, sink.events[0].Type)
+			assert.Equal(t, dbtest.UserUje.ID, sink.events[0].AggregateID)
+			assert.Equal(t, []string{dbtest.TenantAcme.ID}, sink.tenants)
+		}
+		published, _ = d.Dispatch(context.Background())
+		assert.Equal(t, 0, published)
+	})
+	a := t.Run("error-max-attempts", func(t *testing.T) {
+		// the event fails for good after its last attempt
+		sink.err = errors.New("unavailable")
+		assert.NoError(t, o.Publish(dbtest.Context(), outbox.EventUserDeleted, dbtest.UserUje.ID, outbox.NewUserData(dbtest.UserUje)))
+		for i := 0; i < 3; i++ {
+			_, _ = d.Dispatch(context.Background())
+			now = now.Add(time.Hour)
+		}
+		due, _ := r.FindDue(context.Background(), now.Add(24*time.Hour), 10)
+		assert.Len(t, due, 0)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, a, "Max attempts scenario failed run")
+}
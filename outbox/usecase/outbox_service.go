@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"time"
+)
+
+type OutboxService struct {
+	outboxRepository outbox.Repository
+}
+
+func NewOutboxService(r outbox.Repository) outbox.Usecase {
+	return OutboxService{outboxRepository: r}
+}
+
+func (s OutboxService) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.outboxRepository.Transaction(ctx, fn)
+}
+
+func (s OutboxService) Publish(ctx context.Context, eventType string, aggregateID string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	return s.outboxRepository.Store(ctx, &models.OutboxEvent{
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		Payload:       string(payload),
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	})
+}
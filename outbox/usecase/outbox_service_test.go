@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/repository"
+	"go-echo-api/tenant"
+	"testing"
+	"time"
+)
+
+func TestOutboxService_Publish(t *testing.T) {
+	r := repository.NewOutboxMemoryRepository()
+	o := NewOutboxService(r)
+
+	s := t.Run("success", func(t *testing.T) {
+		err := o.Transaction(dbtest.Context(), func(ctx context.Context) error {
+			return o.Publish(ctx, outbox.EventUserRegistered, dbtest.UserUje.ID, outbox.NewUserData(dbtest.UserUje))
+		})
+		assert.NoError(t, err)
+		due, _ := r.FindDue(context.Background(), time.Now().Add(time.Minute), 10)
+		if assert.Len(t, due, 1) {
+			assert.Equal(t, dbtest.TenantAcme.ID, due[0].TenantID)
+			assert.Equal(t, outbox.EventUserRegistered, due[0].Type)
+			assert.JSONEq(t, `{"id":"`+dbtest.UserUje.ID+`","name":"Uje","email":"uje@email.com"}`, due[0].Payload)
+		}
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// the events of a failed transaction are dropped
+		failure := errors.New("failure")
+		err := o.Transaction(dbtest.Context(), func(ctx context.Context) error {
+			assert.NoError(t, o.Publish(ctx, outbox.EventUserDeleted, dbtest.UserUje.ID, outbox.NewUserData(dbtest.UserUje)))
+			return failure
+		})
+		assert.Equal(t, failure, err)
+		due, _ := r.FindDue(context.Background(), time.Now().Add(time.Minute), 10)
+		assert.Len(t, due, 1)
+
+		err = o.Publish(context.Background(), outbox.EventUserDeleted, dbtest.UserUje.ID, nil)
+		assert.Equal(t, tenant.ErrRequired, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	echoMiddleware "github.com/labstack/echo/middleware"
+	"github.com/labstack/gommon/log"
+	"github.com/sirupsen/logrus"
+	apiKeyHandler "go-echo-api/apikey/delivery/http"
+	auditHandler "go-echo-api/audit/delivery/http"
+	authHandler "go-echo-api/auth/delivery/http"
+	identityHandler "go-echo-api/identity/delivery/http"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/metrics"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/validator"
+	jobHandler "go-echo-api/job/delivery/http"
+	magicLinkHandler "go-echo-api/magiclink/delivery/http"
+	mfaHandler "go-echo-api/mfa/delivery/http"
+	"go-echo-api/middleware"
+	oauthHandler "go-echo-api/oauth/delivery/http"
+	organizationHandler "go-echo-api/organization/delivery/http"
+	"go-echo-api/outbox/sink"
+	sessionHandler "go-echo-api/session/delivery/http"
+	tenantHandler "go-echo-api/tenant/delivery/http"
+	userHandler "go-echo-api/user/delivery/http"
+	webAuthnHandler "go-echo-api/webauthn/delivery/http"
+	webhookHandler "go-echo-api/webhook/delivery/http"
+	"net/http"
+	"os"
+)
+
+const (
+	// APIPrefix is the group of the routes of the modules
+	APIPrefix  = "/api/v1"
+	SpecPath   = APIPrefix + "/openapi.json"
+	DocsPath   = APIPrefix + "/docs"
+	apiTitle   = "go-echo-api"
+	apiVersion = "1.0.0"
+)
+
+// Module is a feature of the API, it wires its own dependencies and registers its routes
+// and their middleware on the group of its prefix
+type Module interface {
+	// Prefix of the routes of the module under APIPrefix, e.g. "/user"
+	Prefix() string
+
+	// Routes register the routes of the module on the group of its prefix
+	Routes(g *echo.Group)
+
+	// OpenAPI describe the routes of the module on the group of its prefix
+	OpenAPI(g *openapi.Group)
+}
+
+// Subscriber is a module handling domain events of the outbox in the process,
+// e.g. deleting its records of the users deleted
+type Subscriber interface {
+	// Subscribe the handlers of the module to subscribers
+	Subscribe(subscribers *sink.Subscribers)
+}
+
+// Subscribe the handlers of the modules handling domain events to subscribers
+func Subscribe(modules []Module, subscribers *sink.Subscribers) {
+	for _, module := range modules {
+		if subscriber, ok := module.(Subscriber); ok {
+			subscriber.Subscribe(subscribers)
+		}
+	}
+}
+
+type Config struct {
+	// Logger of the requests, default logger.Default()
+	Logger *logrus.Logger
+
+	// Debug lower the level of the echo logger to debug
+	Debug bool
+
+	Timeout middleware.TimeoutConfig
+
+	// OpenAPI select what is validated against the document, NewServer sets its Document
+	OpenAPI middleware.OpenAPIConfig
+
+	// Modules of the API, default DefaultModules
+	Modules []Module
+}
+
+// ConfigFromEnv read the configuration of the server from the environment
+func ConfigFromEnv(log *logrus.Logger) Config {
+	return Config{
+		Logger:  log,
+		Debug:   os.Getenv("APP_DEBUG") == "true",
+		Timeout: middleware.TimeoutConfigFromEnv(),
+		OpenAPI: middleware.OpenAPIConfigFromEnv(nil),
+	}
+}
+
+// DefaultModules return the modules of the API wired to db
+func DefaultModules(db *gorm.DB) []Module {
+	return []Module{
+		authHandler.NewModule(db),
+		identityHandler.NewModule(db),
+		userHandler.NewModule(db),
+		tenantHandler.NewModule(db),
+		organizationHandler.NewModule(db),
+		apiKeyHandler.NewModule(db),
+		mfaHandler.NewModule(db),
+		webAuthnHandler.NewModule(db),
+		magicLinkHandler.NewModule(db),
+		sessionHandler.NewModule(db),
+		auditHandler.NewModule(db),
+		webhookHandler.NewModule(db),
+		jobHandler.NewModule(db),
+		oauthHandler.NewModule(db),
+	}
+}
+
+// Document describe the routes of the modules
+func Document(modules []Module) *openapi.Document {
+	doc := openapi.New(apiTitle, apiVersion)
+	for _, module := range modules {
+		module.OpenAPI(doc.Group(APIPrefix + module.Prefix()))
+	}
+	return doc
+}
+
+// NewServer return the application with its middleware, the routes of its modules,
+// the API documentation and the operational endpoints
+func NewServer(config Config, db *gorm.DB) *echo.Echo {
+	if config.Logger == nil {
+		config.Logger = logger.Default()
+	}
+	if config.Modules == nil {
+		config.Modules = DefaultModules(db)
+	}
+	doc := Document(config.Modules)
+	config.OpenAPI.Document = doc
+
+	e := echo.New()
+	e.Validator = validator.NewValidator()
+	e.Logger.SetLevel(log.ERROR)
+	if config.Debug {
+		e.Logger.SetLevel(log.DEBUG)
+	}
+
+	e.Pre(echoMiddleware.RemoveTrailingSlash())
+	e.Use(middleware.RequestID(config.Logger))
+	e.Use(middleware.Tracing())
+	e.Use(middleware.AccessLog())
+	e.Use(middleware.Metrics())
+	e.Use(middleware.TimeoutWithConfig(config.Timeout))
+	e.Use(echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, echo.HeaderXRequestID,
+			middleware.HeaderTenantID, middleware.HeaderAPIKey},
+		ExposeHeaders: []string{echo.HeaderXRequestID},
+		AllowMethods:  []string{echo.GET, echo.HEAD, echo.PUT, echo.PATCH, echo.POST, echo.DELETE},
+	}))
+	e.Use(middleware.OpenAPIWithConfig(config.OpenAPI))
+
+	v1 := e.Group(APIPrefix)
+	for _, module := range config.Modules {
+		module.Routes(v1.Group(module.Prefix()))
+	}
+
+	//API documentation
+	e.GET(SpecPath, openapi.Handler(doc))
+	e.GET(DocsPath, openapi.UI(apiTitle, SpecPath))
+
+	e.GET("/metrics", metrics.Handler())
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "Hello, World!")
+	})
+	return e
+}
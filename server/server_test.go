@@ -0,0 +1,1274 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/audit"
+	auditRepository "go-echo-api/audit/repository"
+	auditUsecase "go-echo-api/audit/usecase"
+	"go-echo-api/identity"
+	"go-echo-api/identity/identitytest"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/totp"
+	"go-echo-api/job"
+	jobRepository "go-echo-api/job/repository"
+	jobUsecase "go-echo-api/job/usecase"
+	"go-echo-api/middleware"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	outboxRepository "go-echo-api/outbox/repository"
+	"go-echo-api/outbox/sink"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	"go-echo-api/user"
+	userRepository "go-echo-api/user/repository"
+	userUsecase "go-echo-api/user/usecase"
+	"go-echo-api/webauthn"
+	"go-echo-api/webauthn/webauthntest"
+	"go-echo-api/webhook"
+	webhookRepository "go-echo-api/webhook/repository"
+	webhookUsecase "go-echo-api/webhook/usecase"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// undocumented are the routes of the API serving its own documentation
+var undocumented = map[string]bool{
+	echo.GET + " " + SpecPath: true,
+	echo.GET + " " + DocsPath: true,
+}
+
+// TestMain configure the URLs the modules require to start, those of the server the tests call
+func TestMain(m *testing.M) {
+	_ = os.Setenv("APP_MAGIC_LINK_URL", "http://example.com/api/v1/auth/magic-link/verify")
+	_ = os.Setenv("APP_OIDC_ISSUER", "http://example.com/api/v1/oauth")
+	os.Exit(m.Run())
+}
+
+// newTestServer return the fully wired application on the test database, validating
+// the requests and responses against the OpenAPI document
+func newTestServer(t *testing.T) (*echo.Echo, func()) {
+	db := dbtest.PrepareTestDB(t)
+	log, _ := test.NewNullLogger()
+	e := NewServer(Config{
+		Logger:  log,
+		OpenAPI: middleware.OpenAPIConfig{Requests: true, Responses: true},
+	}, db)
+	return e, func() { dbtest.CleanTestDB(db) }
+}
+
+// call send the request to e in the tenant of the fixture users with the access token when not empty
+// and decode the JSON envelope
+func call(e *echo.Echo, method string, path string, token string, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	return send(e, method, path, map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID}, token, body)
+}
+
+// send the request to e with the given headers, the access token when not empty and decode the JSON envelope
+func send(e *echo.Echo, method string, path string, headers map[string]string, token string, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	var envelope map[string]interface{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &envelope)
+	return rec, envelope
+}
+
+// login return the access token of the user of the tenant
+func login(t *testing.T, e *echo.Echo, tenantID string, email string, password string) string {
+	rec, envelope := send(e, echo.POST, "/api/v1/auth/token", map[string]string{middleware.HeaderTenantID: tenantID}, "",
+		`{"email":"`+email+`","password":"`+password+`"}`)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		t.FailNow()
+	}
+	return envelope["data"].(map[string]interface{})["access_token"].(string)
+}
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	e := NewServer(Config{}, nil)
+
+	var routes []string
+	for _, route := range e.Routes() {
+		key := route.Method + " " + route.Path
+		// echo.Group registers catch-all routes answering 404 for its middleware
+		if !strings.HasPrefix(route.Path, APIPrefix+"/") || undocumented[key] || strings.HasSuffix(route.Name, "(*Group).Use.func1") {
+			continue
+		}
+		routes = append(routes, route.Method+" "+openapi.Path(route.Path))
+	}
+	sort.Strings(routes)
+
+	doc := Document(DefaultModules(nil))
+	assert.Equal(t, doc.Operations(), routes, "the routes registered by the modules drifted from the OpenAPI document")
+
+	// every security requirement names a scheme of the document
+	for path, item := range doc.Paths {
+		for method, operation := range item {
+			for _, requirement := range operation.Security {
+				for scheme := range requirement {
+					_, ok := doc.Components.SecuritySchemes[scheme]
+					assert.True(t, ok, "%s %s requires the undefined security scheme %s", method, path, scheme)
+				}
+			}
+		}
+	}
+}
+
+func TestServer_Auth(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+
+	s := t.Run("success", func(t *testing.T) {
+		// register then log in with the new account
+		rec, envelope := call(e, echo.POST, "/api/v1/auth/register", "", `{"name":"Ahmad","email":"ahmad@email.com","password":"secret"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "ahmad@email.com", envelope["data"].(map[string]interface{})["email"])
+		assert.NotEmpty(t, login(t, e, dbtest.TenantAcme.ID, "ahmad@email.com", "secret"))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// wrong password
+		rec, envelope := call(e, echo.POST, "/api/v1/auth/token", "", `{"email":"uje@email.com","password":"wrong"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.NotEmpty(t, envelope["meta"].(map[string]interface{})["request_id"])
+	})
+	v := t.Run("error-validation", func(t *testing.T) {
+		// the body breaks the OpenAPI document
+		rec, _ := call(e, echo.POST, "/api/v1/auth/register", "", `{"name":"Ahmad","email":"","password":1}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"field":"password","message":"must be of type string"`)
+	})
+	c := t.Run("error-conflict", func(t *testing.T) {
+		rec, _ := call(e, echo.POST, "/api/v1/auth/register", "", `{"name":"Uje","email":"uje@email.com","password":"secret"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, v, "Validation scenario failed run")
+	assert.Equal(t, true, c, "Conflict scenario failed run")
+}
+
+func TestServer_User(t *testing.T) {
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+	log, _ := test.NewNullLogger()
+	modules := DefaultModules(db)
+	e := NewServer(Config{Logger: log, OpenAPI: middleware.OpenAPIConfig{Requests: true, Responses: true}, Modules: modules}, db)
+	// the modules delete the records of the users deleted from the outbox
+	subscribers := sink.NewSubscribers()
+	Subscribe(modules, subscribers)
+	dispatcher := outboxUsecase.NewDispatcher(outboxRepository.NewOutboxRepository(db), []outbox.Sink{subscribers}, outboxUsecase.DefaultDispatcherConfig)
+	token := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+	self := "/api/v1/user/" + dbtest.UserUje.ID
+	other := "/api/v1/user/" + dbtest.UserIpan.ID
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := call(e, echo.GET, "/api/v1/user?limit=1", token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Len(t, envelope["data"], 1)
+		assert.Equal(t, float64(2), envelope["meta"].(map[string]interface{})["page"].(map[string]interface{})["total"])
+
+		rec, _ = call(e, echo.GET, other, token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec, envelope = call(e, echo.PUT, self, token, `{"name":"Uje","email":"uje@new.com","password":"secret"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "uje@new.com", envelope["data"].(map[string]interface{})["email"])
+
+		rec, _ = call(e, echo.DELETE, self, token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		// the sessions of the user deleted end with the user.deleted event
+		// user.updated, user.password_changed and user.deleted
+		published, err := dispatcher.Dispatch(dbtest.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, 3, published)
+		var memberships int
+		assert.NoError(t, db.Model(&models.Membership{}).Where("user_id = ?", dbtest.UserUje.ID).Count(&memberships).Error)
+		assert.Equal(t, 0, memberships)
+		rec, _ = call(e, echo.GET, other, token, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	})
+	ipan := login(t, e, dbtest.TenantAcme.ID, dbtest.UserIpan.Email, dbtest.FixturePassword)
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := call(e, echo.GET, "/api/v1/user/unknown", ipan, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	a := t.Run("error-forbidden", func(t *testing.T) {
+		rec, _ := call(e, echo.DELETE, self, ipan, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	u := t.Run("error-unauthorized", func(t *testing.T) {
+		rec, _ := call(e, echo.GET, "/api/v1/user", "not-a-token", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, a, "Forbidden scenario failed run")
+	assert.Equal(t, true, u, "Unauthorized scenario failed run")
+}
+
+func TestServer_UserImportExport(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	token := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+	csv := map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID, echo.HeaderContentType: "text/csv"}
+	ndjson := map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID, echo.HeaderContentType: "application/x-ndjson"}
+	file := "name,email,password\nAhmad,ahmad@email.com,secret\nIpan,ipan@email.com,\n"
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := send(e, echo.POST, "/api/v1/user/import?dry_run=true", csv, token, file)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var report user.ImportMapper
+		decode(t, envelope, &report)
+		assert.Equal(t, user.ImportMapper{DryRun: true, Total: 2, Imported: 1, Failed: 1, Errors: []user.ImportErrorMapper{
+			{Row: 2, Field: "email", Message: user.ErrEmailTaken.Error()},
+		}}, report)
+
+		rec, envelope = send(e, echo.POST, "/api/v1/user/import", csv, token, file)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		decode(t, envelope, &report)
+		assert.Equal(t, 1, report.Imported)
+		login(t, e, dbtest.TenantAcme.ID, "ahmad@email.com", "secret")
+
+		rec, envelope = send(e, echo.POST, "/api/v1/user/import", ndjson, token, `{"name":"Budi","email":"budi@email.com"}`+"\n")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		decode(t, envelope, &report)
+		assert.Equal(t, 1, report.Imported)
+
+		rec, _ = call(e, echo.GET, "/api/v1/user/export", token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		assert.Equal(t, "id,name,email,created_at", lines[0])
+		assert.Len(t, lines, 5)
+
+		rec, _ = call(e, echo.GET, "/api/v1/user/export?format=json", token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var exported []user.ExportMapper
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &exported))
+		assert.Len(t, exported, 4)
+		for i := 1; i < len(exported); i++ {
+			assert.True(t, exported[i-1].ID < exported[i].ID)
+		}
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := call(e, echo.POST, "/api/v1/user/import", token, `[{"name":"Cici","email":"cici@email.com"}]`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		rec, _ = send(e, echo.POST, "/api/v1/user/import", csv, token, "name\nCici\n")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		rec, _ = send(e, echo.POST, "/api/v1/user/import?dry_run=maybe", csv, token, file)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/user/export?format=xml", token, "")
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	})
+	u := t.Run("error-unauthorized", func(t *testing.T) {
+		rec, _ := send(e, echo.POST, "/api/v1/user/import", csv, "not-a-token", file)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		rec, _ = call(e, echo.GET, "/api/v1/user/export", "not-a-token", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, u, "Unauthorized scenario failed run")
+}
+
+func TestServer_Tenant(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	_ = os.Setenv("APP_ADMIN_KEY", "admin-key")
+	defer os.Unsetenv("APP_ADMIN_KEY")
+	admin := map[string]string{middleware.HeaderAdminKey: "admin-key"}
+	globex := map[string]string{middleware.HeaderTenantID: dbtest.TenantGlobex.ID}
+
+	s := t.Run("success", func(t *testing.T) {
+		// a new tenant gets its own users, the email of a user of another tenant is free
+		rec, envelope := send(e, echo.POST, "/api/v1/tenant", admin, "", `{"name":"Initech","slug":"initech"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		initech := map[string]string{middleware.HeaderTenantID: envelope["data"].(map[string]interface{})["id"].(string)}
+
+		rec, _ = send(e, echo.POST, "/api/v1/auth/register", initech, "", `{"name":"Uje","email":"uje@email.com","password":"secret"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		token := login(t, e, initech[middleware.HeaderTenantID], "uje@email.com", "secret")
+
+		rec, envelope = send(e, echo.GET, "/api/v1/user", initech, token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, float64(1), envelope["meta"].(map[string]interface{})["page"].(map[string]interface{})["total"])
+	})
+	i := t.Run("error-isolation", func(t *testing.T) {
+		// the users of another tenant are not found and its tokens are refused
+		token := login(t, e, dbtest.TenantGlobex.ID, dbtest.UserGlobexUje.Email, dbtest.FixturePassword)
+		rec, _ := send(e, echo.GET, "/api/v1/user/"+dbtest.UserIpan.ID, globex, token, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec, _ = call(e, echo.GET, "/api/v1/user", token, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	a := t.Run("error-suspended", func(t *testing.T) {
+		rec, _ := send(e, echo.POST, "/api/v1/tenant/"+dbtest.TenantGlobex.ID+"/suspend", admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec, _ = send(e, echo.POST, "/api/v1/auth/token", globex, "", `{"email":"uje@email.com","password":"password"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := send(e, echo.POST, "/api/v1/auth/token", nil, "", `{"email":"uje@email.com","password":"password"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec, _ = send(e, echo.POST, "/api/v1/auth/token", map[string]string{middleware.HeaderTenantID: "unknown"}, "", `{"email":"uje@email.com","password":"password"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec, _ = send(e, echo.GET, "/api/v1/tenant", map[string]string{middleware.HeaderAdminKey: "wrong"}, "", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, i, "Isolation scenario failed run")
+	assert.Equal(t, true, a, "Suspended scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_Organization(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	uje := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+	assert.Equal(t, dbtest.OrganizationAcme.ID, claim(t, uje, "organization_id"), "the first organization of the user is active")
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := call(e, echo.POST, "/api/v1/orgs", uje, `{"name":"Acme Research"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		org := "/api/v1/orgs/" + envelope["data"].(map[string]interface{})["id"].(string)
+
+		// a new user registers with an invite and joins the organization
+		rec, envelope = call(e, echo.POST, org+"/invitations", uje, `{"email":"budi@email.com","role":"member"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		invite := envelope["data"].(map[string]interface{})["token"].(string)
+		rec, _ = call(e, echo.POST, "/api/v1/auth/register", "", `{"name":"Budi","email":"budi@email.com","password":"secret","invite_token":"`+invite+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		budi := login(t, e, dbtest.TenantAcme.ID, "budi@email.com", "secret")
+		assert.Equal(t, org, "/api/v1/orgs/"+claim(t, budi, "organization_id"))
+
+		// an existing user accepts an invite then switches to the organization
+		ipan := login(t, e, dbtest.TenantAcme.ID, dbtest.UserIpan.Email, dbtest.FixturePassword)
+		rec, envelope = call(e, echo.POST, org+"/invitations", uje, `{"email":"ipan@email.com","role":"admin"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		invite = envelope["data"].(map[string]interface{})["token"].(string)
+		rec, _ = call(e, echo.POST, "/api/v1/orgs/invitations/accept", ipan, `{"token":"`+invite+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, envelope = call(e, echo.POST, org+"/token", ipan, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, org, "/api/v1/orgs/"+claim(t, envelope["data"].(map[string]interface{})["access_token"].(string), "organization_id"))
+
+		rec, envelope = call(e, echo.GET, org+"/members", ipan, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, float64(3), envelope["meta"].(map[string]interface{})["page"].(map[string]interface{})["total"])
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		ipan := login(t, e, dbtest.TenantAcme.ID, dbtest.UserIpan.Email, dbtest.FixturePassword)
+		org := "/api/v1/orgs/" + dbtest.OrganizationAcme.ID
+		rec, _ := call(e, echo.PUT, org, ipan, `{"name":"Renamed"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec, _ = call(e, echo.DELETE, org+"/members/"+dbtest.UserUje.ID, uje, "")
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		rec, _ = call(e, echo.POST, "/api/v1/orgs/invitations/accept", ipan, `{"token":"not-a-token"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		// a revoked invitation does not join
+		rec, envelope := call(e, echo.POST, org+"/invitations", uje, `{"email":"ipan@email.com","role":"admin"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		invitation := envelope["data"].(map[string]interface{})
+		rec, envelope = call(e, echo.GET, org+"/invitations", uje, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Len(t, envelope["data"], 1)
+		rec, _ = call(e, echo.DELETE, org+"/invitations/"+invitation["id"].(string), ipan, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec, _ = call(e, echo.DELETE, org+"/invitations/"+invitation["id"].(string), uje, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.POST, "/api/v1/orgs/invitations/accept", ipan, `{"token":"`+invitation["token"].(string)+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec, _ = call(e, echo.GET, "/api/v1/orgs/unknown", ipan, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+// claim return a string claim of the access token
+func claim(t *testing.T, token string, name string) string {
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	value, _ := parsed.Claims.(jwt.MapClaims)[name].(string)
+	return value
+}
+
+func TestServer_APIKey(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	token := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := call(e, echo.POST, "/api/v1/me/api-keys", token, `{"name":"batch","scopes":["users:read"]}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		data := envelope["data"].(map[string]interface{})
+		key := data["key"].(string)
+
+		withKey := map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID, middleware.HeaderAPIKey: key}
+		rec, _ = send(e, echo.GET, "/api/v1/user", withKey, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = send(e, echo.DELETE, "/api/v1/user/"+dbtest.UserUje.ID, withKey, "", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code, "the key is read only")
+
+		// the key is listed without its secret then revoked
+		rec, envelope = call(e, echo.GET, "/api/v1/me/api-keys", token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NotContains(t, rec.Body.String(), key)
+		rec, _ = call(e, echo.DELETE, "/api/v1/me/api-keys/"+data["id"].(string), token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = send(e, echo.GET, "/api/v1/user", withKey, "", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := call(e, echo.POST, "/api/v1/me/api-keys", token, `{"name":"batch","scopes":["everything"]}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		rec, _ = call(e, echo.POST, "/api/v1/me/api-keys", token, `{"name":"old","expires_at":"2001-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+// form send a form encoded request to e in the tenant of the fixture users with basic authorization
+// when the client id is not empty, and decode the JSON body
+func form(e *echo.Echo, path string, clientID string, secret string, values url.Values) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req := httptest.NewRequest(echo.POST, path, strings.NewReader(values.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Set(middleware.HeaderTenantID, dbtest.TenantAcme.ID)
+	if clientID != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	var body map[string]interface{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec, body
+}
+
+func TestServer_OAuth(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	uje := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+	redirectURI := "https://app.example.com/callback"
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	rec, envelope := call(e, echo.POST, "/api/v1/oauth/clients", uje, `{"name":"Dashboard","redirect_uris":["`+redirectURI+`"],`+
+		`"grant_types":["authorization_code","refresh_token","client_credentials"],"scopes":["users:read"],"confidential":true}`)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		t.FailNow()
+	}
+	client := envelope["data"].(map[string]interface{})
+	clientID, secret := client["id"].(string), client["secret"].(string)
+	authorize := "/api/v1/oauth/authorize?" + url.Values{
+		"response_type": {"code"}, "client_id": {clientID}, "redirect_uri": {redirectURI}, "state": {"xyz"},
+		"code_challenge": {challenge}, "code_challenge_method": {"S256"},
+	}.Encode()
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, _ := call(e, echo.GET, authorize, uje, "")
+		assert.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+		location, _ := url.Parse(rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, "xyz", location.Query().Get("state"))
+
+		rec, token := form(e, "/api/v1/oauth/token", clientID, secret, url.Values{"grant_type": {"authorization_code"},
+			"code": {location.Query().Get("code")}, "redirect_uri": {redirectURI}, "code_verifier": {verifier}})
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		access := token["access_token"].(string)
+
+		// the access token reaches the routes of its scope only
+		rec, _ = call(e, echo.GET, "/api/v1/user", access, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.DELETE, "/api/v1/user/"+dbtest.UserIpan.ID, access, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec, _ = call(e, echo.POST, "/api/v1/me/api-keys", access, `{"name":"batch"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code, "a client cannot mint credentials")
+		rec, _ = call(e, echo.POST, "/api/v1/auth/refresh-token", "", `{"refresh_token":"`+access+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "a client token is not a refresh token")
+
+		rec, introspection := form(e, "/api/v1/oauth/introspect", clientID, secret, url.Values{"token": {access}})
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, true, introspection["active"])
+		assert.Equal(t, dbtest.UserUje.ID, introspection["sub"])
+
+		// the refresh token is rotated then revoked
+		rec, refreshed := form(e, "/api/v1/oauth/token", clientID, secret, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {token["refresh_token"].(string)}})
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = form(e, "/api/v1/oauth/revoke", clientID, secret, url.Values{"token": {refreshed["refresh_token"].(string)}})
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, introspection = form(e, "/api/v1/oauth/introspect", clientID, secret, url.Values{"token": {refreshed["refresh_token"].(string)}})
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, false, introspection["active"])
+
+		// the client acts for itself with its credentials in the form
+		rec, token = form(e, "/api/v1/oauth/token", "", "", url.Values{"grant_type": {"client_credentials"}, "client_id": {clientID}, "client_secret": {secret}})
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Nil(t, token["refresh_token"])
+		assert.Equal(t, clientID, claim(t, token["access_token"].(string), "sub"))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, body := form(e, "/api/v1/oauth/token", clientID, "wrong", url.Values{"grant_type": {"client_credentials"}})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		assert.Equal(t, "invalid_client", body["error"])
+
+		rec, body = form(e, "/api/v1/oauth/token", clientID, secret, url.Values{"grant_type": {"authorization_code"}, "code": {"unknown"}})
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		assert.Equal(t, "invalid_grant", body["error"])
+
+		// the errors are redirected once the redirect uri is trusted
+		rec, _ = call(e, echo.GET, strings.Replace(authorize, "S256", "plain", 1), uje, "")
+		assert.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+		location, _ := url.Parse(rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, "invalid_request", location.Query().Get("error"))
+		rec, _ = call(e, echo.GET, strings.Replace(authorize, "app.example.com", "evil.example.com", 1), uje, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_OIDC(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	uje := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+	redirectURI := "https://app.example.com/callback"
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	rec, envelope := call(e, echo.POST, "/api/v1/oauth/clients", uje, `{"name":"Wiki","redirect_uris":["`+redirectURI+`"],`+
+		`"grant_types":["authorization_code"],"scopes":["openid","profile","email","users:read"]}`)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		t.FailNow()
+	}
+	clientID := envelope["data"].(map[string]interface{})["id"].(string)
+	token := func(scope string) map[string]interface{} {
+		rec, _ := call(e, echo.GET, "/api/v1/oauth/authorize?"+url.Values{
+			"response_type": {"code"}, "client_id": {clientID}, "redirect_uri": {redirectURI}, "scope": {scope},
+			"nonce": {"n-0S6_WzA2Mj"}, "code_challenge": {challenge}, "code_challenge_method": {"S256"},
+		}.Encode(), uje, "")
+		assert.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+		location, _ := url.Parse(rec.Header().Get(echo.HeaderLocation))
+		rec, token := form(e, "/api/v1/oauth/token", "", "", url.Values{"grant_type": {"authorization_code"}, "client_id": {clientID},
+			"code": {location.Query().Get("code")}, "redirect_uri": {redirectURI}, "code_verifier": {verifier}})
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return token
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, discovery := call(e, echo.GET, "/api/v1/oauth/.well-known/openid-configuration", "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		issuer := discovery["issuer"].(string)
+		assert.Equal(t, "http://example.com/api/v1/oauth", issuer)
+		assert.Equal(t, issuer+"/jwks", discovery["jwks_uri"])
+
+		rec, keys := call(e, echo.GET, "/api/v1/oauth/jwks", "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Len(t, keys["keys"], 1)
+
+		token := token("openid profile email")
+		idToken, _ := token["id_token"].(string)
+		assert.NotEmpty(t, idToken)
+		rec, info := call(e, echo.GET, "/api/v1/oauth/userinfo", token["access_token"].(string), "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, dbtest.UserUje.ID, info["sub"])
+		assert.Equal(t, dbtest.UserUje.Email, info["email"])
+		assert.Equal(t, false, info["email_verified"])
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		token := token("users:read")
+		assert.Nil(t, token["id_token"])
+		rec, _ := call(e, echo.GET, "/api/v1/oauth/userinfo", token["access_token"].(string), "")
+		assert.Equal(t, http.StatusForbidden, rec.Code, "the openid scope is required")
+		rec, _ = call(e, echo.GET, "/api/v1/oauth/userinfo", "invalid", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_Identity(t *testing.T) {
+	mock := identitytest.NewProvider(identity.Claims{Subject: "1234", Email: "jane@example.com", EmailVerified: true, Name: "Jane"})
+	defer mock.Close()
+	env := map[string]string{
+		"APP_IDENTITY_PROVIDERS":          "mock",
+		"APP_IDENTITY_MOCK_ISSUER":        mock.URL,
+		"APP_IDENTITY_MOCK_CLIENT_ID":     identitytest.ClientID,
+		"APP_IDENTITY_MOCK_CLIENT_SECRET": identitytest.ClientSecret,
+	}
+	for key, value := range env {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+	e, clean := newTestServer(t)
+	defer clean()
+	uje := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+
+	// callback log in at the provider and follow its redirection to the callback without the tenant header
+	callback := func(authorization string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		location, err := mock.Authorize(authorization)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "/api/v1/auth/oauth/mock/callback", location.Path)
+		return send(e, echo.GET, location.RequestURI(), nil, "", "")
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, _ := call(e, echo.GET, "/api/v1/auth/oauth/mock/start", "", "")
+		assert.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+		rec, envelope := callback(rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		access := envelope["data"].(map[string]interface{})["access_token"].(string)
+		assert.Equal(t, dbtest.TenantAcme.ID, claim(t, access, "tenant_id"))
+		assert.Equal(t, "jane@example.com", claim(t, access, "email"))
+
+		// Uje links an identity of the provider then logs in with it
+		mock.User = identity.Claims{Subject: "5678", Email: "uje@corp.example.com"}
+		rec, envelope = call(e, echo.POST, "/api/v1/auth/oauth/mock/link", uje, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, envelope = callback(envelope["data"].(map[string]interface{})["authorization_url"].(string))
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "5678", envelope["data"].(map[string]interface{})["subject"])
+		rec, _ = call(e, echo.GET, "/api/v1/auth/oauth/mock/start", "", "")
+		rec, envelope = callback(rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, dbtest.UserUje.ID, claim(t, envelope["data"].(map[string]interface{})["access_token"].(string), "id"))
+
+		rec, envelope = call(e, echo.GET, "/api/v1/auth/oauth/identities", uje, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		identities := envelope["data"].([]interface{})
+		assert.Len(t, identities, 1)
+		rec, _ = call(e, echo.DELETE, "/api/v1/auth/oauth/identities/"+identities[0].(map[string]interface{})["id"].(string), uje, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := call(e, echo.GET, "/api/v1/auth/oauth/unknown/start", "", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+		// the email of Ipan is not taken over by the identity
+		mock.User = identity.Claims{Subject: "9012", Email: dbtest.UserIpan.Email, EmailVerified: true}
+		rec, _ = call(e, echo.GET, "/api/v1/auth/oauth/mock/start", "", "")
+		location, err := mock.Authorize(rec.Header().Get(echo.HeaderLocation))
+		assert.NoError(t, err)
+		rec, _ = send(e, echo.GET, location.RequestURI(), nil, "", "")
+		assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+		// the state is used once
+		rec, _ = send(e, echo.GET, location.RequestURI(), nil, "", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_MFA(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	token := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+	credentials := `{"email":"` + dbtest.UserUje.Email + `","password":"` + dbtest.FixturePassword + `"}`
+
+	// enroll and confirm an authenticator app
+	rec, envelope := call(e, echo.POST, "/api/v1/me/mfa/totp", token, "")
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		t.FailNow()
+	}
+	enrollment := envelope["data"].(map[string]interface{})
+	assert.True(t, strings.HasPrefix(enrollment["qr_code"].(string), "data:image/png;base64,"))
+	secret := enrollment["secret"].(string)
+	code, _ := totp.Code(secret, time.Now())
+	rec, envelope = call(e, echo.POST, "/api/v1/me/mfa/totp/confirm", token, `{"code":"`+code+`"}`)
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		t.FailNow()
+	}
+	recoveryCodes := envelope["data"].(map[string]interface{})["recovery_codes"].([]interface{})
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := call(e, echo.GET, "/api/v1/me/mfa", token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, true, envelope["data"].(map[string]interface{})["enabled"])
+
+		// the password gives an mfa token, the code the token pair
+		rec, envelope = call(e, echo.POST, "/api/v1/auth/token", "", credentials)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		data := envelope["data"].(map[string]interface{})
+		assert.Equal(t, true, data["mfa_required"])
+		assert.Nil(t, data["access_token"])
+		rec, envelope = call(e, echo.POST, "/api/v1/auth/token/mfa", "",
+			`{"mfa_token":"`+data["mfa_token"].(string)+`","code":"`+recoveryCodes[0].(string)+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, dbtest.UserUje.ID, claim(t, envelope["data"].(map[string]interface{})["access_token"].(string), "id"))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, envelope := call(e, echo.POST, "/api/v1/auth/token", "", credentials)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		mfaToken := envelope["data"].(map[string]interface{})["mfa_token"].(string)
+
+		// a used recovery code, and the mfa token is no access token
+		rec, _ = call(e, echo.POST, "/api/v1/auth/token/mfa", "", `{"mfa_token":"`+mfaToken+`","code":"`+recoveryCodes[0].(string)+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/me/mfa", mfaToken, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		rec, _ = call(e, echo.POST, "/api/v1/auth/token/mfa", "", `{"mfa_token":"unknown","code":"123456"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+
+		rec, _ = call(e, echo.POST, "/api/v1/me/mfa/totp", token, "")
+		assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	})
+	d := t.Run("disable", func(t *testing.T) {
+		rec, _ := call(e, echo.POST, "/api/v1/me/mfa/totp/disable", token, `{"code":"`+recoveryCodes[1].(string)+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NotEmpty(t, login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, d, "Disable scenario failed run")
+}
+
+// decode the data of the envelope into v
+func decode(t *testing.T, envelope map[string]interface{}, v interface{}) {
+	data, _ := json.Marshal(envelope["data"])
+	if !assert.NoError(t, json.Unmarshal(data, v)) {
+		t.FailNow()
+	}
+}
+
+func TestServer_WebAuthn(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	token := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+	// the default relying party is localhost
+	authenticator := webauthntest.New("https://localhost")
+
+	rec, envelope := call(e, echo.POST, "/api/v1/auth/webauthn/register/begin", token, "")
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		t.FailNow()
+	}
+	var creation webauthn.CreationOptionsMapper
+	decode(t, envelope, &creation)
+	credential, err := authenticator.Register(creation)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	body, _ := json.Marshal(webauthn.RegistrationDto{Name: "laptop", Credential: credential})
+	rec, _ = call(e, echo.POST, "/api/v1/auth/webauthn/register/finish", token, string(body))
+	if !assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String()) {
+		t.FailNow()
+	}
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := call(e, echo.GET, "/api/v1/auth/webauthn/credentials", token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "laptop", envelope["data"].([]interface{})[0].(map[string]interface{})["name"])
+
+		// a passkey logs in without email nor password
+		rec, envelope = call(e, echo.POST, "/api/v1/auth/webauthn/login/begin", "", `{}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var request webauthn.RequestOptionsMapper
+		decode(t, envelope, &request)
+		assertion, err := authenticator.Login(request)
+		assert.NoError(t, err)
+		body, _ := json.Marshal(assertion)
+		rec, envelope = call(e, echo.POST, "/api/v1/auth/webauthn/login/finish", "", string(body))
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		data := envelope["data"].(map[string]interface{})
+		assert.Equal(t, dbtest.UserUje.ID, claim(t, data["access_token"].(string), "id"))
+		assert.NotEmpty(t, data["refresh_token"])
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// registration requires the login
+		rec, _ := call(e, echo.POST, "/api/v1/auth/webauthn/register/begin", "invalid", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+
+		// the assertion is accepted once
+		rec, envelope := call(e, echo.POST, "/api/v1/auth/webauthn/login/begin", "", `{"email":"`+dbtest.UserUje.Email+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var request webauthn.RequestOptionsMapper
+		decode(t, envelope, &request)
+		assert.Len(t, request.AllowCredentials, 1)
+		assertion, _ := authenticator.Login(request)
+		body, _ := json.Marshal(assertion)
+		rec, _ = call(e, echo.POST, "/api/v1/auth/webauthn/login/finish", "", string(body))
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.POST, "/api/v1/auth/webauthn/login/finish", "", string(body))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+		// a cloned authenticator
+		authenticator.SetSignCount(1)
+		rec, envelope = call(e, echo.POST, "/api/v1/auth/webauthn/login/begin", "", `{}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		decode(t, envelope, &request)
+		assertion, _ = authenticator.Login(request)
+		body, _ = json.Marshal(assertion)
+		rec, _ = call(e, echo.POST, "/api/v1/auth/webauthn/login/finish", "", string(body))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+
+		rec, _ = call(e, echo.POST, "/api/v1/auth/webauthn/login/finish", "", `{"id":"x"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+	})
+	d := t.Run("delete", func(t *testing.T) {
+		rec, envelope := call(e, echo.GET, "/api/v1/auth/webauthn/credentials", token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		id := envelope["data"].([]interface{})[0].(map[string]interface{})["id"].(string)
+		rec, _ = call(e, echo.DELETE, "/api/v1/auth/webauthn/credentials/"+id, token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.DELETE, "/api/v1/auth/webauthn/credentials/"+id, token, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, d, "Delete scenario failed run")
+}
+
+// magicLink return the link of the last email dropped in dir
+func magicLink(t *testing.T, dir string) *url.URL {
+	files, err := ioutil.ReadDir(dir)
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, files) {
+		t.FailNow()
+	}
+	f, err := os.Open(filepath.Join(dir, files[len(files)-1].Name()))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer f.Close()
+	message, err := mail.ReadMessage(f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	body, _ := ioutil.ReadAll(message.Body)
+	link, err := url.Parse(regexp.MustCompile(`https?://\S+`).FindString(string(body)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return link
+}
+
+func TestServer_MagicLink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mail")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("APP_MAILER_DIR", dir)
+	defer os.Unsetenv("APP_MAILER_DIR")
+	e, clean := newTestServer(t)
+	defer clean()
+	request := `{"email":"` + dbtest.UserUje.Email + `"}`
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, _ := call(e, echo.POST, "/api/v1/auth/magic-link", "", request)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		link := magicLink(t, dir)
+		assert.Equal(t, "/api/v1/auth/magic-link/verify", link.Path)
+
+		// the link is opened from the email, without the tenant header
+		rec, envelope := send(e, echo.GET, link.RequestURI(), nil, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, dbtest.UserUje.ID, claim(t, envelope["data"].(map[string]interface{})["access_token"].(string), "id"))
+
+		// the same answer for an unknown email
+		rec, _ = call(e, echo.POST, "/api/v1/auth/magic-link", "", `{"email":"nobody@email.com"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := send(e, echo.GET, magicLink(t, dir).RequestURI(), nil, "", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/auth/magic-link/verify?token=forged", "", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.POST, "/api/v1/auth/magic-link", "", `{"email":"not an email"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+		// the email had a link already, it gets 3 within the window
+		for i := 0; i < 2; i++ {
+			rec, _ = call(e, echo.POST, "/api/v1/auth/magic-link", "", request)
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		}
+		rec, _ = call(e, echo.POST, "/api/v1/auth/magic-link", "", request)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code, rec.Body.String())
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_Sessions(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	_ = os.Setenv("APP_ADMIN_KEY", "admin-key")
+	defer os.Unsetenv("APP_ADMIN_KEY")
+	admin := map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID, middleware.HeaderAdminKey: "admin-key"}
+	credentials := `{"email":"` + dbtest.UserUje.Email + `","password":"` + dbtest.FixturePassword + `"}`
+
+	s := t.Run("success", func(t *testing.T) {
+		// every login starts a session, the one of the access token is current
+		first := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+		rec, envelope := send(e, echo.POST, "/api/v1/auth/token", map[string]string{
+			middleware.HeaderTenantID: dbtest.TenantAcme.ID,
+			"User-Agent":              "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+		}, "", credentials)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		tokens := envelope["data"].(map[string]interface{})
+		second := tokens["access_token"].(string)
+		assert.NotEmpty(t, claim(t, second, "sid"))
+
+		rec, envelope = call(e, echo.GET, "/api/v1/me/sessions", second, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var sessions []map[string]interface{}
+		decode(t, envelope, &sessions)
+		if !assert.Len(t, sessions, 2) {
+			t.FailNow()
+		}
+		assert.Equal(t, claim(t, second, "sid"), sessions[0]["id"])
+		assert.Equal(t, true, sessions[0]["current"])
+		assert.Equal(t, "Chrome on Windows", sessions[0]["device"])
+		assert.Equal(t, false, sessions[1]["current"])
+
+		// the refresh keeps the session and records its use
+		rec, envelope = call(e, echo.POST, "/api/v1/auth/refresh-token", "", `{"refresh_token":"`+tokens["refresh_token"].(string)+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		refreshed := envelope["data"].(map[string]interface{})["access_token"].(string)
+		assert.Equal(t, claim(t, second, "sid"), claim(t, refreshed, "sid"))
+		_, envelope = call(e, echo.GET, "/api/v1/me/sessions", refreshed, "")
+		decode(t, envelope, &sessions)
+		assert.Len(t, sessions, 2)
+		assert.Equal(t, "", sessions[0]["user_agent"])
+
+		// the revoked session cannot be refreshed any longer
+		rec, _ = call(e, echo.DELETE, "/api/v1/me/sessions/"+claim(t, second, "sid"), first, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.POST, "/api/v1/auth/refresh-token", "", `{"refresh_token":"`+tokens["refresh_token"].(string)+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+
+		// the admin revokes the remaining session
+		rec, envelope = send(e, echo.GET, "/api/v1/user/"+dbtest.UserUje.ID+"/sessions", admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		decode(t, envelope, &sessions)
+		if assert.Len(t, sessions, 1) {
+			assert.Equal(t, claim(t, first, "sid"), sessions[0]["id"])
+		}
+		rec, _ = send(e, echo.DELETE, "/api/v1/user/"+dbtest.UserUje.ID+"/sessions/"+claim(t, first, "sid"), admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, envelope = send(e, echo.GET, "/api/v1/user/"+dbtest.UserUje.ID+"/sessions", admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, float64(0), envelope["meta"].(map[string]interface{})["page"].(map[string]interface{})["total"])
+		// the access tokens of the sessions revoked are refused before their expiry
+		rec, _ = call(e, echo.GET, "/api/v1/me/sessions", first, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/me/sessions", refreshed, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		token := login(t, e, dbtest.TenantAcme.ID, dbtest.UserIpan.Email, dbtest.FixturePassword)
+		rec, _ := call(e, echo.DELETE, "/api/v1/me/sessions/unknown", token, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/me/sessions", "invalid", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+
+		// the session of another user is not found, the admin routes need the admin key
+		uje := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+		rec, _ = call(e, echo.DELETE, "/api/v1/me/sessions/"+claim(t, uje, "sid"), token, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/user/"+dbtest.UserUje.ID+"/sessions", token, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		rec, _ = send(e, echo.GET, "/api/v1/user/unknown/sessions", admin, "", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_Audit(t *testing.T) {
+	e, clean := newTestServer(t)
+	defer clean()
+	_ = os.Setenv("APP_ADMIN_KEY", "admin-key")
+	defer os.Unsetenv("APP_ADMIN_KEY")
+	admin := map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID, middleware.HeaderAdminKey: "admin-key"}
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, _ := call(e, echo.POST, "/api/v1/auth/token", "", `{"email":"uje@email.com","password":"wrong"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		token := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+		rec, _ = send(e, echo.PUT, "/api/v1/user/"+dbtest.UserUje.ID, map[string]string{
+			middleware.HeaderTenantID: dbtest.TenantAcme.ID,
+			echo.HeaderXRequestID:     "req-audit",
+		}, token, `{"name":"Ujang","email":"uje@email.com","password":"secret"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec, envelope := send(e, echo.GET, "/api/v1/audit?action=user.updated", admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var events []audit.Mapper
+		decode(t, envelope, &events)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, dbtest.UserUje.ID, events[0].ActorID)
+			assert.Equal(t, dbtest.UserUje.ID, events[0].TargetID)
+			assert.Equal(t, "req-audit", events[0].RequestID)
+			assert.Equal(t, audit.Change{Before: dbtest.UserUje.Name, After: "Ujang"}, events[0].Diff["name"])
+			assert.NotContains(t, events[0].Diff, "email")
+		}
+
+		// the failed login has no actor, the login is acted by its user
+		rec, envelope = send(e, echo.GET, "/api/v1/audit?target_type=email", admin, "", "")
+		decode(t, envelope, &events)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, audit.ActionLoginFailed, events[0].Action)
+			assert.Equal(t, "", events[0].ActorID)
+		}
+		rec, envelope = send(e, echo.GET, "/api/v1/audit?actor_id="+dbtest.UserUje.ID, admin, "", "")
+		decode(t, envelope, &events)
+		assert.Len(t, events, 2)
+
+		rec, envelope = send(e, echo.GET, "/api/v1/audit/verify", admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var verification audit.VerificationMapper
+		decode(t, envelope, &verification)
+		assert.Equal(t, audit.VerificationMapper{Valid: true, Events: 3}, verification)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := send(e, echo.GET, "/api/v1/audit?from=yesterday", admin, "", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+		rec, _ = send(e, echo.GET, "/api/v1/audit", map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID, middleware.HeaderAdminKey: "wrong"}, "", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_Outbox(t *testing.T) {
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+	log, _ := test.NewNullLogger()
+	e := NewServer(Config{Logger: log, OpenAPI: middleware.OpenAPIConfig{Requests: true, Responses: true}}, db)
+	subscribers := sink.NewSubscribers()
+	var mu sync.Mutex
+	var events []outbox.Event
+	subscribers.Subscribe(sink.All, func(ctx context.Context, event outbox.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+		return nil
+	})
+	dispatcher := outboxUsecase.NewDispatcher(outboxRepository.NewOutboxRepository(db), []outbox.Sink{subscribers}, outboxUsecase.DefaultDispatcherConfig)
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := call(e, echo.POST, "/api/v1/auth/register", "", `{"name":"Ahmad","email":"ahmad@email.com","password":"secret"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		id := envelope["data"].(map[string]interface{})["id"].(string)
+		token := login(t, e, dbtest.TenantAcme.ID, "ahmad@email.com", "secret")
+		rec, _ = call(e, echo.PUT, "/api/v1/user/"+id, token, `{"name":"Ahmad","email":"ahmad@email.com","password":"changed"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.DELETE, "/api/v1/user/"+id, token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		published, err := dispatcher.Dispatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 3, published)
+		mu.Lock()
+		defer mu.Unlock()
+		if assert.Len(t, events, 3) {
+			assert.Equal(t, outbox.EventUserRegistered, events[0].Type)
+			assert.Equal(t, outbox.EventUserPasswordChanged, events[1].Type)
+			assert.Equal(t, outbox.EventUserDeleted, events[2].Type)
+			assert.Equal(t, dbtest.TenantAcme.ID, events[0].TenantID)
+			assert.Equal(t, id, events[0].AggregateID)
+			assert.NotContains(t, string(events[0].Data), "password")
+		}
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// a failed change writes no event
+		rec, _ := call(e, echo.POST, "/api/v1/auth/register", "", `{"name":"Uje","email":"uje@email.com","password":"secret"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		published, err := dispatcher.Dispatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, published)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_Webhooks(t *testing.T) {
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+	log, _ := test.NewNullLogger()
+	e := NewServer(Config{Logger: log, OpenAPI: middleware.OpenAPIConfig{Requests: true, Responses: true}}, db)
+	_ = os.Setenv("APP_ADMIN_KEY", "admin-key")
+	defer os.Unsetenv("APP_ADMIN_KEY")
+	admin := map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID, middleware.HeaderAdminKey: "admin-key"}
+	webhooks := webhookUsecase.NewWebhookService(webhookRepository.NewWebhookRepository(db), webhookUsecase.DefaultDeliveryConfig)
+	dispatcher := outboxUsecase.NewDispatcher(outboxRepository.NewOutboxRepository(db), []outbox.Sink{webhookUsecase.NewWebhookSink(webhooks)}, outboxUsecase.DefaultDispatcherConfig)
+
+	// the partner checks the signature of the deliveries and fails the first one
+	var mu sync.Mutex
+	var secret string
+	var received []string
+	status := http.StatusInternalServerError
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := ioutil.ReadAll(r.Body)
+		if webhook.VerifySignature(secret, r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature), body, time.Now(), time.Minute) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received = append(received, r.Header.Get(webhook.HeaderEvent))
+		w.WriteHeader(status)
+	}))
+	defer partner.Close()
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := send(e, echo.POST, "/api/v1/webhooks", admin, "", `{"url":"`+partner.URL+`","event_types":["user.registered"]}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var subscription webhook.SecretMapper
+		decode(t, envelope, &subscription)
+		assert.NotEmpty(t, subscription.Secret)
+		mu.Lock()
+		secret = subscription.Secret
+		mu.Unlock()
+		rec, _ = send(e, echo.GET, "/api/v1/webhooks/"+subscription.ID, admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NotContains(t, rec.Body.String(), subscription.Secret)
+
+		rec, _ = call(e, echo.POST, "/api/v1/auth/register", "", `{"name":"Ahmad","email":"ahmad@email.com","password":"secret"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		_, err := dispatcher.Dispatch(context.Background())
+		assert.NoError(t, err)
+		delivered, err := webhooks.DeliverDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+
+		// the delivery log keeps the response code, the delivery is sent again by hand
+		rec, envelope = send(e, echo.GET, "/api/v1/webhooks/"+subscription.ID+"/deliveries", admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var deliveries []webhook.DeliveryMapper
+		decode(t, envelope, &deliveries)
+		if assert.Len(t, deliveries, 1) {
+			assert.Equal(t, "pending", deliveries[0].Status)
+			assert.Equal(t, http.StatusInternalServerError, deliveries[0].ResponseCode)
+			assert.NotNil(t, deliveries[0].NextAttemptAt)
+			mu.Lock()
+			status = http.StatusOK
+			mu.Unlock()
+			rec, envelope = send(e, echo.POST, "/api/v1/webhooks/"+subscription.ID+"/deliveries/"+deliveries[0].ID+"/redeliver", admin, "", "")
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var delivery webhook.DeliveryMapper
+			decode(t, envelope, &delivery)
+			assert.Equal(t, "succeeded", delivery.Status)
+			assert.Equal(t, http.StatusOK, delivery.ResponseCode)
+			assert.Equal(t, 2, delivery.Attempts)
+		}
+		mu.Lock()
+		assert.Equal(t, []string{"user.registered", "user.registered"}, received)
+		mu.Unlock()
+
+		rec, _ = send(e, echo.DELETE, "/api/v1/webhooks/"+subscription.ID, admin, "", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := send(e, echo.POST, "/api/v1/webhooks", admin, "", `{"url":"`+partner.URL+`","event_types":["user.unknown"]}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+		rec, _ = send(e, echo.GET, "/api/v1/webhooks/unknown/deliveries", admin, "", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/webhooks", "", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestServer_Jobs(t *testing.T) {
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+	log, _ := test.NewNullLogger()
+	e := NewServer(Config{Logger: log, OpenAPI: middleware.OpenAPIConfig{Requests: true, Responses: true}}, db)
+	users := userUsecase.NewUserService(userRepository.NewUserRepository(db), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db)))
+	// a single worker, the test database is a single transaction
+	pool := jobUsecase.NewPool(jobRepository.NewJobRepository(db), job.Handlers{
+		user.JobImport: userUsecase.NewImportJob(users, auditUsecase.NewAuditService(auditRepository.NewAuditRepository(db))),
+	}, jobUsecase.PoolConfig{Concurrency: 1})
+	token := login(t, e, dbtest.TenantAcme.ID, dbtest.UserUje.Email, dbtest.FixturePassword)
+	csv := map[string]string{middleware.HeaderTenantID: dbtest.TenantAcme.ID, echo.HeaderContentType: "text/csv"}
+	var enqueued job.Mapper
+
+	s := t.Run("success", func(t *testing.T) {
+		rec, envelope := send(e, echo.POST, "/api/v1/user/import?async=true", csv, token, "name,email\nAhmad,ahmad@email.com\nIpan,ipan@email.com\n")
+		assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		decode(t, envelope, &enqueued)
+		assert.Equal(t, user.JobImport, enqueued.Type)
+		assert.Equal(t, "queued", enqueued.Status)
+
+		started, err := pool.Work(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, started)
+
+		rec, envelope = call(e, echo.GET, "/api/v1/jobs/"+enqueued.ID, token, "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var finished job.Mapper
+		decode(t, envelope, &finished)
+		assert.Equal(t, "succeeded", finished.Status)
+		assert.Equal(t, 100, finished.Progress)
+		assert.Equal(t, 1, finished.Attempts)
+		assert.NotNil(t, finished.FinishedAt)
+		assert.Nil(t, finished.RunAt)
+		data, _ := json.Marshal(finished.Result)
+		var report user.ImportMapper
+		assert.NoError(t, json.Unmarshal(data, &report))
+		assert.Equal(t, user.ImportMapper{Total: 2, Imported: 1, Failed: 1, Errors: []user.ImportErrorMapper{
+			{Row: 2, Field: "email", Message: user.ErrEmailTaken.Error()},
+		}}, report)
+		rec, _ = call(e, echo.GET, "/api/v1/user/export?format=json", token, "")
+		var exported []user.ExportMapper
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &exported))
+		assert.Len(t, exported, 3)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		rec, _ := send(e, echo.POST, "/api/v1/user/import?async=true", csv, token, "name\nCici\n")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		rec, _ = send(e, echo.POST, "/api/v1/user/import?async=maybe", csv, token, "name,email\n")
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/jobs/unknown", token, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		// the job of another user is not found
+		ipan := login(t, e, dbtest.TenantAcme.ID, dbtest.UserIpan.Email, dbtest.FixturePassword)
+		rec, _ = call(e, echo.GET, "/api/v1/jobs/"+enqueued.ID, ipan, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		rec, _ = call(e, echo.GET, "/api/v1/jobs/"+enqueued.ID, "not-a-token", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
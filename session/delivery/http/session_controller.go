@@ -0,0 +1,47 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/session"
+	"go-echo-api/utils"
+)
+
+type sessionController struct {
+	sessionUsecase session.Usecase
+}
+
+func NewSessionController(s session.Usecase) *sessionController {
+	return &sessionController{sessionUsecase: s}
+}
+
+// FindAll list the active sessions of the logged in user, the session of its access token marked current
+func (c *sessionController) FindAll(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.sessionUsecase.FindAll(ctx.Request().Context(), middleware.UserID(ctx), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, session.NewSessionMapper(middleware.SessionID(ctx)).MapList(result), nil)
+}
+
+func (c *sessionController) Delete(ctx echo.Context) error {
+	if err := c.sessionUsecase.Revoke(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+// errorResponse map the errors of the session use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case session.ErrNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("session use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/middleware"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/sink"
+	"go-echo-api/session/repository"
+	"go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+)
+
+// Module wire the sessions to the database and register its routes under /me/sessions. Every login
+// starts a session, named in the sid claim of its tokens, the logged in user lists its sessions and
+// revokes those of the devices it no longer uses.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/me/sessions"
+}
+
+// Subscribe delete the sessions of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewSessionRepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	sessions := usecase.NewSessionService(repository.NewSessionRepository(m.db), middleware.TokenTTL)
+	controller := NewSessionController(sessions)
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	loggedIn := middleware.IsLoggedIn(sessions)
+	g.GET("", controller.FindAll, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.DELETE("/:id", controller.Delete, tenantScope, loggedIn, middleware.RequireUnscoped)
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/session"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+	unauthorized := g.Error("Missing or invalid access token")
+	scoped := g.Error("Token issued to an OAuth client")
+
+	g.Add(echo.GET, "", openapi.Operation{
+		Tags:        []string{"session"},
+		Summary:     "List the active sessions of the logged in user, the most recently used first",
+		OperationID: "listSessions",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of sessions skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of sessions", session.Mapper{}),
+			"401": unauthorized,
+			"403": scoped,
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.DELETE, "/:id", openapi.Operation{
+		Tags:        []string{"session"},
+		Summary:     "Revoke a session of the logged in user, its refresh token is refused from then",
+		OperationID: "revokeSession",
+		Parameters:  []openapi.Parameter{openapi.PathParam("id", "ID of the session")},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Session revoked", nil),
+			"401": unauthorized,
+			"403": scoped,
+			"404": g.Error("Session not found, revoked or expired"),
+		},
+		Security: openapi.BearerAuth,
+	})
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/session"
+	"time"
+)
+
+type sessionGormRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) session.Repository {
+	return &sessionGormRepository{db: db}
+}
+
+func (r *sessionGormRepository) FindAll(ctx context.Context, userID string, now time.Time, limit int64, offset int64) ([]models.Session, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.Session{}).Where("user_id = ? AND expires_at > ?", userID, now)
+	var model []models.Session
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("last_used_at DESC").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *sessionGormRepository) FindById(ctx context.Context, userID string, id string, now time.Time) (*models.Session, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.Session
+	err = db.Where("user_id = ? AND id = ? AND expires_at > ?", userID, id, now).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, session.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *sessionGormRepository) Store(ctx context.Context, model *models.Session) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *sessionGormRepository) Touch(ctx context.Context, userID string, id string, ip string, userAgent string, usedAt time.Time, expiresAt time.Time) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Model(&models.Session{}).Where("user_id = ? AND id = ? AND expires_at > ?", userID, id, usedAt).
+		UpdateColumns(map[string]interface{}{"ip": ip, "user_agent": userAgent, "last_used_at": usedAt, "expires_at": expiresAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return session.ErrNotFound
+	}
+	return nil
+}
+
+func (r *sessionGormRepository) Delete(ctx context.Context, userID string, id string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&models.Session{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return session.ErrNotFound
+	}
+	return nil
+}
+
+func (r *sessionGormRepository) DeleteExpired(ctx context.Context, userID string, now time.Time) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ? AND expires_at <= ?", userID, now).Delete(&models.Session{}).Error
+}
+
+func (r *sessionGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return db.Where("user_id = ?", userID).Delete(&models.Session{}).Error
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/session"
+	"testing"
+	"time"
+)
+
+func TestSessionGormRepository(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewSessionRepository(db)
+	now := time.Now()
+	active := models.Session{UserID: dbtest.UserUje.ID, Device: "Firefox on Linux", LastUsedAt: now, ExpiresAt: now.Add(time.Hour)}
+	expired := models.Session{UserID: dbtest.UserUje.ID, LastUsedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	assert.NoError(t, r.Store(dbtest.Context(), &active))
+	assert.NoError(t, r.Store(dbtest.Context(), &expired))
+
+	result, total, err := r.FindAll(dbtest.Context(), dbtest.UserUje.ID, now, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, active.ID, result[0].ID)
+	_, total, _ = r.FindAll(dbtest.TenantContext(dbtest.TenantGlobex), dbtest.UserUje.ID, now, 10, 0)
+	assert.Equal(t, int64(0), total)
+	found, err := r.FindById(dbtest.Context(), dbtest.UserUje.ID, active.ID, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "Firefox on Linux", found.Device)
+	_, err = r.FindById(dbtest.Context(), dbtest.UserUje.ID, expired.ID, now)
+	assert.Equal(t, session.ErrNotFound, err)
+	_, err = r.FindById(dbtest.Context(), dbtest.UserIpan.ID, active.ID, now)
+	assert.Equal(t, session.ErrNotFound, err)
+
+	// a use extends the session, an expired session is not used
+	later := now.Add(time.Minute)
+	assert.NoError(t, r.Touch(dbtest.Context(), dbtest.UserUje.ID, active.ID, "192.0.2.1", "curl/7.0", later, later.Add(time.Hour)))
+	assert.Equal(t, session.ErrNotFound, r.Touch(dbtest.Context(), dbtest.UserUje.ID, expired.ID, "192.0.2.1", "curl/7.0", later, later.Add(time.Hour)))
+	assert.Equal(t, session.ErrNotFound, r.Touch(dbtest.Context(), dbtest.UserIpan.ID, active.ID, "192.0.2.1", "curl/7.0", later, later.Add(time.Hour)))
+	result, _, _ = r.FindAll(dbtest.Context(), dbtest.UserUje.ID, now, 10, 0)
+	assert.Equal(t, "192.0.2.1", result[0].IP)
+
+	assert.NoError(t, r.DeleteExpired(dbtest.Context(), dbtest.UserUje.ID, now))
+	count := 0
+	assert.NoError(t, db.Model(&models.Session{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+
+	assert.Equal(t, session.ErrNotFound, r.Delete(dbtest.Context(), dbtest.UserIpan.ID, active.ID))
+	assert.NoError(t, r.Delete(dbtest.Context(), dbtest.UserUje.ID, active.ID))
+	assert.Equal(t, session.ErrNotFound, r.Delete(dbtest.Context(), dbtest.UserUje.ID, active.ID))
+}
+
+func TestSessionGormRepository_DeleteByUser(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	ipan := models.Session{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID}
+	uje := models.Session{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserUje.ID}
+	assert.NoError(t, dbtest.LoadFixtures(db, &ipan, &uje))
+
+	r := NewSessionRepository(db)
+	assert.NoError(t, r.DeleteByUser(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.Session{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Model(&models.Session{}).Where("user_id = ?", dbtest.UserUje.ID).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
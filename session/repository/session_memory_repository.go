@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/models"
+	"go-echo-api/session"
+	"go-echo-api/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sessionMemoryRepository keeps the sessions in memory, it backs the use-case unit tests
+type sessionMemoryRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]models.Session
+}
+
+func NewSessionMemoryRepository() session.Repository {
+	return &sessionMemoryRepository{sessions: make(map[string]models.Session)}
+}
+
+func (r *sessionMemoryRepository) FindAll(ctx context.Context, userID string, now time.Time, limit int64, offset int64) ([]models.Session, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []models.Session
+	for _, s := range r.sessions {
+		if s.TenantID == tenantID && s.UserID == userID && !s.Expired(now) {
+			all = append(all, s)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].LastUsedAt.After(all[j].LastUsedAt)
+	})
+	total := int64(len(all))
+	if offset >= total {
+		return []models.Session{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *sessionMemoryRepository) FindById(ctx context.Context, userID string, id string, now time.Time) (*models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := r.sessions[id]
+	if !ok || s.TenantID != tenantID || s.UserID != userID || s.Expired(now) {
+		return nil, session.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r *sessionMemoryRepository) Store(ctx context.Context, model *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	r.sessions[model.ID] = *model
+	return nil
+}
+
+func (r *sessionMemoryRepository) Touch(ctx context.Context, userID string, id string, ip string, userAgent string, usedAt time.Time, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	s, ok := r.sessions[id]
+	if !ok || s.TenantID != tenantID || s.UserID != userID || s.Expired(usedAt) {
+		return session.ErrNotFound
+	}
+	s.IP, s.UserAgent, s.LastUsedAt, s.ExpiresAt = ip, userAgent, usedAt, expiresAt
+	r.sessions[id] = s
+	return nil
+}
+
+func (r *sessionMemoryRepository) Delete(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if s, ok := r.sessions[id]; !ok || s.TenantID != tenantID || s.UserID != userID {
+		return session.ErrNotFound
+	}
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *sessionMemoryRepository) DeleteExpired(ctx context.Context, userID string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, s := range r.sessions {
+		if s.TenantID == tenantID && s.UserID == userID && s.Expired(now) {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (r *sessionMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, v := range r.sessions {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package session
+
+import "errors"
+
+var ErrNotFound = errors.New("session not found, revoked or expired")
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"go-echo-api/models"
+	"time"
+)
+
+type Mapper struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current tells the session of the access token of the request
+	Current bool `json:"current"`
+	current string
+}
+
+// NewSessionMapper return a mapper marking the session of ID current as the current one
+func NewSessionMapper(current string) *Mapper {
+	return &Mapper{current: current}
+}
+
+func (m *Mapper) Map(model models.Session) *Mapper {
+	m.ID = model.ID
+	m.Device = model.Device
+	m.IP = model.IP
+	m.UserAgent = model.UserAgent
+	m.CreatedAt = model.CreatedAt
+	m.LastUsedAt = model.LastUsedAt
+	m.ExpiresAt = model.ExpiresAt
+	m.Current = m.current != "" && model.ID == m.current
+	return m
+}
+
+func (m *Mapper) MapList(model []models.Session) interface{} {
+	serialized := make([]Mapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&Mapper{current: m.current}).Map(v)
+	}
+	return serialized
+}
@@ -0,0 +1,24 @@
+package session
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+// Repository of the sessions of the tenant of the context
+type Repository interface {
+	// FindAll return a page of the sessions of the user not expired at now, the last used first
+	FindAll(ctx context.Context, userID string, now time.Time, limit int64, offset int64) ([]models.Session, int64, error)
+	// FindById return the session of the user, ErrNotFound when it is revoked or expired at now
+	FindById(ctx context.Context, userID string, id string, now time.Time) (*models.Session, error)
+	Store(ctx context.Context, model *models.Session) error
+	// Touch record a use of the session of the user from ip and userAgent and extend it to expiresAt,
+	// ErrNotFound when the session is revoked or expired at usedAt
+	Touch(ctx context.Context, userID string, id string, ip string, userAgent string, usedAt time.Time, expiresAt time.Time) error
+	Delete(ctx context.Context, userID string, id string) error
+	// DeleteExpired delete the sessions of the user expired at now
+	DeleteExpired(ctx context.Context, userID string, now time.Time) error
+	// DeleteByUser delete the sessions of the user, once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
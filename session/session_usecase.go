@@ -0,0 +1,19 @@
+package session
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+type Usecase interface {
+	// Start a session of the user logging in from ip with userAgent
+	Start(ctx context.Context, userID string, ip string, userAgent string) (models.Session, error)
+	// Refresh record the refresh of the tokens of the session of the user, ErrNotFound when it was revoked or expired
+	Refresh(ctx context.Context, userID string, id string, ip string, userAgent string) error
+	// Check tell whether the session of the user is active, ErrNotFound when it was revoked or expired
+	Check(ctx context.Context, userID string, id string) error
+	// FindAll return a page of the active sessions of the user
+	FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.Session, int64, error)
+	// Revoke the session of the user, its access and refresh tokens are refused from then
+	Revoke(ctx context.Context, userID string, id string) error
+}
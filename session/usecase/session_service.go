@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/models"
+	"go-echo-api/session"
+	"strings"
+	"time"
+)
+
+// maxUserAgent bounds the user agent kept, it is sent by the client
+const maxUserAgent = 512
+
+type SessionService struct {
+	sessionRepository session.Repository
+	// ttl is the lifetime of the refresh tokens, a session not refreshed within it ends
+	ttl time.Duration
+}
+
+func NewSessionService(r session.Repository, ttl time.Duration) session.Usecase {
+	return SessionService{sessionRepository: r, ttl: ttl}
+}
+
+func (s SessionService) Start(ctx context.Context, userID string, ip string, userAgent string) (models.Session, error) {
+	now := time.Now()
+	// the sessions ended are dropped as the user logs in again
+	if err := s.sessionRepository.DeleteExpired(ctx, userID, now); err != nil {
+		return models.Session{}, err
+	}
+	userAgent = truncate(userAgent)
+	model := models.Session{
+		UserID:     userID,
+		Device:     Device(userAgent),
+		IP:         ip,
+		UserAgent:  userAgent,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+	if err := s.sessionRepository.Store(ctx, &model); err != nil {
+		return models.Session{}, err
+	}
+	return model, nil
+}
+
+func (s SessionService) Refresh(ctx context.Context, userID string, id string, ip string, userAgent string) error {
+	now := time.Now()
+	return s.sessionRepository.Touch(ctx, userID, id, ip, truncate(userAgent), now, now.Add(s.ttl))
+}
+
+func (s SessionService) Check(ctx context.Context, userID string, id string) error {
+	_, err := s.sessionRepository.FindById(ctx, userID, id, time.Now())
+	return err
+}
+
+func (s SessionService) FindAll(ctx context.Context, userID string, limit int64, offset int64) ([]models.Session, int64, error) {
+	return s.sessionRepository.FindAll(ctx, userID, time.Now(), limit, offset)
+}
+
+func (s SessionService) Revoke(ctx context.Context, userID string, id string) error {
+	if err := s.sessionRepository.Delete(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, userID).WithField("session_id", id).Info("session revoked")
+	return nil
+}
+
+func truncate(userAgent string) string {
+	if len(userAgent) > maxUserAgent {
+		return userAgent[:maxUserAgent]
+	}
+	return userAgent
+}
+
+// browsers and systems recognized in the user agents, in order: Edge and Opera name Chrome too,
+// Chrome names Safari, Android names Linux
+var (
+	browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"},
+		{"CriOS/", "Chrome"}, {"FxiOS/", "Firefox"}, {"Safari/", "Safari"}, {"curl/", "curl"},
+	}
+	systems = []struct{ token, name string }{
+		{"Windows", "Windows"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	}
+)
+
+// Device describe the browser and system of a user agent, e.g. "Chrome on Windows"
+func Device(userAgent string) string {
+	var browser, system string
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, o := range systems {
+		if strings.Contains(userAgent, o.token) {
+			system = o.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Unknown device"
+	}
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/session"
+	"go-echo-api/session/repository"
+	"testing"
+	"time"
+)
+
+const firefox = "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0"
+
+func TestSessionService(t *testing.T) {
+	s := t.Run("success", func(t *testing.T) {
+		service := NewSessionService(repository.NewSessionMemoryRepository(), time.Hour)
+		started, err := service.Start(dbtest.Context(), dbtest.UserUje.ID, "192.0.2.1", firefox)
+		assert.NoError(t, err)
+		assert.Equal(t, "Firefox on Linux", started.Device)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), started.ExpiresAt, time.Minute)
+
+		assert.NoError(t, service.Check(dbtest.Context(), dbtest.UserUje.ID, started.ID))
+		assert.NoError(t, service.Refresh(dbtest.Context(), dbtest.UserUje.ID, started.ID, "192.0.2.2", firefox))
+		result, total, err := service.FindAll(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "192.0.2.2", result[0].IP)
+		assert.True(t, result[0].LastUsedAt.After(started.LastUsedAt) || result[0].LastUsedAt.Equal(started.LastUsedAt))
+
+		assert.NoError(t, service.Revoke(dbtest.Context(), dbtest.UserUje.ID, started.ID))
+		_, total, _ = service.FindAll(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+		assert.Equal(t, int64(0), total)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		service := NewSessionService(repository.NewSessionMemoryRepository(), time.Hour)
+		started, _ := service.Start(dbtest.Context(), dbtest.UserUje.ID, "192.0.2.1", firefox)
+
+		// a session is refreshed and revoked by its user only
+		assert.Equal(t, session.ErrNotFound, service.Refresh(dbtest.Context(), dbtest.UserIpan.ID, started.ID, "", ""))
+		assert.Equal(t, session.ErrNotFound, service.Revoke(dbtest.Context(), dbtest.UserIpan.ID, started.ID))
+		assert.NoError(t, service.Revoke(dbtest.Context(), dbtest.UserUje.ID, started.ID))
+		assert.Equal(t, session.ErrNotFound, service.Refresh(dbtest.Context(), dbtest.UserUje.ID, started.ID, "", ""))
+		assert.Equal(t, session.ErrNotFound, service.Check(dbtest.Context(), dbtest.UserUje.ID, started.ID))
+
+		// a session not refreshed within its lifetime ends
+		expiring := NewSessionService(repository.NewSessionMemoryRepository(), -time.Second)
+		started, _ = expiring.Start(dbtest.Context(), dbtest.UserUje.ID, "192.0.2.1", firefox)
+		assert.Equal(t, session.ErrNotFound, expiring.Refresh(dbtest.Context(), dbtest.UserUje.ID, started.ID, "", ""))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestDevice(t *testing.T) {
+	for userAgent, expected := range map[string]string{
+		firefox: "Firefox on Linux",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0":           "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1": "Safari on iOS",
+		"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36":                            "Chrome on Android",
+		"curl/8.4.0": "curl",
+		"":           "Unknown device",
+	} {
+		assert.Equal(t, expected, Device(userAgent), userAgent)
+	}
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/tenant"
+	"go-echo-api/utils"
+)
+
+type tenantController struct {
+	tenantUsecase tenant.Usecase
+	tenantMapper  *tenant.Mapper
+}
+
+func NewTenantController(s tenant.Usecase) *tenantController {
+	return &tenantController{tenantUsecase: s,
+		tenantMapper: tenant.NewTenantMapper(),
+	}
+}
+
+func (c *tenantController) FindAll(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.tenantUsecase.FindAll(ctx.Request().Context(), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.tenantMapper.MapList(result), nil)
+}
+
+func (c *tenantController) FindById(ctx echo.Context) error {
+	result, err := c.tenantUsecase.FindById(ctx.Request().Context(), ctx.Param("id"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.tenantMapper.Map(*result), nil)
+}
+
+func (c *tenantController) Store(ctx echo.Context) error {
+	var dto tenant.Dto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.tenantUsecase.Create(ctx.Request().Context(), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.tenantMapper.Map(result), nil)
+}
+
+func (c *tenantController) Suspend(ctx echo.Context) error {
+	result, err := c.tenantUsecase.Suspend(ctx.Request().Context(), ctx.Param("id"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.tenantMapper.Map(result), nil)
+}
+
+func (c *tenantController) Activate(ctx echo.Context) error {
+	result, err := c.tenantUsecase.Activate(ctx.Request().Context(), ctx.Param("id"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.tenantMapper.Map(result), nil)
+}
+
+// errorResponse map the errors of the tenant use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case tenant.ErrNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	case tenant.ErrSlugTaken:
+		return response.Conflict(ctx, utils.Conflict, nil, err.Error())
+	case tenant.ErrInvalidSlug:
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("tenant use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
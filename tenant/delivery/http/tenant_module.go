@@ -0,0 +1,32 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/middleware"
+	"go-echo-api/tenant/repository"
+	"go-echo-api/tenant/usecase"
+)
+
+// Module wire the tenant controller to the database and register the admin routes
+// managing the tenants under /tenant, every route requires the admin key
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/tenant"
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	controller := NewTenantController(usecase.NewTenantService(repository.NewTenantRepository(m.db)))
+	g.GET("", controller.FindAll, middleware.IsAdmin)
+	g.GET("/:id", controller.FindById, middleware.IsAdmin)
+	g.POST("", controller.Store, middleware.IsAdmin)
+	g.POST("/:id/suspend", controller.Suspend, middleware.IsAdmin)
+	g.POST("/:id/activate", controller.Activate, middleware.IsAdmin)
+}
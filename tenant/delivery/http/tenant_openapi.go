@@ -0,0 +1,71 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/tenant"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.AdminOpenAPI(g)
+	id := openapi.PathParam("id", "ID of the tenant")
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+
+	g.Add(echo.GET, "", openapi.Operation{
+		Tags:        []string{"tenant"},
+		Summary:     "List the tenants",
+		OperationID: "listTenants",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of tenants skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of tenants", tenant.Mapper{}),
+		},
+	})
+	g.Add(echo.GET, "/:id", openapi.Operation{
+		Tags:        []string{"tenant"},
+		Summary:     "Find a tenant by ID",
+		OperationID: "findTenant",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Tenant", tenant.Mapper{}),
+			"404": g.Error("Tenant not found"),
+		},
+	})
+	g.Add(echo.POST, "", openapi.Operation{
+		Tags:        []string{"tenant"},
+		Summary:     "Create a tenant, its slug is the subdomain of its users",
+		OperationID: "createTenant",
+		RequestBody: g.Body(tenant.Dto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Created tenant", tenant.Mapper{}),
+			"409": g.Error("Slug already taken"),
+			"422": g.Error("Invalid body or slug"),
+		},
+	})
+	g.Add(echo.POST, "/:id/suspend", openapi.Operation{
+		Tags:        []string{"tenant"},
+		Summary:     "Suspend a tenant, the requests of its users are answered 403",
+		OperationID: "suspendTenant",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Suspended tenant", tenant.Mapper{}),
+			"404": g.Error("Tenant not found"),
+		},
+	})
+	g.Add(echo.POST, "/:id/activate", openapi.Operation{
+		Tags:        []string{"tenant"},
+		Summary:     "Activate a suspended tenant",
+		OperationID: "activateTenant",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Active tenant", tenant.Mapper{}),
+			"404": g.Error("Tenant not found"),
+		},
+	})
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+)
+
+type tenantGormRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantRepository(db *gorm.DB) tenant.Repository {
+	return &tenantGormRepository{db: db}
+}
+
+// conn return the database handle bound to the context of the call
+func (r *tenantGormRepository) conn(ctx context.Context) *gorm.DB {
+	return database.WithContext(ctx, r.db)
+}
+
+func (r *tenantGormRepository) FindAll(ctx context.Context, limit int64, offset int64) ([]models.Tenant, int64, error) {
+	var model []models.Tenant
+	var total int64
+	if err := r.conn(ctx).Model(&models.Tenant{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := r.conn(ctx).Order("created_at").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *tenantGormRepository) FindById(ctx context.Context, id string) (*models.Tenant, error) {
+	return r.first(ctx, "id = ?", id)
+}
+
+func (r *tenantGormRepository) FindBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
+	return r.first(ctx, "slug = ?", slug)
+}
+
+func (r *tenantGormRepository) first(ctx context.Context, query string, value string) (*models.Tenant, error) {
+	var model models.Tenant
+	err := r.conn(ctx).Where(query, value).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, tenant.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *tenantGormRepository) Store(ctx context.Context, model *models.Tenant) error {
+	return r.conn(ctx).Create(model).Error
+}
+
+func (r *tenantGormRepository) Update(ctx context.Context, model *models.Tenant) error {
+	return r.conn(ctx).Save(model).Error
+}
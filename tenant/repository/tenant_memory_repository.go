@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"sort"
+	"sync"
+	"time"
+)
+
+// tenantMemoryRepository keeps the tenants in memory, it backs the use-case unit tests
+type tenantMemoryRepository struct {
+	mu      sync.RWMutex
+	tenants map[string]models.Tenant
+}
+
+func NewTenantMemoryRepository(tenants ...models.Tenant) tenant.Repository {
+	r := &tenantMemoryRepository{tenants: make(map[string]models.Tenant)}
+	for _, t := range tenants {
+		r.tenants[t.ID] = t
+	}
+	return r
+}
+
+func (r *tenantMemoryRepository) FindAll(_ context.Context, limit int64, offset int64) ([]models.Tenant, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]models.Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		all = append(all, t)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	total := int64(len(all))
+	if offset >= total {
+		return []models.Tenant{}, total, nil
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *tenantMemoryRepository) FindById(_ context.Context, id string) (*models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, tenant.ErrNotFound
+	}
+	return &t, nil
+}
+
+func (r *tenantMemoryRepository) FindBySlug(_ context.Context, slug string) (*models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.tenants {
+		if t.Slug == slug {
+			return &t, nil
+		}
+	}
+	return nil, tenant.ErrNotFound
+}
+
+func (r *tenantMemoryRepository) Store(_ context.Context, model *models.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tenants {
+		if t.Slug == model.Slug {
+			return tenant.ErrSlugTaken
+		}
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	if model.Status == "" {
+		model.Status = models.TenantActive
+	}
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.tenants[model.ID] = *model
+	return nil
+}
+
+func (r *tenantMemoryRepository) Update(_ context.Context, model *models.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tenants[model.ID]; !ok {
+		return tenant.ErrNotFound
+	}
+	model.UpdatedAt = time.Now()
+	r.tenants[model.ID] = *model
+	return nil
+}
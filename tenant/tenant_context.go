@@ -0,0 +1,33 @@
+package tenant
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+type contextKey struct{}
+
+// WithContext return a copy of ctx carrying the tenant of the request,
+// the repositories of the tenant-scoped modules only see the records of that tenant
+func WithContext(ctx context.Context, model models.Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, model)
+}
+
+// FromContext return the tenant carried by ctx
+func FromContext(ctx context.Context) (models.Tenant, bool) {
+	if ctx == nil {
+		return models.Tenant{}, false
+	}
+	model, ok := ctx.Value(contextKey{}).(models.Tenant)
+	return model, ok
+}
+
+// ID return the ID of the tenant carried by ctx, ErrRequired when it carries none
+// so a query never runs unscoped
+func ID(ctx context.Context) (string, error) {
+	model, ok := FromContext(ctx)
+	if !ok || model.ID == "" {
+		return "", ErrRequired
+	}
+	return model.ID, nil
+}
@@ -0,0 +1,6 @@
+package tenant
+
+type Dto struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Slug string `json:"slug" validate:"required,max=63"`
+}
@@ -0,0 +1,12 @@
+package tenant
+
+import "errors"
+
+var (
+	ErrNotFound    = errors.New("tenant not found")
+	ErrSlugTaken   = errors.New("slug is already taken")
+	ErrInvalidSlug = errors.New("slug must be lower case letters, digits and dashes, starting and ending with a letter or a digit")
+	ErrSuspended   = errors.New("tenant is suspended")
+	ErrRequired    = errors.New("tenant is required, set the X-Tenant-ID header or use the subdomain of the tenant")
+	ErrMismatch    = errors.New("access token belongs to another tenant")
+)
@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"go-echo-api/models"
+	"time"
+)
+
+type Mapper struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	Status      string     `json:"status"`
+	SuspendedAt *time.Time `json:"suspended_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func NewTenantMapper() *Mapper {
+	return &Mapper{}
+}
+
+func (m *Mapper) Map(model models.Tenant) *Mapper {
+	m.ID = model.ID
+	m.Name = model.Name
+	m.Slug = model.Slug
+	m.Status = model.Status
+	m.SuspendedAt = model.SuspendedAt
+	m.CreatedAt = model.CreatedAt
+	return m
+}
+
+func (m *Mapper) MapList(model []models.Tenant) interface{} {
+	serialized := make([]Mapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&Mapper{}).Map(v)
+	}
+	return serialized
+}
@@ -0,0 +1,14 @@
+package tenant
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+type Repository interface {
+	FindAll(ctx context.Context, limit int64, offset int64) ([]models.Tenant, int64, error)
+	FindById(ctx context.Context, id string) (*models.Tenant, error)
+	FindBySlug(ctx context.Context, slug string) (*models.Tenant, error)
+	Store(ctx context.Context, model *models.Tenant) error
+	Update(ctx context.Context, model *models.Tenant) error
+}
@@ -0,0 +1,14 @@
+package tenant
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+type Usecase interface {
+	FindAll(ctx context.Context, limit int64, offset int64) ([]models.Tenant, int64, error)
+	FindById(ctx context.Context, id string) (*models.Tenant, error)
+	Create(ctx context.Context, dto Dto) (models.Tenant, error)
+	Suspend(ctx context.Context, id string) (models.Tenant, error)
+	Activate(ctx context.Context, id string) (models.Tenant, error)
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// slugPattern is a DNS label, the slug is the subdomain of the tenant
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+type TenantService struct {
+	tenantRepository tenant.Repository
+}
+
+func NewTenantService(r tenant.Repository) tenant.Usecase {
+	return TenantService{tenantRepository: r}
+}
+
+func (s TenantService) FindAll(ctx context.Context, limit int64, offset int64) ([]models.Tenant, int64, error) {
+	return s.tenantRepository.FindAll(ctx, limit, offset)
+}
+
+func (s TenantService) FindById(ctx context.Context, id string) (*models.Tenant, error) {
+	return s.tenantRepository.FindById(ctx, id)
+}
+
+func (s TenantService) Create(ctx context.Context, dto tenant.Dto) (models.Tenant, error) {
+	model := models.Tenant{Name: dto.Name, Slug: strings.ToLower(dto.Slug), Status: models.TenantActive}
+	if !slugPattern.MatchString(model.Slug) {
+		return model, tenant.ErrInvalidSlug
+	}
+	_, err := s.tenantRepository.FindBySlug(ctx, model.Slug)
+	if err == nil {
+		return model, tenant.ErrSlugTaken
+	}
+	if err != tenant.ErrNotFound {
+		return model, err
+	}
+	if err := s.tenantRepository.Store(ctx, &model); err != nil {
+		return model, err
+	}
+	logger.FromContext(ctx).WithField(logger.TenantIDField, model.ID).Info("tenant created")
+	return model, nil
+}
+
+// Suspend lock the users of the tenant out, their requests are answered 403 until the tenant is activated
+func (s TenantService) Suspend(ctx context.Context, id string) (models.Tenant, error) {
+	now := time.Now()
+	return s.setStatus(ctx, id, models.TenantSuspended, &now)
+}
+
+func (s TenantService) Activate(ctx context.Context, id string) (models.Tenant, error) {
+	return s.setStatus(ctx, id, models.TenantActive, nil)
+}
+
+func (s TenantService) setStatus(ctx context.Context, id string, status string, suspendedAt *time.Time) (models.Tenant, error) {
+	existing, err := s.tenantRepository.FindById(ctx, id)
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	model := *existing
+	if model.Status == status {
+		return model, nil
+	}
+	model.Status = status
+	model.SuspendedAt = suspendedAt
+	if err := s.tenantRepository.Update(ctx, &model); err != nil {
+		return model, err
+	}
+	logger.FromContext(ctx).WithField(logger.TenantIDField, model.ID).Info("tenant " + status)
+	return model, nil
+}
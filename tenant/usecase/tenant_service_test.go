@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"go-echo-api/tenant/repository"
+	"testing"
+)
+
+// newTenantService return a service backed by an in-memory repository holding the fixture tenants
+func newTenantService() tenant.Usecase {
+	return NewTenantService(repository.NewTenantMemoryRepository(dbtest.TenantAcme, dbtest.TenantGlobex))
+}
+
+func TestTenantService_Create(t *testing.T) {
+	u := newTenantService()
+
+	s := t.Run("success", func(t *testing.T) {
+		data, err := u.Create(context.Background(), tenant.Dto{Name: "Initech", Slug: "Initech"})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data.ID)
+		assert.Equal(t, "initech", data.Slug)
+		assert.Equal(t, models.TenantActive, data.Status)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := u.Create(context.Background(), tenant.Dto{Name: "Acme", Slug: dbtest.TenantAcme.Slug})
+		assert.Equal(t, tenant.ErrSlugTaken, err)
+
+		_, err = u.Create(context.Background(), tenant.Dto{Name: "Acme", Slug: "not.a-label"})
+		assert.Equal(t, tenant.ErrInvalidSlug, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestTenantService_Suspend(t *testing.T) {
+	u := newTenantService()
+
+	s := t.Run("success", func(t *testing.T) {
+		data, err := u.Suspend(context.Background(), dbtest.TenantGlobex.ID)
+		assert.NoError(t, err)
+		assert.False(t, data.Active())
+		assert.NotNil(t, data.SuspendedAt)
+
+		data, err = u.Activate(context.Background(), dbtest.TenantGlobex.ID)
+		assert.NoError(t, err)
+		assert.True(t, data.Active())
+		assert.Nil(t, data.SuspendedAt)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := u.Suspend(context.Background(), "unknown")
+		assert.Equal(t, tenant.ErrNotFound, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
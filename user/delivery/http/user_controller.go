@@ -1,33 +1,34 @@
 package http
 
 import (
-	"github.com/jinzhu/gorm"
 	"github.com/labstack/echo"
-	"github.com/ulule/paging"
+	"go-echo-api/audit"
+	"go-echo-api/infrastructure/logger"
 	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/session"
 	"go-echo-api/user"
 	"go-echo-api/utils"
-	"net/http"
 )
 
 type userController struct {
-	userRepository user.Repository
-	userMapper     *user.Mapper
-	db             *gorm.DB
+	userUsecase  user.Usecase
+	auditUsecase audit.Usecase
+	userMapper   *user.Mapper
 }
 
-func NewUserController(s user.Repository,db *gorm.DB) *userController {
-	return &userController{userRepository: s,
-		db:db,
-		userMapper: user.NewUserMapper(),
+func NewUserController(s user.Usecase, a audit.Usecase) *userController {
+	return &userController{userUsecase: s,
+		auditUsecase: a,
+		userMapper:   user.NewUserMapper(),
 	}
 }
 
 func (c *userController) FindById(ctx echo.Context) error {
 	id := ctx.Param("id")
-	result, err := c.userRepository.FindById(id)
+	result, err := c.userUsecase.FindById(ctx.Request().Context(), id)
 	if err != nil {
-		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+		return errorResponse(ctx, err)
 	}
 	return response.SingleData(ctx, utils.OK, c.userMapper.Map(*result), nil)
 }
@@ -40,29 +41,22 @@ func (c *userController) Store(ctx echo.Context) error {
 	if err := ctx.Validate(dto); err != nil {
 		return response.ValidationError(ctx, utils.ValidationError, nil, err)
 	}
-	result, err := c.userRepository.Save(dto)
+	result, err := c.userUsecase.Save(ctx.Request().Context(), dto)
 	if err != nil {
-		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+		return errorResponse(ctx, err)
 	}
+	c.record(ctx, audit.Entry{Action: audit.ActionUserCreated, TargetType: audit.TargetUser, TargetID: result.ID, After: user.NewUserMapper().Map(result)})
 	return response.SingleData(ctx, utils.OK, c.userMapper.Map(result), nil)
 }
 
 func (c *userController) FindAll(ctx echo.Context) error {
-	result, err := c.userRepository.FindAll()
-	if err != nil {
-		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
-	}
-	store, err := paging.NewGORMStore(c.db, &result)
-	if err != nil {
-		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
-	}
-	options := paging.NewOptions()
-	request, _ := http.NewRequest(ctx.Request().Method, ctx.Request().URL.String(), nil)
-	paginator, _ := paging.NewOffsetPaginator(store, request, options)
-	err = paginator.Page()
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.userUsecase.FindAll(ctx.Request().Context(), limit, offset)
 	if err != nil {
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("list users failed")
 		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
 	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
 	return response.Paginate(ctx, utils.OK, paginator, c.userMapper.MapList(result), nil)
 }
 
@@ -75,18 +69,55 @@ func (c *userController) Update(ctx echo.Context) error {
 	if err := ctx.Validate(dto); err != nil {
 		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
 	}
-	result, err := c.userRepository.Update(id, dto)
+	before := c.find(ctx, id)
+	result, err := c.userUsecase.Update(ctx.Request().Context(), middleware.UserID(ctx), id, dto)
 	if err != nil {
-		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+		return errorResponse(ctx, err)
 	}
+	c.record(ctx, audit.Entry{Action: audit.ActionUserUpdated, TargetType: audit.TargetUser, TargetID: id, Before: before, After: user.NewUserMapper().Map(result)})
 	return response.SingleData(ctx, utils.OK, c.userMapper.Map(result), nil)
 }
 
 func (c *userController) Delete(ctx echo.Context) error {
 	id := ctx.Param("id")
-	_, err := c.userRepository.Delete(id)
+	before := c.find(ctx, id)
+	_, err := c.userUsecase.Delete(ctx.Request().Context(), middleware.UserID(ctx), id)
 	if err != nil {
-		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+		return errorResponse(ctx, err)
 	}
+	c.record(ctx, audit.Entry{Action: audit.ActionUserDeleted, TargetType: audit.TargetUser, TargetID: id, Before: before})
 	return response.SingleData(ctx, utils.OK, nil, nil)
 }
+
+// find return the user of id as answered, the state before a change for its audit, nil when not found
+func (c *userController) find(ctx echo.Context, id string) *user.Mapper {
+	existing, err := c.userUsecase.FindById(ctx.Request().Context(), id)
+	if err != nil {
+		return nil
+	}
+	return user.NewUserMapper().Map(*existing)
+}
+
+// record the entry in the audit log, a failure is logged and leaves the response as is
+func (c *userController) record(ctx echo.Context, entry audit.Entry) {
+	if err := c.auditUsecase.Record(ctx.Request().Context(), middleware.AuditSource(ctx), entry); err != nil {
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("audit failed")
+	}
+}
+
+// errorResponse map the errors of the user and session use-cases to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case user.ErrNotFound, session.ErrNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	case user.ErrForbidden:
+		return response.Forbidden(ctx, utils.Forbidden, nil, err.Error())
+	case user.ErrEmailTaken:
+		return response.Conflict(ctx, utils.Conflict, nil, err.Error())
+	case user.ErrInvalidImport, user.ErrUnsupportedFormat, user.ErrImportTooLarge:
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("user use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
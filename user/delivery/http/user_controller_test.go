@@ -1,37 +1,51 @@
 package http
 
 import (
+	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
-	"go-echo-api/infrastructure/database"
+	auditRepository "go-echo-api/audit/repository"
+	auditUsecase "go-echo-api/audit/usecase"
+	"go-echo-api/infrastructure/database/dbtest"
 	"go-echo-api/infrastructure/validator"
+	outboxRepository "go-echo-api/outbox/repository"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	"go-echo-api/user/repository"
 	"go-echo-api/user/usecase"
 	"go-echo-api/utils"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 )
 
-func init() {
-	database.RegisterTxDB("txdb")
+// newRequest build a request of the tenant of the fixture users, as resolved by the tenant middleware
+func newRequest(method string, target string, body io.Reader) *http.Request {
+	return httptest.NewRequest(method, target, body).WithContext(dbtest.Context())
 }
+
+// loginAs put the access token of the user in the context the way IsLoggedIn does
+func loginAs(c echo.Context, id string) {
+	c.Set("user", &jwt.Token{Claims: jwt.MapClaims{"id": id}})
+}
+
 func TestNewUserController(t *testing.T) {
 	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
 
 	// setup expectations
 	s := t.Run("success", func(t *testing.T) {
 		// success scenario create object
-		c := NewUserController(usecase.NewUserService(db), db)
-		assert.NotNil(t, c.userRepository, "Null object created")
+		c := NewUserController(usecase.NewUserService(repository.NewUserRepository(db), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db))), auditUsecase.NewAuditService(auditRepository.NewAuditRepository(db)))
+		assert.NotNil(t, c.userUsecase, "Null object created")
 	})
 
 	f := t.Run("error-failed", func(t *testing.T) {
 		// failed scenario create object
-		c := NewUserController(nil, db)
-		assert.Nil(t, c.userRepository)
+		c := NewUserController(nil, nil)
+		assert.Nil(t, c.userUsecase)
 	})
 
 	assert.Equal(t, true, s, "Success scenario failed run")
@@ -40,16 +54,16 @@ func TestNewUserController(t *testing.T) {
 
 func TestUserController_FindAll(t *testing.T) {
 	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
 
 	e := echo.New()
 	limit := "5"
 	offset := "0"
-	req := httptest.NewRequest(echo.GET, "/api/v1/user?limit="+limit+"&offset="+offset, nil)
+	req := newRequest(echo.GET, "/api/v1/user?limit="+limit+"&offset="+offset, nil)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	controller := NewUserController(usecase.NewUserService(db), db)
+	controller := NewUserController(usecase.NewUserService(repository.NewUserRepository(db), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db))), auditUsecase.NewAuditService(auditRepository.NewAuditRepository(db)))
 
 	// Assertions
 	if assert.NoError(t, controller.FindAll(c)) {
@@ -59,14 +73,14 @@ func TestUserController_FindAll(t *testing.T) {
 
 func TestUserController_FindById(t *testing.T) {
 	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
 
 	// create an instance of our test object
-	controller := NewUserController(usecase.NewUserService(db), db)
+	controller := NewUserController(usecase.NewUserService(repository.NewUserRepository(db), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db))), auditUsecase.NewAuditService(auditRepository.NewAuditRepository(db)))
 	e := echo.New()
 
-	req := httptest.NewRequest(echo.GET, "/", nil)
+	req := newRequest(echo.GET, "/", nil)
 
 	s := t.Run("success", func(t *testing.T) {
 		// success scenario
@@ -91,17 +105,30 @@ func TestUserController_FindById(t *testing.T) {
 			assert.Equal(t, http.StatusNotFound, rec.Code)
 		}
 	})
+
+	i := t.Run("error-internal", func(t *testing.T) {
+		// a request without tenant fails rather than finding no user
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(echo.GET, "/", nil), rec)
+		c.SetPath("api/v1/user/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+		if assert.NoError(t, controller.FindById(c)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		}
+	})
 	assert.Equal(t, true, s, "Success scenario failed run")
 	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, i, "Internal scenario failed run")
 }
 
 func TestUserController_Store(t *testing.T) {
 	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
 
 	// create an instance of our test object
-	controller := NewUserController(usecase.NewUserService(db), db)
+	controller := NewUserController(usecase.NewUserService(repository.NewUserRepository(db), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db))), auditUsecase.NewAuditService(auditRepository.NewAuditRepository(db)))
 	hashPassword, _ := utils.HashPassword("password")
 	userJSON := `{"name":"Jon Snow","email":"jon@labstack.com","password":"` + hashPassword + `"}`
 	userJSONFailed := `{"name":"Jon Snow","email":"","password":"` + hashPassword + `"}`
@@ -110,7 +137,7 @@ func TestUserController_Store(t *testing.T) {
 	s := t.Run("success", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = validator.NewValidator()
-		req := httptest.NewRequest(echo.POST, "/api/v1/user", strings.NewReader(userJSON))
+		req := newRequest(echo.POST, "/api/v1/user", strings.NewReader(userJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -125,7 +152,7 @@ func TestUserController_Store(t *testing.T) {
 	v := t.Run("error-validation", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = validator.NewValidator()
-		req := httptest.NewRequest(echo.POST, "/api/v1/user", strings.NewReader(userJSONFailed))
+		req := newRequest(echo.POST, "/api/v1/user", strings.NewReader(userJSONFailed))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -137,7 +164,7 @@ func TestUserController_Store(t *testing.T) {
 	f := t.Run("error-bad-request", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = validator.NewValidator()
-		req := httptest.NewRequest(echo.POST, "/api/v1/user", strings.NewReader(""))
+		req := newRequest(echo.POST, "/api/v1/user", strings.NewReader(""))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
@@ -149,12 +176,12 @@ func TestUserController_Store(t *testing.T) {
 	i := t.Run("error-failed", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = validator.NewValidator()
-		req := httptest.NewRequest(echo.POST, "/api/v1/user", strings.NewReader(userJSONDuplicateEmail))
+		req := newRequest(echo.POST, "/api/v1/user", strings.NewReader(userJSONDuplicateEmail))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		if assert.NoError(t, controller.Store(c)) {
-			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, http.StatusConflict, rec.Code)
 		}
 	})
 	assert.Equal(t, true, s, "Success scenario failed run")
@@ -165,11 +192,11 @@ func TestUserController_Store(t *testing.T) {
 
 func TestUserController_Update(t *testing.T) {
 	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
 
 	// create an instance of our test object
-	controller := NewUserController(usecase.NewUserService(db), db)
+	controller := NewUserController(usecase.NewUserService(repository.NewUserRepository(db), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db))), auditUsecase.NewAuditService(auditRepository.NewAuditRepository(db)))
 	hashPassword, _ := utils.HashPassword("password")
 	userJSON := `{"name":"Jon Snow","email":"jon@labstack.com","password":"` + hashPassword + `"}`
 	userJSONFailed := `{"name":"Jon Snow","email":"","password":"` + hashPassword + `"}`
@@ -178,12 +205,13 @@ func TestUserController_Update(t *testing.T) {
 	s := t.Run("success", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = validator.NewValidator()
-		req := httptest.NewRequest(echo.PUT, "/api/v1/user/:id", strings.NewReader(userJSON))
+		req := newRequest(echo.PUT, "/api/v1/user/:id", strings.NewReader(userJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
 		c.SetParamValues("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+		loginAs(c, "7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
 		if assert.NoError(t, controller.Update(c)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
 			assert.NotEqual(t, http.StatusInternalServerError, rec.Code)
@@ -195,12 +223,13 @@ func TestUserController_Update(t *testing.T) {
 	v := t.Run("error-validation", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = validator.NewValidator()
-		req := httptest.NewRequest(echo.PUT, "/api/v1/user/:id", strings.NewReader(userJSONFailed))
+		req := newRequest(echo.PUT, "/api/v1/user/:id", strings.NewReader(userJSONFailed))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
 		c.SetParamValues("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+		loginAs(c, "7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
 		if assert.NoError(t, controller.Update(c)) {
 			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
 		}
@@ -209,12 +238,13 @@ func TestUserController_Update(t *testing.T) {
 	f := t.Run("error-bad-request", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = validator.NewValidator()
-		req := httptest.NewRequest(echo.POST, "/api/v1/user/:id", strings.NewReader(""))
+		req := newRequest(echo.POST, "/api/v1/user/:id", strings.NewReader(""))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
 		c.SetParamValues("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+		loginAs(c, "7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
 		if assert.NoError(t, controller.Update(c)) {
 			assert.Equal(t, http.StatusBadRequest, rec.Code)
 		}
@@ -223,38 +253,56 @@ func TestUserController_Update(t *testing.T) {
 	i := t.Run("error-failed", func(t *testing.T) {
 		e := echo.New()
 		e.Validator = validator.NewValidator()
-		req := httptest.NewRequest(echo.POST, "/api/v1/user/:id", strings.NewReader(userJSONDuplicateEmail))
+		req := newRequest(echo.POST, "/api/v1/user/:id", strings.NewReader(userJSONDuplicateEmail))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
 		c.SetParamValues("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+		loginAs(c, "7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
 		if assert.NoError(t, controller.Update(c)) {
-			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, http.StatusConflict, rec.Code)
+		}
+	})
+
+	a := t.Run("error-forbidden", func(t *testing.T) {
+		e := echo.New()
+		e.Validator = validator.NewValidator()
+		req := newRequest(echo.PUT, "/api/v1/user/:id", strings.NewReader(userJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+		loginAs(c, dbtest.UserIpan.ID)
+		if assert.NoError(t, controller.Update(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
 		}
 	})
 	assert.Equal(t, true, s, "Success scenario failed run")
 	assert.Equal(t, true, f, "Failed scenario failed run")
 	assert.Equal(t, true, v, "Validator scenario failed run")
 	assert.Equal(t, true, i, "Failed update scenario failed run")
+	assert.Equal(t, true, a, "Forbidden scenario failed run")
 }
 
 func TestUserController_Delete(t *testing.T) {
 	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
 
 	// create an instance of our test object
-	controller := NewUserController(usecase.NewUserService(db), db)
+	controller := NewUserController(usecase.NewUserService(repository.NewUserRepository(db), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(db))), auditUsecase.NewAuditService(auditRepository.NewAuditRepository(db)))
 
 	s := t.Run("success", func(t *testing.T) {
 		e := echo.New()
-		req := httptest.NewRequest(echo.DELETE, "/api/v1/user/:id", strings.NewReader(""))
+		req := newRequest(echo.DELETE, "/api/v1/user/:id", strings.NewReader(""))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 		c.SetParamNames("id")
 		c.SetParamValues("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+		loginAs(c, "7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
 		if assert.NoError(t, controller.Delete(c)) {
 			assert.Equal(t, http.StatusOK, rec.Code)
 			assert.NotEqual(t, http.StatusInternalServerError, rec.Code)
@@ -263,7 +311,7 @@ func TestUserController_Delete(t *testing.T) {
 
 	f := t.Run("error-failed", func(t *testing.T) {
 		e := echo.New()
-		req := httptest.NewRequest(echo.DELETE, "/api/v1/user/:id", strings.NewReader(""))
+		req := newRequest(echo.DELETE, "/api/v1/user/:id", strings.NewReader(""))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
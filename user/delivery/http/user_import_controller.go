@@ -0,0 +1,189 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/labstack/echo"
+	"go-echo-api/audit"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/job"
+	"go-echo-api/middleware"
+	"go-echo-api/models"
+	"go-echo-api/user"
+	"go-echo-api/utils"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	MIMETextCSV = "text/csv"
+	MIMENDJSON  = "application/x-ndjson"
+)
+
+// formats of the imports by content type
+var importFormats = map[string]string{
+	MIMETextCSV: user.FormatCSV,
+	MIMENDJSON:  user.FormatNDJSON,
+}
+
+// userImportController imports and exports the users in bulk, an import runs in the request or as a job
+type userImportController struct {
+	*userController
+	jobUsecase job.Usecase
+}
+
+func NewUserImportController(users *userController, j job.Usecase) *userImportController {
+	return &userImportController{userController: users, jobUsecase: j}
+}
+
+// Import create the users of a CSV or NDJSON body, told apart by its content type.
+// With dry_run=true the rows are only validated, the report tells what an import would do.
+// With async=true the import is enqueued as a job, the report is its result.
+func (c *userImportController) Import(ctx echo.Context) error {
+	dryRun, err := boolParam(ctx, "dry_run")
+	if err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, "dry_run must be a boolean")
+	}
+	async, err := boolParam(ctx, "async")
+	if err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, "async must be a boolean")
+	}
+	mediaType, _, _ := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	format, ok := importFormats[mediaType]
+	if !ok {
+		return errorResponse(ctx, user.ErrUnsupportedFormat)
+	}
+	if async {
+		return c.enqueueImport(ctx, format, dryRun)
+	}
+	rows, err := user.NewRowReader(format, ctx.Request().Body)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	report, err := c.userUsecase.Import(ctx.Request().Context(), rows, dryRun)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	result := user.NewImportMapper(report)
+	if !dryRun && report.Imported > 0 {
+		c.record(ctx, audit.Entry{Action: audit.ActionUsersImported, TargetType: audit.TargetUser, After: result})
+	}
+	return response.SingleData(ctx, utils.OK, result, nil)
+}
+
+// enqueueImport keep the file in a job importing it, the header of a CSV file is checked before
+func (c *userImportController) enqueueImport(ctx echo.Context, format string, dryRun bool) error {
+	data, err := ioutil.ReadAll(io.LimitReader(ctx.Request().Body, user.ImportJobMaxSize+1))
+	if err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if len(data) > user.ImportJobMaxSize {
+		return errorResponse(ctx, user.ErrImportTooLarge)
+	}
+	if _, err := user.NewRowReader(format, bytes.NewReader(data)); err != nil {
+		return errorResponse(ctx, err)
+	}
+	payload := user.ImportJobPayload{Format: format, DryRun: dryRun, Data: string(data), Source: middleware.AuditSource(ctx)}
+	result, err := c.jobUsecase.Enqueue(ctx.Request().Context(), middleware.UserID(ctx), user.JobImport, payload)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.Accepted(ctx, utils.Accepted, job.NewJobMapper().Map(result), nil)
+}
+
+// boolParam return the boolean query param, false when absent
+func boolParam(ctx echo.Context, name string) (bool, error) {
+	value := ctx.QueryParam(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// Export stream the users of the tenant as CSV or as a JSON array, page by page
+func (c *userImportController) Export(ctx echo.Context) error {
+	format := ctx.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return errorResponse(ctx, user.ErrUnsupportedFormat)
+	}
+	middleware.Stream(ctx)
+	res := ctx.Response()
+	var writer *csv.Writer
+	encoder := json.NewEncoder(res)
+	written := 0
+	// the response begins with the first page, a failure before it is answered as usual
+	begin := func() error {
+		if format == "csv" {
+			res.Header().Set(echo.HeaderContentType, MIMETextCSV+"; charset=utf-8")
+			res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="users.csv"`)
+			res.WriteHeader(http.StatusOK)
+			writer = csv.NewWriter(res)
+			return writer.Write([]string{"id", "name", "email", "created_at"})
+		}
+		res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="users.json"`)
+		res.WriteHeader(http.StatusOK)
+		_, err := res.Write([]byte("["))
+		return err
+	}
+	err := c.userUsecase.Export(ctx.Request().Context(), func(page []models.User) error {
+		if !res.Committed {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		for _, model := range page {
+			if format == "csv" {
+				if err := writer.Write([]string{model.ID, model.Name, model.Email, model.CreatedAt.Format(time.RFC3339)}); err != nil {
+					return err
+				}
+				continue
+			}
+			if written > 0 {
+				if _, err := res.Write([]byte(",")); err != nil {
+					return err
+				}
+			}
+			if err := encoder.Encode(user.NewExportMapper(model)); err != nil {
+				return err
+			}
+			written++
+		}
+		if writer != nil {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := res.Writer.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil && !res.Committed {
+		err = begin()
+	}
+	if err != nil && !res.Committed {
+		return errorResponse(ctx, err)
+	}
+	if err != nil {
+		// the client sees a truncated export, the status is already sent
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("export users failed")
+		return nil
+	}
+	if format == "csv" {
+		writer.Flush()
+		return writer.Error()
+	}
+	_, err = res.Write([]byte("]\n"))
+	return err
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/apikey"
+	apiKeyRepository "go-echo-api/apikey/repository"
+	apiKeyUsecase "go-echo-api/apikey/usecase"
+	auditRepository "go-echo-api/audit/repository"
+	auditUsecase "go-echo-api/audit/usecase"
+	jobRepository "go-echo-api/job/repository"
+	jobUsecase "go-echo-api/job/usecase"
+	"go-echo-api/middleware"
+	outboxRepository "go-echo-api/outbox/repository"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	"go-echo-api/user/repository"
+	"go-echo-api/user/usecase"
+)
+
+// Module wire the user controller to the database and register its routes under /user,
+// every route requires an access token or an API key and only sees the users of the tenant of the request.
+// The users are imported in bulk from CSV or NDJSON, in the request or as a job, and exported as a stream of CSV or JSON.
+// The sessions of the users are listed and revoked with the admin key of the deployment.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/user"
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	users := repository.NewUserRepository(m.db)
+	service := usecase.NewUserService(users, outboxUsecase.NewOutboxService(outboxRepository.NewOutboxRepository(m.db)))
+	controller := NewUserController(service, auditUsecase.NewAuditService(auditRepository.NewAuditRepository(m.db)))
+	transfer := NewUserImportController(controller, jobUsecase.NewJobService(jobRepository.NewJobRepository(m.db)))
+	sessionService := sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL)
+	sessions := NewUserSessionController(service, sessionService)
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	authenticate := middleware.Authenticate(apiKeyUsecase.NewAPIKeyService(apiKeyRepository.NewAPIKeyRepository(m.db), users), sessionService)
+	read, write := middleware.RequireScope(apikey.ScopeUsersRead), middleware.RequireScope(apikey.ScopeUsersWrite)
+	g.GET("", controller.FindAll, tenantScope, authenticate, read)
+	g.GET("/export", transfer.Export, tenantScope, authenticate, read)
+	g.POST("/import", transfer.Import, tenantScope, authenticate, write)
+	g.GET("/:id", controller.FindById, tenantScope, authenticate, read)
+	g.POST("", controller.Store, tenantScope, authenticate, write)
+	g.PUT("/:id", controller.Update, tenantScope, authenticate, write)
+	g.DELETE("/:id", controller.Delete, tenantScope, authenticate, write)
+	g.GET("/:id/sessions", sessions.FindAll, tenantScope, middleware.IsAdmin)
+	g.DELETE("/:id/sessions/:session", sessions.Delete, tenantScope, middleware.IsAdmin)
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/job"
+	"go-echo-api/middleware"
+	"go-echo-api/session"
+	"go-echo-api/user"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	id := openapi.PathParam("id", "ID of the user")
+	// PageParams falls back to the defaults on invalid values rather than rejecting them
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+
+	g.Add(echo.GET, "", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "List the users",
+		OperationID: "listUsers",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of users skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of users", user.Mapper{}),
+			"401": g.Error("Missing or invalid access token or API key"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.POST, "/import", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "Import users from CSV or NDJSON, by transactions of " + fmt.Sprint(user.ImportBatchSize) + " users",
+		OperationID: "importUsers",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("dry_run", "Only validate the rows and report, default false", &openapi.Schema{Type: "boolean"}),
+			openapi.QueryParam("async", "Run the import as a job whose result is the report, default false", &openapi.Schema{Type: "boolean"}),
+		},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]openapi.MediaType{
+				MIMETextCSV: {Schema: &openapi.Schema{Type: "string", Description: "Header naming the columns name, email and optionally password, then a user per row"}},
+				MIMENDJSON:  {Schema: &openapi.Schema{Type: "string", Description: "A JSON object per line with name, email and optionally password"}},
+			},
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Report of the import, the rows failed are listed with their errors", user.ImportMapper{}),
+			"202": g.Single("Job of the import, polled on /jobs/{id}", job.Mapper{}),
+			"400": g.Error("Unsupported content type, unreadable CSV header or file too large for a job"),
+			"401": g.Error("Missing or invalid access token or API key"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.GET, "/export", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "Export the users, streamed in the order of their IDs",
+		OperationID: "exportUsers",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("format", "Format of the export, default csv", &openapi.Schema{Type: "string", Enum: []interface{}{"csv", "json"}}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": {
+				Description: "Users of the tenant",
+				Content: map[string]openapi.MediaType{
+					MIMETextCSV:  {Schema: &openapi.Schema{Type: "string", Description: "Columns id, name, email and created_at"}},
+					openapi.JSON: {Schema: &openapi.Schema{Type: "array", Items: g.Schema(user.ExportMapper{})}},
+				},
+			},
+			"400": g.Error("Unsupported format"),
+			"401": g.Error("Missing or invalid access token or API key"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.GET, "/:id", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "Find a user by ID",
+		OperationID: "findUser",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("User", user.Mapper{}),
+			"401": g.Error("Missing or invalid access token or API key"),
+			"404": g.Error("User not found"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.POST, "", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "Create a user",
+		OperationID: "createUser",
+		RequestBody: g.Body(user.Dto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Created user", user.Mapper{}),
+			"401": g.Error("Missing or invalid access token or API key"),
+			"409": g.Error("Email already taken"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.PUT, "/:id", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "Update the logged in user",
+		OperationID: "updateUser",
+		Parameters:  []openapi.Parameter{id},
+		RequestBody: g.Body(user.Dto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Updated user", user.Mapper{}),
+			"401": g.Error("Missing or invalid access token or API key"),
+			"403": g.Error("The user is not the logged in user"),
+			"404": g.Error("User not found"),
+			"409": g.Error("Email already taken"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	g.Add(echo.DELETE, "/:id", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "Delete the logged in user",
+		OperationID: "deleteUser",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("User deleted", nil),
+			"401": g.Error("Missing or invalid access token or API key"),
+			"403": g.Error("The user is not the logged in user"),
+			"404": g.Error("User not found"),
+		},
+		Security: openapi.BearerOrAPIKey,
+	})
+	adminKey := openapi.Parameter{Name: middleware.HeaderAdminKey, In: "header", Description: "Admin key of the deployment", Required: true, Schema: &openapi.Schema{Type: "string"}}
+	g.Add(echo.GET, "/:id/sessions", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "List the active sessions of a user, the most recently used first",
+		OperationID: "listUserSessions",
+		Parameters: []openapi.Parameter{
+			id, adminKey,
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of sessions skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of sessions", session.Mapper{}),
+			"401": g.Error("Missing or wrong admin key"),
+			"403": g.Error("Admin endpoints disabled"),
+			"404": g.Error("User not found"),
+		},
+	})
+	g.Add(echo.DELETE, "/:id/sessions/:session", openapi.Operation{
+		Tags:        []string{"user"},
+		Summary:     "Revoke a session of a user, its refresh token is refused from then",
+		OperationID: "revokeUserSession",
+		Parameters:  []openapi.Parameter{id, openapi.PathParam("session", "ID of the session"), adminKey},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Session revoked", nil),
+			"401": g.Error("Missing or wrong admin key"),
+			"403": g.Error("Admin endpoints disabled"),
+			"404": g.Error("User or session not found"),
+		},
+	})
+}
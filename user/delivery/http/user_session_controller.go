@@ -0,0 +1,44 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/session"
+	"go-echo-api/user"
+	"go-echo-api/utils"
+)
+
+// userSessionController let the administrators of the deployment list and revoke the sessions of any user
+type userSessionController struct {
+	userUsecase    user.Usecase
+	sessionUsecase session.Usecase
+}
+
+func NewUserSessionController(u user.Usecase, s session.Usecase) *userSessionController {
+	return &userSessionController{userUsecase: u, sessionUsecase: s}
+}
+
+func (c *userSessionController) FindAll(ctx echo.Context) error {
+	id := ctx.Param("id")
+	if _, err := c.userUsecase.FindById(ctx.Request().Context(), id); err != nil {
+		return errorResponse(ctx, err)
+	}
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.sessionUsecase.FindAll(ctx.Request().Context(), id, limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, session.NewSessionMapper("").MapList(result), nil)
+}
+
+func (c *userSessionController) Delete(ctx echo.Context) error {
+	id := ctx.Param("id")
+	if _, err := c.userUsecase.FindById(ctx.Request().Context(), id); err != nil {
+		return errorResponse(ctx, err)
+	}
+	if err := c.sessionUsecase.Revoke(ctx.Request().Context(), id, ctx.Param("session")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
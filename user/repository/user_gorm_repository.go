@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/user"
+)
+
+type userGormRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) user.Repository {
+	return &userGormRepository{db: db}
+}
+
+func (r *userGormRepository) FindAll(ctx context.Context, limit int64, offset int64) ([]models.User, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	var model []models.User
+	var total int64
+	if err := db.Model(&models.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = db.Order("created_at").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *userGormRepository) FindById(ctx context.Context, id string) (*models.User, error) {
+	return r.first(ctx, "id = ?", id)
+}
+
+func (r *userGormRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.first(ctx, "email = ?", email)
+}
+
+func (r *userGormRepository) first(ctx context.Context, query string, value string) (*models.User, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.User
+	err = db.Where(query, value).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, user.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *userGormRepository) Store(ctx context.Context, model *models.User) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *userGormRepository) Update(ctx context.Context, model *models.User) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	if model.TenantID != tenantID {
+		return user.ErrNotFound
+	}
+	return db.Save(model).Error
+}
+
+// Delete remove the user, the other modules delete their records of the user on its user.deleted event
+func (r *userGormRepository) Delete(ctx context.Context, id string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Where("id = ?", id).Delete(&models.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return user.ErrNotFound
+	}
+	return nil
+}
+
+// Each page the users by their IDs rather than by an offset, a page is read in the time of a query
+// whatever the size of the table
+func (r *userGormRepository) Each(ctx context.Context, size int64, fn func([]models.User) error) error {
+	last := ""
+	for {
+		db, _, err := database.TenantScope(ctx, r.db)
+		if err != nil {
+			return err
+		}
+		var page []models.User
+		if err := db.Where("id > ?", last).Order("id").Limit(size).Find(&page).Error; err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if int64(len(page)) < size {
+			return nil
+		}
+		last = page[len(page)-1].ID
+	}
+}
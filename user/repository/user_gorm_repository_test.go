@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/user"
+	"testing"
+)
+
+func TestUserGormRepository_FindAll(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewUserRepository(db)
+	list, total, err := r.FindAll(dbtest.Context(), 1, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestUserGormRepository_FindById(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewUserRepository(db)
+	s := t.Run("success", func(t *testing.T) {
+		data, err := r.FindById(dbtest.Context(), dbtest.UserUje.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserUje.Email, data.Email)
+	})
+	f := t.Run("error-not-found", func(t *testing.T) {
+		data, err := r.FindById(dbtest.Context(), "")
+		assert.Equal(t, user.ErrNotFound, err)
+		assert.Nil(t, data)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestUserGormRepository_StoreUpdateDelete(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewUserRepository(db)
+	model := models.User{Name: "Ahmad", Email: "ahmad@email.com", Password: "hash"}
+	assert.NoError(t, r.Store(dbtest.Context(), &model))
+	assert.NotEmpty(t, model.ID)
+
+	model.Name = "Ahmad Updated"
+	assert.NoError(t, r.Update(dbtest.Context(), &model))
+	found, err := r.FindByEmail(dbtest.Context(), "ahmad@email.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ahmad Updated", found.Name)
+
+	assert.NoError(t, r.Delete(dbtest.Context(), model.ID))
+	assert.Equal(t, user.ErrNotFound, r.Delete(dbtest.Context(), model.ID))
+}
+
+func TestUserGormRepository_DeleteKeepsModules(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	// the modules delete their records of the user on its user.deleted event
+	r := NewUserRepository(db)
+	assert.NoError(t, r.Delete(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.Membership{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
+
+func TestUserGormRepository_Each(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewUserRepository(db)
+	s := t.Run("success", func(t *testing.T) {
+		var pages [][]models.User
+		err := r.Each(dbtest.Context(), 1, func(page []models.User) error {
+			pages = append(pages, page)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, pages, 2)
+		assert.True(t, pages[0][0].ID < pages[1][0].ID)
+
+		count := 0
+		assert.NoError(t, r.Each(dbtest.Context(), 10, func(page []models.User) error {
+			count += len(page)
+			return nil
+		}))
+		assert.Equal(t, 2, count)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		calls := 0
+		err := r.Each(dbtest.Context(), 1, func(page []models.User) error {
+			calls++
+			return user.ErrForbidden
+		})
+		assert.Equal(t, user.ErrForbidden, err)
+		assert.Equal(t, 1, calls)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
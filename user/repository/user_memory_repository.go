@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"go-echo-api/user"
+	"sort"
+	"sync"
+	"time"
+)
+
+// userMemoryRepository keeps the users in memory, it backs the use-case unit tests
+type userMemoryRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+}
+
+func NewUserMemoryRepository(users ...models.User) user.Repository {
+	r := &userMemoryRepository{users: make(map[string]models.User)}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+// tenantUsers return the users of the tenant of the context, callers hold the lock
+func (r *userMemoryRepository) tenantUsers(ctx context.Context) ([]models.User, string, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	var users []models.User
+	for _, u := range r.users {
+		if u.TenantID == tenantID {
+			users = append(users, u)
+		}
+	}
+	return users, tenantID, nil
+}
+
+func (r *userMemoryRepository) FindAll(ctx context.Context, limit int64, offset int64) ([]models.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all, _, err := r.tenantUsers(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	total := int64(len(all))
+	if offset >= total {
+		return []models.User{}, total, nil
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *userMemoryRepository) FindById(ctx context.Context, id string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users, _, err := r.tenantUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if u.ID == id {
+			return &u, nil
+		}
+	}
+	return nil, user.ErrNotFound
+}
+
+func (r *userMemoryRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users, _, err := r.tenantUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, user.ErrNotFound
+}
+
+func (r *userMemoryRepository) Store(ctx context.Context, model *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users, tenantID, err := r.tenantUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.Email == model.Email {
+			return user.ErrEmailTaken
+		}
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.users[model.ID] = *model
+	return nil
+}
+
+func (r *userMemoryRepository) Update(ctx context.Context, model *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users, tenantID, err := r.tenantUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.users[model.ID]; !ok || existing.TenantID != tenantID {
+		return user.ErrNotFound
+	}
+	for _, u := range users {
+		if u.Email == model.Email && u.ID != model.ID {
+			return user.ErrEmailTaken
+		}
+	}
+	model.UpdatedAt = time.Now()
+	r.users[model.ID] = *model
+	return nil
+}
+
+func (r *userMemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.users[id]; !ok || existing.TenantID != tenantID {
+		return user.ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func (r *userMemoryRepository) Each(ctx context.Context, size int64, fn func([]models.User) error) error {
+	r.mu.RLock()
+	all, _, err := r.tenantUsers(ctx)
+	r.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	for start := int64(0); start < int64(len(all)); start += size {
+		end := start + size
+		if end > int64(len(all)) {
+			end = int64(len(all))
+		}
+		if err := fn(all[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-echo-api/audit"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/job"
+	"go-echo-api/user"
+	"io"
+	"strings"
+)
+
+// NewImportJob return the handler of the jobs importing users, its progress is the share of the file read.
+// A retry imports the rows again, those imported by the failed run are reported as taken.
+func NewImportJob(u user.Usecase, a audit.Usecase) job.Handler {
+	return func(ctx context.Context, payload json.RawMessage, progress job.Progress) (interface{}, error) {
+		var input user.ImportJobPayload
+		if err := json.Unmarshal(payload, &input); err != nil {
+			return nil, job.Permanent(err)
+		}
+		reader := &progressReader{reader: strings.NewReader(input.Data), size: len(input.Data), progress: progress}
+		rows, err := user.NewRowReader(input.Format, reader)
+		if err != nil {
+			return nil, job.Permanent(err)
+		}
+		report, err := u.Import(ctx, rows, input.DryRun)
+		if err != nil {
+			return nil, err
+		}
+		result := user.NewImportMapper(report)
+		if !input.DryRun && report.Imported > 0 {
+			entry := audit.Entry{Action: audit.ActionUsersImported, TargetType: audit.TargetUser, After: result}
+			if err := a.Record(ctx, input.Source, entry); err != nil {
+				logger.FromContext(ctx).WithError(err).Error("audit failed")
+			}
+		}
+		return result, nil
+	}
+}
+
+// progressReader report the share of the file read, below 100 until the job ends
+type progressReader struct {
+	reader   io.Reader
+	size     int
+	read     int
+	percent  int
+	progress job.Progress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += n
+	if r.size > 0 {
+		if percent := r.read * 99 / r.size; percent > r.percent {
+			r.percent = percent
+			r.progress(percent)
+		}
+	}
+	return n, err
+}
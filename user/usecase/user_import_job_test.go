@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/audit"
+	auditRepository "go-echo-api/audit/repository"
+	auditUsecase "go-echo-api/audit/usecase"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/job"
+	outboxRepository "go-echo-api/outbox/repository"
+	outboxUsecase "go-echo-api/outbox/usecase"
+	"go-echo-api/user"
+	"go-echo-api/user/repository"
+	"testing"
+)
+
+func TestImportJob(t *testing.T) {
+	users := repository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan)
+	u := NewUserService(users, outboxUsecase.NewOutboxService(outboxRepository.NewOutboxMemoryRepository()))
+	a := auditUsecase.NewAuditService(auditRepository.NewAuditMemoryRepository())
+	handler := NewImportJob(u, a)
+	source := audit.Source{ActorID: dbtest.UserUje.ID, IP: "127.0.0.1"}
+
+	s := t.Run("success", func(t *testing.T) {
+		payload, _ := json.Marshal(user.ImportJobPayload{
+			Format: user.FormatNDJSON,
+			Data:   "{\"name\":\"Ahmad\",\"email\":\"ahmad@email.com\"}\n{\"name\":\"Ipan\",\"email\":\"ipan@email.com\"}\n",
+			Source: source,
+		})
+		var progress []int
+		result, err := handler(dbtest.Context(), payload, func(percent int) { progress = append(progress, percent) })
+		assert.NoError(t, err)
+		report := result.(user.ImportMapper)
+		assert.Equal(t, 2, report.Total)
+		assert.Equal(t, 1, report.Imported)
+		assert.Equal(t, 1, report.Failed)
+		if assert.NotEmpty(t, progress) {
+			assert.Equal(t, 99, progress[len(progress)-1])
+		}
+		_, err = users.FindByEmail(dbtest.Context(), "ahmad@email.com")
+		assert.NoError(t, err)
+		// the import is audited as acted by the request enqueuing it
+		events, total, err := a.FindAll(dbtest.Context(), audit.Filter{Action: audit.ActionUsersImported}, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, source.ActorID, events[0].ActorID)
+		assert.Equal(t, source.IP, events[0].IP)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := handler(dbtest.Context(), json.RawMessage(`"not a payload"`), func(int) {})
+		assert.Equal(t, true, job.IsPermanent(err))
+		payload, _ := json.Marshal(user.ImportJobPayload{Format: "xml", Data: "<users/>"})
+		_, err = handler(dbtest.Context(), payload, func(int) {})
+		assert.Equal(t, true, job.IsPermanent(err))
+		payload, _ = json.Marshal(user.ImportJobPayload{Format: user.FormatCSV, Data: "id\n1\n"})
+		_, err = handler(dbtest.Context(), payload, func(int) {})
+		assert.Equal(t, true, job.IsPermanent(err))
+	})
+
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
@@ -1,76 +1,262 @@
 package usecase
 
 import (
-	"github.com/jinzhu/gorm"
+	"context"
+	"fmt"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/tracing"
+	"go-echo-api/infrastructure/validator"
 	"go-echo-api/models"
+	"go-echo-api/outbox"
 	"go-echo-api/user"
 	"go-echo-api/utils"
+	validation "gopkg.in/go-playground/validator.v9"
+	"io"
+	"strings"
 )
 
 type UserService struct {
-	*gorm.DB
+	userRepository user.Repository
+	outboxUsecase  outbox.Usecase
+	validator      *validator.Validator
 }
 
-func NewUserService(db *gorm.DB) user.Repository {
-	return UserService{db}
+// NewUserService return the service of the users, the changes are written with their domain events to the outbox o
+func NewUserService(r user.Repository, o outbox.Usecase) user.Usecase {
+	return UserService{userRepository: r, outboxUsecase: o, validator: validator.NewValidator()}
 }
 
-func (u UserService) FindAll() ([]models.User, error) {
-	var model []models.User
-	err := u.DB.Find(&model).Error
-	return model, err
+func (u UserService) FindAll(ctx context.Context, limit int64, offset int64) ([]models.User, int64, error) {
+	return u.userRepository.FindAll(ctx, limit, offset)
 }
 
-func (u UserService) FindById(id string) (*models.User, error) {
-	var model models.User
-	model.ID = id
-	err := u.DB.First(&model).Error
-	if err != nil {
-		return nil, err
-	}
-	return &model, err
+func (u UserService) FindById(ctx context.Context, id string) (*models.User, error) {
+	return u.userRepository.FindById(ctx, id)
 }
 
-func (u UserService) Save(dto user.Dto) (models.User, error) {
+func (u UserService) Save(ctx context.Context, dto user.Dto) (models.User, error) {
 	var model models.User
+	if err := u.ensureEmailAvailable(ctx, dto.Email, ""); err != nil {
+		return model, err
+	}
 	model.Name = dto.Name
 	model.Email = dto.Email
+	_, span := tracing.Start(ctx, "HashPassword")
 	hashPassword, err := utils.HashPassword(dto.Password)
+	tracing.End(span, err)
 	if err != nil {
 		return model, err
 	}
 	model.Password = hashPassword
-	err = u.DB.Save(&model).Error
+	err = u.outboxUsecase.Transaction(ctx, func(ctx context.Context) error {
+		if err := u.userRepository.Store(ctx, &model); err != nil {
+			return err
+		}
+		return u.outboxUsecase.Publish(ctx, outbox.EventUserRegistered, model.ID, outbox.NewUserData(model))
+	})
 	return model, err
 }
 
-func (u UserService) Update(id string, updateDto user.Dto) (models.User, error) {
-	var model models.User
-	model.ID = id
+// Update change the name, email and password of the user, only the user itself can do it.
+// It emits user.updated when the name or email changed and user.password_changed when the password did.
+func (u UserService) Update(ctx context.Context, actorID string, id string, updateDto user.Dto) (models.User, error) {
+	if actorID != id {
+		return models.User{}, user.ErrForbidden
+	}
+	existing, err := u.userRepository.FindById(ctx, id)
+	if err != nil {
+		return models.User{}, err
+	}
+	model := *existing
+	if err := u.ensureEmailAvailable(ctx, updateDto.Email, id); err != nil {
+		return model, err
+	}
 
+	_, span := tracing.Start(ctx, "HashPassword")
 	hashPassword, err := utils.HashPassword(updateDto.Password)
+	tracing.End(span, err)
 	if err != nil {
 		return model, err
 	}
-	err = u.DB.Model(&model).UpdateColumns(models.User{
-		Name:     updateDto.Name,
-		Email:    updateDto.Email,
-		Password: hashPassword,
-	}).Error
-
+	model.Name = updateDto.Name
+	model.Email = updateDto.Email
+	model.Password = hashPassword
+	_, span = tracing.Start(ctx, "CheckPasswordHash")
+	passwordChanged := !utils.CheckPasswordHash(updateDto.Password, existing.Password)
+	span.End()
+	err = u.outboxUsecase.Transaction(ctx, func(ctx context.Context) error {
+		if err := u.userRepository.Update(ctx, &model); err != nil {
+			return err
+		}
+		if model.Name != existing.Name || model.Email != existing.Email {
+			if err := u.outboxUsecase.Publish(ctx, outbox.EventUserUpdated, model.ID, outbox.NewUserData(model)); err != nil {
+				return err
+			}
+		}
+		if passwordChanged {
+			return u.outboxUsecase.Publish(ctx, outbox.EventUserPasswordChanged, model.ID, outbox.NewUserData(model))
+		}
+		return nil
+	})
 	return model, err
 }
 
-func (u UserService) Delete(id string) (bool, error) {
-	var model models.User
-	model.ID = id
-	isExisting, err := u.FindById(id)
-	if isExisting == nil {
+// Delete remove the user, only the user itself can do it
+func (u UserService) Delete(ctx context.Context, actorID string, id string) (bool, error) {
+	if actorID != id {
+		return false, user.ErrForbidden
+	}
+	existing, err := u.userRepository.FindById(ctx, id)
+	if err != nil {
 		return false, err
 	}
-	err = u.DB.Delete(&model).Error
+	err = u.outboxUsecase.Transaction(ctx, func(ctx context.Context) error {
+		if err := u.userRepository.Delete(ctx, id); err != nil {
+			return err
+		}
+		return u.outboxUsecase.Publish(ctx, outbox.EventUserDeleted, id, outbox.NewUserData(*existing))
+	})
 	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
+
+// ensureEmailAvailable fail with ErrEmailTaken when the email belongs to another user than exceptID
+func (u UserService) ensureEmailAvailable(ctx context.Context, email string, exceptID string) error {
+	existing, err := u.userRepository.FindByEmail(ctx, email)
+	if err == user.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != exceptID {
+		return user.ErrEmailTaken
+	}
+	return nil
+}
+
+// importedRow is a row of an import validated, waiting for the transaction of its batch
+type importedRow struct {
+	row   int
+	model models.User
+}
+
+// Import create the users of the rows by batches of user.ImportBatchSize, each batch in a transaction with the
+// user.registered events of its users. A row invalid, taken by another row or by a user of the tenant is
+// reported and skipped, a batch failing is reported for each of its rows. A dry run only validates the rows.
+func (u UserService) Import(ctx context.Context, rows user.RowReader, dryRun bool) (user.ImportReport, error) {
+	report := user.ImportReport{DryRun: dryRun}
+	seen := make(map[string]int)
+	var batch []importedRow
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Total++
+		errors, err := u.validateImport(ctx, row, seen)
+		if err != nil {
+			return report, err
+		}
+		if len(errors) > 0 {
+			report.Fail(errors...)
+			continue
+		}
+		seen[strings.ToLower(row.Dto.Email)] = row.Row
+		if dryRun {
+			report.Imported++
+			continue
+		}
+		model := models.User{Name: row.Dto.Name, Email: row.Dto.Email}
+		if row.Dto.Password != "" {
+			_, span := tracing.Start(ctx, "HashPassword")
+			model.Password, err = utils.HashPassword(row.Dto.Password)
+			tracing.End(span, err)
+			if err != nil {
+				return report, err
+			}
+		}
+		if batch = append(batch, importedRow{row: row.Row, model: model}); len(batch) == user.ImportBatchSize {
+			u.importBatch(ctx, batch, &report)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		u.importBatch(ctx, batch, &report)
+	}
+	return report, nil
+}
+
+// validateImport return the errors of the row, none when it can be imported
+func (u UserService) validateImport(ctx context.Context, row user.ImportRow, seen map[string]int) ([]user.ImportError, error) {
+	if row.Err != nil {
+		return []user.ImportError{{Row: row.Row, Message: row.Err.Error()}}, nil
+	}
+	if err := u.validator.Validate(row.Dto); err != nil {
+		fields, ok := err.(validation.ValidationErrors)
+		if !ok {
+			return nil, err
+		}
+		errors := make([]user.ImportError, len(fields))
+		for i, field := range fields {
+			errors[i] = user.ImportError{Row: row.Row, Field: strings.ToLower(field.Field()), Message: ruleMessage(field)}
+		}
+		return errors, nil
+	}
+	if first, ok := seen[strings.ToLower(row.Dto.Email)]; ok {
+		return []user.ImportError{{Row: row.Row, Field: "email", Message: fmt.Sprintf("is already in row %d", first)}}, nil
+	}
+	err := u.ensureEmailAvailable(ctx, row.Dto.Email, "")
+	if err == user.ErrEmailTaken {
+		return []user.ImportError{{Row: row.Row, Field: "email", Message: err.Error()}}, nil
+	}
+	return nil, err
+}
+
+// importBatch store the users of the batch in a transaction, all of them or none
+func (u UserService) importBatch(ctx context.Context, batch []importedRow, report *user.ImportReport) {
+	err := u.outboxUsecase.Transaction(ctx, func(ctx context.Context) error {
+		for i := range batch {
+			model := &batch[i].model
+			if err := u.userRepository.Store(ctx, model); err != nil {
+				return err
+			}
+			if err := u.outboxUsecase.Publish(ctx, outbox.EventUserRegistered, model.ID, outbox.NewUserData(*model)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		report.Imported += len(batch)
+		return
+	}
+	logger.FromContext(ctx).WithError(err).WithField("rows", len(batch)).Error("import batch failed")
+	for _, row := range batch {
+		report.Fail(user.ImportError{Row: row.row, Message: "not imported, its batch failed: " + err.Error()})
+	}
+}
+
+// ruleMessage describe the rule of the validation failed
+func ruleMessage(field validation.FieldError) string {
+	switch field.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email"
+	case "max":
+		return "must be at most " + field.Param() + " characters"
+	default:
+		return "must satisfy " + field.Tag()
+	}
+}
+
+// Export call fn with the users of the tenant by pages, the users are never all in memory
+func (u UserService) Export(ctx context.Context, fn func([]models.User) error) error {
+	return u.userRepository.Each(ctx, user.ExportPageSize, fn)
+}
@@ -1,56 +1,70 @@
 package usecase
 
 import (
+	"context"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
 	"go-echo-api/models"
-	"go-echo-api/infrastructure/database"
+	"go-echo-api/outbox"
+	outboxRepository "go-echo-api/outbox/repository"
+	outboxUsecase "go-echo-api/outbox/usecase"
 	"go-echo-api/user"
+	"go-echo-api/user/repository"
 	"go-echo-api/utils"
+	"strings"
 	"testing"
+	"time"
 )
 
-func init() {
-	database.RegisterTxDB("txdb")
+// newUserService return a service backed by an in-memory repository holding the fixture users
+func newUserService() user.Usecase {
+	return NewUserService(repository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan), outboxUsecase.NewOutboxService(outboxRepository.NewOutboxMemoryRepository()))
 }
 
-func TestUserServiceFindAll(t *testing.T) {
-	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
+// eventTypes return the types of the events written to the outbox, the oldest first
+func eventTypes(t *testing.T, events outbox.Repository) []string {
+	due, err := events.FindDue(context.Background(), time.Now().Add(time.Minute), -1)
+	assert.NoError(t, err)
+	var types []string
+	for _, event := range due {
+		types = append(types, event.Type)
+	}
+	return types
+}
 
+func TestUserServiceFindAll(t *testing.T) {
 	// scenario find all success
-	u := NewUserService(db)
-	list, err := u.FindAll()
+	u := newUserService()
+	list, total, err := u.FindAll(dbtest.Context(), 1, 0)
 	assert.NotEmpty(t, list, "No Empty")
+	assert.Len(t, list, 1)
+	assert.Equal(t, int64(2), total)
 	assert.NoError(t, err, "Error")
 
 }
 
 func TestUserService_FindById(t *testing.T) {
-	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
-
 	// create an instance of our test object
 	mockUser := models.User{
 		ID:    "7dd77cc4-f786-4be0-b5a5-0c203b9c62c5",
 		Name:  "Uje",
 		Email: "uje@email.com",
 	}
-	u := NewUserService(db)
+	u := newUserService()
 
 	// setup expectations
 	s := t.Run("success", func(t *testing.T) {
 		// success scenario find by id
-		data, err := u.FindById(mockUser.ID)
+		data, err := u.FindById(dbtest.Context(), mockUser.ID)
 		assert.NoError(t, err)
 		assert.NotNil(t, data)
 	})
 
 	f := t.Run("error-failed", func(t *testing.T) {
 		// failed scenario find by id
-		data, err := u.FindById("test")
-		assert.Error(t, err)
+		data, err := u.FindById(dbtest.Context(), "test")
+		assert.Equal(t, user.ErrNotFound, err)
 		assert.Nil(t, data)
 	})
 	assert.Equal(t, true, s, "Success scenario failed run")
@@ -58,96 +72,227 @@ func TestUserService_FindById(t *testing.T) {
 }
 
 func TestUserService_Save(t *testing.T) {
-	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
-
 	// create an instance of our test object
-	hashPassword, _ := utils.HashPassword("password")
 	mockUser := user.Dto{
 		Name:     "Ahmad",
 		Email:    "ahmad@email.com",
-		Password: hashPassword,
+		Password: "password",
 	}
 	mockUserFailed := user.Dto{
 		Name:     "Ahmad",
 		Email:    "ipan@email.com",
-		Password: hashPassword,
+		Password: "password",
 	}
-	u := NewUserService(db)
+	u := newUserService()
 
 	// setup expectations
 	s := t.Run("success", func(t *testing.T) {
-		// success scenario save
-		data, err := u.Save(mockUser)
+		// success scenario save, the password is stored hashed
+		data, err := u.Save(dbtest.Context(), mockUser)
 		assert.NoError(t, err)
-		assert.NotEmpty(t, data)
+		assert.NotEmpty(t, data.ID)
+		assert.True(t, utils.CheckPasswordHash(mockUser.Password, data.Password))
 	})
 	f := t.Run("error-failed", func(t *testing.T) {
 		// failed scenario save (duplicate)
-		_, err := u.Save(mockUserFailed)
-		assert.Error(t, err)
+		_, err := u.Save(dbtest.Context(), mockUserFailed)
+		assert.Equal(t, user.ErrEmailTaken, err)
 	})
 	assert.Equal(t, true, s, "Success scenario failed run")
 	assert.Equal(t, true, f, "Failed scenario failed run")
 }
 
 func TestUserService_Update(t *testing.T) {
-	//prepare db test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
-
 	// create an instance of our test object
-	hashPassword, _ := utils.HashPassword("password")
 	mockUser := user.Dto{
 		Name:     "Ahmad",
 		Email:    "ahmad@email.com",
-		Password: hashPassword,
+		Password: "password",
 	}
 	mockUserFailed := user.Dto{
 		Name:     "Ahmad",
 		Email:    "ipan@email.com",
-		Password: hashPassword,
+		Password: "password",
 	}
-	u := NewUserService(db)
+	u := newUserService()
+	id := dbtest.UserUje.ID
 
 	// setup expectations
 	s := t.Run("success", func(t *testing.T) {
 		// success scenario update
-		data, err := u.Update("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5", mockUser)
+		data, err := u.Update(dbtest.Context(), id, id, mockUser)
 		assert.NoError(t, err)
-		assert.NotEmpty(t, data)
+		assert.Equal(t, mockUser.Email, data.Email)
+		assert.True(t, utils.CheckPasswordHash(mockUser.Password, data.Password))
 	})
 	f := t.Run("error-failed", func(t *testing.T) {
-		// failed scenario update
-		_, err := u.Update("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5", mockUserFailed)
-		assert.Error(t, err)
+		// failed scenario update (duplicate)
+		_, err := u.Update(dbtest.Context(), id, id, mockUserFailed)
+		assert.Equal(t, user.ErrEmailTaken, err)
+	})
+	a := t.Run("error-forbidden", func(t *testing.T) {
+		// failed scenario update of another user
+		_, err := u.Update(dbtest.Context(), dbtest.UserIpan.ID, id, mockUser)
+		assert.Equal(t, user.ErrForbidden, err)
 	})
 	assert.Equal(t, true, s, "Success scenario failed run")
 	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, a, "Forbidden scenario failed run")
 }
 
 func TestUserService_Delete(t *testing.T) {
-	//prepare database test
-	db, _ := database.PrepareTestDB("txdb")
-	defer database.CleanTestDB(db)
 	//create instance of our test object
-	u := NewUserService(db)
+	u := newUserService()
+	id := dbtest.UserUje.ID
 
 	// setup expectations
+	a := t.Run("error-forbidden", func(t *testing.T) {
+		// failed scenario delete of another user
+		success, err := u.Delete(dbtest.Context(), dbtest.UserIpan.ID, id)
+		assert.Equal(t, user.ErrForbidden, err)
+		assert.Equal(t, false, success)
+	})
 	s := t.Run("success", func(t *testing.T) {
 		// success scenario delete
-		success, err := u.Delete("7dd77cc4-f786-4be0-b5a5-0c203b9c62c5")
+		success, err := u.Delete(dbtest.Context(), id, id)
 		assert.Equal(t, true, success)
 		assert.Nil(t, err)
 		assert.NoError(t, err)
 	})
 	f := t.Run("error-failed", func(t *testing.T) {
 		// failed scenario delete
-		success, err := u.Delete("7dd77cc4-f786-4be0-b5a5-0c203b9e")
+		success, err := u.Delete(dbtest.Context(), "7dd77cc4-f786-4be0-b5a5-0c203b9e", "7dd77cc4-f786-4be0-b5a5-0c203b9e")
 		assert.NotNil(t, err)
 		assert.Equal(t, false, success)
 	})
 	assert.Equal(t, true, s, "Success scenario failed run")
 	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, a, "Forbidden scenario failed run")
+}
+
+func TestUserService_Events(t *testing.T) {
+	events := outboxRepository.NewOutboxMemoryRepository()
+	u := NewUserService(repository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan), outboxUsecase.NewOutboxService(events))
+
+	s := t.Run("success", func(t *testing.T) {
+		data, err := u.Save(dbtest.Context(), user.Dto{Name: "Ahmad", Email: "ahmad@email.com", Password: "password"})
+		assert.NoError(t, err)
+		// the same password changes nothing but the name
+		_, err = u.Update(dbtest.Context(), data.ID, data.ID, user.Dto{Name: "Ahmad D", Email: "ahmad@email.com", Password: "password"})
+		assert.NoError(t, err)
+		_, err = u.Update(dbtest.Context(), data.ID, data.ID, user.Dto{Name: "Ahmad D", Email: "ahmad@email.com", Password: "secret"})
+		assert.NoError(t, err)
+		_, err = u.Delete(dbtest.Context(), data.ID, data.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{outbox.EventUserRegistered, outbox.EventUserUpdated, outbox.EventUserPasswordChanged, outbox.EventUserDeleted}, eventTypes(t, events))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		// no event without a change
+		_, err := u.Save(dbtest.Context(), user.Dto{Name: "Ahmad", Email: "ipan@email.com", Password: "password"})
+		assert.Equal(t, user.ErrEmailTaken, err)
+		_, err = u.Delete(dbtest.Context(), "unknown", "unknown")
+		assert.Equal(t, user.ErrNotFound, err)
+		assert.Len(t, eventTypes(t, events), 4)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestUserService_Import(t *testing.T) {
+	events := outboxRepository.NewOutboxMemoryRepository()
+	users := repository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan)
+	u := NewUserService(users, outboxUsecase.NewOutboxService(events))
+	file := "email,name,password\n" +
+		"ahmad@email.com,Ahmad,secret\n" +
+		"budi@email.com,Budi,\n" +
+		"not-an-email,,\n" +
+		"AHMAD@email.com,Ahmad Again,\n" +
+		"ipan@email.com,Ipan,\n" +
+		"\"broken,Broken,\n"
+
+	s := t.Run("success", func(t *testing.T) {
+		rows, err := user.NewCSVReader(strings.NewReader(file))
+		assert.NoError(t, err)
+		report, err := u.Import(dbtest.Context(), rows, true)
+		assert.NoError(t, err)
+		assert.Equal(t, true, report.DryRun)
+		assert.Equal(t, 6, report.Total)
+		assert.Equal(t, 2, report.Imported)
+		assert.Equal(t, 4, report.Failed)
+		assert.Equal(t, []user.ImportError{
+			{Row: 3, Field: "name", Message: "is required"},
+			{Row: 3, Field: "email", Message: "must be an email"},
+			{Row: 4, Field: "email", Message: "is already in row 1"},
+			{Row: 5, Field: "email", Message: user.ErrEmailTaken.Error()},
+		}, report.Errors[:4])
+		assert.Equal(t, 6, report.Errors[4].Row)
+		// a dry run writes nothing
+		_, err = users.FindByEmail(dbtest.Context(), "ahmad@email.com")
+		assert.Equal(t, user.ErrNotFound, err)
+
+		rows, _ = user.NewCSVReader(strings.NewReader(file))
+		report, err = u.Import(dbtest.Context(), rows, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Imported)
+		ahmad, err := users.FindByEmail(dbtest.Context(), "ahmad@email.com")
+		assert.NoError(t, err)
+		assert.True(t, utils.CheckPasswordHash("secret", ahmad.Password))
+		budi, err := users.FindByEmail(dbtest.Context(), "budi@email.com")
+		assert.NoError(t, err)
+		assert.Empty(t, budi.Password)
+		assert.Equal(t, []string{outbox.EventUserRegistered, outbox.EventUserRegistered}, eventTypes(t, events))
+
+		// more rows than a batch, from NDJSON
+		var ndjson strings.Builder
+		for i := 0; i < user.ImportBatchSize+50; i++ {
+			ndjson.WriteString(fmt.Sprintf("{\"name\": \"User %d\", \"email\": \"bulk%d@email.com\"}\n\n", i, i))
+		}
+		report, err = u.Import(dbtest.Context(), user.NewNDJSONReader(strings.NewReader(ndjson.String())), false)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ImportBatchSize+50, report.Imported)
+		assert.Empty(t, report.Errors)
+		assert.Len(t, eventTypes(t, events), user.ImportBatchSize+52)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		_, err := user.NewCSVReader(strings.NewReader("name,password\nAhmad,secret\n"))
+		assert.Equal(t, user.ErrInvalidImport, err)
+		_, err = user.NewCSVReader(strings.NewReader(""))
+		assert.Equal(t, user.ErrInvalidImport, err)
+
+		report, err := u.Import(dbtest.Context(), user.NewNDJSONReader(strings.NewReader("{\"name\": \"Cici\", \"email\": \"cici@email.com\"}\n{\"name\": \n")), false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, 2, report.Errors[0].Row)
+		assert.Empty(t, report.Errors[0].Field)
+
+		_, err = u.Import(context.Background(), user.NewNDJSONReader(strings.NewReader("{\"name\": \"Dedi\", \"email\": \"dedi@email.com\"}\n")), false)
+		assert.Error(t, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
+
+func TestUserService_Export(t *testing.T) {
+	u := newUserService()
+
+	s := t.Run("success", func(t *testing.T) {
+		var emails []string
+		err := u.Export(dbtest.Context(), func(page []models.User) error {
+			for _, model := range page {
+				emails = append(emails, model.Email)
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{dbtest.UserUje.Email, dbtest.UserIpan.Email}, emails)
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		err := u.Export(dbtest.Context(), func(page []models.User) error {
+			return user.ErrForbidden
+		})
+		assert.Equal(t, user.ErrForbidden, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
 }
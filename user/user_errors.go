@@ -0,0 +1,15 @@
+package user
+
+import "errors"
+
+var (
+	ErrNotFound   = errors.New("user not found")
+	ErrEmailTaken = errors.New("email is already taken")
+	ErrForbidden  = errors.New("user can only be modified by its owner")
+	// ErrInvalidImport is an import whose header or body cannot be read
+	ErrInvalidImport = errors.New("import file cannot be read")
+	// ErrUnsupportedFormat is an import or export format other than CSV and JSON
+	ErrUnsupportedFormat = errors.New("format is not supported")
+	// ErrImportTooLarge is an import in the background of a file over ImportJobMaxSize
+	ErrImportTooLarge = errors.New("import file is too large to run in the background")
+)
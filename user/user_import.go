@@ -0,0 +1,176 @@
+package user
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"go-echo-api/audit"
+	"io"
+	"strings"
+)
+
+const (
+	// ImportBatchSize is the number of users written by a transaction of an import
+	ImportBatchSize = 100
+	// ExportPageSize is the number of users read by a query of an export
+	ExportPageSize = 500
+	// ImportJobMaxSize is the size of a file imported in the background at most, the file is kept in the job
+	ImportJobMaxSize = 10 << 20
+)
+
+// formats of the imports
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// JobImport is the type of the jobs importing users in the background
+const JobImport = "user.import"
+
+// ImportJobPayload is the input of a job importing users: the file itself and the request enqueuing it, for the audit log
+type ImportJobPayload struct {
+	Format string       `json:"format"`
+	DryRun bool         `json:"dry_run"`
+	Data   string       `json:"data"`
+	Source audit.Source `json:"source"`
+}
+
+// NewRowReader return the reader of the rows of an import of format
+func NewRowReader(format string, r io.Reader) (RowReader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(r)
+	case FormatNDJSON:
+		return NewNDJSONReader(r), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ImportDto is a user of an import, without password the user logs in with a magic link
+type ImportDto struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"omitempty,max=72"`
+}
+
+// ImportRow is a row read from an import, Err is set when the row could not be decoded
+type ImportRow struct {
+	// Row is the position of the row in the file from 1, the line for NDJSON and the record after the header for CSV
+	Row int
+	Dto ImportDto
+	Err error
+}
+
+// RowReader read the rows of an import one at a time, Next returns io.EOF after the last
+type RowReader interface {
+	Next() (ImportRow, error)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	row     int
+}
+
+// NewCSVReader return the reader of a CSV import, its header names the columns name, email and
+// optionally password in any order. It fails with ErrInvalidImport when the header lacks a column.
+func NewCSVReader(r io.Reader) (RowReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidImport
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"name", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, ErrInvalidImport
+		}
+	}
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Next() (ImportRow, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return ImportRow{}, io.EOF
+	}
+	r.row++
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return ImportRow{Row: r.row, Err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return ImportRow{}, err
+	}
+	return ImportRow{Row: r.row, Dto: ImportDto{
+		Name:     r.field(record, "name"),
+		Email:    r.field(record, "email"),
+		Password: r.field(record, "password"),
+	}}, nil
+}
+
+func (r *csvReader) field(record []string, column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+// NewNDJSONReader return the reader of an import of one JSON user per line, the blank lines are skipped
+func NewNDJSONReader(r io.Reader) RowReader {
+	return &ndjsonReader{scanner: bufio.NewScanner(r)}
+}
+
+func (r *ndjsonReader) Next() (ImportRow, error) {
+	for r.scanner.Scan() {
+		r.row++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var dto ImportDto
+		if err := json.Unmarshal([]byte(line), &dto); err != nil {
+			return ImportRow{Row: r.row, Err: err}, nil
+		}
+		dto.Name = strings.TrimSpace(dto.Name)
+		dto.Email = strings.TrimSpace(dto.Email)
+		return ImportRow{Row: r.row, Dto: dto}, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return ImportRow{}, err
+	}
+	return ImportRow{}, io.EOF
+}
+
+// ImportReport is the outcome of an import, the rows failed are listed with their errors
+type ImportReport struct {
+	DryRun   bool
+	Total    int
+	Imported int
+	Failed   int
+	Errors   []ImportError
+}
+
+// ImportError is why a row of an import failed, Field is empty when the row as a whole failed
+type ImportError struct {
+	Row     int
+	Field   string
+	Message string
+}
+
+// Fail record the errors of a row failed
+func (r *ImportReport) Fail(errors ...ImportError) {
+	r.Failed++
+	r.Errors = append(r.Errors, errors...)
+}
@@ -1,6 +1,9 @@
 package user
 
-import "go-echo-api/models"
+import (
+	"go-echo-api/models"
+	"time"
+)
 
 type Mapper struct {
 	ID    string `json:"id"`
@@ -31,3 +34,44 @@ func (m *Mapper) MapList(model []models.User) interface{} {
 	}
 	return serialized
 }
+
+// ExportMapper is a user of an export
+type ExportMapper struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewExportMapper(model models.User) ExportMapper {
+	return ExportMapper{ID: model.ID, Name: model.Name, Email: model.Email, CreatedAt: model.CreatedAt}
+}
+
+// ImportMapper is the report of an import
+type ImportMapper struct {
+	DryRun   bool                `json:"dry_run"`
+	Total    int                 `json:"total"`
+	Imported int                 `json:"imported"`
+	Failed   int                 `json:"failed"`
+	Errors   []ImportErrorMapper `json:"errors"`
+}
+
+type ImportErrorMapper struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func NewImportMapper(report ImportReport) ImportMapper {
+	m := ImportMapper{
+		DryRun:   report.DryRun,
+		Total:    report.Total,
+		Imported: report.Imported,
+		Failed:   report.Failed,
+		Errors:   make([]ImportErrorMapper, len(report.Errors)),
+	}
+	for i, e := range report.Errors {
+		m.Errors[i] = ImportErrorMapper{Row: e.Row, Field: e.Field, Message: e.Message}
+	}
+	return m
+}
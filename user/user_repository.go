@@ -1,13 +1,17 @@
 package user
 
 import (
+	"context"
 	"go-echo-api/models"
 )
 
 type Repository interface {
-	FindAll() ([]models.User, error)
-	FindById(id string) (*models.User, error)
-	Save(dto Dto) (models.User, error)
-	Update(id string, dto Dto) (models.User, error)
-	Delete(id string) (bool, error)
+	FindAll(ctx context.Context, limit int64, offset int64) ([]models.User, int64, error)
+	FindById(ctx context.Context, id string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	Store(ctx context.Context, model *models.User) error
+	Update(ctx context.Context, model *models.User) error
+	Delete(ctx context.Context, id string) error
+	// Each call fn with the users of the tenant by pages of size, in the order of their IDs
+	Each(ctx context.Context, size int64, fn func([]models.User) error) error
 }
@@ -0,0 +1,16 @@
+package user
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+type Usecase interface {
+	FindAll(ctx context.Context, limit int64, offset int64) ([]models.User, int64, error)
+	FindById(ctx context.Context, id string) (*models.User, error)
+	Save(ctx context.Context, dto Dto) (models.User, error)
+	Update(ctx context.Context, actorID string, id string, dto Dto) (models.User, error)
+	Delete(ctx context.Context, actorID string, id string) (bool, error)
+	Import(ctx context.Context, rows RowReader, dryRun bool) (ImportReport, error)
+	Export(ctx context.Context, fn func([]models.User) error) error
+}
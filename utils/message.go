@@ -14,4 +14,9 @@ const (
 	ServiceIsNotAccessible        = "We are Sorry, The Service Is Not Available Right Now"
 	Success                       = "Success"
 	NotFound                      = "Not Found"
+	Conflict                      = "Conflict"
+	RequestTimeout                = "The Request Took Too Long To Process"
+	BadGateway                    = "Bad Gateway"
+	TooManyRequests               = "Too Many Requests"
+	Accepted                      = "Accepted"
 )
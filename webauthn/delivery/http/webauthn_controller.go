@@ -0,0 +1,118 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/metrics"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/utils"
+	"go-echo-api/webauthn"
+)
+
+type webAuthnController struct {
+	webAuthnUsecase webauthn.Usecase
+	loginUsecase    auth.Login
+	webAuthnMapper  *webauthn.Mapper
+}
+
+func NewWebAuthnController(s webauthn.Usecase, login auth.Login) *webAuthnController {
+	return &webAuthnController{webAuthnUsecase: s,
+		loginUsecase:   login,
+		webAuthnMapper: webauthn.NewWebAuthnMapper(),
+	}
+}
+
+// BeginRegistration answer the options of navigator.credentials.create for the logged in user
+func (c *webAuthnController) BeginRegistration(ctx echo.Context) error {
+	options, err := c.webAuthnUsecase.BeginRegistration(ctx.Request().Context(), middleware.UserID(ctx))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, options, nil)
+}
+
+func (c *webAuthnController) FinishRegistration(ctx echo.Context) error {
+	var dto webauthn.RegistrationDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.webAuthnUsecase.FinishRegistration(ctx.Request().Context(), middleware.UserID(ctx), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, c.webAuthnMapper.Map(result), nil)
+}
+
+// BeginLogin answer the options of navigator.credentials.get
+func (c *webAuthnController) BeginLogin(ctx echo.Context) error {
+	var dto webauthn.LoginDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	options, err := c.webAuthnUsecase.BeginLogin(ctx.Request().Context(), dto.Email)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, options, nil)
+}
+
+// FinishLogin answer the token pair of the user of the credential, as the login with a password.
+// The credential is a factor strong enough, the user is not asked its two-factor code.
+func (c *webAuthnController) FinishLogin(ctx echo.Context) error {
+	var dto webauthn.AssertionDto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.webAuthnUsecase.FinishLogin(ctx.Request().Context(), dto)
+	metrics.Logins.WithLabelValues(metrics.Result(err)).Inc()
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	tokens, err := c.loginUsecase.TokenPair(ctx.Request().Context(), *result, ctx.RealIP(), ctx.Request().UserAgent())
+	if err != nil {
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+	return response.SingleData(ctx, utils.OK, tokens, nil)
+}
+
+func (c *webAuthnController) FindAll(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.webAuthnUsecase.FindCredentials(ctx.Request().Context(), middleware.UserID(ctx), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.webAuthnMapper.MapList(result), nil)
+}
+
+func (c *webAuthnController) Delete(ctx echo.Context) error {
+	if err := c.webAuthnUsecase.DeleteCredential(ctx.Request().Context(), middleware.UserID(ctx), ctx.Param("id")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+// errorResponse map the errors of the webauthn use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case webauthn.ErrNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	case webauthn.ErrInvalidChallenge, webauthn.ErrInvalidResponse, webauthn.ErrUnsupported:
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	case webauthn.ErrUnknownCredential, webauthn.ErrInvalidSignature, webauthn.ErrCounter:
+		return response.Unauthorized(ctx, utils.Unauthorized, nil, err.Error())
+	case webauthn.ErrRegistered:
+		return response.Conflict(ctx, utils.Conflict, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("webauthn use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	authUsecase "go-echo-api/auth/usecase"
+	"go-echo-api/middleware"
+	organizationRepository "go-echo-api/organization/repository"
+	organizationUsecase "go-echo-api/organization/usecase"
+	"go-echo-api/outbox"
+	"go-echo-api/outbox/sink"
+	sessionRepository "go-echo-api/session/repository"
+	sessionUsecase "go-echo-api/session/usecase"
+	tenantRepository "go-echo-api/tenant/repository"
+	userRepository "go-echo-api/user/repository"
+	"go-echo-api/webauthn"
+	"go-echo-api/webauthn/repository"
+	"go-echo-api/webauthn/usecase"
+)
+
+// Module wire the WebAuthn credentials (passkeys) to the database and register its routes under /auth/webauthn.
+// The logged in user registers the credentials of its authenticators, then logs in with one of them
+// without password. The relying party is configured by the APP_WEBAUTHN_* variables.
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/auth/webauthn"
+}
+
+// Subscribe delete the credentials and ceremonies of the users deleted
+func (m *Module) Subscribe(subscribers *sink.Subscribers) {
+	subscribers.Subscribe(outbox.EventUserDeleted, sink.AggregateHandler(repository.NewWebAuthnRepository(m.db).DeleteByUser))
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	organizations := organizationUsecase.NewOrganizationService(organizationRepository.NewOrganizationRepository(m.db))
+	sessions := sessionUsecase.NewSessionService(sessionRepository.NewSessionRepository(m.db), middleware.TokenTTL)
+	controller := NewWebAuthnController(usecase.NewWebAuthnService(repository.NewWebAuthnRepository(m.db), userRepository.NewUserRepository(m.db), webauthn.ConfigFromEnv()), authUsecase.NewLoginService(organizations, sessions))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	loggedIn := middleware.IsLoggedIn(sessions)
+	g.POST("/register/begin", controller.BeginRegistration, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/register/finish", controller.FinishRegistration, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.POST("/login/begin", controller.BeginLogin, tenantScope)
+	g.POST("/login/finish", controller.FinishLogin, tenantScope)
+	g.GET("/credentials", controller.FindAll, tenantScope, loggedIn, middleware.RequireUnscoped)
+	g.DELETE("/credentials/:id", controller.Delete, tenantScope, loggedIn, middleware.RequireUnscoped)
+}
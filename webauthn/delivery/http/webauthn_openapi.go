@@ -0,0 +1,95 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/auth"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/webauthn"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+	unauthorized := g.Error("Missing or invalid access token")
+	scoped := g.Error("Token issued to an OAuth client")
+
+	g.Add(echo.POST, "/register/begin", openapi.Operation{
+		Tags:        []string{"webauthn"},
+		Summary:     "Begin the registration of a credential of the logged in user, the options are passed to navigator.credentials.create",
+		OperationID: "beginWebAuthnRegistration",
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Creation options of the credential, binary fields base64url encoded", webauthn.CreationOptionsMapper{}),
+			"401": unauthorized,
+			"403": scoped,
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/register/finish", openapi.Operation{
+		Tags:        []string{"webauthn"},
+		Summary:     "Register the credential created by the authenticator",
+		OperationID: "finishWebAuthnRegistration",
+		RequestBody: g.Body(webauthn.RegistrationDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Credential registered", webauthn.Mapper{}),
+			"400": g.Error("Challenge unknown or expired, invalid response, or unsupported key algorithm or attestation"),
+			"401": unauthorized,
+			"403": scoped,
+			"409": g.Error("Credential already registered"),
+			"422": g.Error("Invalid body"),
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.POST, "/login/begin", openapi.Operation{
+		Tags:        []string{"webauthn"},
+		Summary:     "Begin a login with a credential, of the user of the email or any passkey without it, the options are passed to navigator.credentials.get",
+		OperationID: "beginWebAuthnLogin",
+		RequestBody: g.Body(webauthn.LoginDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Request options of the login, binary fields base64url encoded", webauthn.RequestOptionsMapper{}),
+		},
+	})
+	g.Add(echo.POST, "/login/finish", openapi.Operation{
+		Tags:        []string{"webauthn"},
+		Summary:     "Finish the login with the assertion of the authenticator",
+		OperationID: "finishWebAuthnLogin",
+		RequestBody: g.Body(webauthn.AssertionDto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Token pair of the user logged in", auth.TokenMapper{}),
+			"400": g.Error("Challenge unknown or expired, or invalid response"),
+			"401": g.Error("Credential not registered, invalid signature, or signature counter gone back"),
+			"422": g.Error("Invalid body"),
+		},
+	})
+	g.Add(echo.GET, "/credentials", openapi.Operation{
+		Tags:        []string{"webauthn"},
+		Summary:     "List the credentials of the logged in user",
+		OperationID: "listWebAuthnCredentials",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of credentials skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of credentials", webauthn.Mapper{}),
+			"401": unauthorized,
+			"403": scoped,
+		},
+		Security: openapi.BearerAuth,
+	})
+	g.Add(echo.DELETE, "/credentials/:id", openapi.Operation{
+		Tags:        []string{"webauthn"},
+		Summary:     "Delete a credential of the logged in user",
+		OperationID: "deleteWebAuthnCredential",
+		Parameters:  []openapi.Parameter{openapi.PathParam("id", "ID of the credential")},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Credential deleted", nil),
+			"401": unauthorized,
+			"403": scoped,
+			"404": g.Error("Credential not found"),
+		},
+		Security: openapi.BearerAuth,
+	})
+}
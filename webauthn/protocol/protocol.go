@@ -0,0 +1,303 @@
+// Package protocol parse and verify the responses of the WebAuthn authenticators (W3C Web Authentication
+// level 2): the client data, the authenticator data, the attestations "none" and "packed" and the
+// signatures of the COSE keys ES256, EdDSA and RS256. The attestation certificates are not checked
+// against a trust anchor, the API asks for no attestation.
+package protocol
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"go-echo-api/infrastructure/cbor"
+	"go-echo-api/webauthn"
+	"math/big"
+)
+
+// flags of the authenticator data
+const (
+	FlagUserPresent            = 0x01
+	FlagUserVerified           = 0x04
+	FlagAttestedCredentialData = 0x40
+	FlagExtensionData          = 0x80
+)
+
+// COSE algorithms of the keys accepted
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// Algorithms are the COSE algorithms accepted, in order of preference
+var Algorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// client data types of the ceremonies
+const (
+	TypeCreate = "webauthn.create"
+	TypeGet    = "webauthn.get"
+)
+
+// ClientData is the context of a ceremony as collected by the browser
+type ClientData struct {
+	Type string `json:"type"`
+	// Challenge is the base64url challenge of the options
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ParseClientData decode the clientDataJSON of a response
+func ParseClientData(raw []byte) (ClientData, error) {
+	var data ClientData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Type == "" || data.Challenge == "" {
+		return ClientData{}, webauthn.ErrInvalidResponse
+	}
+	return data, nil
+}
+
+// AuthenticatorData is the data signed by the authenticator, with the credential created on a registration
+type AuthenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+	AAGUID    []byte
+	// CredentialID and PublicKey, a COSE key, are present with FlagAttestedCredentialData
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// ParseAuthenticatorData decode the authenticator data of RFC section 6.1
+func ParseAuthenticatorData(data []byte) (AuthenticatorData, error) {
+	if len(data) < 37 {
+		return AuthenticatorData{}, webauthn.ErrInvalidResponse
+	}
+	result := AuthenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	rest := data[37:]
+	if result.Flags&FlagAttestedCredentialData != 0 {
+		if len(rest) < 18 {
+			return AuthenticatorData{}, webauthn.ErrInvalidResponse
+		}
+		result.AAGUID = rest[:16]
+		length := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < length {
+			return AuthenticatorData{}, webauthn.ErrInvalidResponse
+		}
+		result.CredentialID = rest[:length]
+		rest = rest[length:]
+		// the key is followed by the extensions, if any
+		_, after, err := cbor.Decode(rest)
+		if err != nil {
+			return AuthenticatorData{}, webauthn.ErrInvalidResponse
+		}
+		result.PublicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+	if result.Flags&FlagExtensionData != 0 {
+		_, after, err := cbor.Decode(rest)
+		if err != nil {
+			return AuthenticatorData{}, webauthn.ErrInvalidResponse
+		}
+		rest = after
+	}
+	if len(rest) > 0 {
+		return AuthenticatorData{}, webauthn.ErrInvalidResponse
+	}
+	return result, nil
+}
+
+// Check verify that the data is for rpID and that the user was present, and verified when required
+func (a AuthenticatorData) Check(rpID string, userVerification bool) error {
+	sum := sha256.Sum256([]byte(rpID))
+	if string(a.RPIDHash) != string(sum[:]) || a.Flags&FlagUserPresent == 0 {
+		return webauthn.ErrInvalidResponse
+	}
+	if userVerification && a.Flags&FlagUserVerified == 0 {
+		return webauthn.ErrInvalidResponse
+	}
+	return nil
+}
+
+// Attestation is the attestation object of a registration
+type Attestation struct {
+	Format    string
+	Statement map[interface{}]interface{}
+	// RawAuthData is the authenticator data as signed
+	RawAuthData []byte
+	AuthData    AuthenticatorData
+}
+
+// ParseAttestation decode the attestation object, it holds the credential created
+func ParseAttestation(object []byte) (Attestation, error) {
+	v, err := cbor.Unmarshal(object)
+	if err != nil {
+		return Attestation{}, webauthn.ErrInvalidResponse
+	}
+	m, _ := v.(map[interface{}]interface{})
+	format, _ := m["fmt"].(string)
+	statement, _ := m["attStmt"].(map[interface{}]interface{})
+	raw, _ := m["authData"].([]byte)
+	if format == "" || statement == nil || raw == nil {
+		return Attestation{}, webauthn.ErrInvalidResponse
+	}
+	authData, err := ParseAuthenticatorData(raw)
+	if err != nil {
+		return Attestation{}, err
+	}
+	if authData.Flags&FlagAttestedCredentialData == 0 || len(authData.CredentialID) == 0 {
+		return Attestation{}, webauthn.ErrInvalidResponse
+	}
+	return Attestation{Format: format, Statement: statement, RawAuthData: raw, AuthData: authData}, nil
+}
+
+// Verify the statement of the attestation over the authenticator data and the hash of the client data
+func (a Attestation) Verify(clientDataHash []byte) error {
+	switch a.Format {
+	case "none":
+		if len(a.Statement) != 0 {
+			return webauthn.ErrInvalidResponse
+		}
+		return nil
+	case "packed":
+		alg, _ := a.Statement["alg"].(int64)
+		sig, _ := a.Statement["sig"].([]byte)
+		signed := append(append([]byte{}, a.RawAuthData...), clientDataHash...)
+		chain, _ := a.Statement["x5c"].([]interface{})
+		if len(chain) == 0 {
+			// self attestation, signed by the credential
+			key, keyAlg, err := ParsePublicKey(a.AuthData.PublicKey)
+			if err != nil {
+				return err
+			}
+			if alg != keyAlg {
+				return webauthn.ErrInvalidResponse
+			}
+			return verify(key, alg, signed, sig)
+		}
+		der, _ := chain[0].([]byte)
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			return webauthn.ErrInvalidResponse
+		}
+		return verify(certificate.PublicKey, alg, signed, sig)
+	default:
+		return webauthn.ErrUnsupported
+	}
+}
+
+// COSE key parameters
+const (
+	coseKty    = 1
+	coseAlg    = 3
+	coseCrv    = -1
+	coseX      = -2
+	coseY      = -3
+	coseRSAN   = -1
+	coseRSAE   = -2
+	ktyOKP     = 1
+	ktyEC2     = 2
+	ktyRSA     = 3
+	crvP256    = 1
+	crvEd25519 = 6
+)
+
+// ParsePublicKey decode a COSE key (RFC 8152) of an accepted algorithm and return it with its algorithm
+func ParsePublicKey(cose []byte) (crypto.PublicKey, int64, error) {
+	v, err := cbor.Unmarshal(cose)
+	if err != nil {
+		return nil, 0, webauthn.ErrInvalidResponse
+	}
+	m, _ := v.(map[interface{}]interface{})
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv != crvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, webauthn.ErrInvalidResponse
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, 0, webauthn.ErrInvalidResponse
+		}
+		return key, alg, nil
+	case kty == ktyOKP && alg == AlgEdDSA:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, webauthn.ErrInvalidResponse
+		}
+		return ed25519.PublicKey(x), alg, nil
+	case kty == ktyRSA && alg == AlgRS256:
+		n, _ := m[int64(coseRSAN)].([]byte)
+		e, _ := m[int64(coseRSAE)].([]byte)
+		modulus := new(big.Int).SetBytes(n)
+		exponent := new(big.Int).SetBytes(e)
+		if modulus.BitLen() < 2048 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, 0, webauthn.ErrInvalidResponse
+		}
+		return &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}, alg, nil
+	default:
+		return nil, 0, webauthn.ErrUnsupported
+	}
+}
+
+// Verify the signature of data by the COSE key
+func Verify(cose []byte, data []byte, signature []byte) error {
+	key, alg, err := ParsePublicKey(cose)
+	if err != nil {
+		return err
+	}
+	return verify(key, alg, data, signature)
+}
+
+func verify(key crypto.PublicKey, alg int64, data []byte, signature []byte) error {
+	sum := sha256.Sum256(data)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		var sig struct {
+			R, S *big.Int
+		}
+		if alg != AlgES256 {
+			return webauthn.ErrUnsupported
+		}
+		if rest, err := asn1.Unmarshal(signature, &sig); err != nil || len(rest) > 0 {
+			return webauthn.ErrInvalidSignature
+		}
+		if sig.R == nil || sig.S == nil || !ecdsa.Verify(k, sum[:], sig.R, sig.S) {
+			return webauthn.ErrInvalidSignature
+		}
+		return nil
+	case ed25519.PublicKey:
+		if alg != AlgEdDSA {
+			return webauthn.ErrUnsupported
+		}
+		if !ed25519.Verify(k, data, signature) {
+			return webauthn.ErrInvalidSignature
+		}
+		return nil
+	case *rsa.PublicKey:
+		if alg != AlgRS256 {
+			return webauthn.ErrUnsupported
+		}
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], signature) != nil {
+			return webauthn.ErrInvalidSignature
+		}
+		return nil
+	default:
+		return webauthn.ErrUnsupported
+	}
+}
@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/cbor"
+	"go-echo-api/webauthn"
+	"math/big"
+	"testing"
+)
+
+// authData return authenticator data for rpID attesting the credential of the COSE key
+func authData(rpID string, credentialID []byte, key []byte) []byte {
+	sum := sha256.Sum256([]byte(rpID))
+	data := append(sum[:], FlagUserPresent|FlagAttestedCredentialData, 0, 0, 0, 1)
+	data = append(data, make([]byte, 16)...)
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(credentialID)))
+	return append(append(append(data, length...), credentialID...), key...)
+}
+
+func TestProtocol(t *testing.T) {
+	s := t.Run("success", func(t *testing.T) {
+		// packed self attestation of an Ed25519 credential
+		public, private, _ := ed25519.GenerateKey(rand.Reader)
+		key, _ := cbor.Marshal(map[interface{}]interface{}{1: 1, 3: -8, -1: 6, -2: []byte(public)})
+		raw := authData("example.com", []byte{1, 2, 3}, key)
+		clientDataHash := sha256.Sum256([]byte("client data"))
+		signature := ed25519.Sign(private, append(append([]byte{}, raw...), clientDataHash[:]...))
+		object, _ := cbor.Marshal(map[string]interface{}{
+			"fmt":      "packed",
+			"attStmt":  map[string]interface{}{"alg": -8, "sig": signature},
+			"authData": raw,
+		})
+		attestation, err := ParseAttestation(object)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{1, 2, 3}, attestation.AuthData.CredentialID)
+		assert.Equal(t, uint32(1), attestation.AuthData.SignCount)
+		assert.NoError(t, attestation.AuthData.Check("example.com", false))
+		assert.NoError(t, attestation.Verify(clientDataHash[:]))
+
+		// RS256 signature
+		rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		cose, _ := cbor.Marshal(map[interface{}]interface{}{
+			1: 3, 3: -257, -1: rsaKey.N.Bytes(), -2: big.NewInt(int64(rsaKey.E)).Bytes(),
+		})
+		sum := sha256.Sum256([]byte("data"))
+		rsaSignature, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+		assert.NoError(t, Verify(cose, []byte("data"), rsaSignature))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		public, _, _ := ed25519.GenerateKey(rand.Reader)
+		key, _ := cbor.Marshal(map[interface{}]interface{}{1: 1, 3: -8, -1: 6, -2: []byte(public)})
+		raw := authData("example.com", []byte{1}, key)
+
+		_, err := ParseAuthenticatorData(raw[:40])
+		assert.Equal(t, webauthn.ErrInvalidResponse, err)
+		_, err = ParseAuthenticatorData(append(raw, 0))
+		assert.Equal(t, webauthn.ErrInvalidResponse, err)
+		data, _ := ParseAuthenticatorData(raw)
+		assert.Equal(t, webauthn.ErrInvalidResponse, data.Check("evil.example.org", false))
+		assert.Equal(t, webauthn.ErrInvalidResponse, data.Check("example.com", true))
+
+		assert.Equal(t, webauthn.ErrInvalidSignature, Verify(key, []byte("data"), make([]byte, ed25519.SignatureSize)))
+		object, _ := cbor.Marshal(map[string]interface{}{"fmt": "tpm", "attStmt": map[string]interface{}{}, "authData": raw})
+		attestation, err := ParseAttestation(object)
+		assert.NoError(t, err)
+		assert.Equal(t, webauthn.ErrUnsupported, attestation.Verify(nil))
+
+		// a P-384 key
+		_, _, err = ParsePublicKey([]byte{0xa2, 0x01, 0x02, 0x03, 0x38, 0x22})
+		assert.Equal(t, webauthn.ErrUnsupported, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/webauthn"
+	"time"
+)
+
+type webAuthnGormRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnRepository(db *gorm.DB) webauthn.Repository {
+	return &webAuthnGormRepository{db: db}
+}
+
+func (r *webAuthnGormRepository) FindCredentials(ctx context.Context, userID string, limit int64, offset int64) ([]models.WebAuthnCredential, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.WebAuthnCredential{}).Where("user_id = ?", userID)
+	var model []models.WebAuthnCredential
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	scoped = scoped.Order("created_at")
+	// a negative limit lists all the credentials, the ceremonies exclude or allow each of them
+	if limit >= 0 {
+		scoped = scoped.Limit(limit).Offset(offset)
+	}
+	err = scoped.Find(&model).Error
+	return model, total, err
+}
+
+func (r *webAuthnGormRepository) FindCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.WebAuthnCredential
+	err = db.Where("credential_id = ?", credentialID).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, webauthn.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *webAuthnGormRepository) StoreCredential(ctx context.Context, model *models.WebAuthnCredential) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *webAuthnGormRepository) UseCredential(ctx context.Context, id string, previous int64, next int64, usedAt time.Time) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Model(&models.WebAuthnCredential{}).Where("id = ? AND sign_count = ?", id, previous).
+		UpdateColumns(map[string]interface{}{"sign_count": next, "last_used_at": usedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return webauthn.ErrCounter
+	}
+	return nil
+}
+
+func (r *webAuthnGormRepository) DeleteCredential(ctx context.Context, userID string, id string) error {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	result := db.Where("user_id = ? AND id = ?", userID, id).Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return webauthn.ErrNotFound
+	}
+	return nil
+}
+
+func (r *webAuthnGormRepository) StoreSession(ctx context.Context, model *models.WebAuthnSession) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *webAuthnGormRepository) TakeSession(ctx context.Context, hash string) (*models.WebAuthnSession, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.WebAuthnSession
+	err = db.Where("challenge_hash = ?", hash).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, webauthn.ErrInvalidChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+	// of two concurrent responses to the challenge only the one deleting it wins
+	result := db.Where("id = ?", model.ID).Delete(&models.WebAuthnSession{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, webauthn.ErrInvalidChallenge
+	}
+	return &model, nil
+}
+
+func (r *webAuthnGormRepository) DeleteByUser(ctx context.Context, userID string) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.WebAuthnCredential{}, &models.WebAuthnSession{}} {
+			if err := tx.New().Where("tenant_id = ? AND user_id = ?", tenantID, userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/webauthn"
+	"testing"
+	"time"
+)
+
+func TestWebAuthnGormRepository_Credential(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewWebAuthnRepository(db)
+	model := models.WebAuthnCredential{UserID: dbtest.UserUje.ID, CredentialID: "credential", PublicKey: []byte{1, 2}, Algorithm: -7, Name: "laptop"}
+	assert.NoError(t, r.StoreCredential(dbtest.Context(), &model))
+	assert.Equal(t, dbtest.TenantAcme.ID, model.TenantID)
+
+	found, err := r.FindCredential(dbtest.Context(), "credential")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, found.PublicKey)
+	_, total, err := r.FindCredentials(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// the credential ID is registered once per tenant
+	assert.Error(t, r.StoreCredential(dbtest.Context(), &models.WebAuthnCredential{UserID: dbtest.UserIpan.ID, CredentialID: "credential"}))
+	_, err = r.FindCredential(dbtest.TenantContext(dbtest.TenantGlobex), "credential")
+	assert.Equal(t, webauthn.ErrNotFound, err)
+
+	// the counter moves from the value read only
+	assert.NoError(t, r.UseCredential(dbtest.Context(), model.ID, 0, 5, time.Now()))
+	assert.Equal(t, webauthn.ErrCounter, r.UseCredential(dbtest.Context(), model.ID, 0, 6, time.Now()))
+	found, _ = r.FindCredential(dbtest.Context(), "credential")
+	assert.Equal(t, int64(5), found.SignCount)
+	assert.NotNil(t, found.LastUsedAt)
+
+	assert.Equal(t, webauthn.ErrNotFound, r.DeleteCredential(dbtest.Context(), dbtest.UserIpan.ID, model.ID))
+	assert.NoError(t, r.DeleteCredential(dbtest.Context(), dbtest.UserUje.ID, model.ID))
+	_, err = r.FindCredential(dbtest.Context(), "credential")
+	assert.Equal(t, webauthn.ErrNotFound, err)
+}
+
+func TestWebAuthnGormRepository_Session(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewWebAuthnRepository(db)
+	session := models.WebAuthnSession{Ceremony: models.WebAuthnLogin, ChallengeHash: "challenge", ExpiresAt: time.Now().Add(time.Minute)}
+	assert.NoError(t, r.StoreSession(dbtest.Context(), &session))
+
+	_, err := r.TakeSession(dbtest.TenantContext(dbtest.TenantGlobex), "challenge")
+	assert.Equal(t, webauthn.ErrInvalidChallenge, err)
+
+	// a session is taken once
+	taken, err := r.TakeSession(dbtest.Context(), "challenge")
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebAuthnLogin, taken.Ceremony)
+	_, err = r.TakeSession(dbtest.Context(), "challenge")
+	assert.Equal(t, webauthn.ErrInvalidChallenge, err)
+}
+
+func TestWebAuthnGormRepository_DeleteByUser(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	credential := models.WebAuthnCredential{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID, CredentialID: "credential"}
+	ceremony := models.WebAuthnSession{TenantID: dbtest.TenantAcme.ID, UserID: dbtest.UserIpan.ID, ChallengeHash: "hash"}
+	passkey := models.WebAuthnSession{TenantID: dbtest.TenantAcme.ID, ChallengeHash: "passkey"}
+	assert.NoError(t, dbtest.LoadFixtures(db, &credential, &ceremony, &passkey))
+
+	r := NewWebAuthnRepository(db)
+	assert.NoError(t, r.DeleteByUser(dbtest.Context(), dbtest.UserIpan.ID))
+	count := 0
+	assert.NoError(t, db.Model(&models.WebAuthnCredential{}).Where("user_id = ?", dbtest.UserIpan.ID).Count(&count).Error)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Model(&models.WebAuthnSession{}).Where("tenant_id = ?", dbtest.TenantAcme.ID).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
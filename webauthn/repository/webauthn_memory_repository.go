@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"go-echo-api/webauthn"
+	"sort"
+	"sync"
+	"time"
+)
+
+// webAuthnMemoryRepository keeps the credentials and ceremonies in memory, it backs the use-case unit tests
+type webAuthnMemoryRepository struct {
+	mu          sync.RWMutex
+	credentials map[string]models.WebAuthnCredential
+	sessions    map[string]models.WebAuthnSession
+}
+
+func NewWebAuthnMemoryRepository() webauthn.Repository {
+	return &webAuthnMemoryRepository{
+		credentials: make(map[string]models.WebAuthnCredential),
+		sessions:    make(map[string]models.WebAuthnSession),
+	}
+}
+
+func (r *webAuthnMemoryRepository) FindCredentials(ctx context.Context, userID string, limit int64, offset int64) ([]models.WebAuthnCredential, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []models.WebAuthnCredential
+	for _, c := range r.credentials {
+		if c.TenantID == tenantID && c.UserID == userID {
+			all = append(all, c)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	total := int64(len(all))
+	if offset >= total {
+		return []models.WebAuthnCredential{}, total, nil
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *webAuthnMemoryRepository) FindCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range r.credentials {
+		if c.TenantID == tenantID && c.CredentialID == credentialID {
+			return &c, nil
+		}
+	}
+	return nil, webauthn.ErrNotFound
+}
+
+func (r *webAuthnMemoryRepository) StoreCredential(ctx context.Context, model *models.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.credentials[model.ID] = *model
+	return nil
+}
+
+func (r *webAuthnMemoryRepository) UseCredential(ctx context.Context, id string, previous int64, next int64, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	c, ok := r.credentials[id]
+	if !ok || c.TenantID != tenantID || c.SignCount != previous {
+		return webauthn.ErrCounter
+	}
+	c.SignCount = next
+	c.LastUsedAt = &usedAt
+	r.credentials[id] = c
+	return nil
+}
+
+func (r *webAuthnMemoryRepository) DeleteCredential(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.credentials[id]; !ok || existing.TenantID != tenantID || existing.UserID != userID {
+		return webauthn.ErrNotFound
+	}
+	delete(r.credentials, id)
+	return nil
+}
+
+func (r *webAuthnMemoryRepository) StoreSession(ctx context.Context, model *models.WebAuthnSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	r.sessions[model.ChallengeHash] = *model
+	return nil
+}
+
+func (r *webAuthnMemoryRepository) TakeSession(ctx context.Context, hash string) (*models.WebAuthnSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := r.sessions[hash]
+	if !ok || s.TenantID != tenantID {
+		return nil, webauthn.ErrInvalidChallenge
+	}
+	delete(r.sessions, hash)
+	return &s, nil
+}
+
+func (r *webAuthnMemoryRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	for id, v := range r.credentials {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.credentials, id)
+		}
+	}
+	for id, v := range r.sessions {
+		if v.TenantID == tenantID && v.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/models"
+	"go-echo-api/user"
+	"go-echo-api/webauthn"
+	"go-echo-api/webauthn/protocol"
+	"strings"
+	"time"
+)
+
+// DefaultName names a credential registered without a name
+const DefaultName = "Passkey"
+
+const publicKeyType = "public-key"
+
+type WebAuthnService struct {
+	webAuthnRepository webauthn.Repository
+	userRepository     user.Repository
+	config             webauthn.Config
+}
+
+func NewWebAuthnService(r webauthn.Repository, users user.Repository, config webauthn.Config) webauthn.Usecase {
+	return WebAuthnService{webAuthnRepository: r, userRepository: users, config: config}
+}
+
+func (s WebAuthnService) BeginRegistration(ctx context.Context, userID string) (webauthn.CreationOptionsMapper, error) {
+	owner, err := s.userRepository.FindById(ctx, userID)
+	if err != nil {
+		return webauthn.CreationOptionsMapper{}, err
+	}
+	credentials, _, err := s.webAuthnRepository.FindCredentials(ctx, userID, -1, 0)
+	if err != nil {
+		return webauthn.CreationOptionsMapper{}, err
+	}
+	challenge, err := s.begin(ctx, models.WebAuthnRegistration, userID)
+	if err != nil {
+		return webauthn.CreationOptionsMapper{}, err
+	}
+	params := make([]webauthn.CredentialParameter, len(protocol.Algorithms))
+	for i, alg := range protocol.Algorithms {
+		params[i] = webauthn.CredentialParameter{Type: publicKeyType, Alg: alg}
+	}
+	return webauthn.CreationOptionsMapper{
+		Challenge: challenge,
+		RP:        webauthn.RelyingParty{ID: s.config.RPID, Name: s.config.RPName},
+		User: webauthn.UserEntity{
+			ID:          encode([]byte(owner.ID)),
+			Name:        owner.Email,
+			DisplayName: owner.Name,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            s.config.Timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(credentials),
+		// a discoverable credential logs in without typing the email, the user verification makes it a second factor
+		AuthenticatorSelection: webauthn.AuthenticatorSelection{ResidentKey: "preferred", UserVerification: "required"},
+		Attestation:            "none",
+	}, nil
+}
+
+func (s WebAuthnService) FinishRegistration(ctx context.Context, userID string, dto webauthn.RegistrationDto) (models.WebAuthnCredential, error) {
+	clientDataJSON, err := decode(dto.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	session, err := s.finish(ctx, protocol.TypeCreate, clientDataJSON)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	if session.Ceremony != models.WebAuthnRegistration || session.UserID != userID {
+		return models.WebAuthnCredential{}, webauthn.ErrInvalidChallenge
+	}
+	object, err := decode(dto.Credential.Response.AttestationObject)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	attestation, err := protocol.ParseAttestation(object)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	if err := attestation.AuthData.Check(s.config.RPID, true); err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := attestation.Verify(clientDataHash[:]); err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	_, alg, err := protocol.ParsePublicKey(attestation.AuthData.PublicKey)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	credentialID := encode(attestation.AuthData.CredentialID)
+	if id, err := normalize(dto.Credential.ID); err != nil || id != credentialID {
+		return models.WebAuthnCredential{}, webauthn.ErrInvalidResponse
+	}
+	if _, err := s.webAuthnRepository.FindCredential(ctx, credentialID); err == nil {
+		return models.WebAuthnCredential{}, webauthn.ErrRegistered
+	} else if err != webauthn.ErrNotFound {
+		return models.WebAuthnCredential{}, err
+	}
+	aaguid, err := uuid.FromBytes(attestation.AuthData.AAGUID)
+	if err != nil {
+		return models.WebAuthnCredential{}, webauthn.ErrInvalidResponse
+	}
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		name = DefaultName
+	}
+	model := models.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: credentialID,
+		PublicKey:    attestation.AuthData.PublicKey,
+		Algorithm:    alg,
+		SignCount:    int64(attestation.AuthData.SignCount),
+		Name:         name,
+		AAGUID:       aaguid.String(),
+	}
+	if err := s.webAuthnRepository.StoreCredential(ctx, &model); err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, userID).Info("webauthn credential registered")
+	return model, nil
+}
+
+func (s WebAuthnService) BeginLogin(ctx context.Context, email string) (webauthn.RequestOptionsMapper, error) {
+	var userID string
+	allowed := []webauthn.CredentialDescriptor{}
+	if email = strings.TrimSpace(email); email != "" {
+		owner, err := s.userRepository.FindByEmail(ctx, email)
+		if err != nil && err != user.ErrNotFound {
+			return webauthn.RequestOptionsMapper{}, err
+		}
+		if owner != nil {
+			userID = owner.ID
+			credentials, _, err := s.webAuthnRepository.FindCredentials(ctx, userID, -1, 0)
+			if err != nil {
+				return webauthn.RequestOptionsMapper{}, err
+			}
+			allowed = descriptors(credentials)
+		}
+	}
+	challenge, err := s.begin(ctx, models.WebAuthnLogin, userID)
+	if err != nil {
+		return webauthn.RequestOptionsMapper{}, err
+	}
+	return webauthn.RequestOptionsMapper{
+		Challenge:        challenge,
+		RPID:             s.config.RPID,
+		Timeout:          s.config.Timeout.Milliseconds(),
+		AllowCredentials: allowed,
+		UserVerification: "required",
+	}, nil
+}
+
+func (s WebAuthnService) FinishLogin(ctx context.Context, dto webauthn.AssertionDto) (*models.User, error) {
+	clientDataJSON, err := decode(dto.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.finish(ctx, protocol.TypeGet, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if session.Ceremony != models.WebAuthnLogin {
+		return nil, webauthn.ErrInvalidChallenge
+	}
+	credentialID, err := normalize(dto.ID)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := s.webAuthnRepository.FindCredential(ctx, credentialID)
+	if err == webauthn.ErrNotFound {
+		return nil, webauthn.ErrUnknownCredential
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the login begun with an email accepts the credentials of its user only
+	if session.UserID != "" && session.UserID != credential.UserID {
+		return nil, webauthn.ErrUnknownCredential
+	}
+	if dto.Response.UserHandle != "" {
+		handle, err := decode(dto.Response.UserHandle)
+		if err != nil || string(handle) != credential.UserID {
+			return nil, webauthn.ErrInvalidResponse
+		}
+	}
+	rawAuthData, err := decode(dto.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	authData, err := protocol.ParseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	// the passkey logs in without the two-factor of the password, the authenticator verified the user
+	// with its PIN or biometrics
+	if err := authData.Check(s.config.RPID, true); err != nil {
+		return nil, err
+	}
+	signature, err := decode(dto.Response.Signature)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := protocol.Verify(credential.PublicKey, append(append([]byte{}, rawAuthData...), clientDataHash[:]...), signature); err != nil {
+		return nil, err
+	}
+	log := logger.FromContext(ctx).WithField(logger.UserIDField, credential.UserID)
+	// the authenticators without a counter always send 0, the others a counter growing on every assertion
+	next := int64(authData.SignCount)
+	if (next != 0 || credential.SignCount != 0) && next <= credential.SignCount {
+		log.Warn("webauthn signature counter went back")
+		return nil, webauthn.ErrCounter
+	}
+	if err := s.webAuthnRepository.UseCredential(ctx, credential.ID, credential.SignCount, next, time.Now()); err != nil {
+		return nil, err
+	}
+	owner, err := s.userRepository.FindById(ctx, credential.UserID)
+	if err == user.ErrNotFound {
+		return nil, webauthn.ErrUnknownCredential
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Info("login succeeded")
+	return owner, nil
+}
+
+func (s WebAuthnService) FindCredentials(ctx context.Context, userID string, limit int64, offset int64) ([]models.WebAuthnCredential, int64, error) {
+	return s.webAuthnRepository.FindCredentials(ctx, userID, limit, offset)
+}
+
+func (s WebAuthnService) DeleteCredential(ctx context.Context, userID string, id string) error {
+	if err := s.webAuthnRepository.DeleteCredential(ctx, userID, id); err != nil {
+		return err
+	}
+	logger.FromContext(ctx).WithField(logger.UserIDField, userID).Info("webauthn credential deleted")
+	return nil
+}
+
+// begin store the session of a new ceremony and return its challenge
+func (s WebAuthnService) begin(ctx context.Context, ceremony string, userID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	challenge := encode(b)
+	model := models.WebAuthnSession{
+		Ceremony:      ceremony,
+		ChallengeHash: hash(challenge),
+		UserID:        userID,
+		ExpiresAt:     time.Now().Add(s.config.Timeout),
+	}
+	if err := s.webAuthnRepository.StoreSession(ctx, &model); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// finish check the client data of a response of type typ and take the session of its challenge
+func (s WebAuthnService) finish(ctx context.Context, typ string, clientDataJSON []byte) (*models.WebAuthnSession, error) {
+	clientData, err := protocol.ParseClientData(clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if clientData.Type != typ || clientData.CrossOrigin || !s.allowed(clientData.Origin) {
+		return nil, webauthn.ErrInvalidResponse
+	}
+	session, err := s.webAuthnRepository.TakeSession(ctx, hash(clientData.Challenge))
+	if err != nil {
+		return nil, err
+	}
+	if session.Expired(time.Now()) {
+		return nil, webauthn.ErrInvalidChallenge
+	}
+	return session, nil
+}
+
+func (s WebAuthnService) allowed(origin string) bool {
+	for _, o := range s.config.Origins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func descriptors(credentials []models.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	result := make([]webauthn.CredentialDescriptor, len(credentials))
+	for i, c := range credentials {
+		result[i] = webauthn.CredentialDescriptor{Type: publicKeyType, ID: c.CredentialID}
+	}
+	return result
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decode a base64url field of a response, padded or not
+func decode(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, webauthn.ErrInvalidResponse
+	}
+	return b, nil
+}
+
+// normalize a credential ID to its unpadded base64url form, as stored
+func normalize(id string) (string, error) {
+	b, err := decode(id)
+	if err != nil || len(b) == 0 {
+		return "", webauthn.ErrInvalidResponse
+	}
+	return encode(b), nil
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	userRepository "go-echo-api/user/repository"
+	"go-echo-api/webauthn"
+	"go-echo-api/webauthn/repository"
+	"go-echo-api/webauthn/webauthntest"
+	"testing"
+	"time"
+)
+
+const origin = "https://app.example.com"
+
+func newWebAuthnService() webauthn.Usecase {
+	users := userRepository.NewUserMemoryRepository(dbtest.UserUje, dbtest.UserIpan)
+	config := webauthn.Config{RPID: "example.com", RPName: "Example", Origins: []string{origin}, Timeout: time.Minute}
+	return NewWebAuthnService(repository.NewWebAuthnMemoryRepository(), users, config)
+}
+
+// register a credential of the authenticator for the user
+func register(t *testing.T, w webauthn.Usecase, a *webauthntest.Authenticator, userID string) {
+	options, err := w.BeginRegistration(dbtest.Context(), userID)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	credential, err := a.Register(options)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = w.FinishRegistration(dbtest.Context(), userID, webauthn.RegistrationDto{Credential: credential})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+}
+
+func TestWebAuthnService(t *testing.T) {
+	s := t.Run("success", func(t *testing.T) {
+		w := newWebAuthnService()
+		a := webauthntest.New(origin)
+
+		options, err := w.BeginRegistration(dbtest.Context(), dbtest.UserUje.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "example.com", options.RP.ID)
+		assert.Equal(t, dbtest.UserUje.Email, options.User.Name)
+		assert.Empty(t, options.ExcludeCredentials)
+		credential, err := a.Register(options)
+		assert.NoError(t, err)
+		model, err := w.FinishRegistration(dbtest.Context(), dbtest.UserUje.ID, webauthn.RegistrationDto{Name: "laptop", Credential: credential})
+		assert.NoError(t, err)
+		assert.Equal(t, "laptop", model.Name)
+		assert.Equal(t, credential.ID, model.CredentialID)
+
+		// the credential registered is excluded from the next registration
+		options, err = w.BeginRegistration(dbtest.Context(), dbtest.UserUje.ID)
+		assert.NoError(t, err)
+		assert.Len(t, options.ExcludeCredentials, 1)
+
+		// login with the email allows the credential of the user
+		request, err := w.BeginLogin(dbtest.Context(), dbtest.UserUje.Email)
+		assert.NoError(t, err)
+		assert.Len(t, request.AllowCredentials, 1)
+		assertion, err := a.Login(request)
+		assert.NoError(t, err)
+		owner, err := w.FinishLogin(dbtest.Context(), assertion)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserUje.ID, owner.ID)
+
+		// login with a passkey, without email
+		request, err = w.BeginLogin(dbtest.Context(), "")
+		assert.NoError(t, err)
+		assert.Empty(t, request.AllowCredentials)
+		assertion, err = a.Login(request)
+		assert.NoError(t, err)
+		owner, err = w.FinishLogin(dbtest.Context(), assertion)
+		assert.NoError(t, err)
+		assert.Equal(t, dbtest.UserUje.ID, owner.ID)
+
+		credentials, total, err := w.FindCredentials(dbtest.Context(), dbtest.UserUje.ID, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, int64(2), credentials[0].SignCount)
+		assert.NotNil(t, credentials[0].LastUsedAt)
+		assert.NoError(t, w.DeleteCredential(dbtest.Context(), dbtest.UserUje.ID, credentials[0].ID))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		w := newWebAuthnService()
+		a := webauthntest.New(origin)
+		register(t, w, a, dbtest.UserUje.ID)
+
+		// the challenge is answered once
+		request, _ := w.BeginLogin(dbtest.Context(), "")
+		assertion, _ := a.Login(request)
+		_, err := w.FinishLogin(dbtest.Context(), assertion)
+		assert.NoError(t, err)
+		_, err = w.FinishLogin(dbtest.Context(), assertion)
+		assert.Equal(t, webauthn.ErrInvalidChallenge, err)
+
+		// a registration challenge does not log in
+		options, _ := w.BeginRegistration(dbtest.Context(), dbtest.UserUje.ID)
+		assertion, _ = a.Login(webauthn.RequestOptionsMapper{Challenge: options.Challenge, RPID: "example.com"})
+		_, err = w.FinishLogin(dbtest.Context(), assertion)
+		assert.Equal(t, webauthn.ErrInvalidChallenge, err)
+
+		// another origin
+		request, _ = w.BeginLogin(dbtest.Context(), "")
+		other := *a
+		other.Origin = "https://evil.example.org"
+		assertion, _ = other.Login(request)
+		_, err = w.FinishLogin(dbtest.Context(), assertion)
+		assert.Equal(t, webauthn.ErrInvalidResponse, err)
+
+		// the credential of uje does not log ipan in
+		request, _ = w.BeginLogin(dbtest.Context(), dbtest.UserIpan.Email)
+		assertion, _ = a.Login(request)
+		_, err = w.FinishLogin(dbtest.Context(), assertion)
+		assert.Equal(t, webauthn.ErrUnknownCredential, err)
+
+		// a forged signature
+		request, _ = w.BeginLogin(dbtest.Context(), "")
+		assertion, _ = a.Login(request)
+		assertion.Response.Signature = assertion.Response.Signature[:len(assertion.Response.Signature)-4] + "AAAA"
+		_, err = w.FinishLogin(dbtest.Context(), assertion)
+		assert.Equal(t, webauthn.ErrInvalidSignature, err)
+
+		// the authenticator did not verify the user
+		a.UserVerified = false
+		request, _ = w.BeginLogin(dbtest.Context(), "")
+		assertion, _ = a.Login(request)
+		_, err = w.FinishLogin(dbtest.Context(), assertion)
+		assert.Equal(t, webauthn.ErrInvalidResponse, err)
+		a.UserVerified = true
+
+		// a cloned authenticator sends a counter seen already
+		a.SetSignCount(0)
+		request, _ = w.BeginLogin(dbtest.Context(), "")
+		assertion, _ = a.Login(request)
+		_, err = w.FinishLogin(dbtest.Context(), assertion)
+		assert.Equal(t, webauthn.ErrCounter, err)
+
+		// the registration of another user
+		options, _ = w.BeginRegistration(dbtest.Context(), dbtest.UserUje.ID)
+		credential, _ := webauthntest.New(origin).Register(options)
+		_, err = w.FinishRegistration(dbtest.Context(), dbtest.UserIpan.ID, webauthn.RegistrationDto{Credential: credential})
+		assert.Equal(t, webauthn.ErrInvalidChallenge, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
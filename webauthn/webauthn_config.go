@@ -0,0 +1,46 @@
+package webauthn
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// Config of the relying party, the API as seen by the authenticators
+type Config struct {
+	// RPID is the domain of the credentials, the web clients run on it or on one of its subdomains
+	RPID   string
+	RPName string
+	// Origins are the origins of the web clients allowed to use the credentials, e.g. https://app.example.com
+	Origins []string
+	// Timeout is the time the user has to answer its authenticator
+	Timeout time.Duration
+}
+
+// ConfigFromEnv read APP_WEBAUTHN_RP_ID (default localhost), APP_WEBAUTHN_RP_NAME (default go-echo-api),
+// APP_WEBAUTHN_ORIGINS separated by commas (default https://<rp id>) and APP_WEBAUTHN_TIMEOUT (default 5m)
+func ConfigFromEnv() Config {
+	config := Config{
+		RPID:    os.Getenv("APP_WEBAUTHN_RP_ID"),
+		RPName:  os.Getenv("APP_WEBAUTHN_RP_NAME"),
+		Timeout: 5 * time.Minute,
+	}
+	if config.RPID == "" {
+		config.RPID = "localhost"
+	}
+	if config.RPName == "" {
+		config.RPName = "go-echo-api"
+	}
+	for _, origin := range strings.Split(os.Getenv("APP_WEBAUTHN_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			config.Origins = append(config.Origins, strings.TrimSuffix(origin, "/"))
+		}
+	}
+	if len(config.Origins) == 0 {
+		config.Origins = []string{"https://" + config.RPID}
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("APP_WEBAUTHN_TIMEOUT")); err == nil && timeout > 0 {
+		config.Timeout = timeout
+	}
+	return config
+}
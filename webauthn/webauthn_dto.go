@@ -0,0 +1,46 @@
+package webauthn
+
+// AttestationResponseDto is the response of the authenticator to the creation of a credential,
+// its fields are base64url encoded as in the JSON of the PublicKeyCredential of the browsers
+type AttestationResponseDto struct {
+	ClientDataJSON    string   `json:"clientDataJSON" validate:"required"`
+	AttestationObject string   `json:"attestationObject" validate:"required"`
+	Transports        []string `json:"transports,omitempty"`
+}
+
+// AttestationDto is the credential created by navigator.credentials.create
+type AttestationDto struct {
+	ID       string                 `json:"id" validate:"required"`
+	RawID    string                 `json:"rawId"`
+	Type     string                 `json:"type" validate:"required"`
+	Response AttestationResponseDto `json:"response" validate:"required"`
+}
+
+// RegistrationDto finish the registration of a credential, Name tells it apart from the other credentials of the user
+type RegistrationDto struct {
+	Name       string         `json:"name,omitempty"`
+	Credential AttestationDto `json:"credential" validate:"required"`
+}
+
+// LoginDto begin a login, with the email of the user to allow its credentials only,
+// or without it for a passkey (discoverable credential) chosen on the authenticator
+type LoginDto struct {
+	Email string `json:"email,omitempty"`
+}
+
+// AssertionResponseDto is the response of the authenticator to a login, base64url encoded
+type AssertionResponseDto struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required"`
+	Signature         string `json:"signature" validate:"required"`
+	// UserHandle is the user ID the credential was created for, sent by the passkeys
+	UserHandle string `json:"userHandle,omitempty"`
+}
+
+// AssertionDto is the credential returned by navigator.credentials.get
+type AssertionDto struct {
+	ID       string               `json:"id" validate:"required"`
+	RawID    string               `json:"rawId"`
+	Type     string               `json:"type" validate:"required"`
+	Response AssertionResponseDto `json:"response" validate:"required"`
+}
@@ -0,0 +1,14 @@
+package webauthn
+
+import "errors"
+
+var (
+	ErrNotFound          = errors.New("webauthn credential not found")
+	ErrInvalidChallenge  = errors.New("webauthn ceremony unknown or expired, begin again")
+	ErrInvalidResponse   = errors.New("invalid webauthn response of the authenticator")
+	ErrUnsupported       = errors.New("unsupported webauthn key algorithm or attestation format")
+	ErrRegistered        = errors.New("webauthn credential already registered")
+	ErrUnknownCredential = errors.New("webauthn credential not registered")
+	ErrInvalidSignature  = errors.New("invalid webauthn signature")
+	ErrCounter           = errors.New("webauthn signature counter went back, the authenticator may be cloned")
+)
@@ -0,0 +1,87 @@
+package webauthn
+
+import (
+	"go-echo-api/models"
+	"time"
+)
+
+type Mapper struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func NewWebAuthnMapper() *Mapper {
+	return &Mapper{}
+}
+
+func (m *Mapper) Map(model models.WebAuthnCredential) *Mapper {
+	m.ID = model.ID
+	m.Name = model.Name
+	m.LastUsedAt = model.LastUsedAt
+	m.CreatedAt = model.CreatedAt
+	return m
+}
+
+func (m *Mapper) MapList(model []models.WebAuthnCredential) interface{} {
+	serialized := make([]Mapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&Mapper{}).Map(v)
+	}
+	return serialized
+}
+
+// RelyingParty is the API as named to the authenticators
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity is the user a credential is created for, ID is the base64url user handle
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter is a key algorithm accepted, by its COSE identifier
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor name a credential registered, by its base64url ID
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptionsMapper is the JSON of the PublicKeyCredentialCreationOptions of the registration,
+// the binary fields base64url encoded, for PublicKeyCredential.parseCreationOptionsFromJSON
+type CreationOptionsMapper struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptionsMapper is the JSON of the PublicKeyCredentialRequestOptions of the login,
+// for PublicKeyCredential.parseRequestOptionsFromJSON
+type RequestOptionsMapper struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int64                  `json:"timeout"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
@@ -0,0 +1,26 @@
+package webauthn
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+// Repository of the credentials and ceremonies of the tenant of the context
+type Repository interface {
+	// FindCredentials return a page of the credentials of the user, all of them for a negative limit
+	FindCredentials(ctx context.Context, userID string, limit int64, offset int64) ([]models.WebAuthnCredential, int64, error)
+	// FindCredential return the credential of the ID chosen by the authenticator, ErrNotFound when it is not registered
+	FindCredential(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+	StoreCredential(ctx context.Context, model *models.WebAuthnCredential) error
+	// UseCredential move the counter of the credential from previous to next, ErrCounter when a concurrent
+	// assertion moved it first
+	UseCredential(ctx context.Context, id string, previous int64, next int64, usedAt time.Time) error
+	DeleteCredential(ctx context.Context, userID string, id string) error
+
+	StoreSession(ctx context.Context, model *models.WebAuthnSession) error
+	// TakeSession return and delete the session of the challenge hash, ErrInvalidChallenge when it is unknown
+	TakeSession(ctx context.Context, hash string) (*models.WebAuthnSession, error)
+	// DeleteByUser delete the credentials and ceremonies of the user, once the user is deleted
+	DeleteByUser(ctx context.Context, userID string) error
+}
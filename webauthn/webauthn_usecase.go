@@ -0,0 +1,21 @@
+package webauthn
+
+import (
+	"context"
+	"go-echo-api/models"
+)
+
+type Usecase interface {
+	// BeginRegistration return the options of the creation of a credential for the user
+	BeginRegistration(ctx context.Context, userID string) (CreationOptionsMapper, error)
+	// FinishRegistration verify the response of the authenticator and store the credential of the user
+	FinishRegistration(ctx context.Context, userID string, dto RegistrationDto) (models.WebAuthnCredential, error)
+	// BeginLogin return the options of a login, allowing the credentials of the user of the email when not empty.
+	// An unknown email is not told apart, it allows no credential.
+	BeginLogin(ctx context.Context, email string) (RequestOptionsMapper, error)
+	// FinishLogin verify the assertion of the authenticator and return the user of the credential
+	FinishLogin(ctx context.Context, dto AssertionDto) (*models.User, error)
+
+	FindCredentials(ctx context.Context, userID string, limit int64, offset int64) ([]models.WebAuthnCredential, int64, error)
+	DeleteCredential(ctx context.Context, userID string, id string) error
+}
@@ -0,0 +1,192 @@
+// Package webauthntest is a software authenticator answering the WebAuthn ceremonies in the tests,
+// as a browser with a platform authenticator would: ES256 passkeys, attestation "none".
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"go-echo-api/infrastructure/cbor"
+	"go-echo-api/webauthn"
+	"math/big"
+)
+
+var (
+	ErrNoAlgorithm  = errors.New("webauthntest: ES256 not allowed by the options")
+	ErrExcluded     = errors.New("webauthntest: a credential of the authenticator is excluded")
+	ErrNoCredential = errors.New("webauthntest: no credential allowed by the options")
+)
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+type credential struct {
+	id         []byte
+	key        *ecdsa.PrivateKey
+	rpID       string
+	userHandle string
+	signCount  uint32
+}
+
+// Authenticator holds the credentials it created, it answers the ceremonies from Origin
+type Authenticator struct {
+	Origin string
+	// UserVerified tells whether the responses have the user verified flag, default true
+	UserVerified bool
+	credentials  []*credential
+}
+
+func New(origin string) *Authenticator {
+	return &Authenticator{Origin: origin, UserVerified: true}
+}
+
+// Register answer the creation options with a new credential
+func (a *Authenticator) Register(options webauthn.CreationOptionsMapper) (webauthn.AttestationDto, error) {
+	allowed := false
+	for _, param := range options.PubKeyCredParams {
+		allowed = allowed || param.Alg == -7
+	}
+	if !allowed {
+		return webauthn.AttestationDto{}, ErrNoAlgorithm
+	}
+	for _, excluded := range options.ExcludeCredentials {
+		if a.find(excluded.ID) != nil {
+			return webauthn.AttestationDto{}, ErrExcluded
+		}
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return webauthn.AttestationDto{}, err
+	}
+	c := &credential{id: make([]byte, 16), key: key, rpID: options.RP.ID, userHandle: options.User.ID}
+	if _, err := rand.Read(c.id); err != nil {
+		return webauthn.AttestationDto{}, err
+	}
+	publicKey, err := cbor.Marshal(map[interface{}]interface{}{
+		1: 2, 3: -7, -1: 1, -2: pad(key.X), -3: pad(key.Y),
+	})
+	if err != nil {
+		return webauthn.AttestationDto{}, err
+	}
+	authData := a.authData(c, flagAttested)
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(c.id)))
+	authData = append(authData, make([]byte, 16)...)
+	authData = append(append(append(authData, length...), c.id...), publicKey...)
+	object, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		return webauthn.AttestationDto{}, err
+	}
+	clientData, err := a.clientData("webauthn.create", options.Challenge)
+	if err != nil {
+		return webauthn.AttestationDto{}, err
+	}
+	a.credentials = append(a.credentials, c)
+	id := encode(c.id)
+	return webauthn.AttestationDto{ID: id, RawID: id, Type: "public-key", Response: webauthn.AttestationResponseDto{
+		ClientDataJSON:    encode(clientData),
+		AttestationObject: encode(object),
+		Transports:        []string{"internal"},
+	}}, nil
+}
+
+// Login answer the request options with the first credential allowed, or with the first passkey
+// of the relying party when the options allow any
+func (a *Authenticator) Login(options webauthn.RequestOptionsMapper) (webauthn.AssertionDto, error) {
+	var c *credential
+	for _, allowed := range options.AllowCredentials {
+		if c = a.find(allowed.ID); c != nil {
+			break
+		}
+	}
+	if len(options.AllowCredentials) == 0 {
+		for _, candidate := range a.credentials {
+			if candidate.rpID == options.RPID {
+				c = candidate
+				break
+			}
+		}
+	}
+	if c == nil {
+		return webauthn.AssertionDto{}, ErrNoCredential
+	}
+	c.signCount++
+	authData := a.authData(c, 0)
+	clientData, err := a.clientData("webauthn.get", options.Challenge)
+	if err != nil {
+		return webauthn.AssertionDto{}, err
+	}
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return webauthn.AssertionDto{}, err
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		return webauthn.AssertionDto{}, err
+	}
+	id := encode(c.id)
+	return webauthn.AssertionDto{ID: id, RawID: id, Type: "public-key", Response: webauthn.AssertionResponseDto{
+		ClientDataJSON:    encode(clientData),
+		AuthenticatorData: encode(authData),
+		Signature:         encode(signature),
+		UserHandle:        c.userHandle,
+	}}, nil
+}
+
+// SetSignCount set the signature counter of the credentials, e.g. back to 0 for a clone
+func (a *Authenticator) SetSignCount(count uint32) {
+	for _, c := range a.credentials {
+		c.signCount = count
+	}
+}
+
+func (a *Authenticator) find(id string) *credential {
+	for _, c := range a.credentials {
+		if encode(c.id) == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// authData return the authenticator data of c, without the attested credential
+func (a *Authenticator) authData(c *credential, flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+	flags |= flagUserPresent
+	if a.UserVerified {
+		flags |= flagUserVerified
+	}
+	data := append(rpIDHash[:], flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, c.signCount)
+	return append(data, counter...)
+}
+
+func (a *Authenticator) clientData(typ string, challenge string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"type": typ, "challenge": challenge, "origin": a.Origin, "crossOrigin": false})
+}
+
+// pad return the 32 bytes big-endian coordinate of the P-256 curve
+func pad(n *big.Int) []byte {
+	b := n.Bytes()
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/utils"
+	"go-echo-api/webhook"
+)
+
+type webhookController struct {
+	webhookUsecase webhook.Usecase
+	webhookMapper  *webhook.Mapper
+	deliveryMapper *webhook.DeliveryMapper
+}
+
+func NewWebhookController(s webhook.Usecase) *webhookController {
+	return &webhookController{webhookUsecase: s,
+		webhookMapper:  webhook.NewWebhookMapper(),
+		deliveryMapper: webhook.NewDeliveryMapper(),
+	}
+}
+
+func (c *webhookController) FindAll(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.webhookUsecase.FindAll(ctx.Request().Context(), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.webhookMapper.MapList(result), nil)
+}
+
+func (c *webhookController) FindById(ctx echo.Context) error {
+	result, err := c.webhookUsecase.FindById(ctx.Request().Context(), ctx.Param("id"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, webhook.NewWebhookMapper().Map(*result), nil)
+}
+
+func (c *webhookController) Store(ctx echo.Context) error {
+	var dto webhook.Dto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.webhookUsecase.Store(ctx.Request().Context(), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, webhook.NewSecretMapper(result), nil)
+}
+
+func (c *webhookController) Update(ctx echo.Context) error {
+	var dto webhook.Dto
+	if err := ctx.Bind(&dto); err != nil {
+		return response.BadRequest(ctx, utils.BadRequest, nil, err.Error())
+	}
+	if err := ctx.Validate(dto); err != nil {
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	}
+	result, err := c.webhookUsecase.Update(ctx.Request().Context(), ctx.Param("id"), dto)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, webhook.NewWebhookMapper().Map(result), nil)
+}
+
+func (c *webhookController) Delete(ctx echo.Context) error {
+	if err := c.webhookUsecase.Delete(ctx.Request().Context(), ctx.Param("id")); err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, nil, nil)
+}
+
+// FindDeliveries list the delivery log of the subscription, the latest first
+func (c *webhookController) FindDeliveries(ctx echo.Context) error {
+	limit, offset := response.PageParams(ctx)
+	result, total, err := c.webhookUsecase.FindDeliveries(ctx.Request().Context(), ctx.Param("id"), limit, offset)
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	paginator := response.NewPaginator(ctx, limit, offset, total)
+	return response.Paginate(ctx, utils.OK, paginator, c.deliveryMapper.MapList(result), nil)
+}
+
+// Redeliver send a delivery again and answer its outcome
+func (c *webhookController) Redeliver(ctx echo.Context) error {
+	result, err := c.webhookUsecase.Redeliver(ctx.Request().Context(), ctx.Param("id"), ctx.Param("delivery"))
+	if err != nil {
+		return errorResponse(ctx, err)
+	}
+	return response.SingleData(ctx, utils.OK, webhook.NewDeliveryMapper().Map(result), nil)
+}
+
+// errorResponse map the errors of the webhook use-case to their response
+func errorResponse(ctx echo.Context, err error) error {
+	switch err {
+	case webhook.ErrNotFound, webhook.ErrDeliveryNotFound:
+		return response.NotFound(ctx, utils.NotFound, nil, err.Error())
+	case webhook.ErrInvalidURL:
+		return response.ValidationError(ctx, utils.ValidationError, nil, err.Error())
+	default:
+		logger.FromContext(ctx.Request().Context()).WithError(err).Error("webhook use-case failed")
+		return response.InternalServerError(ctx, utils.InternalServerError, nil, err.Error())
+	}
+}
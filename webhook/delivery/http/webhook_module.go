@@ -0,0 +1,36 @@
+package http
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/labstack/echo"
+	"go-echo-api/middleware"
+	tenantRepository "go-echo-api/tenant/repository"
+	"go-echo-api/webhook/repository"
+	"go-echo-api/webhook/usecase"
+)
+
+// Module wire the webhook subscriptions to the database and register their routes under /webhooks,
+// they manage the subscriptions of the tenant of the request with the admin key of the deployment
+type Module struct {
+	db *gorm.DB
+}
+
+func NewModule(db *gorm.DB) *Module {
+	return &Module{db: db}
+}
+
+func (m *Module) Prefix() string {
+	return "/webhooks"
+}
+
+func (m *Module) Routes(g *echo.Group) {
+	controller := NewWebhookController(usecase.NewWebhookService(repository.NewWebhookRepository(m.db), usecase.DeliveryConfigFromEnv()))
+	tenantScope := middleware.Tenant(middleware.TenantConfigFromEnv(tenantRepository.NewTenantRepository(m.db)))
+	g.GET("", controller.FindAll, tenantScope, middleware.IsAdmin)
+	g.POST("", controller.Store, tenantScope, middleware.IsAdmin)
+	g.GET("/:id", controller.FindById, tenantScope, middleware.IsAdmin)
+	g.PUT("/:id", controller.Update, tenantScope, middleware.IsAdmin)
+	g.DELETE("/:id", controller.Delete, tenantScope, middleware.IsAdmin)
+	g.GET("/:id/deliveries", controller.FindDeliveries, tenantScope, middleware.IsAdmin)
+	g.POST("/:id/deliveries/:delivery/redeliver", controller.Redeliver, tenantScope, middleware.IsAdmin)
+}
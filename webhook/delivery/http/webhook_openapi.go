@@ -0,0 +1,97 @@
+package http
+
+import (
+	"fmt"
+	"github.com/labstack/echo"
+	"go-echo-api/infrastructure/openapi"
+	"go-echo-api/infrastructure/response"
+	"go-echo-api/middleware"
+	"go-echo-api/webhook"
+)
+
+// OpenAPI describe the routes of the module on the group of its prefix
+func (m *Module) OpenAPI(g *openapi.Group) {
+	middleware.TenantOpenAPI(g)
+	middleware.AdminOpenAPI(g)
+	limit := fmt.Sprintf("Size of the page, default %d and at most %d", response.DefaultLimit, response.MaxLimit)
+	id := openapi.PathParam("id", "ID of the webhook subscription")
+
+	g.Add(echo.GET, "", openapi.Operation{
+		Tags:        []string{"webhook"},
+		Summary:     "List the webhook subscriptions of the tenant, without their secrets",
+		OperationID: "listWebhooks",
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of subscriptions skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of webhook subscriptions", webhook.Mapper{}),
+		},
+	})
+	g.Add(echo.POST, "", openapi.Operation{
+		Tags:        []string{"webhook"},
+		Summary:     "Subscribe a URL to domain events, the secret signing the deliveries is in this response only",
+		OperationID: "createWebhook",
+		RequestBody: g.Body(webhook.Dto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Webhook subscription with its secret", webhook.SecretMapper{}),
+			"422": g.Error("Invalid body"),
+		},
+	})
+	g.Add(echo.GET, "/:id", openapi.Operation{
+		Tags:        []string{"webhook"},
+		Summary:     "Find a webhook subscription",
+		OperationID: "getWebhook",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Webhook subscription", webhook.Mapper{}),
+			"404": g.Error("Webhook subscription not found"),
+		},
+	})
+	g.Add(echo.PUT, "/:id", openapi.Operation{
+		Tags:        []string{"webhook"},
+		Summary:     "Update a webhook subscription, its secret is kept unless given",
+		OperationID: "updateWebhook",
+		Parameters:  []openapi.Parameter{id},
+		RequestBody: g.Body(webhook.Dto{}),
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Updated webhook subscription", webhook.Mapper{}),
+			"404": g.Error("Webhook subscription not found"),
+			"422": g.Error("Invalid body"),
+		},
+	})
+	g.Add(echo.DELETE, "/:id", openapi.Operation{
+		Tags:        []string{"webhook"},
+		Summary:     "Delete a webhook subscription and its deliveries",
+		OperationID: "deleteWebhook",
+		Parameters:  []openapi.Parameter{id},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Webhook subscription deleted", nil),
+			"404": g.Error("Webhook subscription not found"),
+		},
+	})
+	g.Add(echo.GET, "/:id/deliveries", openapi.Operation{
+		Tags:        []string{"webhook"},
+		Summary:     "List the deliveries of a webhook subscription with the response code of their last attempt, the latest first",
+		OperationID: "listWebhookDeliveries",
+		Parameters: []openapi.Parameter{
+			id,
+			openapi.QueryParam("limit", limit, &openapi.Schema{Type: "integer", Format: "int64"}),
+			openapi.QueryParam("offset", "Number of deliveries skipped, default 0", &openapi.Schema{Type: "integer", Format: "int64"}),
+		},
+		Responses: map[string]openapi.Response{
+			"200": g.Paging("Page of deliveries", webhook.DeliveryMapper{}),
+			"404": g.Error("Webhook subscription not found"),
+		},
+	})
+	g.Add(echo.POST, "/:id/deliveries/:delivery/redeliver", openapi.Operation{
+		Tags:        []string{"webhook"},
+		Summary:     "Send a delivery again now, whatever its status",
+		OperationID: "redeliverWebhook",
+		Parameters:  []openapi.Parameter{id, openapi.PathParam("delivery", "ID of the delivery")},
+		Responses: map[string]openapi.Response{
+			"200": g.Single("Delivery with the outcome of the attempt", webhook.DeliveryMapper{}),
+			"404": g.Error("Webhook subscription or delivery not found"),
+		},
+	})
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"github.com/jinzhu/gorm"
+	"go-echo-api/infrastructure/database"
+	"go-echo-api/models"
+	"go-echo-api/webhook"
+	"time"
+)
+
+type webhookGormRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) webhook.Repository {
+	return &webhookGormRepository{db: db}
+}
+
+func (r *webhookGormRepository) FindAll(ctx context.Context, limit int64, offset int64) ([]models.WebhookSubscription, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.WebhookSubscription{})
+	var model []models.WebhookSubscription
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("created_at").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *webhookGormRepository) FindById(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.WebhookSubscription
+	err = db.Where("id = ?", id).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, webhook.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *webhookGormRepository) FindByEventType(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	// the event types are few, the subscriptions of the tenant are matched here
+	var all []models.WebhookSubscription
+	if err := db.Order("created_at").Find(&all).Error; err != nil {
+		return nil, err
+	}
+	var model []models.WebhookSubscription
+	for _, subscription := range all {
+		if subscription.Subscribes(eventType) {
+			model = append(model, subscription)
+		}
+	}
+	return model, nil
+}
+
+func (r *webhookGormRepository) Store(ctx context.Context, model *models.WebhookSubscription) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	model.TenantID = tenantID
+	return db.Create(model).Error
+}
+
+func (r *webhookGormRepository) Update(ctx context.Context, model *models.WebhookSubscription) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	if model.TenantID != tenantID {
+		return webhook.ErrNotFound
+	}
+	return db.Save(model).Error
+}
+
+func (r *webhookGormRepository) Delete(ctx context.Context, id string) error {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&models.WebhookSubscription{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return webhook.ErrNotFound
+		}
+		return tx.New().Where("tenant_id = ? AND subscription_id = ?", tenantID, id).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+func (r *webhookGormRepository) StoreDelivery(ctx context.Context, model *models.WebhookDelivery) (bool, error) {
+	db, tenantID, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	err = db.Model(&models.WebhookDelivery{}).Where("subscription_id = ? AND event_id = ?", model.SubscriptionID, model.EventID).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return false, err
+	}
+	model.TenantID = tenantID
+	return true, db.Create(model).Error
+}
+
+func (r *webhookGormRepository) FindDeliveries(ctx context.Context, subscriptionID string, limit int64, offset int64) ([]models.WebhookDelivery, int64, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoped := db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	var model []models.WebhookDelivery
+	var total int64
+	if err := scoped.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = scoped.Order("created_at DESC").Limit(limit).Offset(offset).Find(&model).Error
+	return model, total, err
+}
+
+func (r *webhookGormRepository) FindDelivery(ctx context.Context, subscriptionID string, id string) (*models.WebhookDelivery, error) {
+	db, _, err := database.TenantScope(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	var model models.WebhookDelivery
+	err = db.Where("subscription_id = ? AND id = ?", subscriptionID, id).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, webhook.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// FindDueDeliveries is not scoped to a tenant, the worker sends the deliveries of every tenant
+func (r *webhookGormRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int64) ([]models.WebhookDelivery, error) {
+	var model []models.WebhookDelivery
+	err := database.WithContext(ctx, r.db).Where("status = ? AND next_attempt_at <= ?", models.WebhookPending, now).
+		Order("created_at ASC").Limit(limit).Find(&model).Error
+	return model, err
+}
+
+func (r *webhookGormRepository) ClaimDelivery(ctx context.Context, id string, now time.Time, until time.Time) (bool, error) {
+	result := database.WithContext(ctx, r.db).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, models.WebhookPending, now).
+		Update("next_attempt_at", until)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *webhookGormRepository) UpdateDelivery(ctx context.Context, model *models.WebhookDelivery) error {
+	return database.WithContext(ctx, r.db).Model(&models.WebhookDelivery{}).Where("id = ?", model.ID).
+		Updates(map[string]interface{}{
+			"status":          model.Status,
+			"attempts":        model.Attempts,
+			"response_code":   model.ResponseCode,
+			"last_error":      model.LastError,
+			"next_attempt_at": model.NextAttemptAt,
+			"delivered_at":    model.DeliveredAt,
+			"updated_at":      time.Now(),
+		}).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"go-echo-api/webhook"
+	"testing"
+	"time"
+)
+
+func TestWebhookGormRepository(t *testing.T) {
+	//prepare db test
+	db := dbtest.PrepareTestDB(t)
+	defer dbtest.CleanTestDB(db)
+
+	r := NewWebhookRepository(db)
+	now := time.Now().Truncate(time.Second)
+	all := models.WebhookSubscription{URL: "https://partner.example.com/all", EventTypes: "*", Secret: "secret"}
+	deleted := models.WebhookSubscription{URL: "https://partner.example.com/deleted", EventTypes: outbox.EventUserDeleted, Secret: "secret"}
+	other := models.WebhookSubscription{URL: "https://globex.example.com", EventTypes: "*", Secret: "secret"}
+	assert.NoError(t, r.Store(dbtest.Context(), &all))
+	assert.NoError(t, r.Store(dbtest.Context(), &deleted))
+	assert.NoError(t, r.Store(dbtest.TenantContext(dbtest.TenantGlobex), &other))
+
+	result, total, err := r.FindAll(dbtest.Context(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, result, 2)
+	_, err = r.FindById(dbtest.Context(), other.ID)
+	assert.Equal(t, webhook.ErrNotFound, err)
+
+	subscribed, err := r.FindByEventType(dbtest.Context(), outbox.EventUserRegistered)
+	assert.NoError(t, err)
+	if assert.Len(t, subscribed, 1) {
+		assert.Equal(t, all.ID, subscribed[0].ID)
+	}
+	subscribed, _ = r.FindByEventType(dbtest.Context(), outbox.EventUserDeleted)
+	assert.Len(t, subscribed, 2)
+
+	// a single delivery of an event per subscription
+	delivery := models.WebhookDelivery{SubscriptionID: all.ID, EventID: "event", EventType: outbox.EventUserDeleted, Payload: "{}", Status: models.WebhookPending, NextAttemptAt: now}
+	stored, err := r.StoreDelivery(dbtest.Context(), &delivery)
+	assert.NoError(t, err)
+	assert.Equal(t, true, stored)
+	stored, err = r.StoreDelivery(dbtest.Context(), &models.WebhookDelivery{SubscriptionID: all.ID, EventID: "event", Status: models.WebhookPending, NextAttemptAt: now})
+	assert.NoError(t, err)
+	assert.Equal(t, false, stored)
+
+	due, err := r.FindDueDeliveries(context.Background(), now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+	claimed, err := r.ClaimDelivery(context.Background(), delivery.ID, now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, true, claimed)
+	claimed, _ = r.ClaimDelivery(context.Background(), delivery.ID, now, now.Add(time.Minute))
+	assert.Equal(t, false, claimed)
+
+	delivery.Status = models.WebhookSucceeded
+	delivery.Attempts = 1
+	delivery.ResponseCode = 204
+	delivery.DeliveredAt = &now
+	assert.NoError(t, r.UpdateDelivery(context.Background(), &delivery))
+	found, err := r.FindDelivery(dbtest.Context(), all.ID, delivery.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 204, found.ResponseCode)
+	due, _ = r.FindDueDeliveries(context.Background(), now.Add(time.Hour), 10)
+	assert.Len(t, due, 0)
+	deliveries, total, err := r.FindDeliveries(dbtest.Context(), all.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, deliveries, 1)
+
+	all.EventTypes = outbox.EventUserUpdated
+	assert.NoError(t, r.Update(dbtest.Context(), &all))
+	assert.Equal(t, webhook.ErrNotFound, r.Update(dbtest.Context(), &other))
+
+	// the deliveries go with their subscription
+	assert.NoError(t, r.Delete(dbtest.Context(), all.ID))
+	assert.Equal(t, webhook.ErrNotFound, r.Delete(dbtest.Context(), all.ID))
+	_, err = r.FindDelivery(dbtest.Context(), all.ID, delivery.ID)
+	assert.Equal(t, webhook.ErrDeliveryNotFound, err)
+}
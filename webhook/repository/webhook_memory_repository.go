@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go-echo-api/models"
+	"go-echo-api/tenant"
+	"go-echo-api/webhook"
+	"sort"
+	"sync"
+	"time"
+)
+
+// webhookMemoryRepository keeps the subscriptions and their deliveries in memory, it backs the use-case unit tests
+type webhookMemoryRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[string]models.WebhookSubscription
+	deliveries    map[string]models.WebhookDelivery
+}
+
+func NewWebhookMemoryRepository(subscriptions ...models.WebhookSubscription) webhook.Repository {
+	r := &webhookMemoryRepository{
+		subscriptions: make(map[string]models.WebhookSubscription),
+		deliveries:    make(map[string]models.WebhookDelivery),
+	}
+	for _, s := range subscriptions {
+		r.subscriptions[s.ID] = s
+	}
+	return r
+}
+
+// tenantSubscriptions return the subscriptions of the tenant of the context, the oldest first, callers hold the lock
+func (r *webhookMemoryRepository) tenantSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var all []models.WebhookSubscription
+	for _, s := range r.subscriptions {
+		if s.TenantID == tenantID {
+			all = append(all, s)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].ID < all[j].ID
+		}
+		return all[i].CreatedAt.Before(all[j].CreatedAt)
+	})
+	return all, nil
+}
+
+func (r *webhookMemoryRepository) FindAll(ctx context.Context, limit int64, offset int64) ([]models.WebhookSubscription, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all, err := r.tenantSubscriptions(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := int64(len(all))
+	if offset >= total {
+		return []models.WebhookSubscription{}, total, nil
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *webhookMemoryRepository) FindById(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	model, ok := r.subscriptions[id]
+	if !ok || model.TenantID != tenantID {
+		return nil, webhook.ErrNotFound
+	}
+	return &model, nil
+}
+
+func (r *webhookMemoryRepository) FindByEventType(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all, err := r.tenantSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var model []models.WebhookSubscription
+	for _, s := range all {
+		if s.Subscribes(eventType) {
+			model = append(model, s)
+		}
+	}
+	return model, nil
+}
+
+func (r *webhookMemoryRepository) Store(ctx context.Context, model *models.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if model.ID == "" {
+		model.ID = uuid.New().String()
+	}
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.subscriptions[model.ID] = *model
+	return nil
+}
+
+func (r *webhookMemoryRepository) Update(ctx context.Context, model *models.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.subscriptions[model.ID]; !ok || existing.TenantID != tenantID {
+		return webhook.ErrNotFound
+	}
+	model.UpdatedAt = time.Now()
+	r.subscriptions[model.ID] = *model
+	return nil
+}
+
+func (r *webhookMemoryRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return err
+	}
+	if existing, ok := r.subscriptions[id]; !ok || existing.TenantID != tenantID {
+		return webhook.ErrNotFound
+	}
+	delete(r.subscriptions, id)
+	for k, d := range r.deliveries {
+		if d.SubscriptionID == id {
+			delete(r.deliveries, k)
+		}
+	}
+	return nil
+}
+
+func (r *webhookMemoryRepository) StoreDelivery(ctx context.Context, model *models.WebhookDelivery) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == model.SubscriptionID && d.EventID == model.EventID {
+			return false, nil
+		}
+	}
+	model.ID = uuid.New().String()
+	model.TenantID = tenantID
+	model.CreatedAt = time.Now()
+	model.UpdatedAt = model.CreatedAt
+	r.deliveries[model.ID] = *model
+	return true, nil
+}
+
+func (r *webhookMemoryRepository) FindDeliveries(ctx context.Context, subscriptionID string, limit int64, offset int64) ([]models.WebhookDelivery, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []models.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.TenantID == tenantID && d.SubscriptionID == subscriptionID {
+			all = append(all, d)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+	total := int64(len(all))
+	if offset >= total {
+		return []models.WebhookDelivery{}, total, nil
+	}
+	end := offset + limit
+	if limit < 0 || end > total {
+		end = total
+	}
+	return all[offset:end], total, nil
+}
+
+func (r *webhookMemoryRepository) FindDelivery(ctx context.Context, subscriptionID string, id string) (*models.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID, err := tenant.ID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	model, ok := r.deliveries[id]
+	if !ok || model.TenantID != tenantID || model.SubscriptionID != subscriptionID {
+		return nil, webhook.ErrDeliveryNotFound
+	}
+	return &model, nil
+}
+
+func (r *webhookMemoryRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int64) ([]models.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var model []models.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status == models.WebhookPending && !d.NextAttemptAt.After(now) {
+			model = append(model, d)
+		}
+	}
+	sort.Slice(model, func(i, j int) bool {
+		return model[i].CreatedAt.Before(model[j].CreatedAt)
+	})
+	if limit >= 0 && int64(len(model)) > limit {
+		model = model[:limit]
+	}
+	return model, nil
+}
+
+func (r *webhookMemoryRepository) ClaimDelivery(ctx context.Context, id string, now time.Time, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	model, ok := r.deliveries[id]
+	if !ok || model.Status != models.WebhookPending || model.NextAttemptAt.After(now) {
+		return false, nil
+	}
+	model.NextAttemptAt = until
+	r.deliveries[id] = model
+	return true, nil
+}
+
+func (r *webhookMemoryRepository) UpdateDelivery(ctx context.Context, model *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.deliveries[model.ID]
+	if !ok {
+		return nil
+	}
+	model.CreatedAt = existing.CreatedAt
+	model.UpdatedAt = time.Now()
+	r.deliveries[model.ID] = *model
+	return nil
+}
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/retry"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"go-echo-api/tenant"
+	"go-echo-api/webhook"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SecretPrefix start the secrets generated for the subscriptions
+const SecretPrefix = "whsec_"
+
+type DeliveryConfig struct {
+	// Client sending the deliveries, a client with a 10s timeout when nil
+	Client *http.Client
+
+	// Lease is how long a worker holds a delivery it sends before another one may take it, default 1m
+	Lease time.Duration
+
+	// BatchSize is the number of deliveries sent by DeliverDue at most, default 100
+	BatchSize int64
+
+	// Policy of the retries of a delivery failed, marked failed after MaxAttempts, default 8 attempts
+	// and a backoff of 10s up to 6h
+	retry.Policy
+}
+
+var DefaultDeliveryConfig = DeliveryConfig{
+	Lease:     time.Minute,
+	BatchSize: 100,
+	Policy:    retry.Policy{MaxAttempts: 8, Backoff: 10 * time.Second, MaxBackoff: 6 * time.Hour},
+}
+
+// DeliveryConfigFromEnv read the configuration of the deliveries from APP_WEBHOOK_TIMEOUT,
+// APP_WEBHOOK_MAX_ATTEMPTS and APP_WEBHOOK_BACKOFF, e.g. "30s"
+func DeliveryConfigFromEnv() DeliveryConfig {
+	config := DefaultDeliveryConfig
+	if timeout, err := time.ParseDuration(os.Getenv("APP_WEBHOOK_TIMEOUT")); err == nil && timeout > 0 {
+		config.Client = &http.Client{Timeout: timeout}
+	}
+	config.Policy = config.Policy.FromEnv("APP_WEBHOOK")
+	return config
+}
+
+type WebhookService struct {
+	webhookRepository webhook.Repository
+	config            DeliveryConfig
+	now               func() time.Time
+}
+
+func NewWebhookService(r webhook.Repository, config DeliveryConfig) webhook.Usecase {
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	config.Lease = retry.Duration(config.Lease, DefaultDeliveryConfig.Lease)
+	config.BatchSize = retry.Int64(config.BatchSize, DefaultDeliveryConfig.BatchSize)
+	config.Policy = config.Policy.WithDefaults(DefaultDeliveryConfig.Policy)
+	return WebhookService{webhookRepository: r, config: config, now: time.Now}
+}
+
+func (s WebhookService) FindAll(ctx context.Context, limit int64, offset int64) ([]models.WebhookSubscription, int64, error) {
+	return s.webhookRepository.FindAll(ctx, limit, offset)
+}
+
+func (s WebhookService) FindById(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	return s.webhookRepository.FindById(ctx, id)
+}
+
+func (s WebhookService) Store(ctx context.Context, dto webhook.Dto) (models.WebhookSubscription, error) {
+	if err := checkURL(dto.URL); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	secret := dto.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			return models.WebhookSubscription{}, err
+		}
+	}
+	model := models.WebhookSubscription{URL: dto.URL, EventTypes: strings.Join(dto.EventTypes, " "), Secret: secret}
+	if err := s.webhookRepository.Store(ctx, &model); err != nil {
+		return model, err
+	}
+	logger.FromContext(ctx).WithField(logger.WebhookIDField, model.ID).Info("webhook subscribed")
+	return model, nil
+}
+
+func (s WebhookService) Update(ctx context.Context, id string, dto webhook.Dto) (models.WebhookSubscription, error) {
+	if err := checkURL(dto.URL); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	existing, err := s.webhookRepository.FindById(ctx, id)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	model := *existing
+	model.URL = dto.URL
+	model.EventTypes = strings.Join(dto.EventTypes, " ")
+	if dto.Secret != "" {
+		model.Secret = dto.Secret
+	}
+	err = s.webhookRepository.Update(ctx, &model)
+	return model, err
+}
+
+func (s WebhookService) Delete(ctx context.Context, id string) error {
+	return s.webhookRepository.Delete(ctx, id)
+}
+
+func (s WebhookService) FindDeliveries(ctx context.Context, id string, limit int64, offset int64) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.webhookRepository.FindById(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return s.webhookRepository.FindDeliveries(ctx, id, limit, offset)
+}
+
+func (s WebhookService) Redeliver(ctx context.Context, id string, deliveryID string) (models.WebhookDelivery, error) {
+	subscription, err := s.webhookRepository.FindById(ctx, id)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	delivery, err := s.webhookRepository.FindDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	s.send(ctx, *subscription, delivery)
+	return *delivery, s.webhookRepository.UpdateDelivery(ctx, delivery)
+}
+
+func (s WebhookService) Enqueue(ctx context.Context, event outbox.Event) error {
+	subscriptions, err := s.webhookRepository.FindByEventType(ctx, event.Type)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		// the outbox may publish the event again, the delivery already stored is kept
+		_, err := s.webhookRepository.StoreDelivery(ctx, &models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			Status:         models.WebhookPending,
+			NextAttemptAt:  s.now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+	deliveries, err := s.webhookRepository.FindDueDeliveries(ctx, now, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+		claimed, err := s.webhookRepository.ClaimDelivery(ctx, delivery.ID, now, now.Add(s.config.Lease))
+		if err != nil {
+			return delivered, err
+		}
+		if !claimed {
+			continue
+		}
+		tenantCtx := tenant.WithContext(ctx, models.Tenant{ID: delivery.TenantID})
+		subscription, err := s.webhookRepository.FindById(tenantCtx, delivery.SubscriptionID)
+		if err == webhook.ErrNotFound {
+			// the subscription is gone with its deliveries
+			continue
+		}
+		if err != nil {
+			return delivered, err
+		}
+		s.send(tenantCtx, *subscription, &delivery)
+		if err := s.webhookRepository.UpdateDelivery(ctx, &delivery); err != nil {
+			return delivered, err
+		}
+		if delivery.Status == models.WebhookSucceeded {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// send POST the delivery to the subscription, signed with its secret, and record the outcome on the delivery:
+// succeeded on a 2xx, otherwise retried after a backoff until the attempts run out
+func (s WebhookService) send(ctx context.Context, subscription models.WebhookSubscription, delivery *models.WebhookDelivery) {
+	now := s.now().UTC()
+	delivery.Attempts++
+	delivery.ResponseCode = 0
+	code, err := s.post(ctx, subscription, *delivery, now)
+	delivery.ResponseCode = code
+	if err == nil {
+		delivery.Status = models.WebhookSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+	logger.FromContext(ctx).WithError(err).WithField(logger.WebhookIDField, subscription.ID).
+		WithField("delivery_id", delivery.ID).Warn("webhook delivery failed")
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= s.config.MaxAttempts {
+		delivery.Status = models.WebhookFailed
+		return
+	}
+	delivery.Status = models.WebhookPending
+	delivery.NextAttemptAt = now.Add(s.config.Delay(delivery.Attempts))
+}
+
+// post send the delivery and return the status code answered, 0 when the receiver was not reached
+func (s WebhookService) post(ctx context.Context, subscription models.WebhookSubscription, delivery models.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderID, delivery.ID)
+	req.Header.Set(webhook.HeaderEvent, delivery.EventType)
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(subscription.Secret, now, body))
+	res, err := s.config.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver answered %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func checkURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return webhook.ErrInvalidURL
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return SecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
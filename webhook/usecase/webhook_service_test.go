@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-echo-api/infrastructure/database/dbtest"
+	"go-echo-api/infrastructure/retry"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+	"go-echo-api/webhook"
+	"go-echo-api/webhook/repository"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is a partner endpoint checking the signature of the deliveries, it answers status
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	status   int
+	received []outbox.Event
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := ioutil.ReadAll(req.Body)
+	err := webhook.VerifySignature(r.secret, req.Header.Get(webhook.HeaderTimestamp), req.Header.Get(webhook.HeaderSignature), body, time.Now(), 5*time.Minute)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var event outbox.Event
+	_ = json.Unmarshal(body, &event)
+	r.received = append(r.received, event)
+	w.WriteHeader(r.status)
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	rcv := &receiver{secret: "a-secret-of-16-chars", status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+	r := repository.NewWebhookMemoryRepository()
+	w := NewWebhookService(r, DeliveryConfig{Policy: retry.Policy{MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: time.Hour}}).(WebhookService)
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	subscription, err := w.Store(dbtest.Context(), webhook.Dto{URL: server.URL, EventTypes: []string{outbox.EventUserRegistered}, Secret: rcv.secret})
+	assert.NoError(t, err)
+	event := outbox.Event{ID: "event", Type: outbox.EventUserRegistered, TenantID: dbtest.TenantAcme.ID, AggregateID: dbtest.UserUje.ID, Data: json.RawMessage(`{}`)}
+	assert.NoError(t, w.Enqueue(dbtest.Context(), event))
+	// the outbox publishing the event again enqueues nothing
+	assert.NoError(t, w.Enqueue(dbtest.Context(), event))
+	assert.NoError(t, w.Enqueue(dbtest.Context(), outbox.Event{ID: "other", Type: outbox.EventUserDeleted, Data: json.RawMessage(`{}`)}))
+
+	f := t.Run("error-failed", func(t *testing.T) {
+		// the receiver fails, the delivery is retried after a backoff doubling every attempt
+		delivered, err := w.DeliverDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		deliveries, total, _ := w.FindDeliveries(dbtest.Context(), subscription.ID, 10, 0)
+		assert.Equal(t, int64(1), total)
+		if assert.Len(t, deliveries, 1) {
+			assert.Equal(t, models.WebhookPending, deliveries[0].Status)
+			assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].ResponseCode)
+			assert.Equal(t, now.UTC().Add(time.Minute), deliveries[0].NextAttemptAt)
+		}
+		now = now.Add(time.Minute)
+		_, _ = w.DeliverDue(context.Background())
+		deliveries, _, _ = w.FindDeliveries(dbtest.Context(), subscription.ID, 10, 0)
+		assert.Equal(t, now.UTC().Add(2*time.Minute), deliveries[0].NextAttemptAt)
+		now = now.Add(2 * time.Minute)
+		_, _ = w.DeliverDue(context.Background())
+		deliveries, _, _ = w.FindDeliveries(dbtest.Context(), subscription.ID, 10, 0)
+		assert.Equal(t, models.WebhookFailed, deliveries[0].Status)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+		assert.Len(t, rcv.received, 3)
+	})
+	s := t.Run("success", func(t *testing.T) {
+		// the delivery failed for good is sent again by hand, signed
+		rcv.status = http.StatusNoContent
+		deliveries, _, _ := w.FindDeliveries(dbtest.Context(), subscription.ID, 10, 0)
+		result, err := w.Redeliver(dbtest.Context(), subscription.ID, deliveries[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.WebhookSucceeded, result.Status)
+		assert.Equal(t, http.StatusNoContent, result.ResponseCode)
+		assert.NotNil(t, result.DeliveredAt)
+		if assert.Len(t, rcv.received, 4) {
+			assert.Equal(t, event.ID, rcv.received[3].ID)
+			assert.Equal(t, dbtest.UserUje.ID, rcv.received[3].AggregateID)
+		}
+	})
+	a := t.Run("error-signature", func(t *testing.T) {
+		// a wrong secret is rejected by the receiver
+		_, err := w.Update(dbtest.Context(), subscription.ID, webhook.Dto{URL: server.URL, EventTypes: []string{"*"}, Secret: "another-secret-of-16"})
+		assert.NoError(t, err)
+		assert.NoError(t, w.Enqueue(dbtest.Context(), outbox.Event{ID: "deleted", Type: outbox.EventUserDeleted, Data: json.RawMessage(`{}`)}))
+		delivered, err := w.DeliverDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		deliveries, _, _ := w.FindDeliveries(dbtest.Context(), subscription.ID, 1, 0)
+		assert.Equal(t, http.StatusUnauthorized, deliveries[0].ResponseCode)
+		assert.Equal(t, "receiver answered 401", deliveries[0].LastError)
+	})
+	v := t.Run("error-validation", func(t *testing.T) {
+		_, err := w.Store(dbtest.Context(), webhook.Dto{URL: "ftp://partner.example.com", EventTypes: []string{"*"}})
+		assert.Equal(t, webhook.ErrInvalidURL, err)
+		_, err = w.Redeliver(dbtest.Context(), subscription.ID, "unknown")
+		assert.Equal(t, webhook.ErrDeliveryNotFound, err)
+		_, _, err = w.FindDeliveries(dbtest.Context(), "unknown", 10, 0)
+		assert.Equal(t, webhook.ErrNotFound, err)
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+	assert.Equal(t, true, a, "Signature scenario failed run")
+	assert.Equal(t, true, v, "Validation scenario failed run")
+}
+
+func TestWebhookService_Store(t *testing.T) {
+	w := NewWebhookService(repository.NewWebhookMemoryRepository(), DefaultDeliveryConfig)
+
+	// a secret is generated when none is given, and kept by an update without one
+	model, err := w.Store(dbtest.Context(), webhook.Dto{URL: "https://partner.example.com", EventTypes: []string{"*"}})
+	assert.NoError(t, err)
+	assert.Contains(t, model.Secret, SecretPrefix)
+	updated, err := w.Update(dbtest.Context(), model.ID, webhook.Dto{URL: "https://partner.example.com/v2", EventTypes: []string{outbox.EventUserDeleted}})
+	assert.NoError(t, err)
+	assert.Equal(t, model.Secret, updated.Secret)
+	assert.Equal(t, []string{outbox.EventUserDeleted}, updated.EventTypeList())
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"id":"event"}`)
+	signature := webhook.Sign("secret", now, body)
+
+	s := t.Run("success", func(t *testing.T) {
+		assert.NoError(t, webhook.VerifySignature("secret", timestamp, signature, body, now, time.Minute))
+	})
+	f := t.Run("error-failed", func(t *testing.T) {
+		assert.Equal(t, webhook.ErrInvalidSignature, webhook.VerifySignature("wrong", timestamp, signature, body, now, time.Minute))
+		assert.Equal(t, webhook.ErrInvalidSignature, webhook.VerifySignature("secret", timestamp, signature, []byte(`{}`), now, time.Minute))
+		// a replay past the tolerance
+		assert.Equal(t, webhook.ErrInvalidSignature, webhook.VerifySignature("secret", timestamp, signature, body, now.Add(time.Hour), time.Minute))
+	})
+	assert.Equal(t, true, s, "Success scenario failed run")
+	assert.Equal(t, true, f, "Failed scenario failed run")
+}
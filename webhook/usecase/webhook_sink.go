@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"context"
+	"go-echo-api/outbox"
+	"go-echo-api/webhook"
+)
+
+// webhookSink enqueues the domain events published by the outbox for the webhook subscriptions of their tenant
+type webhookSink struct {
+	webhookUsecase webhook.Usecase
+}
+
+func NewWebhookSink(u webhook.Usecase) outbox.Sink {
+	return webhookSink{webhookUsecase: u}
+}
+
+func (s webhookSink) Name() string {
+	return "webhooks"
+}
+
+func (s webhookSink) Publish(ctx context.Context, event outbox.Event) error {
+	return s.webhookUsecase.Enqueue(ctx, event)
+}
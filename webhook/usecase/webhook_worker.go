@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"go-echo-api/infrastructure/logger"
+	"go-echo-api/infrastructure/retry"
+	"go-echo-api/webhook"
+	"time"
+)
+
+// Worker sends the webhook deliveries due, the workers of several instances share the deliveries
+type Worker struct {
+	webhookUsecase webhook.Usecase
+	interval       time.Duration
+}
+
+// NewWorker return a worker polling the deliveries every interval, APP_WEBHOOK_INTERVAL or 1s when zero
+func NewWorker(u webhook.Usecase, interval time.Duration) *Worker {
+	interval = retry.Duration(interval, retry.EnvDuration("APP_WEBHOOK_INTERVAL", time.Second))
+	return &Worker{webhookUsecase: u, interval: interval}
+}
+
+// Run send the deliveries due every interval until ctx is done
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.webhookUsecase.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).WithError(err).Error("deliver webhooks")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package webhook
+
+type Dto struct {
+	URL string `json:"url" validate:"required,url,max=2048"`
+	// EventTypes the subscription is notified of, "*" for every event
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=* user.registered user.updated user.deleted user.password_changed"`
+	// Secret signing the deliveries, empty generates one
+	Secret string `json:"secret,omitempty" validate:"omitempty,min=16,max=256"`
+}
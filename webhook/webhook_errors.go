@@ -0,0 +1,10 @@
+package webhook
+
+import "errors"
+
+var (
+	ErrNotFound         = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidURL       = errors.New("url must be http or https")
+	ErrInvalidSignature = errors.New("webhook signature not valid")
+)
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"go-echo-api/models"
+	"time"
+)
+
+type Mapper struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewWebhookMapper() *Mapper {
+	return &Mapper{}
+}
+
+func (m *Mapper) Map(model models.WebhookSubscription) *Mapper {
+	m.ID = model.ID
+	m.URL = model.URL
+	m.EventTypes = append([]string{}, model.EventTypeList()...)
+	m.CreatedAt = model.CreatedAt
+	m.UpdatedAt = model.UpdatedAt
+	return m
+}
+
+func (m *Mapper) MapList(model []models.WebhookSubscription) interface{} {
+	serialized := make([]Mapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&Mapper{}).Map(v)
+	}
+	return serialized
+}
+
+// SecretMapper is the only response holding the secret, answered when it is set
+type SecretMapper struct {
+	Mapper
+	Secret string `json:"secret"`
+}
+
+func NewSecretMapper(model models.WebhookSubscription) *SecretMapper {
+	return &SecretMapper{Mapper: *NewWebhookMapper().Map(model), Secret: model.Secret}
+}
+
+type DeliveryMapper struct {
+	ID            string     `json:"id"`
+	EventID       string     `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ResponseCode  int        `json:"response_code"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func NewDeliveryMapper() *DeliveryMapper {
+	return &DeliveryMapper{}
+}
+
+func (m *DeliveryMapper) Map(model models.WebhookDelivery) *DeliveryMapper {
+	m.ID = model.ID
+	m.EventID = model.EventID
+	m.EventType = model.EventType
+	m.Status = model.Status
+	m.Attempts = model.Attempts
+	m.ResponseCode = model.ResponseCode
+	m.LastError = model.LastError
+	m.NextAttemptAt = nil
+	if model.Status == models.WebhookPending {
+		next := model.NextAttemptAt
+		m.NextAttemptAt = &next
+	}
+	m.DeliveredAt = model.DeliveredAt
+	m.CreatedAt = model.CreatedAt
+	return m
+}
+
+func (m *DeliveryMapper) MapList(model []models.WebhookDelivery) interface{} {
+	serialized := make([]DeliveryMapper, len(model))
+	for k, v := range model {
+		serialized[k] = *(&DeliveryMapper{}).Map(v)
+	}
+	return serialized
+}
@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"context"
+	"go-echo-api/models"
+	"time"
+)
+
+// Repository of the webhook subscriptions of the tenant of the context and of their deliveries
+type Repository interface {
+	FindAll(ctx context.Context, limit int64, offset int64) ([]models.WebhookSubscription, int64, error)
+	FindById(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	// FindByEventType return the subscriptions notified of the events of eventType
+	FindByEventType(ctx context.Context, eventType string) ([]models.WebhookSubscription, error)
+	Store(ctx context.Context, model *models.WebhookSubscription) error
+	Update(ctx context.Context, model *models.WebhookSubscription) error
+	// Delete the subscription and its deliveries
+	Delete(ctx context.Context, id string) error
+
+	// StoreDelivery store the delivery unless the subscription already has one of its event,
+	// false in that case
+	StoreDelivery(ctx context.Context, model *models.WebhookDelivery) (bool, error)
+	// FindDeliveries return the deliveries of the subscription, the latest first
+	FindDeliveries(ctx context.Context, subscriptionID string, limit int64, offset int64) ([]models.WebhookDelivery, int64, error)
+	FindDelivery(ctx context.Context, subscriptionID string, id string) (*models.WebhookDelivery, error)
+	// FindDueDeliveries return at most limit pending deliveries of every tenant due at now, the oldest first
+	FindDueDeliveries(ctx context.Context, now time.Time, limit int64) ([]models.WebhookDelivery, error)
+	// ClaimDelivery push the next attempt of the pending delivery due at now back to until, false when
+	// another worker claimed it first
+	ClaimDelivery(ctx context.Context, id string, now time.Time, until time.Time) (bool, error)
+	// UpdateDelivery save the outcome of an attempt
+	UpdateDelivery(ctx context.Context, model *models.WebhookDelivery) error
+}
@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"context"
+	"go-echo-api/models"
+	"go-echo-api/outbox"
+)
+
+type Usecase interface {
+	FindAll(ctx context.Context, limit int64, offset int64) ([]models.WebhookSubscription, int64, error)
+	FindById(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	// Store the subscription, with a generated secret when the dto has none
+	Store(ctx context.Context, dto Dto) (models.WebhookSubscription, error)
+	// Update the subscription, its secret is kept when the dto has none
+	Update(ctx context.Context, id string, dto Dto) (models.WebhookSubscription, error)
+	Delete(ctx context.Context, id string) error
+
+	FindDeliveries(ctx context.Context, id string, limit int64, offset int64) ([]models.WebhookDelivery, int64, error)
+	// Redeliver send the delivery of the subscription again now, whatever its status, and return its outcome
+	Redeliver(ctx context.Context, id string, deliveryID string) (models.WebhookDelivery, error)
+
+	// Enqueue a delivery of the event to every subscription of its type in the tenant of the context
+	Enqueue(ctx context.Context, event outbox.Event) error
+	// DeliverDue send the deliveries due of every tenant and return the number that succeeded
+	DeliverDue(ctx context.Context) (int, error)
+}